@@ -15,6 +15,7 @@ import (
 	"photostudio/internal/domain/chat"
 	"photostudio/internal/domain/favorite"
 	"photostudio/internal/domain/lead"
+	"photostudio/internal/domain/ledger"
 	"photostudio/internal/domain/manager"
 	"photostudio/internal/domain/mwork"
 	"photostudio/internal/domain/notification"
@@ -73,6 +74,16 @@ func main() {
 		&catalog.Room{},
 		&catalog.Equipment{},
 		&booking.Booking{},
+		&booking.BookingSeries{},
+		&booking.BookingGroup{},
+		&booking.BookingStatusHistory{},
+		&booking.BookingEquipment{},
+		&booking.WaitlistEntry{},
+		&booking.BookingReminder{},
+		&booking.CalendarFeed{},
+		&booking.ExternalCalendar{},
+		&booking.ExternalBusyBlock{},
+		&booking.BookingParticipant{},
 		&review.Review{},
 		&notification.Notification{},
 		&notification.UserPreferences{},
@@ -87,7 +98,16 @@ func main() {
 		&owner.CompanyProfile{},
 		&owner.PortfolioProject{},
 		&catalog.StudioWorkingHours{}, // Добавляем новую таблицу
-		&payment.RobokassaPayment{},
+		&catalog.StudioDateOverride{},
+		&catalog.RoomBlackout{},
+		&payment.Payment{},
+		&payment.Refund{},
+		&payment.Discrepancy{},
+		&ledger.Transaction{},
+		&ledger.Entry{},
+		&ledger.CommissionRate{},
+		&ledger.PayoutBatch{},
+		&ledger.PayoutItem{},
 	}
 
 	// Check if migrations should be run via environment variable
@@ -124,7 +144,7 @@ func main() {
 	// chatRepo is initialized after relationship service below
 	favoriteRepo := favorite.NewFavoriteRepository(db)
 	ownerCRMRepo := owner.NewOwnerCRMRepository(db)
-	robokassaPaymentRepo := payment.NewRobokassaPaymentRepository(db)
+	paymentRepo := payment.NewPaymentRepository(db)
 
 	// Profile Repositories
 	clientProfileRepo := profile.NewClientRepository(sqlxDB)
@@ -180,9 +200,48 @@ func main() {
 	stopCleanup := cleanupService.ScheduleCleanup(context.Background(), cleanupConfig)
 	defer close(stopCleanup) // Stop cleanup on shutdown
 
-	bookingService := booking.NewService(bookingRepo, roomRepo, notificationService, studioWorkingHoursRepo)
+	// Subscription service (Studio Owners only — clients are NOT affected)
+	subscriptionRepo := subscription.NewRepository(db)
+	subscriptionService := subscription.NewService(subscriptionRepo, roomRepo)
+	subscriptionHandler := subscription.NewHandler(subscriptionService)
+
+	paymentLogger := func(format string, args ...interface{}) { log.Printf(format, args...) }
+	// Учёт долга перед владельцами студий, комиссии платформы и выплат
+	ledgerService := ledger.NewService(ledger.NewRepository(db), subscriptionService, paymentLogger)
+	ledgerHandler := ledger.NewHandler(ledgerService)
+	// Adapter for booking service to match payment expectations if needed, or update payment service
+	// For now assuming existing payment service signature is correct for the codebase
+	paymentService := payment.NewService(paymentRepo, bookingRepo, bookingRepo, notificationService, ledgerService, paymentLogger, payment.NewRobokassaProvider()) // bookingRepo implements all needed interfaces now
+	paymentHandler := payment.NewHandler(paymentService, paymentLogger)
+
+	// Сверка счетов со шлюзом и с payment_status броней
+	stopReconcile := paymentService.ScheduleReconciliation(context.Background(), payment.DefaultReconcileConfig())
+	defer close(stopReconcile)
+
+	// Возвраты при отмене броней идут через платёжного провайдера
+	bookingService := booking.NewService(bookingRepo, roomRepo, notificationService, studioWorkingHoursRepo, paymentService)
 	bookingHandler := booking.NewHandler(bookingService)
 
+	// Снимаем неоплаченные holds по TTL
+	stopHoldSweeper := bookingService.ScheduleHoldSweeper(context.Background(), booking.DefaultHoldConfig())
+	defer close(stopHoldSweeper)
+
+	// Передаём просроченные предложения листа ожидания следующим в очереди
+	stopWaitlistSweeper := bookingService.ScheduleWaitlistSweeper(context.Background(), booking.DefaultWaitlistConfig())
+	defer close(stopWaitlistSweeper)
+
+	// Автозавершение прошедших броней, неявки и просроченные неподтверждённые брони
+	stopLifecycle := bookingService.ScheduleLifecycle(context.Background(), booking.DefaultLifecycleConfig())
+	defer close(stopLifecycle)
+
+	// Напоминания клиентам перед сеансом (по расписанию студии, по умолчанию за 24ч и 2ч)
+	stopReminders := bookingService.ScheduleReminders(context.Background(), booking.DefaultReminderConfig())
+	defer close(stopReminders)
+
+	// Импорт занятого времени из внешних календарей комнат (iCal-ссылки)
+	stopCalendarImport := bookingService.ScheduleCalendarImport(context.Background(), booking.DefaultExternalCalendarConfig())
+	defer close(stopCalendarImport)
+
 	reviewService := review.NewService(reviewRepo, bookingRepo, studioRepo)
 	reviewHandler := review.NewHandler(reviewService)
 	_ = reviewHandler
@@ -218,27 +277,16 @@ func main() {
 
 	ownerHandler := owner.NewHandler(ownerCRMRepo)
 
-	managerHandler := manager.NewHandler(bookingRepo, ownerCRMRepo)
+	managerHandler := manager.NewHandler(bookingRepo, ownerCRMRepo, bookingService)
 
 	mworkService := mwork.NewService(userRepo)
 	mworkHandler := mwork.NewHandler(mworkService)
 
-	paymentLogger := func(format string, args ...interface{}) { log.Printf(format, args...) }
-	// Adapter for booking service to match payment expectations if needed, or update payment service
-	// For now assuming existing payment service signature is correct for the codebase
-	paymentService := payment.NewService(robokassaPaymentRepo, bookingRepo, bookingRepo, paymentLogger) // bookingRepo implements all needed interfaces now
-	paymentHandler := payment.NewHandler(paymentService, paymentLogger)
-
 	// Initialize new profile handlers
 	clientProfileHandler := profile.NewClientHandler(profileService)
 	ownerProfileHandler := profile.NewOwnerHandler(profileService)
 	adminProfileHandler := profile.NewAdminHandler(profileService)
 
-	// Subscription service (Studio Owners only — clients are NOT affected)
-	subscriptionRepo := subscription.NewRepository(db)
-	subscriptionService := subscription.NewService(subscriptionRepo, roomRepo)
-	subscriptionHandler := subscription.NewHandler(subscriptionService)
-
 	// Upload service — simple local file storage, available to all authenticated users
 	uploadRepo := upload.NewRepository(db)
 	uploadService := upload.NewService(uploadRepo, "./uploads", "/static/uploads")
@@ -267,6 +315,7 @@ func main() {
 	lead.RegisterPublicRoutes(v1, leadHandler)                 // Changed from RegisterRoutes
 	adminHandler.RegisterPublicRoutes(v1)                      // New Admin Login (Public)
 	subscription.RegisterPublicRoutes(v1, subscriptionHandler) // Public plan listing
+	bookingHandler.RegisterPublicRoutes(v1)                    // iCal feeds by secret token
 
 	// Webhooks
 	paymentHandler.RegisterWebhookRoutes(v1)
@@ -277,6 +326,8 @@ func main() {
 	{
 		adminHandler.RegisterProtectedRoutes(adminGroup)
 		lead.RegisterAdminRoutes(adminGroup, leadHandler)
+		paymentHandler.RegisterAdminRoutes(adminGroup)
+		ledger.RegisterAdminRoutes(adminGroup, ledgerHandler)
 	}
 
 	// Protected routes
@@ -321,6 +372,7 @@ func main() {
 			ownerHandler.RegisterCompanyRoutes(ownerCRMGroup)
 			// Subscription management — Studio Owners only
 			subscription.RegisterOwnerRoutes(ownerCRMGroup, subscriptionHandler)
+			ledger.RegisterOwnerRoutes(ownerCRMGroup, ledgerHandler)
 		}
 
 		managerGroup := protected.Group("")
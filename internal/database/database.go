@@ -17,8 +17,27 @@ func Connect(dsn string) (*gorm.DB, error) {
 
 	log.Println("Using SQLite for local development:", dsn)
 
+	// Писатель в SQLite один: параллельные транзакции ждут блокировку,
+	// а не падают сразу с SQLITE_BUSY
+	if !strings.Contains(dsn, "busy_timeout") {
+		dsn += dsnSep(dsn) + "_pragma=busy_timeout(5000)"
+	}
+	// Транзакции берут блокировку записи сразу (BEGIN IMMEDIATE): проверка
+	// свободного слота и вставка брони идут без чужих писателей между ними,
+	// даже из другого соединения или процесса
+	if !strings.Contains(dsn, "_txlock") {
+		dsn += dsnSep(dsn) + "_txlock=immediate"
+	}
+
 	return gorm.Open(
 		gormsqlite.Open(dsn),
 		&gorm.Config{},
 	)
 }
+
+func dsnSep(dsn string) string {
+	if strings.Contains(dsn, "?") {
+		return "&"
+	}
+	return "?"
+}
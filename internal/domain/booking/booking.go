@@ -9,10 +9,13 @@ import (
 type BookingStatus string
 
 const (
-	BookingPending   BookingStatus = "pending"
-	BookingConfirmed BookingStatus = "confirmed"
-	BookingCancelled BookingStatus = "cancelled"
-	BookingCompleted BookingStatus = "completed"
+	BookingHeld        BookingStatus = "held" // временная блокировка слота до оплаты
+	BookingPending     BookingStatus = "pending"
+	BookingConfirmed   BookingStatus = "confirmed"
+	BookingCancelled   BookingStatus = "cancelled"
+	BookingCompleted   BookingStatus = "completed"
+	BookingNoShow      BookingStatus = "no_show"     // клиент не пришёл
+	BookingRescheduled BookingStatus = "rescheduled" // перенесена на другое время
 )
 
 type PaymentStatus string
@@ -21,6 +24,8 @@ const (
 	PaymentUnpaid   PaymentStatus = "unpaid"
 	PaymentPaid     PaymentStatus = "paid"
 	PaymentRefunded PaymentStatus = "refunded"
+
+	PaymentPartiallyRefunded PaymentStatus = "partially_refunded" // по политике отмены вернули часть
 )
 
 type Booking struct {
@@ -44,7 +49,36 @@ type Booking struct {
 	// Block 10: Предоплата (для менеджеров)
 	DepositAmount float64 `json:"deposit_amount,omitempty"`
 
+	// Политика отмены: сколько вернули клиенту при отмене
+	RefundAmount float64 `json:"refund_amount,omitempty"`
+
+	// Hold: до какого момента слот удерживается без оплаты (только для status=held)
+	HoldExpiresAt *time.Time `json:"hold_expires_at,omitempty"`
+
+	// Повторяющаяся бронь: ссылка на серию (nil для разовых)
+	SeriesID *int64 `json:"series_id,omitempty" gorm:"index"`
+
+	// Бронь нескольких комнат: ссылка на группу (nil для одиночных)
+	GroupID *int64 `json:"group_id,omitempty" gorm:"index"`
+
+	// Гость без аккаунта (бронь по телефону или на стойке): UserID = 0,
+	// пока владелец не привяжет бронь к клиенту
+	GuestName  string `json:"guest_name,omitempty"`
+	GuestPhone string `json:"guest_phone,omitempty"`
+	GuestEmail string `json:"guest_email,omitempty"`
+
+	// Аренда оборудования в брони
+	Equipment []BookingEquipment `json:"equipment,omitempty" gorm:"foreignKey:BookingID"`
+
+	// Участники, оплачивающие свои доли отдельно (пусто — платит клиент брони)
+	Participants []BookingParticipant `json:"participants,omitempty" gorm:"foreignKey:BookingID"`
+
 	// Связи
 	User *auth.User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Room *catalog.Room `json:"room,omitempty" gorm:"foreignKey:RoomID"`
-}
\ No newline at end of file
+}
+
+// IsGuest — бронь гостя, ещё не привязанная к аккаунту клиента
+func (b *Booking) IsGuest() bool {
+	return b.UserID == 0
+}
@@ -0,0 +1,62 @@
+package booking
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCheckAvailability_RoomBuffers(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+	// 15 минут уборки после и 15 минут подготовки до — зазор 30 минут
+	if err := repo.db.Exec(`INSERT INTO rooms (id, studio_id, buffer_before_minutes, buffer_after_minutes) VALUES (1, 1, 15, 15)`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(2 * time.Hour),
+		Status: BookingPending, PaymentStatus: PaymentUnpaid}
+	if err := repo.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		from, to   time.Duration
+		wantFreely bool
+	}{
+		{"back-to-back after", 2 * time.Hour, 3 * time.Hour, false},
+		{"inside after buffer", 2*time.Hour + 20*time.Minute, 3 * time.Hour, false},
+		{"after full gap", 2*time.Hour + 30*time.Minute, 3 * time.Hour, true},
+		{"back-to-back before", -time.Hour, 0, false},
+		{"before full gap", -time.Hour, -30 * time.Minute, true},
+	}
+	for _, c := range cases {
+		ok, err := repo.CheckAvailability(ctx, 1, start.Add(c.from), start.Add(c.to))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != c.wantFreely {
+			t.Errorf("%s: available = %v, want %v", c.name, ok, c.wantFreely)
+		}
+	}
+
+	// комната без буферов по-прежнему допускает брони встык
+	ok, err := repo.CheckAvailability(ctx, 2, start, start.Add(time.Hour))
+	if err != nil || !ok {
+		t.Fatalf("room without buffers: ok=%v err=%v", ok, err)
+	}
+}
+
+func TestSubtractBusy_WithBufferedSlots(t *testing.T) {
+	day := time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)
+	open, close := day.Add(10*time.Hour), day.Add(20*time.Hour)
+	gap := 30 * time.Minute
+	bookedFrom, bookedTo := day.Add(12*time.Hour), day.Add(14*time.Hour)
+
+	free := subtractBusy(open, close, []TimeSlot{{Start: bookedFrom.Add(-gap), End: bookedTo.Add(gap)}})
+	if len(free) != 2 || !free[0].End.Equal(day.Add(11*time.Hour+30*time.Minute)) || !free[1].Start.Equal(day.Add(14*time.Hour+30*time.Minute)) {
+		t.Fatalf("unexpected free slots %+v", free)
+	}
+}
@@ -0,0 +1,221 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"photostudio/internal/pkg/ical"
+
+	"gorm.io/gorm"
+)
+
+const (
+	calendarProdID = "-//PhotoStudio//Bookings//RU"
+
+	// окно подписки: недавнее прошлое и год вперёд
+	calendarFeedPast   = 90 * 24 * time.Hour
+	calendarFeedFuture = 365 * 24 * time.Hour
+	calendarFeedLimit  = 2000
+)
+
+// CalendarFeed — секретная ссылка-подписка (iCal) на брони владельца:
+// всех его студий или одной комнаты (RoomID). Кто знает токен, видит брони,
+// поэтому ссылку можно отозвать и выпустить новую.
+type CalendarFeed struct {
+	ID        int64     `json:"id"`
+	OwnerID   int64     `json:"owner_id" gorm:"not null;index"`
+	RoomID    *int64    `json:"room_id,omitempty"`
+	Token     string    `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	URL       string    `json:"url" gorm:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (CalendarFeed) TableName() string { return "calendar_feeds" }
+
+// CreateCalendarFeedRequest — room_id не задан: все студии владельца
+type CreateCalendarFeedRequest struct {
+	RoomID *int64 `json:"room_id,omitempty"`
+}
+
+func calendarFeedPath(token string) string {
+	return fmt.Sprintf("/api/v1/calendar/feeds/%s.ics", token)
+}
+
+func bookingEventUID(bookingID int64) string {
+	return fmt.Sprintf("booking-%d@photostudio", bookingID)
+}
+
+// icalStatus — статус события календаря по статусу брони.
+// Отменённые остаются в ленте как CANCELLED, чтобы календарь убрал событие.
+func icalStatus(status string) string {
+	switch BookingStatus(status) {
+	case BookingCancelled:
+		return ical.StatusCancelled
+	case BookingHeld, BookingPending:
+		return ical.StatusTentative
+	default:
+		return ical.StatusConfirmed
+	}
+}
+
+// CreateCalendarFeed выпускает ссылку-подписку для владельца студии
+func (s *Service) CreateCalendarFeed(ctx context.Context, actor Actor, req CreateCalendarFeedRequest) (*CalendarFeed, error) {
+	if actor.Role != ActorOwner {
+		return nil, ErrForbidden
+	}
+	if req.RoomID != nil {
+		if _, err := s.requireRoomOwner(ctx, actor, *req.RoomID); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, err
+	}
+	feed := &CalendarFeed{OwnerID: actor.UserID, RoomID: req.RoomID, Token: token}
+	if err := s.bookings.CreateCalendarFeed(ctx, feed); err != nil {
+		return nil, err
+	}
+	feed.URL = calendarFeedPath(feed.Token)
+	return feed, nil
+}
+
+// ListCalendarFeeds возвращает ссылки-подписки владельца
+func (s *Service) ListCalendarFeeds(ctx context.Context, ownerID int64) ([]CalendarFeed, error) {
+	feeds, err := s.bookings.ListCalendarFeeds(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range feeds {
+		feeds[i].URL = calendarFeedPath(feeds[i].Token)
+	}
+	return feeds, nil
+}
+
+// DeleteCalendarFeed отзывает ссылку: календари, подписанные на неё, перестают обновляться
+func (s *Service) DeleteCalendarFeed(ctx context.Context, ownerID, feedID int64) error {
+	ok, err := s.bookings.DeleteCalendarFeed(ctx, ownerID, feedID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CalendarFeedICS собирает ленту по токену подписки
+func (s *Service) CalendarFeedICS(ctx context.Context, token string) ([]byte, error) {
+	feed, err := s.bookings.GetCalendarFeedByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	filters := ManagerBookingFilters{
+		DateFrom: now.Add(-calendarFeedPast),
+		DateTo:   now.Add(calendarFeedFuture),
+		PerPage:  calendarFeedLimit,
+	}
+	if feed.RoomID != nil {
+		filters.RoomID = *feed.RoomID
+	}
+	rows, _, err := s.bookings.GetManagerBookings(ctx, feed.OwnerID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ical.Calendar{ProdID: calendarProdID, Name: "Брони студий"}
+	if feed.RoomID != nil {
+		if room, err := s.rooms.GetByID(ctx, *feed.RoomID); err == nil {
+			cal.Name = "Брони: " + room.Name
+		}
+	}
+	var loc *time.Location
+	for _, r := range rows {
+		if loc == nil {
+			if l, err := s.roomLocation(ctx, r.RoomID); err == nil {
+				loc = l
+				cal.Timezone = l.String()
+			}
+		}
+		if BookingStatus(r.Status) == BookingHeld {
+			continue // неоплаченная блокировка на минуты — в календаре не нужна
+		}
+		cal.Events = append(cal.Events, managerRowEvent(r))
+	}
+	return cal.Bytes(now), nil
+}
+
+func managerRowEvent(r ManagerBookingRow) ical.Event {
+	desc := []string{"Клиент: " + r.ClientName}
+	if r.ClientPhone != "" {
+		desc = append(desc, "Телефон: "+r.ClientPhone)
+	}
+	if r.ClientEmail != "" {
+		desc = append(desc, "Email: "+r.ClientEmail)
+	}
+	desc = append(desc, fmt.Sprintf("Сумма: %.2f, к оплате: %.2f", r.TotalPrice, r.Balance))
+	desc = append(desc, "Статус: "+r.Status)
+	if r.Notes != "" {
+		desc = append(desc, "Заметки: "+r.Notes)
+	}
+	if r.CancellationReason != "" {
+		desc = append(desc, "Причина отмены: "+r.CancellationReason)
+	}
+
+	return ical.Event{
+		UID:         bookingEventUID(r.ID),
+		Start:       r.StartTime,
+		End:         r.EndTime,
+		Summary:     fmt.Sprintf("%s: %s", r.RoomName, r.ClientName),
+		Description: strings.Join(desc, "\n"),
+		Location:    fmt.Sprintf("%s, %s", r.StudioName, r.RoomName),
+		Status:      icalStatus(r.Status),
+	}
+}
+
+// BookingICS — .ics-файл одной брони клиента для добавления в календарь.
+// SEQUENCE растёт с каждой сменой статуса или переносом, поэтому повторно
+// скачанный файл обновляет событие (в том числе отменяет его).
+func (s *Service) BookingICS(ctx context.Context, userID, bookingID int64) ([]byte, error) {
+	d, err := s.bookings.GetUserBookingDetails(ctx, userID, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.bookings.GetStatusHistory(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ical.Calendar{ProdID: calendarProdID, Method: "PUBLISH"}
+	if loc, err := s.roomLocation(ctx, d.RoomID); err == nil {
+		cal.Timezone = loc.String()
+	}
+	cal.Events = []ical.Event{{
+		UID:          bookingEventUID(d.ID),
+		Start:        d.StartTime,
+		End:          d.EndTime,
+		Summary:      fmt.Sprintf("Фотостудия %s", d.StudioName),
+		Description:  fmt.Sprintf("Зал: %s\nСумма: %.2f\nСтатус: %s", d.RoomName, d.TotalPrice, d.Status),
+		Location:     fmt.Sprintf("%s, %s", d.StudioName, d.RoomName),
+		Status:       icalStatus(d.Status),
+		Sequence:     len(history),
+		LastModified: b.UpdatedAt,
+	}}
+	return cal.Bytes(time.Now()), nil
+}
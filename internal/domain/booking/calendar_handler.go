@@ -0,0 +1,152 @@
+package booking
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"photostudio/internal/pkg/ical"
+	"photostudio/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DownloadBookingICS отдаёт .ics-файл брони
+// @Summary		Скачать бронь в формате .ics
+// @Description	Файл iCalendar для добавления брони в Google Calendar, Apple Calendar или Outlook. Время указано в UTC, X-WR-TIMEZONE — часовой пояс студии. Повторно скачанный файл обновляет событие; у отменённой брони STATUS:CANCELLED.
+// @Tags		Бронирования - Календарь
+// @Security	BearerAuth
+// @Produce		text/calendar
+// @Param		id path integer true "ID брони"
+// @Success		200 {file} file "booking-<id>.ics"
+// @Failure		401 {object} map[string]interface{} "Ошибка аутентификации"
+// @Failure		404 {object} map[string]interface{} "Бронь не найдена"
+// @Router		/bookings/{id}/ics [get]
+func (h *Handler) DownloadBookingICS(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID <= 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Missing auth")
+		return
+	}
+	bookingID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid booking id")
+		return
+	}
+
+	data, err := h.service.BookingICS(c.Request.Context(), userID, bookingID)
+	if err != nil {
+		h.handleCalendarError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="booking-%d.ics"`, bookingID))
+	c.Data(http.StatusOK, ical.ContentType, data)
+}
+
+// CreateCalendarFeed выпускает ссылку-подписку на брони
+// @Summary		Создать ссылку на календарь броней
+// @Description	Только для владельца студии. Без room_id лента включает брони всех студий владельца, с room_id — одной комнаты. Ссылка секретная: любой, кто её знает, видит брони, поэтому её можно отозвать.
+// @Tags		Бронирования - Календарь
+// @Security	BearerAuth
+// @Param		body body CreateCalendarFeedRequest false "Комната (необязательно)"
+// @Success		201 {object} CalendarFeed "Ссылка создана"
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Комната не найдена"
+// @Router		/calendar-feeds [post]
+func (h *Handler) CreateCalendarFeed(c *gin.Context) {
+	var req CreateCalendarFeedRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+			return
+		}
+	}
+
+	feed, err := h.service.CreateCalendarFeed(c.Request.Context(), actorFromContext(c), req)
+	if err != nil {
+		h.handleCalendarError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, feed)
+}
+
+// ListCalendarFeeds возвращает ссылки-подписки владельца
+// @Summary		Мои ссылки на календарь
+// @Tags		Бронирования - Календарь
+// @Security	BearerAuth
+// @Success		200 {object} map[string]interface{} "feeds"
+// @Router		/calendar-feeds [get]
+func (h *Handler) ListCalendarFeeds(c *gin.Context) {
+	feeds, err := h.service.ListCalendarFeeds(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		h.handleCalendarError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"feeds": feeds})
+}
+
+// DeleteCalendarFeed отзывает ссылку-подписку
+// @Summary		Отозвать ссылку на календарь
+// @Tags		Бронирования - Календарь
+// @Security	BearerAuth
+// @Param		id path integer true "ID ссылки"
+// @Success		200 {object} map[string]interface{}
+// @Failure		404 {object} map[string]interface{} "Ссылка не найдена"
+// @Router		/calendar-feeds/{id} [delete]
+func (h *Handler) DeleteCalendarFeed(c *gin.Context) {
+	feedID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || feedID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid feed id")
+		return
+	}
+
+	if err := h.service.DeleteCalendarFeed(c.Request.Context(), c.GetInt64("user_id"), feedID); err != nil {
+		h.handleCalendarError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"deleted": true})
+}
+
+// GetCalendarFeed отдаёт ленту iCalendar по секретному токену
+// @Summary		Лента броней (iCalendar)
+// @Description	Публичный адрес для подписки в календарном приложении. Включает брони за последние 90 дней и на год вперёд; отменённые остаются со STATUS:CANCELLED.
+// @Tags		Бронирования - Календарь
+// @Produce		text/calendar
+// @Param		token path string true "Токен ленты (можно с суффиксом .ics)"
+// @Success		200 {file} file "text/calendar"
+// @Failure		404 {object} map[string]interface{} "Лента не найдена"
+// @Router		/calendar/feeds/{token} [get]
+func (h *Handler) GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	if token == "" {
+		response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Calendar feed not found")
+		return
+	}
+
+	data, err := h.service.CalendarFeedICS(c.Request.Context(), token)
+	if err != nil {
+		h.handleCalendarError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, ical.ContentType, data)
+}
+
+func (h *Handler) handleCalendarError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Not found")
+	case errors.Is(err, ErrForbidden):
+		response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Only the studio owner can manage calendar feeds")
+	default:
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to build calendar")
+	}
+}
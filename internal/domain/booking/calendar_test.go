@@ -0,0 +1,129 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCalendarTestService(t *testing.T) *Service {
+	t.Helper()
+	svc := newGroupTestService(t)
+	db := svc.bookings.DB()
+	for _, q := range []string{
+		`ALTER TABLE rooms ADD COLUMN name TEXT`,
+		`ALTER TABLE studios ADD COLUMN name TEXT`,
+		`INSERT INTO rooms (id, studio_id, name) VALUES (1, 1, 'Белый зал'), (2, 1, 'Лофт')`,
+		`UPDATE studios SET name = 'Свет' WHERE id = 1`,
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, phone TEXT, email TEXT)`,
+		`INSERT INTO users (id, name, phone, email) VALUES (7, 'Айгерим', '+77010000000', 'a@example.com')`,
+	} {
+		if err := db.Exec(q).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.AutoMigrate(&CalendarFeed{}); err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestCalendarFeed_OwnerFeed(t *testing.T) {
+	ctx := context.Background()
+	svc := newCalendarTestService(t)
+
+	day := nextWeekday(time.Tuesday)
+	kept, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 1, StudioID: 1, UserID: 7, StartTime: day.Add(12 * time.Hour), EndTime: day.Add(14 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropped, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 2, StudioID: 1, UserID: 7, StartTime: day.Add(15 * time.Hour), EndTime: day.Add(16 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.bookings.TransitionStatus(ctx, dropped.ID, BookingCancelled, SystemActor, "client changed plans"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.CreateCalendarFeed(ctx, Actor{UserID: 7, Role: ActorClient}, CreateCalendarFeedRequest{}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("client must not create feeds, got %v", err)
+	}
+
+	roomID := int64(1)
+	if _, err := svc.CreateCalendarFeed(ctx, Actor{UserID: 200, Role: "studio_owner"}, CreateCalendarFeedRequest{RoomID: &roomID}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("owner of another studio must not subscribe to the room, got %v", err)
+	}
+
+	feed, err := svc.CreateCalendarFeed(ctx, Actor{UserID: 100, Role: "studio_owner"}, CreateCalendarFeedRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(feed.URL, feed.Token+".ics") {
+		t.Fatalf("unexpected feed url %q", feed.URL)
+	}
+
+	data, err := svc.CalendarFeedICS(ctx, feed.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := strings.ReplaceAll(string(data), "\r\n ", "")
+	if strings.Count(out, "BEGIN:VEVENT") != 2 {
+		t.Fatalf("expected 2 events, got:\n%s", out)
+	}
+	keptEvent := out[strings.Index(out, bookingEventUID(kept.ID)):]
+	if !strings.Contains(keptEvent, "STATUS:TENTATIVE") || !strings.Contains(keptEvent, "SUMMARY:Белый зал: Айгерим") {
+		t.Fatalf("pending booking must be a tentative event:\n%s", keptEvent)
+	}
+	droppedEvent := out[strings.Index(out, bookingEventUID(dropped.ID)):]
+	if !strings.Contains(droppedEvent, "STATUS:CANCELLED") {
+		t.Fatalf("cancelled booking must stay in the feed as CANCELLED:\n%s", droppedEvent)
+	}
+
+	if err := svc.DeleteCalendarFeed(ctx, 100, feed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CalendarFeedICS(ctx, feed.Token); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("revoked feed must be gone, got %v", err)
+	}
+}
+
+func TestBookingICS_OnlyOwnBookingAndSequence(t *testing.T) {
+	ctx := context.Background()
+	svc := newCalendarTestService(t)
+
+	day := nextWeekday(time.Tuesday)
+	b, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 1, StudioID: 1, UserID: 7, StartTime: day.Add(12 * time.Hour), EndTime: day.Add(14 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.BookingICS(ctx, 8, b.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("stranger must not download the booking, got %v", err)
+	}
+
+	first, err := svc.BookingICS(ctx, 7, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.bookings.TransitionStatus(ctx, b.ID, BookingConfirmed, SystemActor, ""); err != nil {
+		t.Fatal(err)
+	}
+	second, err := svc.BookingICS(ctx, 7, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seq := func(data []byte) string {
+		s := string(data)
+		i := strings.Index(s, "SEQUENCE:")
+		return s[i : i+strings.Index(s[i:], "\r\n")]
+	}
+	if seq(first) == seq(second) {
+		t.Fatalf("sequence must grow after a status change: %s", seq(second))
+	}
+	if !strings.Contains(string(second), "STATUS:CONFIRMED") || !strings.Contains(string(second), "METHOD:PUBLISH") {
+		t.Fatalf("unexpected ics:\n%s", second)
+	}
+}
@@ -0,0 +1,89 @@
+package booking
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+)
+
+// paidAmount — сколько клиент фактически заплатил: вся сумма при paid,
+// иначе внесённая предоплата
+func paidAmount(m bookingModel) float64 {
+	switch PaymentStatus(m.PaymentStatus) {
+	case PaymentPaid:
+		return m.TotalPrice
+	case PaymentUnpaid:
+		if m.DepositAmount != nil {
+			return *m.DepositAmount
+		}
+	}
+	return 0
+}
+
+// computeRefund считает сумму возврата: percent% от оплаченного
+func computeRefund(paid float64, percent int) float64 {
+	if paid <= 0 || percent <= 0 {
+		return 0
+	}
+	if percent >= 100 {
+		return paid
+	}
+	return math.Round(paid*float64(percent)) / 100
+}
+
+// cancellationRefundPercent — процент возврата при отмене брони.
+// Политика студии применяется к отменам клиентом; отмена студией или
+// администратором возвращает всё.
+func (s *Service) cancellationRefundPercent(ctx context.Context, b *Booking, actor Actor, at time.Time) (int, error) {
+	if actor.Role == ActorOwner || actor.Role == ActorAdmin || actor.Role == ActorSystem {
+		return 100, nil
+	}
+	policy, err := s.rooms.GetStudioCancellationPolicyByRoomID(ctx, b.RoomID)
+	if err != nil {
+		return 0, err
+	}
+	return policy.RefundPercent(b.StartTime.Sub(at).Hours()), nil
+}
+
+// withGroupMembers — бронь вместе с остальными бронями её группы
+func (s *Service) withGroupMembers(ctx context.Context, b Booking) []Booking {
+	if b.GroupID != nil {
+		if members, err := s.bookings.GetByGroupID(ctx, *b.GroupID); err == nil {
+			return members
+		}
+	}
+	return []Booking{b}
+}
+
+// afterCancel — общее продолжение любой отмены брони: возврат денег,
+// снятие напоминаний и предложение окна листу ожидания для каждой
+// освободившейся брони (у группы — каждой комнаты)
+func (s *Service) afterCancel(ctx context.Context, actor Actor, cancelled Booking, reason string) {
+	freed := s.withGroupMembers(ctx, cancelled)
+	s.issueRefunds(ctx, actor, freed, reason)
+	now := time.Now()
+	for _, b := range freed {
+		s.cancelReminders(ctx, b.ID)
+		if b.StartTime.After(now) {
+			s.offerWaitlist(ctx, b.RoomID, b.StartTime, b.EndTime)
+		}
+	}
+}
+
+// issueRefunds отправляет суммы к возврату отменённых броней платёжному
+// провайдеру. Брони без счетов провайдера (оплата на месте) студия
+// возвращает сама — сумма к возврату остаётся в refund_amount.
+func (s *Service) issueRefunds(ctx context.Context, actor Actor, cancelled []Booking, reason string) {
+	if s.refunds == nil {
+		return
+	}
+	for _, b := range cancelled {
+		if b.RefundAmount <= 0 {
+			continue
+		}
+		if err := s.refunds.RefundCancelledBooking(ctx, actor, b.ID, b.RefundAmount, reason); err != nil {
+			log.Printf("Refund for cancelled booking %d: %v", b.ID, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package booking
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCancelWithRefund(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	client := Actor{UserID: 7, Role: ActorClient}
+
+	cases := []struct {
+		name       string
+		payment    PaymentStatus
+		deposit    float64
+		percent    int
+		wantRefund float64
+	}{
+		{"paid full refund", PaymentPaid, 0, 100, 1000},
+		{"paid half refund", PaymentPaid, 0, 50, 500},
+		{"deposit only", PaymentUnpaid, 300, 50, 150},
+		{"too late", PaymentPaid, 0, 0, 0},
+		{"nothing paid", PaymentUnpaid, 0, 100, 0},
+	}
+	for i, c := range cases {
+		b := &Booking{RoomID: 1, StudioID: 1, UserID: 7,
+			StartTime: start.Add(time.Duration(i) * 2 * time.Hour), EndTime: start.Add(time.Duration(i)*2*time.Hour + time.Hour),
+			TotalPrice: 1000, DepositAmount: c.deposit, Status: BookingConfirmed, PaymentStatus: c.payment}
+		if err := repo.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := repo.CancelWithRefund(ctx, b.ID, client, "changed my plans", c.percent)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		// статус оплаты меняется только после подтверждения возврата провайдером
+		if got.Status != BookingCancelled || got.RefundAmount != c.wantRefund || got.PaymentStatus != c.payment {
+			t.Errorf("%s: got status=%s refund=%v payment=%s", c.name, got.Status, got.RefundAmount, got.PaymentStatus)
+		}
+		if c.wantRefund == 500 {
+			if got, _ := repo.ApplyRefund(ctx, b.ID, 200); got.PaymentStatus != PaymentPartiallyRefunded || got.RefundAmount != 500 {
+				t.Errorf("%s: after a partial provider refund got refund=%v payment=%s", c.name, got.RefundAmount, got.PaymentStatus)
+			}
+			if got, _ := repo.ApplyRefund(ctx, b.ID, 1000); got.PaymentStatus != PaymentRefunded {
+				t.Errorf("%s: after a full provider refund got payment=%s", c.name, got.PaymentStatus)
+			}
+		}
+	}
+}
+
+// recordingRefunds запоминает возвраты, отправленные платёжному сервису
+type recordingRefunds struct {
+	amounts map[int64]float64
+}
+
+func (r *recordingRefunds) RefundCancelledBooking(ctx context.Context, actor Actor, bookingID int64, amount float64, reason string) error {
+	r.amounts[bookingID] = amount
+	return nil
+}
+
+func TestCancelBooking_IssuesProviderRefund(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+	refunds := &recordingRefunds{amounts: map[int64]float64{}}
+	svc.refunds = refunds
+
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(time.Hour),
+		TotalPrice: 1000, Status: BookingConfirmed, PaymentStatus: PaymentPaid}
+	if err := svc.bookings.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.CancelBooking(ctx, b.ID, Actor{UserID: 100, Role: ActorOwner}, "studio closed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refunds.amounts[b.ID] != 1000 {
+		t.Fatalf("expected the full amount sent to the provider, got %v", refunds.amounts)
+	}
+	if got.PaymentStatus != PaymentPaid || got.RefundAmount != 1000 {
+		t.Fatalf("expected payment status kept until the refund is confirmed, got %s refund=%v", got.PaymentStatus, got.RefundAmount)
+	}
+}
@@ -1,6 +1,7 @@
 package booking
 
 import (
+	"photostudio/internal/domain/catalog"
 	"time"
 )
 
@@ -11,6 +12,26 @@ type CreateBookingRequest struct {
 	StartTime time.Time `json:"start_time" binding:"required"`
 	EndTime   time.Time `json:"end_time" binding:"required"`
 	Notes     string    `json:"notes,omitempty"`
+
+	// Аренда оборудования студии; стоимость входит в TotalPrice
+	Equipment []EquipmentItemRequest `json:"equipment,omitempty" binding:"omitempty,dive"`
+
+	SeriesID *int64 `json:"-"` // заполняется сервисом при создании серии
+
+	Guest *GuestContact `json:"-"` // бронь гостя без аккаунта (UserID = 0)
+}
+
+// GuestContact — контакты гостя, записанного владельцем на стойке или по телефону
+type GuestContact struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// EquipmentItemRequest — позиция аренды оборудования
+type EquipmentItemRequest struct {
+	EquipmentID int64 `json:"equipment_id" binding:"required"`
+	Quantity    int   `json:"quantity" binding:"required,min=1"`
 }
 
 type UpdatePaymentStatusRequest struct {
@@ -18,9 +39,30 @@ type UpdatePaymentStatusRequest struct {
 }
 
 type BookedSlot struct {
-	Start  string `json:"start"`
-	End    string `json:"end"`
-	Status string `json:"status"`
+	Start   string    `json:"start"` // "10:00" по времени студии
+	End     string    `json:"end"`
+	StartAt time.Time `json:"start_at"` // RFC3339 со смещением зоны студии
+	EndAt   time.Time `json:"end_at"`
+	Status  string    `json:"status"` // booked | buffer | blackout
+}
+
+// Типы занятых слотов в GetAvailability
+const (
+	SlotBooked = "booked"
+	SlotBuffer = "buffer" // подготовка/уборка комнаты вокруг брони
+
+	SlotBlackout = "blackout" // комната закрыта владельцем (ремонт, обслуживание)
+)
+
+func newBookedSlot(start, end time.Time, loc *time.Location, status string) BookedSlot {
+	start, end = start.In(loc), end.In(loc)
+	return BookedSlot{
+		Start:   start.Format("15:04"),
+		End:     end.Format("15:04"),
+		StartAt: start,
+		EndAt:   end,
+		Status:  status,
+	}
 }
 
 type WorkingHours struct {
@@ -31,6 +73,8 @@ type WorkingHours struct {
 type AvailabilityResponse struct {
 	RoomID       int64        `json:"room_id"`
 	Date         string       `json:"date"`
+	Timezone     string       `json:"timezone"`
+	UTCOffset    string       `json:"utc_offset"` // "+05:00"
 	WorkingHours WorkingHours `json:"working_hours"`
 	BookedSlots  []BookedSlot `json:"booked_slots"`
 }
@@ -48,9 +92,15 @@ type BookingResponse struct {
 	TotalPrice float64 `json:"total_price"`
 	Notes      string  `json:"notes,omitempty"`
 	CreatedAt  string  `json:"created_at"`
+	SeriesID   *int64  `json:"series_id,omitempty"`
+	GroupID    *int64  `json:"group_id,omitempty"`
+
+	Equipment []BookingEquipment `json:"equipment,omitempty"`
 
 	// Block 9: Только если отменено
-	CancellationReason string `json:"cancellation_reason,omitempty"`
+	CancellationReason string  `json:"cancellation_reason,omitempty"`
+	RefundAmount       float64 `json:"refund_amount,omitempty"` // возврат по политике отмены
+	PaymentStatus      string  `json:"payment_status,omitempty"`
 
 	// Block 10: Для менеджеров
 	DepositAmount float64 `json:"deposit_amount,omitempty"`
@@ -63,9 +113,35 @@ type CancelBookingRequest struct {
 	Reason string `json:"reason" binding:"required,min=10"`
 }
 
-// UpdateDepositRequest — запрос на обновление предоплаты (для менеджеров)
+// QuoteRequest — запрос предварительного расчёта цены
+type QuoteRequest struct {
+	RoomID    int64     `json:"room_id" binding:"required"`
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+
+	Equipment []EquipmentItemRequest `json:"equipment,omitempty" binding:"omitempty,dive"`
+}
+
+// QuoteResponse — цена слота с разбивкой по строкам
+type QuoteResponse struct {
+	RoomID    int64               `json:"room_id"`
+	StartTime string              `json:"start_time"`
+	EndTime   string              `json:"end_time"`
+	Lines     []catalog.PriceLine `json:"lines"`
+	Subtotal  float64             `json:"subtotal"`
+	Total     float64             `json:"total"`
+}
+
+// RescheduleBookingRequest — новое окно для переноса брони
+type RescheduleBookingRequest struct {
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+}
+
+// UpdateDepositRequest — оплата, принятая студией на месте (для менеджеров).
+// DepositAmount прибавляется к уже внесённой сумме и не может превышать остаток.
 type UpdateDepositRequest struct {
-	DepositAmount float64 `json:"deposit_amount" binding:"required,min=0"`
+	DepositAmount float64 `json:"deposit_amount" binding:"required,gt=0"`
 }
 
 // ToBookingResponse конвертирует Booking в DTO
@@ -79,6 +155,9 @@ func ToBookingResponse(b *Booking, includeDeposit bool) BookingResponse {
 		TotalPrice: b.TotalPrice,
 		Notes:      b.Notes,
 		CreatedAt:  b.CreatedAt.Format(time.RFC3339),
+		SeriesID:   b.SeriesID,
+		GroupID:    b.GroupID,
+		Equipment:  b.Equipment,
 	}
 
 	// Room info
@@ -92,6 +171,8 @@ func ToBookingResponse(b *Booking, includeDeposit bool) BookingResponse {
 	// Причина отмены (если есть)
 	if b.Status == BookingCancelled {
 		resp.CancellationReason = b.CancellationReason
+		resp.RefundAmount = b.RefundAmount
+		resp.PaymentStatus = string(b.PaymentStatus)
 	}
 
 	// Deposit info (только для менеджеров)
@@ -102,3 +183,52 @@ func ToBookingResponse(b *Booking, includeDeposit bool) BookingResponse {
 
 	return resp
 }
+
+// CreateSeriesRequest — запрос на создание повторяющейся брони.
+// StartTime/EndTime задают первое занятие; нужен Count или Until (YYYY-MM-DD, включительно).
+type CreateSeriesRequest struct {
+	RoomID        int64           `json:"room_id" binding:"required"`
+	StudioID      int64           `json:"studio_id" binding:"required"`
+	UserID        int64           `json:"-"`
+	StartTime     time.Time       `json:"start_time" binding:"required"`
+	EndTime       time.Time       `json:"end_time" binding:"required"`
+	Frequency     SeriesFrequency `json:"frequency" binding:"required,oneof=weekly biweekly monthly"`
+	Count         int             `json:"count,omitempty"`
+	Until         string          `json:"until,omitempty"`
+	Notes         string          `json:"notes,omitempty"`
+	SkipConflicts bool            `json:"skip_conflicts"` // создать серию без конфликтных дат
+}
+
+// CancelSeriesRequest — отмена одного занятия (booking_id) или всей серии
+type CancelSeriesRequest struct {
+	Reason    string `json:"reason" binding:"required,min=10"`
+	BookingID *int64 `json:"booking_id,omitempty"`
+}
+
+// RescheduleSeriesRequest — перенос одного занятия (booking_id + start_time/end_time)
+// или всех будущих занятий серии на новое время суток (start_clock/end_clock, "HH:MM")
+type RescheduleSeriesRequest struct {
+	BookingID  *int64     `json:"booking_id,omitempty"`
+	StartTime  *time.Time `json:"start_time,omitempty"`
+	EndTime    *time.Time `json:"end_time,omitempty"`
+	StartClock string     `json:"start_clock,omitempty"`
+	EndClock   string     `json:"end_clock,omitempty"`
+}
+
+// SeriesOccurrence — одно занятие серии (время — в зоне студии)
+type SeriesOccurrence struct {
+	Date          string    `json:"date"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	Status        string    `json:"status"`           // available | booked | conflict
+	Reason        string    `json:"reason,omitempty"` // not_available | outside_working_hours | closed_period | in_past
+	BookingID     int64     `json:"booking_id,omitempty"`
+	BookingStatus string    `json:"booking_status,omitempty"`
+}
+
+type SeriesResponse struct {
+	Series      *BookingSeries     `json:"series,omitempty"`
+	Occurrences []SeriesOccurrence `json:"occurrences"`
+	Booked      int                `json:"booked"`
+	Conflicts   int                `json:"conflicts"`
+}
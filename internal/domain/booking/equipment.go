@@ -0,0 +1,97 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"photostudio/internal/domain/catalog"
+	"time"
+)
+
+// BookingEquipment — оборудование, арендованное в брони.
+// Name и UnitPrice фиксируются на момент брони, цена — за всю бронь.
+type BookingEquipment struct {
+	ID          int64     `json:"id"`
+	BookingID   int64     `json:"booking_id" gorm:"index;not null"`
+	EquipmentID int64     `json:"equipment_id" gorm:"index;not null"`
+	Name        string    `json:"name" gorm:"type:varchar(255)"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	UnitPrice   float64   `json:"unit_price"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (BookingEquipment) TableName() string { return "booking_equipment" }
+
+// resolveEquipment проверяет позиции аренды и фиксирует название и цену.
+// Оборудование должно принадлежать студии комнаты; повторы одной позиции складываются.
+func (s *Service) resolveEquipment(ctx context.Context, roomID int64, items []EquipmentItemRequest) ([]BookingEquipment, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	qty := make(map[int64]int, len(items))
+	ids := make([]int64, 0, len(items))
+	for _, it := range items {
+		if it.EquipmentID <= 0 || it.Quantity <= 0 {
+			return nil, ErrValidation
+		}
+		if _, ok := qty[it.EquipmentID]; !ok {
+			ids = append(ids, it.EquipmentID)
+		}
+		qty[it.EquipmentID] += it.Quantity
+	}
+
+	found, err := s.bookings.GetStudioEquipmentForRoom(ctx, roomID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]catalog.Equipment, len(found))
+	for _, e := range found {
+		byID[e.ID] = e
+	}
+
+	out := make([]BookingEquipment, 0, len(ids))
+	for _, id := range ids {
+		e, ok := byID[id]
+		if !ok {
+			return nil, ErrValidation
+		}
+		// больше, чем есть у студии, не дадим ни в какое время
+		if qty[id] > e.Quantity {
+			return nil, ErrEquipmentUnavailable
+		}
+		out = append(out, BookingEquipment{
+			EquipmentID: e.ID,
+			Name:        e.Name,
+			Quantity:    qty[id],
+			UnitPrice:   e.RentalPrice,
+		})
+	}
+	return out, nil
+}
+
+// addEquipmentLines добавляет аренду оборудования в расчёт цены (после скидки за длительность)
+func addEquipmentLines(quote *catalog.PriceQuote, equipment []BookingEquipment) {
+	var sum float64
+	for _, e := range equipment {
+		amount := math.Round(float64(e.Quantity)*e.UnitPrice*100) / 100
+		quote.Lines = append(quote.Lines, catalog.PriceLine{
+			Kind:        catalog.PriceLineEquipment,
+			Description: fmt.Sprintf("%s × %d", e.Name, e.Quantity),
+			Rate:        e.UnitPrice,
+			Amount:      amount,
+		})
+		sum += amount
+	}
+	quote.Subtotal = math.Round((quote.Subtotal+sum)*100) / 100
+	quote.Total = math.Round((quote.Total+sum)*100) / 100
+}
+
+// equipmentTotal — стоимость аренды уже зафиксированных позиций
+func equipmentTotal(equipment []BookingEquipment) float64 {
+	var sum float64
+	for _, e := range equipment {
+		sum += float64(e.Quantity) * e.UnitPrice
+	}
+	return math.Round(sum*100) / 100
+}
@@ -0,0 +1,50 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEquipmentInventory(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+	if err := repo.db.Exec(`INSERT INTO equipment (id, room_id, name, quantity, rental_price) VALUES (1, 1, 'Profoto B10', 2, 5000)`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	rent := func(roomID int64, from, to time.Duration, qty int) (*Booking, error) {
+		b := &Booking{RoomID: roomID, StudioID: 1, UserID: 7, StartTime: start.Add(from), EndTime: start.Add(to),
+			Status: BookingPending, PaymentStatus: PaymentUnpaid,
+			Equipment: []BookingEquipment{{EquipmentID: 1, Name: "Profoto B10", Quantity: qty, UnitPrice: 5000}}}
+		return b, repo.Create(ctx, b)
+	}
+
+	first, err := rent(1, 0, 2*time.Hour, 2)
+	if err != nil {
+		t.Fatalf("first rental: %v", err)
+	}
+	// другая комната, но то же оборудование в пересекающемся окне
+	if _, err := rent(2, time.Hour, 3*time.Hour, 1); !errors.Is(err, ErrEquipmentUnavailable) {
+		t.Fatalf("expected ErrEquipmentUnavailable, got %v", err)
+	}
+	// окна не пересекаются — весь остаток снова свободен
+	if _, err := rent(2, 2*time.Hour, 4*time.Hour, 2); err != nil {
+		t.Fatalf("adjacent rental: %v", err)
+	}
+
+	items, err := repo.GetBookingEquipment(ctx, first.ID)
+	if err != nil || len(items) != 1 || items[0].Quantity != 2 {
+		t.Fatalf("expected equipment line to be stored with booking, got %+v err=%v", items, err)
+	}
+
+	// отменённая бронь возвращает оборудование
+	if _, err := repo.TransitionStatus(ctx, first.ID, BookingCancelled, SystemActor, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rent(3, time.Hour, 2*time.Hour, 2); err != nil {
+		t.Fatalf("rental after cancel: %v", err)
+	}
+}
@@ -9,4 +9,15 @@ var (
 	ErrForbidden               = errors.New("forbidden")
 	ErrInvalidStatusTransition = errors.New("invalid_status_transition")
 	ErrNotFound                = errors.New("not_found")
+	ErrOutsideWorkingHours     = errors.New("outside_working_hours")
+	ErrSeriesConflict          = errors.New("series_conflict")
+	ErrHoldExpired             = errors.New("hold_expired")
+	ErrEquipmentUnavailable    = errors.New("equipment_unavailable")
+	ErrClosedPeriod            = errors.New("closed_period")
+	ErrSlotAvailable           = errors.New("slot_available")
+	ErrWaitlistOfferExpired    = errors.New("waitlist_offer_expired")
+	ErrGroupedBooking          = errors.New("grouped_booking")
+	ErrInvalidCalendar         = errors.New("invalid_calendar")
+	ErrCalendarFetch           = errors.New("calendar_fetch_failed")
+	ErrSharesNotCovered        = errors.New("participant_shares_not_covered")
 )
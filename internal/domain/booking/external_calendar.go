@@ -0,0 +1,421 @@
+package booking
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"photostudio/internal/pkg/utils"
+	"strings"
+	"syscall"
+	"time"
+
+	"photostudio/internal/domain/catalog"
+	"photostudio/internal/pkg/ical"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// окно импорта: прошедшие события не нужны, дальше года вперёд не бронируют
+	externalImportHorizon = 365 * 24 * time.Hour
+
+	// maxCalendarBytes — предел размера внешнего календаря
+	maxCalendarBytes = 5 << 20
+
+	calendarFetchTimeout = 20 * time.Second
+)
+
+// ExternalCalendar — внешний календарь комнаты (например, брони из Instagram,
+// которые студия ведёт в Google Calendar). Подключается ссылкой iCal и
+// периодически перечитывается, либо загружается один раз файлом .ics (URL пуст).
+type ExternalCalendar struct {
+	ID           int64      `json:"id"`
+	RoomID       int64      `json:"room_id" gorm:"not null;index"`
+	OwnerID      int64      `json:"owner_id" gorm:"not null"`
+	Name         string     `json:"name" gorm:"type:varchar(255);not null"`
+	URL          string     `json:"url,omitempty" gorm:"type:text"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty" gorm:"type:text"`
+	BlockCount   int        `json:"block_count" gorm:"not null;default:0"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (ExternalCalendar) TableName() string { return "room_external_calendars" }
+
+// ExternalBusyBlock — занятое время комнаты из внешнего календаря.
+// Блоки календаря полностью заменяются при каждом импорте.
+type ExternalBusyBlock struct {
+	ID         int64     `json:"id"`
+	CalendarID int64     `json:"calendar_id" gorm:"not null;index"`
+	RoomID     int64     `json:"room_id" gorm:"not null;index:idx_external_busy_blocks_room_time,priority:1"`
+	UID        string    `json:"uid" gorm:"type:varchar(255)"`
+	StartTime  time.Time `json:"start_time" gorm:"not null;index:idx_external_busy_blocks_room_time,priority:2"`
+	EndTime    time.Time `json:"end_time" gorm:"not null"`
+}
+
+func (ExternalBusyBlock) TableName() string { return "external_busy_blocks" }
+
+// AddExternalCalendarRequest — подключение календаря по ссылке
+type AddExternalCalendarRequest struct {
+	Name string `json:"name" binding:"required"`
+	URL  string `json:"url" binding:"required"`
+}
+
+// ExternalCalendarConfig — настройки периодического импорта
+type ExternalCalendarConfig struct {
+	Interval        time.Duration // Как часто перечитывать календари по ссылкам (default: 15m)
+	EnableScheduler bool
+}
+
+// DefaultExternalCalendarConfig возвращает настройки; интервал — BOOKING_ICAL_IMPORT_INTERVAL
+func DefaultExternalCalendarConfig() ExternalCalendarConfig {
+	return ExternalCalendarConfig{
+		Interval:        utils.DurationFromEnv("BOOKING_ICAL_IMPORT_INTERVAL", 15*time.Minute),
+		EnableScheduler: true,
+	}
+}
+
+// CalendarFetcher загружает календарь по ссылке
+type CalendarFetcher interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+var errPrivateAddress = errors.New("calendar url points to a private address")
+
+type httpCalendarFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPCalendarFetcher — загрузка календарей по http(s). Ссылку задаёт
+// владелец студии, поэтому адреса внутренней сети не запрашиваются.
+func NewHTTPCalendarFetcher(timeout time.Duration) CalendarFetcher {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &httpCalendarFetcher{client: &http.Client{Timeout: timeout, Transport: transport}}
+}
+
+func (f *httpCalendarFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/calendar")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCalendarBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCalendarBytes {
+		return nil, fmt.Errorf("calendar is larger than %d bytes", maxCalendarBytes)
+	}
+	return data, nil
+}
+
+// normalizeCalendarURL принимает http(s) и webcal (так ссылки отдаёт Apple/Google)
+func normalizeCalendarURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "", ErrValidation
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "webcal", "webcals":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return "", ErrValidation
+	}
+	return u.String(), nil
+}
+
+// requireRoomOwner проверяет, что комнатой управляет владелец её студии (или админ)
+func (s *Service) requireRoomOwner(ctx context.Context, actor Actor, roomID int64) (*catalog.Room, error) {
+	if actor.Role != ActorOwner && actor.Role != ActorAdmin {
+		return nil, ErrForbidden
+	}
+	room, err := s.rooms.GetByID(ctx, roomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if actor.Role == ActorAdmin {
+		return room, nil
+	}
+	owns, err := s.bookings.IsStudioOwnedByUser(ctx, room.StudioID, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !owns {
+		return nil, ErrForbidden
+	}
+	return room, nil
+}
+
+// externalCalendarFor возвращает календарь, если actor им управляет
+func (s *Service) externalCalendarFor(ctx context.Context, actor Actor, id int64) (*ExternalCalendar, error) {
+	cal, err := s.bookings.GetExternalCalendar(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if _, err := s.requireRoomOwner(ctx, actor, cal.RoomID); err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// AddExternalCalendar подключает календарь по ссылке и сразу импортирует его.
+// Если ссылка не отдаёт календарь, подключение не сохраняется.
+func (s *Service) AddExternalCalendar(ctx context.Context, actor Actor, roomID int64, req AddExternalCalendarRequest) (*ExternalCalendar, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, ErrValidation
+	}
+	link, err := normalizeCalendarURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.requireRoomOwner(ctx, actor, roomID); err != nil {
+		return nil, err
+	}
+
+	data, err := s.calendarFetcher.Fetch(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCalendarFetch, err)
+	}
+	blocks, err := s.parseBusyBlocks(ctx, roomID, data, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ExternalCalendar{RoomID: roomID, OwnerID: actor.UserID, Name: strings.TrimSpace(req.Name), URL: link}
+	if err := s.bookings.CreateExternalCalendar(ctx, cal); err != nil {
+		return nil, err
+	}
+	if err := s.storeBusyBlocks(ctx, cal, blocks); err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// UploadExternalCalendar импортирует файл .ics; такой календарь не обновляется
+// сам — чтобы учесть изменения, файл загружают заново
+func (s *Service) UploadExternalCalendar(ctx context.Context, actor Actor, roomID int64, name string, r io.Reader) (*ExternalCalendar, error) {
+	if strings.TrimSpace(name) == "" {
+		name = "Загруженный календарь"
+	}
+	if _, err := s.requireRoomOwner(ctx, actor, roomID); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxCalendarBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCalendarBytes {
+		return nil, ErrInvalidCalendar
+	}
+	blocks, err := s.parseBusyBlocks(ctx, roomID, data, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ExternalCalendar{RoomID: roomID, OwnerID: actor.UserID, Name: strings.TrimSpace(name)}
+	if err := s.bookings.CreateExternalCalendar(ctx, cal); err != nil {
+		return nil, err
+	}
+	if err := s.storeBusyBlocks(ctx, cal, blocks); err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// ListExternalCalendars возвращает календари, подключённые к комнате
+func (s *Service) ListExternalCalendars(ctx context.Context, actor Actor, roomID int64) ([]ExternalCalendar, error) {
+	if _, err := s.requireRoomOwner(ctx, actor, roomID); err != nil {
+		return nil, err
+	}
+	return s.bookings.ListExternalCalendars(ctx, roomID)
+}
+
+// DeleteExternalCalendar отключает календарь; его занятое время освобождается
+func (s *Service) DeleteExternalCalendar(ctx context.Context, actor Actor, id int64) error {
+	cal, err := s.externalCalendarFor(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	return s.bookings.DeleteExternalCalendar(ctx, cal.ID)
+}
+
+// SyncExternalCalendar перечитывает календарь по ссылке вне расписания
+func (s *Service) SyncExternalCalendar(ctx context.Context, actor Actor, id int64) (*ExternalCalendar, error) {
+	cal, err := s.externalCalendarFor(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if cal.URL == "" {
+		return nil, ErrValidation // загруженный файл нечего перечитывать
+	}
+	if err := s.syncExternalCalendar(ctx, cal, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// RunCalendarImport перечитывает все календари, подключённые по ссылке.
+// Ошибка одного календаря сохраняется в last_error, прежние блоки остаются.
+func (s *Service) RunCalendarImport(ctx context.Context) (int, error) {
+	return s.runCalendarImport(ctx, time.Now().UTC())
+}
+
+func (s *Service) runCalendarImport(ctx context.Context, now time.Time) (int, error) {
+	cals, err := s.bookings.ListLinkedExternalCalendars(ctx)
+	if err != nil {
+		return 0, err
+	}
+	synced := 0
+	for i := range cals {
+		if err := s.syncExternalCalendar(ctx, &cals[i], now); err != nil {
+			log.Printf("External calendar %d (room %d): %v", cals[i].ID, cals[i].RoomID, err)
+			continue
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+func (s *Service) syncExternalCalendar(ctx context.Context, cal *ExternalCalendar, now time.Time) error {
+	data, err := s.calendarFetcher.Fetch(ctx, cal.URL)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrCalendarFetch, err)
+		s.markCalendarFailed(ctx, cal, err)
+		return err
+	}
+	blocks, err := s.parseBusyBlocks(ctx, cal.RoomID, data, now)
+	if err != nil {
+		s.markCalendarFailed(ctx, cal, err)
+		return err
+	}
+	return s.storeBusyBlocks(ctx, cal, blocks)
+}
+
+func (s *Service) markCalendarFailed(ctx context.Context, cal *ExternalCalendar, cause error) {
+	cal.LastError = cause.Error()
+	if err := s.bookings.SetExternalCalendarError(ctx, cal.ID, cal.LastError); err != nil {
+		log.Printf("External calendar %d: save error: %v", cal.ID, err)
+	}
+}
+
+func (s *Service) storeBusyBlocks(ctx context.Context, cal *ExternalCalendar, blocks []ExternalBusyBlock) error {
+	for i := range blocks {
+		blocks[i].CalendarID = cal.ID
+	}
+	syncedAt := time.Now().UTC()
+	if err := s.bookings.ReplaceExternalBusyBlocks(ctx, cal.ID, blocks, syncedAt); err != nil {
+		return err
+	}
+	cal.LastSyncedAt = &syncedAt
+	cal.LastError = ""
+	cal.BlockCount = len(blocks)
+	return nil
+}
+
+// parseBusyBlocks переводит события календаря в занятое время комнаты.
+// Пропускаются отменённые и «свободные» (TRANSP:TRANSPARENT) события,
+// прошедшие и дальше горизонта импорта. Время без зоны — время студии.
+func (s *Service) parseBusyBlocks(ctx context.Context, roomID int64, data []byte, now time.Time) ([]ExternalBusyBlock, error) {
+	loc, err := s.roomLocation(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := ical.Parse(bytes.NewReader(data), loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+
+	horizon := now.Add(externalImportHorizon)
+	blocks := make([]ExternalBusyBlock, 0, len(events))
+	for _, e := range events {
+		if e.Status == ical.StatusCancelled || e.Transparent {
+			continue
+		}
+		if !e.End.After(e.Start) || !e.End.After(now) || !e.Start.Before(horizon) {
+			continue
+		}
+		uid := e.UID
+		if len(uid) > 255 {
+			uid = uid[:255]
+		}
+		blocks = append(blocks, ExternalBusyBlock{
+			RoomID:    roomID,
+			UID:       uid,
+			StartTime: e.Start.UTC(),
+			EndTime:   e.End.UTC(),
+		})
+	}
+	return blocks, nil
+}
+
+// ScheduleCalendarImport запускает периодический импорт внешних календарей
+func (s *Service) ScheduleCalendarImport(ctx context.Context, config ExternalCalendarConfig) chan struct{} {
+	if !config.EnableScheduler {
+		log.Println("External calendar import is disabled")
+		return nil
+	}
+
+	stopCh := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.RunCalendarImport(ctx); err != nil {
+					log.Printf("External calendar import error: %v", err)
+				}
+			case <-stopCh:
+				log.Println("External calendar import stopped")
+				return
+			case <-ctx.Done():
+				log.Println("External calendar import stopped (context Done)")
+				return
+			}
+		}
+	}()
+
+	log.Printf("External calendar import started with interval %v", config.Interval)
+	return stopCh
+}
@@ -0,0 +1,183 @@
+package booking
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"photostudio/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddExternalCalendar подключает внешний календарь комнаты по ссылке
+// @Summary		Подключить внешний календарь комнаты
+// @Description	Только для владельца студии. Ссылка iCal (https или webcal, например «секретный адрес» Google Calendar) сразу импортируется и затем перечитывается каждые BOOKING_ICAL_IMPORT_INTERVAL (15 минут по умолчанию). События календаря становятся занятым временем комнаты: их не забронировать, они видны в busy-slots и доступности. Отменённые и «свободные» события не учитываются, повторения (RRULE) не разворачиваются.
+// @Tags		Бронирования - Календарь
+// @Security	BearerAuth
+// @Param		id path integer true "ID комнаты"
+// @Param		body body AddExternalCalendarRequest true "Название и ссылка"
+// @Success		201 {object} ExternalCalendar "Календарь подключён"
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации или файл не iCalendar"
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Комната не найдена"
+// @Failure		422 {object} map[string]interface{} "Ссылка не отдаёт календарь"
+// @Router		/rooms/{id}/external-calendars [post]
+func (h *Handler) AddExternalCalendar(c *gin.Context) {
+	roomID, ok := roomIDParam(c)
+	if !ok {
+		return
+	}
+	var req AddExternalCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	cal, err := h.service.AddExternalCalendar(c.Request.Context(), actorFromContext(c), roomID, req)
+	if err != nil {
+		h.handleExternalCalendarError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, cal)
+}
+
+// UploadExternalCalendar импортирует файл .ics в занятое время комнаты
+// @Summary		Загрузить .ics для комнаты
+// @Description	Только для владельца студии. Файл импортируется один раз; чтобы учесть изменения, его загружают заново (старую загрузку удаляют). Размер — до 5 МБ.
+// @Tags		Бронирования - Календарь
+// @Security	BearerAuth
+// @Accept		multipart/form-data
+// @Param		id path integer true "ID комнаты"
+// @Param		file formData file true "Файл .ics"
+// @Param		name formData string false "Название"
+// @Success		201 {object} ExternalCalendar "Календарь импортирован"
+// @Failure		400 {object} map[string]interface{} "Нет файла или файл не iCalendar"
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Комната не найдена"
+// @Router		/rooms/{id}/external-calendars/upload [post]
+func (h *Handler) UploadExternalCalendar(c *gin.Context) {
+	roomID, ok := roomIDParam(c)
+	if !ok {
+		return
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "File is required")
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Cannot read file")
+		return
+	}
+	defer f.Close()
+
+	cal, err := h.service.UploadExternalCalendar(c.Request.Context(), actorFromContext(c), roomID, c.PostForm("name"), f)
+	if err != nil {
+		h.handleExternalCalendarError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, cal)
+}
+
+// ListExternalCalendars возвращает внешние календари комнаты
+// @Summary		Внешние календари комнаты
+// @Tags		Бронирования - Календарь
+// @Security	BearerAuth
+// @Param		id path integer true "ID комнаты"
+// @Success		200 {object} map[string]interface{} "calendars"
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Router		/rooms/{id}/external-calendars [get]
+func (h *Handler) ListExternalCalendars(c *gin.Context) {
+	roomID, ok := roomIDParam(c)
+	if !ok {
+		return
+	}
+
+	cals, err := h.service.ListExternalCalendars(c.Request.Context(), actorFromContext(c), roomID)
+	if err != nil {
+		h.handleExternalCalendarError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"calendars": cals})
+}
+
+// SyncExternalCalendar перечитывает календарь по ссылке сразу
+// @Summary		Обновить внешний календарь
+// @Tags		Бронирования - Календарь
+// @Security	BearerAuth
+// @Param		id path integer true "ID календаря"
+// @Success		200 {object} ExternalCalendar
+// @Failure		400 {object} map[string]interface{} "Календарь загружен файлом или файл не iCalendar"
+// @Failure		404 {object} map[string]interface{} "Календарь не найден"
+// @Failure		422 {object} map[string]interface{} "Ссылка не отдаёт календарь"
+// @Router		/external-calendars/{id}/sync [post]
+func (h *Handler) SyncExternalCalendar(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid calendar id")
+		return
+	}
+
+	cal, err := h.service.SyncExternalCalendar(c.Request.Context(), actorFromContext(c), id)
+	if err != nil {
+		h.handleExternalCalendarError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, cal)
+}
+
+// DeleteExternalCalendar отключает внешний календарь
+// @Summary		Отключить внешний календарь
+// @Description	Занятое время из календаря освобождается.
+// @Tags		Бронирования - Календарь
+// @Security	BearerAuth
+// @Param		id path integer true "ID календаря"
+// @Success		200 {object} map[string]interface{}
+// @Failure		404 {object} map[string]interface{} "Календарь не найден"
+// @Router		/external-calendars/{id} [delete]
+func (h *Handler) DeleteExternalCalendar(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid calendar id")
+		return
+	}
+
+	if err := h.service.DeleteExternalCalendar(c.Request.Context(), actorFromContext(c), id); err != nil {
+		h.handleExternalCalendarError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"deleted": true})
+}
+
+func roomIDParam(c *gin.Context) (int64, bool) {
+	roomID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || roomID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid room id")
+		return 0, false
+	}
+	return roomID, true
+}
+
+func (h *Handler) handleExternalCalendarError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrValidation):
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Name and an http(s) or webcal url are required")
+	case errors.Is(err, ErrInvalidCalendar):
+		response.CustomError(c, http.StatusBadRequest, "INVALID_CALENDAR", "File is not a valid iCalendar")
+	case errors.Is(err, ErrCalendarFetch):
+		response.CustomError(c, http.StatusUnprocessableEntity, "CALENDAR_FETCH_FAILED", err.Error())
+	case errors.Is(err, ErrForbidden):
+		response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Only the studio owner can manage room calendars")
+	case errors.Is(err, ErrNotFound):
+		response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Not found")
+	default:
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to import calendar")
+	}
+}
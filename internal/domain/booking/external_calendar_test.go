@@ -0,0 +1,182 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// calendarStub отдаёт testdata/external.ics по HTTP, подставляя даты
+type calendarStub struct {
+	mu     sync.Mutex
+	body   string
+	status int
+}
+
+func newCalendarStub(t *testing.T, day time.Time) (*calendarStub, *httptest.Server) {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/external.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &calendarStub{status: http.StatusOK}
+	stub.setDay(string(raw), day)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		w.Header().Set("Content-Type", "text/calendar")
+		w.WriteHeader(stub.status)
+		_, _ = w.Write([]byte(stub.body))
+	}))
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func (s *calendarStub) setDay(tmpl string, day time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = strings.NewReplacer(
+		"{{DAY}}", day.Format("20060102"),
+		"{{NEXT_DAY}}", day.AddDate(0, 0, 1).Format("20060102"),
+	).Replace(tmpl)
+}
+
+func newExternalCalendarTestService(t *testing.T, srv *httptest.Server) *Service {
+	t.Helper()
+	svc := newGroupTestService(t)
+	if err := svc.bookings.DB().AutoMigrate(&ExternalCalendar{}); err != nil {
+		t.Fatal(err)
+	}
+	// заглушка живёт на localhost, который боевой загрузчик не запрашивает
+	svc.calendarFetcher = &httpCalendarFetcher{client: srv.Client()}
+	return svc
+}
+
+func TestExternalCalendar_ImportBlocksAvailability(t *testing.T) {
+	ctx := context.Background()
+	day := nextWeekday(time.Tuesday)
+	stub, srv := newCalendarStub(t, day)
+	svc := newExternalCalendarTestService(t, srv)
+	owner := Actor{UserID: 100, Role: ActorOwner}
+
+	if _, err := svc.AddExternalCalendar(ctx, Actor{UserID: 7, Role: ActorClient}, 1, AddExternalCalendarRequest{Name: "Instagram", URL: srv.URL}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("client must not attach calendars, got %v", err)
+	}
+	if _, err := svc.AddExternalCalendar(ctx, owner, 1, AddExternalCalendarRequest{Name: "Instagram", URL: "file:///etc/passwd"}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("non-http urls must be rejected, got %v", err)
+	}
+
+	cal, err := svc.AddExternalCalendar(ctx, owner, 1, AddExternalCalendarRequest{Name: "Instagram", URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// отменённое и «свободное» события не занимают комнату
+	if cal.BlockCount != 2 || cal.LastSyncedAt == nil {
+		t.Fatalf("expected 2 imported blocks, got %+v", cal)
+	}
+
+	// 17:00-19:00 Asia/Almaty = 12:00-14:00 UTC (время студии в тесте — UTC)
+	busy, err := svc.GetBusySlots(ctx, 1, day, day.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(busy) != 2 || !busy[0].Start.Equal(day.Add(12*time.Hour)) || !busy[1].End.Equal(day.AddDate(0, 0, 2)) {
+		t.Fatalf("unexpected busy slots: %+v", busy)
+	}
+
+	ok, err := svc.bookings.CheckAvailability(ctx, 1, day.Add(13*time.Hour), day.Add(15*time.Hour))
+	if err != nil || ok {
+		t.Fatalf("externally busy window must be unavailable (ok=%v, err=%v)", ok, err)
+	}
+	if _, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 1, StudioID: 1, UserID: 7, StartTime: day.Add(13 * time.Hour), EndTime: day.Add(15 * time.Hour)}); !errors.Is(err, ErrNotAvailable) {
+		t.Fatalf("expected ErrNotAvailable, got %v", err)
+	}
+	// другая комната не затронута
+	if _, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 2, StudioID: 1, UserID: 7, StartTime: day.Add(13 * time.Hour), EndTime: day.Add(15 * time.Hour)}); err != nil {
+		t.Fatalf("room 2 must stay free: %v", err)
+	}
+
+	// событие перенесли на неделю: импорт освобождает старое время
+	raw, _ := os.ReadFile("testdata/external.ics")
+	stub.setDay(string(raw), day.AddDate(0, 0, 7))
+	if n, err := svc.RunCalendarImport(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 synced calendar, got %d (%v)", n, err)
+	}
+	if _, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 1, StudioID: 1, UserID: 7, StartTime: day.Add(13 * time.Hour), EndTime: day.Add(15 * time.Hour)}); err != nil {
+		t.Fatalf("old window must be free after re-import: %v", err)
+	}
+
+	// недоступный календарь не стирает прежнее занятое время
+	stub.mu.Lock()
+	stub.status = http.StatusNotFound
+	stub.mu.Unlock()
+	if n, _ := svc.RunCalendarImport(ctx); n != 0 {
+		t.Fatalf("failed import must not count as synced")
+	}
+	got, err := svc.bookings.GetExternalCalendar(ctx, cal.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LastError == "" || got.BlockCount != 2 {
+		t.Fatalf("expected last_error and kept blocks, got %+v", got)
+	}
+	nextWeek := day.AddDate(0, 0, 7)
+	if ok, _ := svc.bookings.CheckAvailability(ctx, 1, nextWeek.Add(13*time.Hour), nextWeek.Add(15*time.Hour)); ok {
+		t.Fatal("blocks must survive a failed import")
+	}
+
+	if err := svc.DeleteExternalCalendar(ctx, owner, cal.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := svc.bookings.CheckAvailability(ctx, 1, nextWeek.Add(13*time.Hour), nextWeek.Add(15*time.Hour)); !ok {
+		t.Fatal("deleting the calendar must free its busy time")
+	}
+}
+
+func TestExternalCalendar_Upload(t *testing.T) {
+	ctx := context.Background()
+	day := nextWeekday(time.Wednesday)
+	stub, srv := newCalendarStub(t, day)
+	svc := newExternalCalendarTestService(t, srv)
+	owner := Actor{UserID: 100, Role: ActorOwner}
+
+	if _, err := svc.UploadExternalCalendar(ctx, owner, 2, "", strings.NewReader("not a calendar")); !errors.Is(err, ErrInvalidCalendar) {
+		t.Fatalf("expected ErrInvalidCalendar, got %v", err)
+	}
+
+	cal, err := svc.UploadExternalCalendar(ctx, owner, 2, "", strings.NewReader(stub.body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cal.URL != "" || cal.BlockCount != 2 {
+		t.Fatalf("unexpected upload result: %+v", cal)
+	}
+	if _, err := svc.SyncExternalCalendar(ctx, owner, cal.ID); !errors.Is(err, ErrValidation) {
+		t.Fatalf("uploaded calendars cannot be re-synced, got %v", err)
+	}
+	// загруженные файлы не перечитываются планировщиком
+	if n, _ := svc.RunCalendarImport(ctx); n != 0 {
+		t.Fatalf("expected no linked calendars, got %d", n)
+	}
+
+	// целодневное событие закрывает следующий день
+	next := day.AddDate(0, 0, 1)
+	if ok, _ := svc.bookings.CheckAvailability(ctx, 2, next.Add(10*time.Hour), next.Add(11*time.Hour)); ok {
+		t.Fatal("all-day external event must block the whole day")
+	}
+}
+
+func TestHTTPCalendarFetcher_RejectsPrivateAddresses(t *testing.T) {
+	_, srv := newCalendarStub(t, time.Now())
+	_, err := NewHTTPCalendarFetcher(time.Second).Fetch(context.Background(), srv.URL)
+	if err == nil || !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("expected private address error, got %v", err)
+	}
+}
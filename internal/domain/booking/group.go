@@ -0,0 +1,238 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxGroupRooms — сколько комнат можно занять одной групповой бронью
+const maxGroupRooms = 20
+
+// BookingGroup — бронь нескольких комнат одной студии на одно окно.
+// Брони группы создаются и меняют статус только вместе; Status повторяет их общий статус.
+type BookingGroup struct {
+	ID         int64         `json:"id"`
+	UserID     int64         `json:"user_id" gorm:"not null;index"`
+	StudioID   int64         `json:"studio_id" gorm:"not null;index"`
+	StartTime  time.Time     `json:"start_time" gorm:"not null"`
+	EndTime    time.Time     `json:"end_time" gorm:"not null"`
+	TotalPrice float64       `json:"total_price"`
+	Status     BookingStatus `json:"status" gorm:"type:varchar(20);not null"`
+	Notes      string        `json:"notes,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+func (BookingGroup) TableName() string { return "booking_groups" }
+
+// CreateGroupRequest — бронь нескольких комнат (RoomIDs) или всей студии (WholeStudio)
+type CreateGroupRequest struct {
+	StudioID    int64     `json:"studio_id" binding:"required"`
+	RoomIDs     []int64   `json:"room_ids,omitempty"`
+	WholeStudio bool      `json:"whole_studio,omitempty"`
+	StartTime   time.Time `json:"start_time" binding:"required"`
+	EndTime     time.Time `json:"end_time" binding:"required"`
+	Notes       string    `json:"notes,omitempty"`
+	UserID      int64     `json:"-"`
+}
+
+// GroupRoomConflict — почему комнату нельзя занять в окне группы
+type GroupRoomConflict struct {
+	RoomID int64  `json:"room_id"`
+	Reason string `json:"reason"` // not_available | outside_working_hours | closed_period
+}
+
+// GroupResponse — группа с бронями комнат; при конфликте — только Conflicts
+type GroupResponse struct {
+	Group     *BookingGroup       `json:"group,omitempty"`
+	Bookings  []BookingResponse   `json:"bookings,omitempty"`
+	Conflicts []GroupRoomConflict `json:"conflicts,omitempty"`
+}
+
+// groupRoomIDs определяет комнаты группы: все активные комнаты студии
+// или явный список (минимум две разные комнаты этой студии)
+func (s *Service) groupRoomIDs(ctx context.Context, req CreateGroupRequest) ([]int64, error) {
+	if req.WholeStudio {
+		rooms, err := s.rooms.GetByStudioID(ctx, req.StudioID)
+		if err != nil {
+			return nil, err
+		}
+		if len(rooms) == 0 {
+			return nil, ErrNotFound
+		}
+		ids := make([]int64, 0, len(rooms))
+		for _, r := range rooms {
+			ids = append(ids, r.ID)
+		}
+		return ids, nil
+	}
+
+	seen := make(map[int64]bool, len(req.RoomIDs))
+	ids := make([]int64, 0, len(req.RoomIDs))
+	for _, id := range req.RoomIDs {
+		if id <= 0 || seen[id] {
+			return nil, ErrValidation
+		}
+		seen[id] = true
+
+		room, err := s.rooms.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		if room.StudioID != req.StudioID || !room.IsActive {
+			return nil, ErrValidation
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) < 2 {
+		return nil, ErrValidation
+	}
+	return ids, nil
+}
+
+// CreateGroup бронирует несколько комнат студии на одно окно по принципу
+// «всё или ничего». Если хоть одна комната недоступна, возвращается
+// ErrNotAvailable и разбор по комнатам, ничего не создаётся.
+func (s *Service) CreateGroup(ctx context.Context, req CreateGroupRequest) (*GroupResponse, error) {
+	if !req.EndTime.After(req.StartTime) || req.StartTime.Before(time.Now()) {
+		return nil, ErrValidation
+	}
+	if req.WholeStudio == (len(req.RoomIDs) > 0) {
+		// либо список комнат, либо вся студия
+		return nil, ErrValidation
+	}
+
+	roomIDs, err := s.groupRoomIDs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(roomIDs) > maxGroupRooms {
+		return nil, ErrValidation
+	}
+	// одинаковый порядок вставки снижает риск deadlock между группами
+	sort.Slice(roomIDs, func(i, j int) bool { return roomIDs[i] < roomIDs[j] })
+
+	resp := &GroupResponse{}
+	for _, roomID := range roomIDs {
+		reason, err := s.occurrenceConflict(ctx, roomID, TimeSlot{Start: req.StartTime, End: req.EndTime}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			resp.Conflicts = append(resp.Conflicts, GroupRoomConflict{RoomID: roomID, Reason: reason})
+		}
+	}
+	if len(resp.Conflicts) > 0 {
+		return resp, ErrNotAvailable
+	}
+
+	bookings := make([]*Booking, 0, len(roomIDs))
+	var total float64
+	for _, roomID := range roomIDs {
+		price, err := s.quotePrice(ctx, roomID, req.StartTime, req.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		total += price
+		bookings = append(bookings, &Booking{
+			RoomID:        roomID,
+			StudioID:      req.StudioID,
+			UserID:        req.UserID,
+			StartTime:     req.StartTime.UTC(),
+			EndTime:       req.EndTime.UTC(),
+			TotalPrice:    price,
+			Status:        BookingPending,
+			PaymentStatus: PaymentUnpaid,
+			Notes:         req.Notes,
+		})
+	}
+
+	group := &BookingGroup{
+		UserID:     req.UserID,
+		StudioID:   req.StudioID,
+		StartTime:  req.StartTime.UTC(),
+		EndTime:    req.EndTime.UTC(),
+		TotalPrice: math.Round(total*100) / 100,
+		Status:     BookingPending,
+		Notes:      req.Notes,
+	}
+	if err := s.bookings.CreateGroup(ctx, group, bookings); err != nil {
+		return nil, err
+	}
+
+	// Владельцу — одно уведомление на группу
+	s.notifyOwnerBookingCreated(ctx, bookings[0])
+
+	resp.Group = group
+	for _, b := range bookings {
+		resp.Bookings = append(resp.Bookings, ToBookingResponse(b, false))
+	}
+	return resp, nil
+}
+
+// GetGroup возвращает группу с бронями. Доступно клиенту, владельцу студии и администратору.
+func (s *Service) GetGroup(ctx context.Context, groupID, userID int64, role string) (*GroupResponse, error) {
+	group, bookings, err := s.groupFor(ctx, groupID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	resp := &GroupResponse{Group: group}
+	for i := range bookings {
+		resp.Bookings = append(resp.Bookings, ToBookingResponse(&bookings[i], false))
+	}
+	return resp, nil
+}
+
+// CancelGroup отменяет все брони группы. Отмена клиентом идёт по политике
+// отмены студии, отмена студией или администратором возвращает всё.
+func (s *Service) CancelGroup(ctx context.Context, groupID int64, actor Actor, reason string) (*GroupResponse, error) {
+	group, bookings, err := s.groupFor(ctx, groupID, actor.UserID, actor.Role)
+	if err != nil {
+		return nil, err
+	}
+	if group.UserID == actor.UserID {
+		actor.Role = ActorClient
+	}
+	// любая бронь группы отменяет всю группу
+	if _, err := s.CancelBooking(ctx, bookings[0].ID, actor, reason); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, groupID, actor.UserID, actor.Role)
+}
+
+// groupFor загружает группу и её брони с проверкой доступа
+func (s *Service) groupFor(ctx context.Context, groupID, userID int64, role string) (*BookingGroup, []Booking, error) {
+	group, err := s.bookings.GetGroupByID(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	bookings, err := s.bookings.GetByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(bookings) == 0 {
+		return nil, nil, ErrNotFound
+	}
+
+	if role != ActorAdmin && group.UserID != userID {
+		isOwner, err := s.IsBookingStudioOwner(ctx, userID, bookings[0].ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !isOwner {
+			return nil, nil, ErrForbidden
+		}
+	}
+	return group, bookings, nil
+}
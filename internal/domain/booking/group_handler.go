@@ -0,0 +1,132 @@
+package booking
+
+import (
+	"errors"
+	"net/http"
+	"photostudio/internal/domain/catalog"
+	"photostudio/internal/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateGroup бронирует несколько комнат студии или всю студию
+// @Summary		Забронировать несколько комнат
+// @Description	Создаёт групповую бронь: room_ids (минимум две комнаты одной студии) или whole_studio=true (все активные комнаты). Комнаты бронируются атомарно — если хоть одна занята, ничего не создаётся и в error.details возвращается разбор по комнатам. Брони группы меняют статус только вместе: подтверждение, отмена или завершение любой из них применяется ко всей группе.
+// @Tags		Бронирования - Группы
+// @Security	BearerAuth
+// @Param		body body CreateGroupRequest true "Студия, комнаты и окно"
+// @Success		201 {object} GroupResponse "Группа создана"
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure		401 {object} map[string]interface{} "Ошибка аутентификации"
+// @Failure		404 {object} map[string]interface{} "Комната или студия не найдена"
+// @Failure		409 {object} map[string]interface{} "Часть комнат недоступна"
+// @Failure		500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router		/booking-groups [post]
+func (h *Handler) CreateGroup(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID <= 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Missing auth")
+		return
+	}
+
+	var req CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.service.CreateGroup(c.Request.Context(), req)
+	if err != nil {
+		h.handleGroupError(c, resp, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, resp)
+}
+
+// GetGroup возвращает групповую бронь
+// @Summary		Получить групповую бронь
+// @Tags		Бронирования - Группы
+// @Security	BearerAuth
+// @Param		id path integer true "ID группы"
+// @Success		200 {object} GroupResponse
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Группа не найдена"
+// @Router		/booking-groups/{id} [get]
+func (h *Handler) GetGroup(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || groupID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid group id")
+		return
+	}
+
+	resp, err := h.service.GetGroup(c.Request.Context(), groupID, c.GetInt64("user_id"), c.GetString("role"))
+	if err != nil {
+		h.handleGroupError(c, nil, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+// CancelGroup отменяет все брони группы
+// @Summary		Отменить групповую бронь
+// @Description	Отменяет брони всех комнат группы. Возврат считается по политике отмены студии для каждой брони.
+// @Tags		Бронирования - Группы
+// @Security	BearerAuth
+// @Param		id path integer true "ID группы"
+// @Param		body body CancelBookingRequest true "Причина отмены (минимум 10 символов)"
+// @Success		200 {object} GroupResponse
+// @Failure		400 {object} map[string]interface{} "Группу нельзя отменить в текущем статусе"
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Группа не найдена"
+// @Router		/booking-groups/{id}/cancel [patch]
+func (h *Handler) CancelGroup(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || groupID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid group id")
+		return
+	}
+
+	var req CancelBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR",
+			"Причина отмены обязательна (минимум 10 символов)")
+		return
+	}
+
+	resp, err := h.service.CancelGroup(c.Request.Context(), groupID, actorFromContext(c), req.Reason)
+	if err != nil {
+		h.handleGroupError(c, nil, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+func (h *Handler) handleGroupError(c *gin.Context, resp *GroupResponse, err error) {
+	switch {
+	case errors.Is(err, ErrNotAvailable), errors.Is(err, ErrOverbooking):
+		var details any
+		if resp != nil {
+			details = resp.Conflicts
+		}
+		response.ErrorWithDetails(c, http.StatusConflict, "GROUP_CONFLICT",
+			"Some rooms are not available for the selected time", details)
+	case errors.Is(err, ErrValidation):
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR",
+			"Invalid group: pass room_ids (2+ active rooms of the studio) or whole_studio, and a future time range")
+	case errors.Is(err, catalog.ErrBelowMinDuration):
+		response.CustomError(c, http.StatusBadRequest, "BELOW_MIN_DURATION", "Booking is shorter than a room minimum duration")
+	case errors.Is(err, ErrInvalidStatusTransition):
+		response.CustomError(c, http.StatusBadRequest, "INVALID_STATUS", "Group cannot be changed in its current status")
+	case errors.Is(err, ErrForbidden):
+		response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Access denied")
+	case errors.Is(err, ErrNotFound):
+		response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Group, studio or room not found")
+	default:
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+	}
+}
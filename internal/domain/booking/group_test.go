@@ -0,0 +1,135 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newGroupTestService(t *testing.T) *Service {
+	t.Helper()
+	svc, _ := newScheduleTestService(t)
+	db := svc.bookings.DB()
+	if err := db.AutoMigrate(&BookingGroup{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`CREATE TABLE studios (id INTEGER PRIMARY KEY, owner_id INTEGER)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`INSERT INTO studios (id, owner_id) VALUES (1, 100)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestCreateGroup_AllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+
+	day := nextWeekday(time.Tuesday)
+	start, end := day.Add(12*time.Hour), day.Add(14*time.Hour)
+	if _, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 2, StudioID: 1, UserID: 9, StartTime: start, EndTime: end}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := svc.CreateGroup(ctx, CreateGroupRequest{StudioID: 1, WholeStudio: true, UserID: 7, StartTime: start, EndTime: end})
+	if !errors.Is(err, ErrNotAvailable) {
+		t.Fatalf("expected ErrNotAvailable, got %v", err)
+	}
+	if resp == nil || len(resp.Conflicts) != 1 || resp.Conflicts[0].RoomID != 2 {
+		t.Fatalf("expected a conflict for room 2 only, got %+v", resp)
+	}
+
+	var count int64
+	svc.bookings.DB().Model(&bookingModel{}).Where("user_id = ?", 7).Count(&count)
+	if count != 0 {
+		t.Fatalf("no room must be booked when one is busy, got %d bookings", count)
+	}
+}
+
+func TestCreateGroup_Validation(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+
+	day := nextWeekday(time.Tuesday)
+	start, end := day.Add(12*time.Hour), day.Add(14*time.Hour)
+	cases := map[string]CreateGroupRequest{
+		"single room":     {StudioID: 1, RoomIDs: []int64{1}, StartTime: start, EndTime: end},
+		"duplicate rooms": {StudioID: 1, RoomIDs: []int64{1, 1}, StartTime: start, EndTime: end},
+		"both modes":      {StudioID: 1, RoomIDs: []int64{1, 2}, WholeStudio: true, StartTime: start, EndTime: end},
+		"other studio":    {StudioID: 2, RoomIDs: []int64{1, 2}, StartTime: start, EndTime: end},
+	}
+	for name, req := range cases {
+		req.UserID = 7
+		if _, err := svc.CreateGroup(ctx, req); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: expected ErrValidation, got %v", name, err)
+		}
+	}
+}
+
+func TestCreateGroup_SharedStatus(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+
+	day := nextWeekday(time.Tuesday)
+	start, end := day.Add(12*time.Hour), day.Add(14*time.Hour)
+	resp, err := svc.CreateGroup(ctx, CreateGroupRequest{StudioID: 1, RoomIDs: []int64{3, 1}, UserID: 7, StartTime: start, EndTime: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Bookings) != 2 || resp.Group.TotalPrice != 4000 {
+		t.Fatalf("expected 2 bookings for 4000 in total, got %+v", resp)
+	}
+	for _, b := range resp.Bookings {
+		if b.GroupID == nil || *b.GroupID != resp.Group.ID {
+			t.Fatalf("booking %d is not linked to the group", b.ID)
+		}
+	}
+
+	// подтверждение одной брони подтверждает всю группу
+	if err := svc.UpdateStatus(ctx, resp.Bookings[0].ID, string(BookingConfirmed), Actor{UserID: 1, Role: ActorAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.GetGroup(ctx, resp.Group.ID, 7, ActorClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Group.Status != BookingConfirmed {
+		t.Fatalf("group status = %s, want confirmed", got.Group.Status)
+	}
+	for _, b := range got.Bookings {
+		if b.Status != string(BookingConfirmed) {
+			t.Fatalf("booking %d status = %s, want confirmed", b.ID, b.Status)
+		}
+	}
+
+	// перенос отдельной комнаты группы запрещён
+	_, err = svc.RescheduleBooking(ctx, resp.Bookings[1].ID, Actor{UserID: 7, Role: ActorClient},
+		RescheduleBookingRequest{StartTime: start.Add(3 * time.Hour), EndTime: end.Add(3 * time.Hour)})
+	if !errors.Is(err, ErrGroupedBooking) {
+		t.Fatalf("expected ErrGroupedBooking, got %v", err)
+	}
+
+	if _, err := svc.GetGroup(ctx, resp.Group.ID, 8, ActorClient); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("stranger must not see the group, got %v", err)
+	}
+
+	cancelled, err := svc.CancelGroup(ctx, resp.Group.ID, Actor{UserID: 7, Role: ActorClient}, "event moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Group.Status != BookingCancelled {
+		t.Fatalf("group status = %s, want cancelled", cancelled.Group.Status)
+	}
+	for _, b := range cancelled.Bookings {
+		if b.Status != string(BookingCancelled) {
+			t.Fatalf("booking %d status = %s, want cancelled", b.ID, b.Status)
+		}
+	}
+
+	// освободившиеся комнаты снова можно бронировать
+	if _, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 3, StudioID: 1, UserID: 9, StartTime: start, EndTime: end}); err != nil {
+		t.Fatalf("room must be free after group cancel: %v", err)
+	}
+}
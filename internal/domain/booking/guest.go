@@ -0,0 +1,94 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GuestBookingRequest — бронь, которую владелец записывает за гостя без
+// аккаунта (звонок, клиент на стойке). Студия берётся из комнаты.
+type GuestBookingRequest struct {
+	RoomID     int64                  `json:"room_id" binding:"required"`
+	StartTime  time.Time              `json:"start_time" binding:"required"`
+	EndTime    time.Time              `json:"end_time" binding:"required"`
+	GuestName  string                 `json:"guest_name" binding:"required,max=255"`
+	GuestPhone string                 `json:"guest_phone,omitempty" binding:"omitempty,max=50"`
+	GuestEmail string                 `json:"guest_email,omitempty" binding:"omitempty,email,max=255"`
+	Notes      string                 `json:"notes,omitempty"`
+	Equipment  []EquipmentItemRequest `json:"equipment,omitempty" binding:"omitempty,dive"`
+}
+
+// CreateGuestBooking записывает гостя на слот от имени владельца студии.
+// Слот проверяется так же, как для клиента (часы работы, закрытия, буферы,
+// оборудование), а бронь сразу подтверждается: её создала сама студия.
+// Уведомления и напоминания гостю не отправляются — у него нет аккаунта.
+func (s *Service) CreateGuestBooking(ctx context.Context, actor Actor, req GuestBookingRequest) (*Booking, error) {
+	guest := GuestContact{
+		Name:  strings.TrimSpace(req.GuestName),
+		Phone: strings.TrimSpace(req.GuestPhone),
+		Email: strings.ToLower(strings.TrimSpace(req.GuestEmail)),
+	}
+	// без телефона или почты гостя потом не найти и не привязать к аккаунту
+	if guest.Name == "" || (guest.Phone == "" && guest.Email == "") {
+		return nil, ErrValidation
+	}
+
+	room, err := s.requireRoomOwner(ctx, actor, req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.insertBooking(ctx, CreateBookingRequest{
+		RoomID:    req.RoomID,
+		StudioID:  room.StudioID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Notes:     req.Notes,
+		Equipment: req.Equipment,
+		Guest:     &guest,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.TransitionStatus(ctx, b.ID, BookingConfirmed, actor, "guest booking")
+}
+
+// LinkGuestBooking привязывает гостевую бронь к аккаунту клиента (гость
+// зарегистрировался позже). Контакты гостя остаются в брони для истории.
+func (s *Service) LinkGuestBooking(ctx context.Context, actor Actor, bookingID, userID int64) (*Booking, error) {
+	if userID <= 0 {
+		return nil, ErrValidation
+	}
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if actor.Role != ActorAdmin {
+		owns, err := s.bookings.IsStudioOwnedByUser(ctx, b.StudioID, actor.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !owns {
+			return nil, ErrForbidden
+		}
+	}
+	if !b.IsGuest() {
+		return nil, ErrValidation
+	}
+
+	if err := s.bookings.LinkGuestBooking(ctx, bookingID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s.GetByID(ctx, bookingID)
+}
@@ -0,0 +1,109 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newGuestTestService(t *testing.T) *Service {
+	t.Helper()
+	svc, _ := newScheduleTestService(t)
+	db := svc.bookings.DB()
+	for _, stmt := range []string{
+		`CREATE TABLE studios (id INTEGER PRIMARY KEY, owner_id INTEGER, name TEXT)`,
+		`INSERT INTO studios (id, owner_id, name) VALUES (1, 100, 'Loft')`,
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT, phone TEXT)`,
+		`INSERT INTO users (id, name, email, phone) VALUES (5, 'Anna', 'anna@example.com', '+77010000005')`,
+		`ALTER TABLE rooms ADD COLUMN name TEXT`,
+		`INSERT INTO rooms (id, studio_id, name) VALUES (2, 1, 'Hall')`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return svc
+}
+
+func TestGuestBooking_CreateListAndLink(t *testing.T) {
+	ctx := context.Background()
+	svc := newGuestTestService(t)
+	owner := Actor{UserID: 100, Role: ActorOwner}
+
+	day := nextWeekday(time.Thursday)
+	req := GuestBookingRequest{
+		RoomID: 2, StartTime: day.Add(12 * time.Hour), EndTime: day.Add(14 * time.Hour),
+		GuestName: " Walk-in Guest ", GuestPhone: "+77010000001",
+	}
+	b, err := svc.CreateGuestBooking(ctx, owner, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.IsGuest() || b.Status != BookingConfirmed || b.GuestName != "Walk-in Guest" || b.StudioID != 1 {
+		t.Fatalf("expected a confirmed guest booking, got %+v", b)
+	}
+	var nullUsers int64
+	svc.bookings.DB().Model(&bookingModel{}).Where("id = ? AND user_id IS NULL", b.ID).Count(&nullUsers)
+	if nullUsers != 1 {
+		t.Fatal("guest booking must store user_id as NULL")
+	}
+
+	// слот гостя занят для всех
+	if _, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 2, StudioID: 1, UserID: 5, StartTime: req.StartTime, EndTime: req.EndTime}); !errors.Is(err, ErrNotAvailable) {
+		t.Fatalf("expected ErrNotAvailable, got %v", err)
+	}
+
+	rows, total, err := svc.bookings.GetManagerBookings(ctx, 100, ManagerBookingFilters{ClientName: "walk-in"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || !rows[0].IsGuest || rows[0].ClientID != 0 || rows[0].ClientPhone != "+77010000001" {
+		t.Fatalf("expected the guest in manager bookings, got %d %+v", total, rows)
+	}
+
+	if _, err := svc.LinkGuestBooking(ctx, owner, b.ID, 404); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an unknown user, got %v", err)
+	}
+	if _, err := svc.LinkGuestBooking(ctx, Actor{UserID: 200, Role: ActorOwner}, b.ID, 5); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for another owner, got %v", err)
+	}
+	linked, err := svc.LinkGuestBooking(ctx, owner, b.ID, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked.UserID != 5 || linked.GuestName != "Walk-in Guest" {
+		t.Fatalf("expected the booking linked to user 5 with guest contacts kept, got %+v", linked)
+	}
+	if _, err := svc.LinkGuestBooking(ctx, owner, b.ID, 5); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation for an already linked booking, got %v", err)
+	}
+
+	row, err := svc.bookings.GetBookingForManager(ctx, 100, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.IsGuest || row.ClientID != 5 || row.ClientName != "Anna" {
+		t.Fatalf("expected the linked client in manager view, got %+v", row)
+	}
+}
+
+func TestGuestBooking_Validation(t *testing.T) {
+	ctx := context.Background()
+	svc := newGuestTestService(t)
+
+	day := nextWeekday(time.Thursday)
+	req := GuestBookingRequest{RoomID: 2, StartTime: day.Add(12 * time.Hour), EndTime: day.Add(14 * time.Hour), GuestName: "Guest"}
+
+	if _, err := svc.CreateGuestBooking(ctx, Actor{UserID: 100, Role: ActorOwner}, req); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation without phone or email, got %v", err)
+	}
+
+	req.GuestEmail = "guest@example.com"
+	if _, err := svc.CreateGuestBooking(ctx, Actor{UserID: 200, Role: ActorOwner}, req); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for another owner's room, got %v", err)
+	}
+	if _, err := svc.CreateGuestBooking(ctx, Actor{UserID: 5, Role: ActorClient}, req); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for a client, got %v", err)
+	}
+}
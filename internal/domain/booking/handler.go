@@ -21,7 +21,7 @@ func NewHandler(service *Service) *Handler {
 
 // CreateBooking создаёт новое бронирование на указанное время и комнату
 // @Summary		Создать новое бронирование
-// @Description	Создаёт новое бронирование на указанную дату и время в выбранной комнате. Проверяет доступность времени, наличие конфликтов с существующими бронированиями и валидность переданных данных. Пользователь идентифицируется по токену аутентификации. При успешном создании возвращается ID и статус новой брони.
+// @Description	Создаёт новое бронирование на указанную дату и время в выбранной комнате. Проверяет доступность времени, наличие конфликтов с существующими бронированиями и валидность переданных данных. Пользователь идентифицируется по токену аутентификации. Можно арендовать оборудование студии (equipment: equipment_id, quantity) — остаток проверяется по пересекающимся броням, стоимость входит в total_price. При успешном создании возвращается ID, статус и итоговая цена новой брони.
 // @Tags		Бронирования
 // @Security	BearerAuth
 // @Param		body body CreateBookingRequest true "Данные для создания бронирования (room_id, user_id, start_time, end_time)"
@@ -68,58 +68,155 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 
 	b, err := h.service.CreateBooking(c.Request.Context(), req)
 	if err != nil {
-		switch {
-		case errors.Is(err, ErrValidation):
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error": gin.H{
-					"code":    "VALIDATION_ERROR",
-					"message": "Invalid booking time range",
-				},
-			})
-			return
-		case errors.Is(err, ErrNotAvailable), errors.Is(err, ErrOverbooking):
-			c.JSON(http.StatusConflict, gin.H{
-				"success": false,
-				"error": gin.H{
-					"code":    "BOOKING_CONFLICT",
-					"message": "Room is not available for the selected time",
-				},
-			})
-			return
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error": gin.H{
-					"code":    "INTERNAL_ERROR",
-					"message": "Failed to create booking",
-				},
-			})
-			return
-		}
+		writeCreateBookingError(c, err)
+		return
 	}
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"data": gin.H{
 			"booking": gin.H{
-				"id":     b.ID,
-				"status": b.Status,
+				"id":          b.ID,
+				"status":      b.Status,
+				"total_price": b.TotalPrice,
+				"equipment":   b.Equipment,
 			},
 		},
 	})
 }
 
+// writeCreateBookingError маппит ошибки создания брони/hold в HTTP-ответ
+func writeCreateBookingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrValidation):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid booking time range",
+			},
+		})
+		return
+	case errors.Is(err, ErrOutsideWorkingHours):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "OUTSIDE_WORKING_HOURS",
+				"message": "Booking must fit within studio working hours (studio local time)",
+			},
+		})
+		return
+	case errors.Is(err, ErrClosedPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CLOSED_PERIOD",
+				"message": "Room is closed for the selected time",
+			},
+		})
+		return
+	case errors.Is(err, catalog.ErrBelowMinDuration):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BELOW_MIN_DURATION",
+				"message": "Booking is shorter than the room minimum duration",
+			},
+		})
+		return
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Room not found",
+			},
+		})
+		return
+	case errors.Is(err, ErrNotAvailable), errors.Is(err, ErrOverbooking):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BOOKING_CONFLICT",
+				"message": "Room is not available for the selected time",
+			},
+		})
+		return
+	case errors.Is(err, ErrEquipmentUnavailable):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "EQUIPMENT_UNAVAILABLE",
+				"message": "Not enough equipment available for the selected time",
+			},
+		})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to create booking",
+			},
+		})
+		return
+	}
+}
+
+// CreateHold временно блокирует слот до оплаты
+// @Summary		Заблокировать слот до оплаты
+// @Description	Создаёт бронь в статусе held, которая удерживает слот на время оплаты (TTL задаётся BOOKING_HOLD_TTL, по умолчанию 15 минут). Слот сразу считается занятым. После успешной оплаты через /payments/robokassa/init с этим booking_id бронь переходит в pending; без оплаты hold снимается автоматически.
+// @Tags		Бронирования
+// @Security	BearerAuth
+// @Param		body body CreateBookingRequest true "Слот для блокировки"
+// @Success		201 {object} map[string]interface{} "Слот заблокирован"
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure		401 {object} map[string]interface{} "Ошибка аутентификации"
+// @Failure		409 {object} map[string]interface{} "Слот уже занят"
+// @Failure		500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router		/bookings/holds [post]
+func (h *Handler) CreateHold(c *gin.Context) {
+	var req CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	if userID <= 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Missing auth")
+		return
+	}
+	req.UserID = userID
+
+	b, err := h.service.CreateHold(c.Request.Context(), req)
+	if err != nil {
+		writeCreateBookingError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{
+		"booking": gin.H{
+			"id":              b.ID,
+			"status":          b.Status,
+			"total_price":     b.TotalPrice,
+			"hold_expires_at": b.HoldExpiresAt,
+		},
+	})
+}
+
 type BusySlotDTO struct {
 	Start string `json:"start"` // "10:00"
 	End   string `json:"end"`   // "12:00"
 }
 
 type BusySlotsResponse struct {
-	Date      string     `json:"date"`
-	RoomID    int64      `json:"room_id"`
+	Date      string        `json:"date"`
+	RoomID    int64         `json:"room_id"`
+	Timezone  string        `json:"timezone"`
+	UTCOffset string        `json:"utc_offset"`
 	BusySlots []BusySlotDTO `json:"busy_slots"`
-	OpenTime  string     `json:"open_time"`
-	CloseTime string     `json:"close_time"`
+	OpenTime  string        `json:"open_time"`
+	CloseTime string        `json:"close_time"`
 }
 
 // GetBusySlots возвращает список занятых временных слотов для конкретной комнаты на указанную дату
@@ -146,44 +243,26 @@ func (h *Handler) GetBusySlots(c *gin.Context) {
 		return
 	}
 
-	date, err := time.Parse("2006-01-02", dateStr)
+	// Сутки и часы работы считаются в часовом поясе студии
+	resp, err := h.service.GetBusySlotsForDate(c.Request.Context(), roomID, dateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid date format, use YYYY-MM-DD"})
-		return
-	}
-
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
-	// Берём занятые слоты через уже существующую логику репозитория
-	rows, err := h.service.GetBusySlots(c.Request.Context(), roomID, startOfDay, endOfDay)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to get busy slots"})
+		switch {
+		case errors.Is(err, ErrValidation):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid date format, use YYYY-MM-DD"})
+		case errors.Is(err, ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "room not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to get busy slots"})
+		}
 		return
 	}
 
-	busy := make([]BusySlotDTO, 0, len(rows))
-	for _, s := range rows {
-		busy = append(busy, BusySlotDTO{
-			Start: s.Start.Format("15:04"),
-			End:   s.End.Format("15:04"),
-		})
-	}
-
-	resp := BusySlotsResponse{
-		Date:      dateStr,
-		RoomID:    roomID,
-		BusySlots: busy,
-		OpenTime:  "09:00",
-		CloseTime: "21:00",
-	}
-
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": resp})
 }
 
 // GetRoomAvailability возвращает информацию о доступности комнаты и забронированных слотах на конкретную дату
 // @Summary		Проверить доступность комнаты на дату
-// @Description	Возвращает детальную информацию о доступности указанной комнаты на определённую дату, включая забронированные временные слоты. Помогает пользователю выбрать свободное время для создания новой брони. Ответ включает информацию о всех занятых и свободных промежутках времени.
+// @Description	Возвращает детальную информацию о доступности указанной комнаты на определённую дату, включая забронированные временные слоты. Помогает пользователю выбрать свободное время для создания новой брони. Ответ включает информацию о всех занятых и свободных промежутках времени. Буферы комнаты на подготовку и уборку возвращаются отдельными слотами со status=buffer.
 // @Tags		Бронирования
 // @Param		id path integer true "ID комнаты"
 // @Param		date query string true "Дата в формате YYYY-MM-DD (обязательный параметр)"
@@ -220,6 +299,10 @@ func (h *Handler) GetRoomAvailability(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": gin.H{"code": code, "message": msg}})
 			return
 		}
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": gin.H{"code": "NOT_FOUND", "message": "Room not found"}})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": code, "message": msg}})
 		return
 	}
@@ -442,6 +525,12 @@ func (h *Handler) UpdateBookingStatus(c *gin.Context) {
 				"error":   gin.H{"code": "INVALID_STATUS_TRANSITION", "message": "Invalid status transition"},
 			})
 			return
+		case errors.Is(err, ErrSharesNotCovered):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   gin.H{"code": "SHARES_NOT_COVERED", "message": "Not all participant shares are paid yet"},
+			})
+			return
 		case errors.Is(err, ErrNotFound):
 			c.JSON(http.StatusNotFound, gin.H{
 				"success": false,
@@ -490,7 +579,7 @@ func (h *Handler) ConfirmBooking(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateStatus(c.Request.Context(), bookingID, "confirmed"); err != nil {
+	if err := h.service.UpdateStatus(c.Request.Context(), bookingID, "confirmed", actorFromContext(c)); err != nil {
 		response.CustomError(c, http.StatusBadRequest, "UPDATE_ERROR", err)
 		return
 	}
@@ -500,7 +589,7 @@ func (h *Handler) ConfirmBooking(c *gin.Context) {
 
 // CancelBooking отменяет существующее бронирование с обязательным указанием причины
 // @Summary		Отменить бронирование
-// @Description	Отменяет указанное бронирование и устанавливает его статус в 'cancelled'. Требует обязательное указание причины отмены (минимум 10 символов). Бронирование может быть отменено клиентом (автором) или владельцем/менеджером студии. Невозможно отменить уже завершённое или уже отменённое бронирование. Причина отмены сохраняется в системе для аналитики.
+// @Description	Отменяет указанное бронирование и устанавливает его статус в 'cancelled'. Требует обязательное указание причины отмены (минимум 10 символов). Бронирование может быть отменено клиентом (автором) или владельцем/менеджером студии. Невозможно отменить уже завершённое или уже отменённое бронирование. Причина отмены сохраняется в системе для аналитики. При отмене клиентом сумма возврата считается по политике отмены студии (refund_amount, payment_status refunded/partially_refunded); при отмене студией возвращается вся оплата.
 // @Tags		Бронирования
 // @Security	BearerAuth
 // @Param		id path integer true "ID бронирования"
@@ -556,8 +645,16 @@ func (h *Handler) CancelBooking(c *gin.Context) {
 	}
 
 	// Выполняем отмену с причиной
-	updatedBooking, err := h.service.CancelBooking(c.Request.Context(), bookingID, req.Reason)
+	actor := actorFromContext(c)
+	if booking.UserID == userID {
+		actor.Role = ActorClient
+	}
+	updatedBooking, err := h.service.CancelBooking(c.Request.Context(), bookingID, actor, req.Reason)
 	if err != nil {
+		if errors.Is(err, ErrInvalidStatusTransition) {
+			response.CustomError(c, http.StatusBadRequest, "INVALID_STATUS", "Booking cannot be cancelled in its current status")
+			return
+		}
 		response.CustomError(c, http.StatusBadRequest, "CANCEL_ERROR", err)
 		return
 	}
@@ -600,7 +697,7 @@ func (h *Handler) CompleteBooking(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateStatus(c.Request.Context(), bookingID, "completed"); err != nil {
+	if err := h.service.UpdateStatus(c.Request.Context(), bookingID, "completed", actorFromContext(c)); err != nil {
 		response.CustomError(c, http.StatusBadRequest, "UPDATE_ERROR", err)
 		return
 	}
@@ -646,27 +743,111 @@ func (h *Handler) MarkBookingPaid(c *gin.Context) {
 	response.Success(c, http.StatusOK, b)
 }
 
-// UpdateDeposit обновляет размер залога (предоплаты) для бронирования, доступно только для менеджеров и владельцев
-// @Summary		Обновить размер залога
-// @Description	Обновляет размер залога (предоплаты) для указанного бронирования. Эта операция доступна только администраторам и владельцам студий. Залог представляет собой предварительный платёж для гарантирования брони. Сумма залога может быть изменена в зависимости от политики студии.
+// QuoteBooking считает цену слота до бронирования
+// @Summary		Рассчитать цену бронирования
+// @Description	Возвращает цену слота по правилам комнаты (пиковые часы, надбавка за выходные, праздничные цены, скидка за длительность) с разбивкой по строкам. Минимальная длительность проверяется так же, как при создании брони. Бронь не создаётся.
+// @Tags		Бронирования
+// @Security	BearerAuth
+// @Param		body body QuoteRequest true "Комната и время"
+// @Success		200 {object} QuoteResponse "Расчёт цены"
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации или короче минимальной длительности"
+// @Failure		404 {object} map[string]interface{} "Комната не найдена"
+// @Router		/bookings/quote [post]
+func (h *Handler) QuoteBooking(c *gin.Context) {
+	var req QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	quote, err := h.service.Quote(c.Request.Context(), req.RoomID, req.StartTime, req.EndTime, req.Equipment)
+	if err != nil {
+		writeCreateBookingError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, QuoteResponse{
+		RoomID:    req.RoomID,
+		StartTime: req.StartTime.Format(time.RFC3339),
+		EndTime:   req.EndTime.Format(time.RFC3339),
+		Lines:     quote.Lines,
+		Subtotal:  quote.Subtotal,
+		Total:     quote.Total,
+	})
+}
+
+// RescheduleBooking переносит бронирование на новое время
+// @Summary		Перенести бронирование
+// @Description	Переносит бронь на новое окно той же комнаты. Окно проверяется на пересечения (без самой брони), часы работы студии и остаток арендованного оборудования; цена пересчитывается по правилам комнаты, аренда оборудования сохраняется по зафиксированной цене. Статус, предоплата и оплата сохраняются. Переносить может клиент брони, владелец студии или администратор; другая сторона получает уведомление.
 // @Tags		Бронирования
 // @Security	BearerAuth
 // @Param		id path integer true "ID бронирования"
-// @Param		body body UpdateDepositRequest true "Новая сумма залога (deposit_amount)"
-// @Success		200 {object} map[string]interface{} "Размер залога успешно обновлен"
-// @Failure		400 {object} map[string]interface{} "Ошибка валидации запроса или некорректная сумма залога"
-// @Failure		403 {object} map[string]interface{} "Доступ запрещен - только администраторы и владельцы студий могут обновлять залог"
-// @Failure		500 {object} map[string]interface{} "Внутренняя ошибка сервера при обновлении залога"
-// @Router		/bookings/{id}/deposit [patch]
-func (h *Handler) UpdateDeposit(c *gin.Context) {
-	userRole, _ := c.Get("role")
+// @Param		body body RescheduleBookingRequest true "Новое время"
+// @Success		200 {object} BookingResponse "Бронирование перенесено"
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации или неверный статус"
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Бронирование не найдено"
+// @Failure		409 {object} map[string]interface{} "Новое время занято"
+// @Router		/bookings/{id}/reschedule [patch]
+func (h *Handler) RescheduleBooking(c *gin.Context) {
+	bookingID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid booking id")
+		return
+	}
+
+	var req RescheduleBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
 
-	// Только менеджеры и владельцы
-	if userRole != "admin" && userRole != "studio_owner" {
-		response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Access denied")
+	b, err := h.service.RescheduleBooking(c.Request.Context(), bookingID, actorFromContext(c), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotAvailable), errors.Is(err, ErrOverbooking):
+			response.CustomError(c, http.StatusConflict, "BOOKING_CONFLICT", "Room is not available for selected time")
+		case errors.Is(err, ErrEquipmentUnavailable):
+			response.CustomError(c, http.StatusConflict, "EQUIPMENT_UNAVAILABLE", "Rented equipment is not available for selected time")
+		case errors.Is(err, ErrOutsideWorkingHours):
+			response.CustomError(c, http.StatusBadRequest, "OUTSIDE_WORKING_HOURS", "Booking is outside studio working hours")
+		case errors.Is(err, ErrClosedPeriod):
+			response.CustomError(c, http.StatusBadRequest, "CLOSED_PERIOD", "Room is closed for the selected time")
+		case errors.Is(err, catalog.ErrBelowMinDuration):
+			response.CustomError(c, http.StatusBadRequest, "BELOW_MIN_DURATION", "Booking is shorter than the room minimum duration")
+		case errors.Is(err, ErrValidation):
+			response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid time range, deposit exceeds new price or the price of a split booking would change")
+		case errors.Is(err, ErrInvalidStatusTransition):
+			response.CustomError(c, http.StatusBadRequest, "INVALID_STATUS", "Booking cannot be rescheduled in its current status")
+		case errors.Is(err, ErrGroupedBooking):
+			response.CustomError(c, http.StatusBadRequest, "GROUPED_BOOKING", "Booking is part of a multi-room group and cannot be rescheduled alone")
+		case errors.Is(err, ErrForbidden):
+			response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Cannot reschedule this booking")
+		case errors.Is(err, ErrNotFound):
+			response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Booking not found")
+		default:
+			response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		}
 		return
 	}
 
+	response.Success(c, http.StatusOK, ToBookingResponse(b, false))
+}
+
+// UpdateDeposit записывает оплату, принятую студией на месте, доступно только владельцу студии и администратору
+// @Summary		Записать оплату на месте
+// @Description	Прибавляет сумму, которую студия приняла сама (наличные, перевод), к внесённой по брони (deposit_amount). Сумма не может превышать остаток к оплате; когда внесено всё, бронь становится оплаченной, а ожидающая бронь подтверждается. Доступно владельцу студии брони и администратору.
+// @Tags		Бронирования
+// @Security	BearerAuth
+// @Param		id path integer true "ID бронирования"
+// @Param		body body UpdateDepositRequest true "Принятая сумма (deposit_amount)"
+// @Success		200 {object} map[string]interface{} "Оплата записана"
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации запроса, сумма больше остатка или бронь нельзя оплатить"
+// @Failure		403 {object} map[string]interface{} "Доступ запрещен - бронь не в студии владельца"
+// @Failure		404 {object} map[string]interface{} "Бронирование не найдено"
+// @Failure		500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router		/bookings/{id}/deposit [patch]
+func (h *Handler) UpdateDeposit(c *gin.Context) {
 	bookingID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid booking ID")
@@ -679,11 +860,66 @@ func (h *Handler) UpdateDeposit(c *gin.Context) {
 		return
 	}
 
-	booking, err := h.service.UpdateDeposit(c.Request.Context(), bookingID, req.DepositAmount)
+	booking, err := h.service.UpdateDeposit(c.Request.Context(), bookingID, req.DepositAmount, actorFromContext(c))
 	if err != nil {
-		response.CustomError(c, http.StatusBadRequest, "UPDATE_ERROR", err)
+		switch {
+		case errors.Is(err, ErrForbidden):
+			response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Access denied")
+		case errors.Is(err, ErrNotFound):
+			response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Booking not found")
+		case errors.Is(err, ErrValidation):
+			response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Amount must be positive and not exceed the outstanding balance")
+		case errors.Is(err, ErrInvalidStatusTransition):
+			response.CustomError(c, http.StatusBadRequest, "INVALID_STATUS", "Booking cannot be paid in its current status")
+		default:
+			response.CustomError(c, http.StatusInternalServerError, "UPDATE_ERROR", err)
+		}
 		return
 	}
 
 	response.Success(c, http.StatusOK, ToBookingResponse(booking, true))
-}
\ No newline at end of file
+}
+
+// GetStatusHistory возвращает историю смены статусов бронирования
+// @Summary		История статусов бронирования
+// @Description	Возвращает все переходы статуса брони: из какого в какой, кто изменил (actor_id, actor_role), причину и время. Доступно клиенту брони, владельцу студии и администратору.
+// @Tags		Бронирования
+// @Security	BearerAuth
+// @Param		id path integer true "ID бронирования"
+// @Success		200 {array} BookingStatusHistory "История статусов"
+// @Failure		400 {object} map[string]interface{} "Неверный ID"
+// @Failure		403 {object} map[string]interface{} "Нет доступа к бронированию"
+// @Failure		404 {object} map[string]interface{} "Бронирование не найдено"
+// @Failure		500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router		/bookings/{id}/history [get]
+func (h *Handler) GetStatusHistory(c *gin.Context) {
+	bookingID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid booking ID")
+		return
+	}
+
+	history, err := h.service.GetStatusHistory(c.Request.Context(), bookingID, c.GetInt64("user_id"), c.GetString("role"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Booking not found")
+		case errors.Is(err, ErrForbidden):
+			response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Access denied")
+		default:
+			response.CustomError(c, http.StatusInternalServerError, "FETCH_FAILED", err)
+		}
+		return
+	}
+
+	response.Success(c, http.StatusOK, history)
+}
+
+// actorFromContext — инициатор действия для истории статусов
+func actorFromContext(c *gin.Context) Actor {
+	role := c.GetString("role")
+	if role == "" {
+		role = ActorClient
+	}
+	return Actor{UserID: c.GetInt64("user_id"), Role: role}
+}
@@ -0,0 +1,89 @@
+package booking
+
+import (
+	"context"
+	"log"
+	"photostudio/internal/pkg/utils"
+	"time"
+)
+
+const holdExpiredReason = "Hold expired: payment was not completed in time"
+
+// HoldConfig — настройки временной блокировки слота до оплаты
+type HoldConfig struct {
+	TTL           time.Duration // Сколько слот держится без оплаты (default: 15m)
+	SweepInterval time.Duration // Как часто снимать истёкшие holds (default: 1m)
+	EnableSweeper bool
+}
+
+// DefaultHoldConfig возвращает настройки holds; TTL и интервал можно
+// переопределить через BOOKING_HOLD_TTL и BOOKING_HOLD_SWEEP_INTERVAL ("15m", "30s")
+func DefaultHoldConfig() HoldConfig {
+	return HoldConfig{
+		TTL:           utils.DurationFromEnv("BOOKING_HOLD_TTL", 15*time.Minute),
+		SweepInterval: utils.DurationFromEnv("BOOKING_HOLD_SWEEP_INTERVAL", time.Minute),
+		EnableSweeper: true,
+	}
+}
+
+// CreateHold блокирует слот на holdTTL, пока клиент оплачивает бронь.
+// Hold — это бронь в статусе held: она видна в busy-slots и CheckAvailability,
+// после оплаты становится pending, а без оплаты снимается sweeper-ом.
+func (s *Service) CreateHold(ctx context.Context, req CreateBookingRequest) (*Booking, error) {
+	until := time.Now().Add(s.holdTTL).UTC()
+	return s.insertBooking(ctx, req, &until)
+}
+
+// ConvertHold превращает оплаченный hold в обычную бронь и уведомляет владельца
+func (s *Service) ConvertHold(ctx context.Context, bookingID int64) (*Booking, error) {
+	b, err := s.bookings.ConvertHold(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyOwnerBookingCreated(ctx, b)
+	return b, nil
+}
+
+// ReleaseExpiredHolds снимает holds, не оплаченные за TTL
+func (s *Service) ReleaseExpiredHolds(ctx context.Context) (int64, error) {
+	released, err := s.bookings.ReleaseExpiredHolds(ctx, time.Now().UTC())
+	if err != nil {
+		log.Printf("Error releasing expired holds: %v", err)
+		return 0, err
+	}
+	if released > 0 {
+		log.Printf("Released %d expired booking holds", released)
+	}
+	return released, nil
+}
+
+// ScheduleHoldSweeper запускает фоновое снятие истёкших holds
+func (s *Service) ScheduleHoldSweeper(ctx context.Context, config HoldConfig) chan struct{} {
+	if !config.EnableSweeper {
+		log.Println("Booking hold sweeper is disabled")
+		return nil
+	}
+
+	stopCh := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(config.SweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = s.ReleaseExpiredHolds(ctx)
+			case <-stopCh:
+				log.Println("Booking hold sweeper stopped")
+				return
+			case <-ctx.Done():
+				log.Println("Booking hold sweeper stopped (context Done)")
+				return
+			}
+		}
+	}()
+
+	log.Printf("Booking hold sweeper started with interval %v (ttl %v)", config.SweepInterval, config.TTL)
+	return stopCh
+}
@@ -0,0 +1,216 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"photostudio/internal/database"
+)
+
+func newHoldTestRepo(t *testing.T) *bookingRepository {
+	t.Helper()
+	return openHoldTestRepo(t, ":memory:")
+}
+
+func openHoldTestRepo(t *testing.T, dsn string) *bookingRepository {
+	t.Helper()
+	db, err := database.Connect(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`CREATE TABLE bookings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER, studio_id INTEGER, user_id INTEGER,
+		start_time DATETIME, end_time DATETIME,
+		total_price REAL, status TEXT, payment_status TEXT, notes TEXT,
+		created_at DATETIME, updated_at DATETIME, cancelled_at DATETIME,
+		cancellation_reason TEXT, deposit_amount REAL,
+		series_id INTEGER, group_id INTEGER, hold_expires_at DATETIME,
+		refund_amount REAL DEFAULT 0,
+		guest_name TEXT, guest_phone TEXT, guest_email TEXT
+	)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`CREATE TABLE rooms (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, studio_id INTEGER,
+		buffer_before_minutes INTEGER DEFAULT 0, buffer_after_minutes INTEGER DEFAULT 0
+	)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`CREATE TABLE equipment (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER, name TEXT, category TEXT, brand TEXT, model TEXT,
+		quantity INTEGER, rental_price REAL, created_at DATETIME
+	)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&BookingStatusHistory{}, &BookingEquipment{}, &ExternalBusyBlock{}, &BookingParticipant{}); err != nil {
+		t.Fatal(err)
+	}
+	return &bookingRepository{db: db}
+}
+
+func TestHolds_BlockSlotUntilExpiry(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	end := start.Add(2 * time.Hour)
+	active := time.Now().Add(10 * time.Minute).UTC()
+	expired := time.Now().Add(-time.Minute).UTC()
+
+	held := &Booking{RoomID: 1, StudioID: 1, UserID: 1, StartTime: start, EndTime: end,
+		Status: BookingHeld, PaymentStatus: PaymentUnpaid, HoldExpiresAt: &active}
+	if err := repo.Create(ctx, held); err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := repo.CheckAvailability(ctx, 1, start, end)
+	if err != nil || ok {
+		t.Fatalf("active hold must block the slot: ok=%v err=%v", ok, err)
+	}
+	busy, _ := repo.GetBusySlotsForRoom(ctx, 1, start.Add(-time.Hour), end.Add(time.Hour))
+	if len(busy) != 1 {
+		t.Fatalf("active hold must appear in busy slots, got %d", len(busy))
+	}
+
+	// Истёкший TTL перестаёт блокировать слот ещё до sweeper-а
+	repo.db.Model(&bookingModel{}).Where("id = ?", held.ID).Update("hold_expires_at", expired)
+	ok, err = repo.CheckAvailability(ctx, 1, start, end)
+	if err != nil || !ok {
+		t.Fatalf("expired hold must not block the slot: ok=%v err=%v", ok, err)
+	}
+
+	released, err := repo.ReleaseExpiredHolds(ctx, time.Now().UTC())
+	if err != nil || released != 1 {
+		t.Fatalf("expected 1 released hold, got %d err=%v", released, err)
+	}
+	b, _ := repo.GetByID(ctx, held.ID)
+	if b.Status != BookingCancelled {
+		t.Fatalf("expected released hold to be cancelled, got %s", b.Status)
+	}
+}
+
+func TestConvertHold(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+
+	start := time.Now().Add(72 * time.Hour).UTC().Truncate(time.Hour)
+	end := start.Add(time.Hour)
+	until := time.Now().Add(10 * time.Minute).UTC()
+
+	held := &Booking{RoomID: 1, StudioID: 1, UserID: 1, StartTime: start, EndTime: end,
+		Status: BookingHeld, PaymentStatus: PaymentUnpaid, HoldExpiresAt: &until}
+	if err := repo.Create(ctx, held); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := repo.ConvertHold(ctx, held.ID)
+	if err != nil || b.Status != BookingPending || b.HoldExpiresAt != nil {
+		t.Fatalf("expected hold converted to pending, got %+v err=%v", b, err)
+	}
+	// Повторный callback ничего не меняет
+	if b, err = repo.ConvertHold(ctx, held.ID); err != nil || b.Status != BookingPending {
+		t.Fatalf("convert must be idempotent, got %+v err=%v", b, err)
+	}
+
+	// Hold снят по TTL, а слот уже занял другой клиент — восстановить нельзя
+	past := time.Now().Add(-time.Minute).UTC()
+	late := &Booking{RoomID: 2, StudioID: 1, UserID: 1, StartTime: start, EndTime: end,
+		Status: BookingHeld, PaymentStatus: PaymentUnpaid, HoldExpiresAt: &past}
+	if err := repo.Create(ctx, late); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ReleaseExpiredHolds(ctx, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	other := &Booking{RoomID: 2, StudioID: 1, UserID: 2, StartTime: start, EndTime: end,
+		Status: BookingPending, PaymentStatus: PaymentUnpaid}
+	if err := repo.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ConvertHold(ctx, late.ID); err != ErrHoldExpired {
+		t.Fatalf("expected ErrHoldExpired, got %v", err)
+	}
+}
+
+func TestApplyPayment_AccumulatesToPaid(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+
+	start := time.Now().Add(96 * time.Hour).UTC().Truncate(time.Hour)
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 1, StartTime: start, EndTime: start.Add(2 * time.Hour),
+		TotalPrice: 1000, Status: BookingPending, PaymentStatus: PaymentUnpaid}
+	if err := repo.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.ApplyPayment(ctx, b.ID, 300.1)
+	if err != nil || got.DepositAmount != 300.1 || got.PaymentStatus != PaymentUnpaid {
+		t.Fatalf("expected deposit 300.10 and unpaid, got %+v err=%v", got, err)
+	}
+	got, err = repo.ApplyPayment(ctx, b.ID, 699.9)
+	if err != nil || got.DepositAmount != 1000 || got.PaymentStatus != PaymentPaid {
+		t.Fatalf("expected booking paid once payments cover the price, got %+v err=%v", got, err)
+	}
+	if stored, _ := repo.GetByID(ctx, b.ID); stored.PaymentStatus != PaymentPaid {
+		t.Fatalf("expected paid status persisted, got %s", stored.PaymentStatus)
+	}
+}
+
+func TestUpdateDeposit_RecordsOnSitePayment(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+
+	start := time.Now().Add(96 * time.Hour).UTC().Truncate(time.Hour)
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(2 * time.Hour),
+		TotalPrice: 1000, DepositAmount: 300, Status: BookingPending, PaymentStatus: PaymentUnpaid}
+	if err := svc.bookings.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	owner := Actor{UserID: 100, Role: ActorOwner}
+
+	if _, err := svc.UpdateDeposit(ctx, b.ID, 200, Actor{UserID: 101, Role: ActorOwner}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for another studio's owner, got %v", err)
+	}
+	if _, err := svc.UpdateDeposit(ctx, b.ID, 700.01, owner); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation above the outstanding balance, got %v", err)
+	}
+
+	// принятое на месте прибавляется к уже внесённому, а не затирает его
+	got, err := svc.UpdateDeposit(ctx, b.ID, 200, owner)
+	if err != nil || got.DepositAmount != 500 || got.PaymentStatus != PaymentUnpaid || got.Status != BookingConfirmed {
+		t.Fatalf("expected 500 paid and the booking confirmed, got %+v err=%v", got, err)
+	}
+	got, err = svc.UpdateDeposit(ctx, b.ID, 500, owner)
+	if err != nil || got.DepositAmount != 1000 || got.PaymentStatus != PaymentPaid {
+		t.Fatalf("expected the booking paid in full, got %+v err=%v", got, err)
+	}
+}
+
+func TestApplyRefund_KeepsPolicyAmount(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+
+	start := time.Now().Add(120 * time.Hour).UTC().Truncate(time.Hour)
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 1, StartTime: start, EndTime: start.Add(time.Hour),
+		TotalPrice: 1000, Status: BookingConfirmed, PaymentStatus: PaymentPaid, DepositAmount: 1000}
+	if err := repo.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.ApplyRefund(ctx, b.ID, 400)
+	if err != nil || got.RefundAmount != 400 || got.PaymentStatus != PaymentPartiallyRefunded {
+		t.Fatalf("expected partial refund, got %+v err=%v", got, err)
+	}
+	// сумма по политике отмены больше фактического возврата — не уменьшаем
+	repo.db.Model(&bookingModel{}).Where("id = ?", b.ID).Update("refund_amount", 700)
+	if got, _ = repo.ApplyRefund(ctx, b.ID, 500); got.RefundAmount != 700 {
+		t.Fatalf("expected refund_amount to stay 700, got %v", got.RefundAmount)
+	}
+	if got, _ = repo.ApplyRefund(ctx, b.ID, 1000); got.PaymentStatus != PaymentRefunded {
+		t.Fatalf("expected refunded once everything is returned, got %s", got.PaymentStatus)
+	}
+}
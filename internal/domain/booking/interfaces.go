@@ -12,9 +12,13 @@ import (
 // BookingRepository defines the interface for booking operations
 type BookingRepository interface {
 	CheckAvailability(ctx context.Context, roomID int64, start, end time.Time) (bool, error)
+	CheckAvailabilityExcluding(ctx context.Context, roomID int64, start, end time.Time, excludeIDs []int64) (bool, error)
 	Create(ctx context.Context, b *Booking) error
 	GetBusySlotsForRoom(ctx context.Context, roomID int64, start, end time.Time) ([]BusySlot, error)
+	GetBusySlotsForRooms(ctx context.Context, roomIDs []int64, start, end time.Time) (map[int64][]BusySlot, error)
+	ListSearchRooms(ctx context.Context, city, roomType string, minPrice, maxPrice float64, limit int) ([]catalog.Room, []SearchStudio, error)
 	GetUserBookingsWithDetails(ctx context.Context, userID int64, limit, offset int) ([]UserBookingDetails, error)
+	GetUserBookingDetails(ctx context.Context, userID, bookingID int64) (*UserBookingDetails, error)
 	GetStudioOwnerForBooking(ctx context.Context, bookingID int64) (ownerID int64, status string, err error)
 	UpdateStatus(ctx context.Context, bookingID int64, status string) error
 	GetByID(ctx context.Context, id int64) (*Booking, error)
@@ -22,10 +26,11 @@ type BookingRepository interface {
 	IsBookingOwnedByUser(ctx context.Context, bookingID, ownerID int64) (bool, error)
 	UpdatePaymentStatus(ctx context.Context, bookingID int64, status PaymentStatus) (*Booking, error)
 	UpdatePaymentStatusSystem(ctx context.Context, bookingID int64, status PaymentStatus) (*Booking, error)
-	// Block 9: Cancel with reason
-	CancelWithReason(ctx context.Context, bookingID int64, reason string) error
-	// Block 10: Update deposit
-	UpdateDeposit(ctx context.Context, bookingID int64, amount float64) error
+	// State machine: переход по таблице + запись в booking_status_history
+	TransitionStatus(ctx context.Context, bookingID int64, to BookingStatus, actor Actor, reason string) (*Booking, error)
+	GetStatusHistory(ctx context.Context, bookingID int64) ([]BookingStatusHistory, error)
+	CancelWithRefund(ctx context.Context, bookingID int64, actor Actor, reason string, refundPercent int) (*Booking, error)
+	RescheduleBooking(ctx context.Context, change BookingTimeChange, actor Actor, reason string) (*Booking, error)
 
 	// Manager methods
 	GetManagerBookings(ctx context.Context, ownerID int64, filters ManagerBookingFilters) ([]ManagerBookingRow, int64, error)
@@ -34,19 +39,110 @@ type BookingRepository interface {
 	GetRecentByUserID(userID int64, limit int) ([]auth.RecentBookingRow, error)
 	GetStatsByUserID(userID int64) (*auth.BookingStats, error)
 	HasCompletedBookingForStudio(ctx context.Context, userID, studioID int64) (bool, error)
+
+	// Slot holds
+	ConvertHold(ctx context.Context, bookingID int64) (*Booking, error)
+	// Оплата через провайдера: накапливается в deposit_amount до TotalPrice
+	ApplyPayment(ctx context.Context, bookingID int64, amount float64) (*Booking, error)
+	// Возврат через провайдера: refunded — сколько всего возвращено по брони
+	ApplyRefund(ctx context.Context, bookingID int64, refunded float64) (*Booking, error)
+	ReleaseExpiredHolds(ctx context.Context, now time.Time) (int64, error)
+
+	// Lifecycle jobs
+	ListFinishedConfirmed(ctx context.Context, endedBefore time.Time, limit int) ([]Booking, error)
+	ListStalePending(ctx context.Context, createdBefore, startBefore time.Time, limit int) ([]Booking, error)
+
+	// Reminders
+	ListBookingsWithoutReminders(ctx context.Context, startAfter, startBefore time.Time, limit int) ([]Booking, error)
+	CreateReminders(ctx context.Context, reminders []BookingReminder) error
+	ReplaceReminders(ctx context.Context, bookingID int64, reminders []BookingReminder) error
+	CancelReminders(ctx context.Context, bookingID int64) error
+	ListDueReminders(ctx context.Context, now time.Time, limit int) ([]BookingReminder, error)
+	SetReminderStatus(ctx context.Context, id int64, from, to ReminderStatus, sentAt *time.Time) (bool, error)
+
+	// Recurring series
+	CreateSeries(ctx context.Context, series *BookingSeries) error
+	GetSeriesByID(ctx context.Context, id int64) (*BookingSeries, error)
+	UpdateSeries(ctx context.Context, series *BookingSeries) error
+	GetBySeriesID(ctx context.Context, seriesID int64) ([]Booking, error)
+	CancelSeries(ctx context.Context, seriesID int64, from time.Time, actor Actor, reason string) error
+	RescheduleBookings(ctx context.Context, changes []BookingTimeChange, actor Actor, reason string) ([]Booking, error)
+
+	// Multi-room groups
+	CreateGroup(ctx context.Context, group *BookingGroup, bookings []*Booking) error
+	GetGroupByID(ctx context.Context, id int64) (*BookingGroup, error)
+	GetByGroupID(ctx context.Context, groupID int64) ([]Booking, error)
+
+	// Calendar feeds
+	CreateCalendarFeed(ctx context.Context, feed *CalendarFeed) error
+	GetCalendarFeedByToken(ctx context.Context, token string) (*CalendarFeed, error)
+	ListCalendarFeeds(ctx context.Context, ownerID int64) ([]CalendarFeed, error)
+	DeleteCalendarFeed(ctx context.Context, ownerID, id int64) (bool, error)
+	IsStudioOwnedByUser(ctx context.Context, studioID, userID int64) (bool, error)
+
+	// Participants (split payments)
+	ReplaceParticipants(ctx context.Context, bookingID int64, participants []BookingParticipant) error
+	ListParticipants(ctx context.Context, bookingID int64) ([]BookingParticipant, error)
+	GetParticipant(ctx context.Context, id int64) (*BookingParticipant, error)
+	SetParticipantInvoice(ctx context.Context, id, invID int64) error
+	MarkParticipantPaid(ctx context.Context, participantID int64, paidAt time.Time) (*Booking, error)
+
+	// Guest bookings
+	LinkGuestBooking(ctx context.Context, bookingID, userID int64) error
+
+	// External calendars
+	CreateExternalCalendar(ctx context.Context, cal *ExternalCalendar) error
+	GetExternalCalendar(ctx context.Context, id int64) (*ExternalCalendar, error)
+	ListExternalCalendars(ctx context.Context, roomID int64) ([]ExternalCalendar, error)
+	ListLinkedExternalCalendars(ctx context.Context) ([]ExternalCalendar, error)
+	DeleteExternalCalendar(ctx context.Context, id int64) error
+	ReplaceExternalBusyBlocks(ctx context.Context, calendarID int64, blocks []ExternalBusyBlock, syncedAt time.Time) error
+	SetExternalCalendarError(ctx context.Context, id int64, message string) error
+
+	// Equipment rental
+	GetStudioEquipmentForRoom(ctx context.Context, roomID int64, ids []int64) ([]catalog.Equipment, error)
+	GetBookingEquipment(ctx context.Context, bookingID int64) ([]BookingEquipment, error)
+
+	// Waitlist
+	CreateWaitlistEntry(ctx context.Context, entry *WaitlistEntry) error
+	GetWaitlistEntry(ctx context.Context, id int64) (*WaitlistEntry, error)
+	GetWaitlistEntryByToken(ctx context.Context, token string) (*WaitlistEntry, error)
+	FindActiveWaitlistEntry(ctx context.Context, userID, roomID int64, start, end time.Time) (*WaitlistEntry, error)
+	ListWaitlistByUser(ctx context.Context, userID int64) ([]WaitlistEntry, error)
+	ListWaitlistForWindow(ctx context.Context, roomID int64, start, end time.Time) ([]WaitlistEntry, error)
+	OfferWaitlistEntry(ctx context.Context, id int64, token string, expiresAt time.Time) (bool, error)
+	SetWaitlistStatus(ctx context.Context, id int64, from, to WaitlistStatus, bookingID *int64) (bool, error)
+	ExpireWaitlistOffers(ctx context.Context, now time.Time) ([]WaitlistEntry, error)
+
 	DB() *gorm.DB
 }
 
 // RoomRepository defines the interface for room operations
 type RoomRepository interface {
 	GetPriceByID(ctx context.Context, id int64) (float64, error)
-	GetStudioWorkingHoursByRoomID(ctx context.Context, roomID int64) ([]byte, error)
+	GetStudioTimezoneByRoomID(ctx context.Context, roomID int64) (string, error)
+	GetStudioCancellationPolicyByRoomID(ctx context.Context, roomID int64) (*catalog.CancellationPolicy, error)
+	GetStudioReminderScheduleByRoomID(ctx context.Context, roomID int64) (*catalog.ReminderSchedule, error)
 	// Добавляем метод GetByID
 	GetByID(ctx context.Context, roomID int64) (*catalog.Room, error)
+	GetByStudioID(ctx context.Context, studioID int64) ([]catalog.Room, error)
+}
+
+// RefundIssuer возвращает деньги за отменённую бронь через платёжного
+// провайдера (payment.Service). Статус оплаты брони меняется, когда
+// провайдер подтвердит возврат.
+type RefundIssuer interface {
+	RefundCancelledBooking(ctx context.Context, actor Actor, bookingID int64, amount float64, reason string) error
 }
 
 type NotificationSender interface {
 	NotifyBookingCreated(ctx context.Context, ownerUserID, bookingID, studioID, roomID int64, start time.Time) error
 	NotifyBookingConfirmed(ctx context.Context, clientUserID, bookingID, studioID int64) error
 	NotifyBookingCancelled(ctx context.Context, clientUserID, bookingID, studioID int64, reason string) error
+	NotifyBookingCompleted(ctx context.Context, userID, bookingID, studioID int64) error
+	NotifyBookingNoShow(ctx context.Context, userID, bookingID, studioID int64) error
+	NotifyBookingReminder(ctx context.Context, userID, bookingID, studioID int64, start time.Time, hoursBefore int) error
+	NotifyBookingRescheduled(ctx context.Context, userID, bookingID, studioID int64, oldStart, newStart time.Time) error
+	NotifyEquipmentBooked(ctx context.Context, ownerID, equipmentID, bookingID int64, equipmentName string) error
+	NotifyWaitlistSlotAvailable(ctx context.Context, userID, entryID, studioID, roomID int64, start time.Time, claimURL string, expiresAt time.Time) error
 }
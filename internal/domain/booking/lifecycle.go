@@ -0,0 +1,182 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"log"
+	"photostudio/internal/pkg/utils"
+	"time"
+)
+
+const (
+	sessionFinishedReason = "Session finished"
+	pendingExpiredReason  = "Booking was not confirmed in time"
+
+	// lifecycleBatchSize — сколько броней каждого вида обрабатывается за один проход
+	lifecycleBatchSize = 500
+)
+
+// LifecycleConfig — настройки автоматических переходов статусов
+type LifecycleConfig struct {
+	Interval        time.Duration // Как часто запускать проход (default: 5m)
+	PendingTTL      time.Duration // Сколько бронь ждёт подтверждения студии (default: 24h)
+	NoShowGrace     time.Duration // Сколько после окончания неоплаченной брони ждать ручной отметки no_show (default: 2h)
+	EnableScheduler bool
+}
+
+// DefaultLifecycleConfig возвращает настройки; переопределяются через
+// BOOKING_LIFECYCLE_INTERVAL, BOOKING_PENDING_TTL и BOOKING_NO_SHOW_GRACE
+func DefaultLifecycleConfig() LifecycleConfig {
+	return LifecycleConfig{
+		Interval:        utils.DurationFromEnv("BOOKING_LIFECYCLE_INTERVAL", 5*time.Minute),
+		PendingTTL:      utils.DurationFromEnv("BOOKING_PENDING_TTL", 24*time.Hour),
+		NoShowGrace:     utils.DurationFromEnv("BOOKING_NO_SHOW_GRACE", 2*time.Hour),
+		EnableScheduler: true,
+	}
+}
+
+// LifecycleResult — сколько броней перевёл один проход
+type LifecycleResult struct {
+	Completed int `json:"completed"`
+	Expired   int `json:"expired"`
+}
+
+// RunLifecycle выполняет один проход автоматических переходов:
+//   - confirmed после окончания → completed; без оплаты и предоплаты — только
+//     через NoShowGrace: клиент мог заплатить на месте, а неявку студия
+//     отмечает вручную (no_show), система её не угадывает;
+//   - pending без оплаты и предоплаты, не подтверждённые за PendingTTL или
+//     к началу сеанса → cancelled с предложением окна листу ожидания.
+//
+// Брони группы меняют статус вместе, поэтому группа обрабатывается по первой брони.
+func (s *Service) RunLifecycle(ctx context.Context, config LifecycleConfig) (LifecycleResult, error) {
+	return s.runLifecycle(ctx, config, time.Now().UTC())
+}
+
+func (s *Service) runLifecycle(ctx context.Context, config LifecycleConfig, now time.Time) (LifecycleResult, error) {
+	var res LifecycleResult
+	groups := make(map[int64]bool)
+	firstInGroup := func(b Booking) bool {
+		if b.GroupID == nil {
+			return true
+		}
+		if groups[*b.GroupID] {
+			return false
+		}
+		groups[*b.GroupID] = true
+		return true
+	}
+
+	finished, err := s.bookings.ListFinishedConfirmed(ctx, now, lifecycleBatchSize)
+	if err != nil {
+		return res, err
+	}
+	for _, b := range finished {
+		if b.PaymentStatus != PaymentPaid && b.DepositAmount <= 0 && b.EndTime.After(now.Add(-config.NoShowGrace)) {
+			continue // студия ещё может отметить неявку
+		}
+		if !firstInGroup(b) {
+			continue
+		}
+		if s.lifecycleTransition(ctx, b, BookingCompleted, sessionFinishedReason) {
+			res.Completed++
+		}
+	}
+
+	stale, err := s.bookings.ListStalePending(ctx, now.Add(-config.PendingTTL), now, lifecycleBatchSize)
+	if err != nil {
+		return res, err
+	}
+	for _, b := range stale {
+		if !firstInGroup(b) {
+			continue
+		}
+		if s.lifecycleTransition(ctx, b, BookingCancelled, pendingExpiredReason) {
+			res.Expired++
+		}
+	}
+
+	if res != (LifecycleResult{}) {
+		log.Printf("Booking lifecycle: %d completed, %d expired", res.Completed, res.Expired)
+	}
+	return res, nil
+}
+
+// lifecycleTransition переводит бронь системой и уведомляет клиента и владельца.
+// Если статус успели поменять вручную, бронь пропускается.
+func (s *Service) lifecycleTransition(ctx context.Context, b Booking, to BookingStatus, reason string) bool {
+	var (
+		cancelled *Booking
+		err       error
+	)
+	if to == BookingCancelled {
+		// студия не подтвердила бронь — клиенту возвращается всё
+		cancelled, err = s.bookings.CancelWithRefund(ctx, b.ID, SystemActor, reason, 100)
+	} else {
+		_, err = s.bookings.TransitionStatus(ctx, b.ID, to, SystemActor, reason)
+	}
+	if err != nil {
+		if !errors.Is(err, ErrInvalidStatusTransition) {
+			log.Printf("Booking lifecycle: booking %d -> %s: %v", b.ID, to, err)
+		}
+		return false
+	}
+
+	if to == BookingCancelled {
+		s.afterCancel(ctx, SystemActor, *cancelled, reason)
+	}
+
+	if s.notifs == nil {
+		return true
+	}
+	var recipients []int64
+	if !b.IsGuest() {
+		recipients = append(recipients, b.UserID)
+	}
+	if ownerID, _, err := s.bookings.GetStudioOwnerForBooking(ctx, b.ID); err == nil && ownerID > 0 {
+		recipients = append(recipients, ownerID)
+	}
+	for _, userID := range recipients {
+		switch to {
+		case BookingCompleted:
+			_ = s.notifs.NotifyBookingCompleted(ctx, userID, b.ID, b.StudioID)
+		case BookingCancelled:
+			_ = s.notifs.NotifyBookingCancelled(ctx, userID, b.ID, b.StudioID, reason)
+		}
+	}
+	return true
+}
+
+// ScheduleLifecycle запускает фоновые автоматические переходы статусов
+func (s *Service) ScheduleLifecycle(ctx context.Context, config LifecycleConfig) chan struct{} {
+	if !config.EnableScheduler {
+		log.Println("Booking lifecycle scheduler is disabled")
+		return nil
+	}
+
+	stopCh := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.RunLifecycle(ctx, config); err != nil {
+					log.Printf("Booking lifecycle error: %v", err)
+				}
+			case <-stopCh:
+				log.Println("Booking lifecycle scheduler stopped")
+				return
+			case <-ctx.Done():
+				log.Println("Booking lifecycle scheduler stopped (context Done)")
+				return
+			}
+		}
+	}()
+
+	log.Printf("Booking lifecycle scheduler started with interval %v (pending ttl %v, no-show grace %v)",
+		config.Interval, config.PendingTTL, config.NoShowGrace)
+	return stopCh
+}
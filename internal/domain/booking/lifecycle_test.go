@@ -0,0 +1,116 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// lifecycleNotifs запоминает уведомления о переходах
+type lifecycleNotifs struct {
+	waitlistNotifs
+	events []string
+}
+
+func (n *lifecycleNotifs) NotifyBookingCompleted(ctx context.Context, userID, bookingID, studioID int64) error {
+	n.events = append(n.events, fmt.Sprintf("completed:%d:%d", bookingID, userID))
+	return nil
+}
+
+func (n *lifecycleNotifs) NotifyBookingNoShow(ctx context.Context, userID, bookingID, studioID int64) error {
+	n.events = append(n.events, fmt.Sprintf("no_show:%d:%d", bookingID, userID))
+	return nil
+}
+
+func (n *lifecycleNotifs) NotifyBookingCancelled(ctx context.Context, userID, bookingID, studioID int64, reason string) error {
+	n.events = append(n.events, fmt.Sprintf("cancelled:%d:%d", bookingID, userID))
+	return nil
+}
+
+func TestRunLifecycle(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+	notifs := &lifecycleNotifs{}
+	svc.notifs = notifs
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	cfg := LifecycleConfig{PendingTTL: 24 * time.Hour, NoShowGrace: 2 * time.Hour}
+	insert := func(roomID int64, status BookingStatus, payment PaymentStatus, start time.Time, created time.Time) int64 {
+		t.Helper()
+		b := &Booking{RoomID: roomID, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(time.Hour),
+			Status: status, PaymentStatus: payment, CreatedAt: created}
+		if err := svc.bookings.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+		return b.ID
+	}
+
+	paid := insert(1, BookingConfirmed, PaymentPaid, now.Add(-2*time.Hour), now.Add(-48*time.Hour))
+	unpaidLate := insert(2, BookingConfirmed, PaymentUnpaid, now.Add(-4*time.Hour), now.Add(-48*time.Hour))
+	unpaidRecent := insert(3, BookingConfirmed, PaymentUnpaid, now.Add(-90*time.Minute), now.Add(-48*time.Hour))
+	upcoming := insert(1, BookingConfirmed, PaymentUnpaid, now.Add(3*time.Hour), now.Add(-48*time.Hour))
+	stale := insert(2, BookingPending, PaymentUnpaid, now.Add(72*time.Hour), now.Add(-25*time.Hour))
+	fresh := insert(3, BookingPending, PaymentUnpaid, now.Add(72*time.Hour), now.Add(-time.Hour))
+	missed := insert(1, BookingPending, PaymentUnpaid, now.Add(-30*time.Minute), now.Add(-time.Hour))
+	paidPending := insert(3, BookingPending, PaymentPaid, now.Add(96*time.Hour), now.Add(-25*time.Hour))
+
+	res, err := svc.runLifecycle(ctx, cfg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (LifecycleResult{Completed: 2, Expired: 2}) {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	want := map[int64]BookingStatus{
+		paid:         BookingCompleted,
+		unpaidLate:   BookingCompleted, // оплата на месте; неявку отмечает студия
+		unpaidRecent: BookingConfirmed,
+		upcoming:     BookingConfirmed,
+		stale:        BookingCancelled,
+		fresh:        BookingPending,
+		missed:       BookingCancelled,
+		paidPending:  BookingPending, // оплачена — ждёт студию, таймер её не снимает
+	}
+	for id, status := range want {
+		b, err := svc.bookings.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Status != status {
+			t.Errorf("booking %d: status %s, want %s", id, b.Status, status)
+		}
+	}
+
+	// клиент (7) и владелец студии (100) узнают о каждом переходе
+	if len(notifs.events) != 8 {
+		t.Fatalf("expected 8 notifications, got %v", notifs.events)
+	}
+	for _, e := range []string{
+		fmt.Sprintf("completed:%d:7", paid), fmt.Sprintf("completed:%d:100", paid),
+		fmt.Sprintf("completed:%d:7", unpaidLate), fmt.Sprintf("cancelled:%d:100", stale),
+	} {
+		found := false
+		for _, got := range notifs.events {
+			found = found || got == e
+		}
+		if !found {
+			t.Errorf("missing notification %s in %v", e, notifs.events)
+		}
+	}
+
+	// повторный проход ничего не меняет
+	if res, err := svc.runLifecycle(ctx, cfg, now); err != nil || res != (LifecycleResult{}) {
+		t.Fatalf("second run must be a no-op, got %+v err=%v", res, err)
+	}
+
+	// неявку отмечает владелец, пока не истёк NoShowGrace
+	b, err := svc.UpdateBookingStatus(ctx, unpaidRecent, 100, "studio_owner", string(BookingNoShow))
+	if err != nil || b.Status != BookingNoShow {
+		t.Fatalf("expected manual no_show, got %+v err=%v", b, err)
+	}
+	if last := notifs.events[len(notifs.events)-1]; last != fmt.Sprintf("no_show:%d:7", unpaidRecent) {
+		t.Fatalf("expected the client notified about the no-show, got %s", last)
+	}
+}
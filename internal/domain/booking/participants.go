@@ -0,0 +1,205 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const maxParticipants = 20
+
+type ParticipantStatus string
+
+const (
+	ParticipantPending ParticipantStatus = "pending"
+	ParticipantPaid    ParticipantStatus = "paid"
+)
+
+// BookingParticipant — участник брони, который оплачивает свою долю отдельным
+// счётом. Бронь с участниками подтверждается только когда доли покрывают
+// TotalPrice целиком.
+type BookingParticipant struct {
+	ID          int64             `json:"id"`
+	BookingID   int64             `json:"booking_id" gorm:"index;not null"`
+	UserID      *int64            `json:"user_id,omitempty"`
+	Name        string            `json:"name" gorm:"type:varchar(255);not null"`
+	Email       string            `json:"email,omitempty" gorm:"type:varchar(255)"`
+	Phone       string            `json:"phone,omitempty" gorm:"type:varchar(50)"`
+	ShareAmount float64           `json:"share_amount" gorm:"not null"`
+	Status      ParticipantStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	InvID       *int64            `json:"inv_id,omitempty"` // последний выставленный счёт провайдера
+	PaidAt      *time.Time        `json:"paid_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+func (BookingParticipant) TableName() string { return "booking_participants" }
+
+// ParticipantInput — участник в запросе; share_amount = 0 у всех — поровну
+type ParticipantInput struct {
+	Name        string  `json:"name" binding:"required,max=255"`
+	Email       string  `json:"email,omitempty" binding:"omitempty,email,max=255"`
+	Phone       string  `json:"phone,omitempty" binding:"omitempty,max=50"`
+	UserID      *int64  `json:"user_id,omitempty"`
+	ShareAmount float64 `json:"share_amount" binding:"min=0"`
+}
+
+type SetParticipantsRequest struct {
+	Participants []ParticipantInput `json:"participants" binding:"required,min=2,dive"`
+}
+
+// ParticipantsResponse — доли участников и сколько уже оплачено
+type ParticipantsResponse struct {
+	BookingID    int64                `json:"booking_id"`
+	TotalPrice   float64              `json:"total_price"`
+	PaidAmount   float64              `json:"paid_amount"`
+	Covered      bool                 `json:"covered"`
+	Participants []BookingParticipant `json:"participants"`
+}
+
+// SetParticipants делит стоимость брони между участниками. Доли должны в сумме
+// дать TotalPrice (без долей — делится поровну, копейки остатка у первого).
+// Список заменяется целиком, пока никто из участников не заплатил.
+// Менять может клиент брони, владелец студии или администратор.
+func (s *Service) SetParticipants(ctx context.Context, actor Actor, bookingID int64, inputs []ParticipantInput) (*ParticipantsResponse, error) {
+	b, err := s.participantsBookingFor(ctx, actor, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != BookingHeld && b.Status != BookingPending {
+		return nil, ErrInvalidStatusTransition
+	}
+	if b.PaymentStatus == PaymentPaid || b.DepositAmount > 0 {
+		// оплаченную целиком или с внесённой предоплатой бронь уже не делят
+		return nil, ErrValidation
+	}
+
+	shares, err := splitShares(b.TotalPrice, inputs)
+	if err != nil {
+		return nil, err
+	}
+	participants := make([]BookingParticipant, len(inputs))
+	for i, in := range inputs {
+		participants[i] = BookingParticipant{
+			BookingID:   bookingID,
+			UserID:      in.UserID,
+			Name:        strings.TrimSpace(in.Name),
+			Email:       strings.ToLower(strings.TrimSpace(in.Email)),
+			Phone:       strings.TrimSpace(in.Phone),
+			ShareAmount: shares[i],
+			Status:      ParticipantPending,
+		}
+		if participants[i].Name == "" {
+			return nil, ErrValidation
+		}
+	}
+
+	if err := s.bookings.ReplaceParticipants(ctx, bookingID, participants); err != nil {
+		return nil, err
+	}
+	return s.participantsResponse(ctx, b)
+}
+
+// GetParticipants возвращает участников брони и покрытие стоимости
+func (s *Service) GetParticipants(ctx context.Context, actor Actor, bookingID int64) (*ParticipantsResponse, error) {
+	b, err := s.participantsBookingFor(ctx, actor, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	return s.participantsResponse(ctx, b)
+}
+
+func (s *Service) participantsResponse(ctx context.Context, b *Booking) (*ParticipantsResponse, error) {
+	list, err := s.bookings.ListParticipants(ctx, b.ID)
+	if err != nil {
+		return nil, err
+	}
+	var paid int64
+	for _, p := range list {
+		if p.Status == ParticipantPaid {
+			paid += toCents(p.ShareAmount)
+		}
+	}
+	return &ParticipantsResponse{
+		BookingID:    b.ID,
+		TotalPrice:   b.TotalPrice,
+		PaidAmount:   float64(paid) / 100,
+		Covered:      len(list) > 0 && paid >= toCents(b.TotalPrice),
+		Participants: list,
+	}, nil
+}
+
+func (s *Service) participantsBookingFor(ctx context.Context, actor Actor, bookingID int64) (*Booking, error) {
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if actor.Role != ActorAdmin && (b.IsGuest() || b.UserID != actor.UserID) {
+		owns, err := s.bookings.IsStudioOwnedByUser(ctx, b.StudioID, actor.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !owns {
+			return nil, ErrForbidden
+		}
+	}
+	return b, nil
+}
+
+// splitShares проверяет доли (в копейках) или делит total поровну
+func splitShares(total float64, inputs []ParticipantInput) ([]float64, error) {
+	if len(inputs) < 2 || len(inputs) > maxParticipants {
+		return nil, ErrValidation
+	}
+	totalCents := toCents(total)
+
+	explicit := 0
+	var sum int64
+	for _, in := range inputs {
+		if in.ShareAmount > 0 {
+			explicit++
+			sum += toCents(in.ShareAmount)
+		}
+	}
+
+	shares := make([]float64, len(inputs))
+	switch explicit {
+	case 0:
+		n := int64(len(inputs))
+		each, rest := totalCents/n, totalCents%n
+		for i := range shares {
+			c := each
+			if i == 0 {
+				c += rest
+			}
+			shares[i] = float64(c) / 100
+		}
+	case len(inputs):
+		if sum != totalCents {
+			return nil, ErrValidation
+		}
+		for i, in := range inputs {
+			shares[i] = float64(toCents(in.ShareAmount)) / 100
+		}
+	default:
+		// либо доли у всех, либо ни у кого
+		return nil, ErrValidation
+	}
+	for _, sh := range shares {
+		if sh <= 0 {
+			return nil, ErrValidation
+		}
+	}
+	return shares, nil
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
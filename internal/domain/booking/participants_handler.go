@@ -0,0 +1,85 @@
+package booking
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"photostudio/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetParticipants делит оплату брони между участниками
+// @Summary		Разделить оплату между участниками
+// @Description	Для групповых съёмок: каждый участник оплачивает свою долю отдельным счётом (POST /payments/init с participant_id). Доли в сумме должны дать стоимость брони; если share_amount не указан ни у кого — стоимость делится поровну. Бронь подтверждается автоматически, когда оплачены все доли, и не может быть подтверждена раньше. Список заменяется целиком, пока никто не заплатил. Доступно клиенту брони и владельцу студии.
+// @Tags		Бронирования
+// @Security	BearerAuth
+// @Param		id path integer true "ID брони"
+// @Param		body body SetParticipantsRequest true "Участники (от 2 до 20)"
+// @Success		200 {object} ParticipantsResponse
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации: доли не сходятся со стоимостью или кто-то уже заплатил"
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Бронь не найдена"
+// @Router		/bookings/{id}/participants [put]
+func (h *Handler) SetParticipants(c *gin.Context) {
+	bookingID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid booking id")
+		return
+	}
+	var req SetParticipantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	resp, err := h.service.SetParticipants(c.Request.Context(), actorFromContext(c), bookingID, req.Participants)
+	if err != nil {
+		h.handleParticipantsError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+// GetParticipants возвращает участников брони и оплаченные доли
+// @Summary		Участники брони
+// @Tags		Бронирования
+// @Security	BearerAuth
+// @Param		id path integer true "ID брони"
+// @Success		200 {object} ParticipantsResponse
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Бронь не найдена"
+// @Router		/bookings/{id}/participants [get]
+func (h *Handler) GetParticipants(c *gin.Context) {
+	bookingID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid booking id")
+		return
+	}
+
+	resp, err := h.service.GetParticipants(c.Request.Context(), actorFromContext(c), bookingID)
+	if err != nil {
+		h.handleParticipantsError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+func (h *Handler) handleParticipantsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrValidation):
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR",
+			"2-20 participants with names are required; shares must be set for all or none and add up to the booking price; the booking must not be paid yet")
+	case errors.Is(err, ErrInvalidStatusTransition):
+		response.CustomError(c, http.StatusBadRequest, "INVALID_STATUS", "Only held or pending bookings can be split")
+	case errors.Is(err, ErrForbidden):
+		response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Only the booking client or studio owner can manage participants")
+	case errors.Is(err, ErrNotFound):
+		response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Booking not found")
+	default:
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update participants")
+	}
+}
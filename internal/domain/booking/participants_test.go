@@ -0,0 +1,105 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParticipants_ConfirmOnlyWhenSharesCovered(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+	client := Actor{UserID: 9, Role: ActorClient}
+
+	day := nextWeekday(time.Wednesday)
+	b, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 2, StudioID: 1, UserID: 9, StartTime: day.Add(12 * time.Hour), EndTime: day.Add(14 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.TotalPrice != 2000 {
+		t.Fatalf("expected 2000 for two hours, got %v", b.TotalPrice)
+	}
+
+	// без долей — поровну, копейки остатка у первого
+	resp, err := svc.SetParticipants(ctx, client, b.ID, []ParticipantInput{{Name: "A"}, {Name: "B"}, {Name: "C"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := []float64{resp.Participants[0].ShareAmount, resp.Participants[1].ShareAmount, resp.Participants[2].ShareAmount}
+	if shares[0] != 666.68 || shares[1] != 666.66 || shares[2] != 666.66 || resp.Covered {
+		t.Fatalf("unexpected split %v covered=%v", shares, resp.Covered)
+	}
+
+	owner := Actor{UserID: 100, Role: ActorOwner}
+	if _, err := svc.TransitionStatus(ctx, b.ID, BookingConfirmed, owner, ""); !errors.Is(err, ErrSharesNotCovered) {
+		t.Fatalf("expected ErrSharesNotCovered before shares are paid, got %v", err)
+	}
+
+	for i, p := range resp.Participants {
+		updated, err := svc.bookings.MarkParticipantPaid(ctx, p.ID, time.Now().UTC())
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := i == len(resp.Participants)-1
+		if last != (updated.Status == BookingConfirmed) || last != (updated.PaymentStatus == PaymentPaid) {
+			t.Fatalf("after %d of 3 shares got status=%s payment=%s", i+1, updated.Status, updated.PaymentStatus)
+		}
+		if i == 0 {
+			// после первой оплаты список уже не меняется
+			if _, err := svc.SetParticipants(ctx, client, b.ID, []ParticipantInput{{Name: "A"}, {Name: "B"}}); !errors.Is(err, ErrValidation) {
+				t.Fatalf("expected ErrValidation once a share is paid, got %v", err)
+			}
+		}
+	}
+
+	// повторный callback той же доли ничего не ломает
+	again, err := svc.bookings.MarkParticipantPaid(ctx, resp.Participants[0].ID, time.Now().UTC())
+	if err != nil || again.Status != BookingConfirmed {
+		t.Fatalf("expected idempotent mark, got %v %v", again, err)
+	}
+	got, err := svc.GetParticipants(ctx, owner, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Covered || got.PaidAmount != 2000 {
+		t.Fatalf("expected fully covered shares, got %+v", got)
+	}
+}
+
+func TestParticipants_Validation(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+	client := Actor{UserID: 9, Role: ActorClient}
+
+	day := nextWeekday(time.Wednesday)
+	b, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 2, StudioID: 1, UserID: 9, StartTime: day.Add(12 * time.Hour), EndTime: day.Add(14 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]ParticipantInput{
+		"single participant":   {{Name: "A"}},
+		"shares do not add up": {{Name: "A", ShareAmount: 1000}, {Name: "B", ShareAmount: 900}},
+		"mixed shares":         {{Name: "A", ShareAmount: 1000}, {Name: "B"}},
+	}
+	for name, in := range cases {
+		if _, err := svc.SetParticipants(ctx, client, b.ID, in); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: expected ErrValidation, got %v", name, err)
+		}
+	}
+
+	valid := []ParticipantInput{{Name: "A", ShareAmount: 1500}, {Name: "B", ShareAmount: 500}}
+	if _, err := svc.SetParticipants(ctx, Actor{UserID: 10, Role: ActorClient}, b.ID, valid); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for a stranger, got %v", err)
+	}
+	if _, err := svc.SetParticipants(ctx, Actor{UserID: 100, Role: ActorOwner}, b.ID, valid); err != nil {
+		t.Fatalf("studio owner can split the booking: %v", err)
+	}
+
+	// перенос на другую стоимость сломал бы доли
+	later := day.Add(15 * time.Hour)
+	if _, err := svc.RescheduleBooking(ctx, b.ID, client, RescheduleBookingRequest{StartTime: later, EndTime: later.Add(3 * time.Hour)}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation when rescheduling changes a split price, got %v", err)
+	}
+}
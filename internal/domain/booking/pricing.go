@@ -0,0 +1,43 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"photostudio/internal/domain/catalog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Quote считает цену слота по правилам комнаты (catalog.QuoteRoomPrice) в зоне студии
+// плюс аренду оборудования. Через него же считается TotalPrice при создании брони.
+func (s *Service) Quote(ctx context.Context, roomID int64, start, end time.Time, items []EquipmentItemRequest) (*catalog.PriceQuote, error) {
+	if !end.After(start) {
+		return nil, ErrValidation
+	}
+	equipment, err := s.resolveEquipment(ctx, roomID, items)
+	if err != nil {
+		return nil, err
+	}
+	return s.quoteWithEquipment(ctx, roomID, start, end, equipment)
+}
+
+func (s *Service) quoteWithEquipment(ctx context.Context, roomID int64, start, end time.Time, equipment []BookingEquipment) (*catalog.PriceQuote, error) {
+	room, err := s.rooms.GetByID(ctx, roomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	loc, err := s.roomLocation(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := catalog.QuoteRoomPrice(room, start, end, loc)
+	if err != nil {
+		return nil, err
+	}
+	addEquipmentLines(quote, equipment)
+	return quote, nil
+}
@@ -0,0 +1,204 @@
+package booking
+
+import (
+	"context"
+	"log"
+	"photostudio/internal/pkg/utils"
+	"time"
+
+	"photostudio/internal/domain/catalog"
+)
+
+// ReminderStatus — состояние напоминания о брони
+type ReminderStatus string
+
+const (
+	ReminderScheduled ReminderStatus = "scheduled" // ждёт своего времени
+	ReminderSent      ReminderStatus = "sent"
+	ReminderSkipped   ReminderStatus = "skipped"   // время уже прошло, когда бронь подтвердили
+	ReminderCancelled ReminderStatus = "cancelled" // бронь отменили или перенесли
+)
+
+const (
+	// reminderPlanHorizon — за сколько до начала бронь попадает в планировщик
+	// (не меньше самого раннего допустимого напоминания)
+	reminderPlanHorizon = 7 * 24 * time.Hour
+
+	reminderBatchSize = 500
+)
+
+// BookingReminder — запланированное напоминание клиенту. Хранится в БД,
+// поэтому переживает перезапуск; отправляется фоновым планировщиком.
+type BookingReminder struct {
+	ID          int64          `json:"id"`
+	BookingID   int64          `json:"booking_id" gorm:"not null;index"`
+	UserID      int64          `json:"user_id" gorm:"not null"`
+	HoursBefore int            `json:"hours_before" gorm:"not null"`
+	RemindAt    time.Time      `json:"remind_at" gorm:"not null;index:idx_booking_reminders_due,priority:2"`
+	Status      ReminderStatus `json:"status" gorm:"type:varchar(20);not null;index:idx_booking_reminders_due,priority:1"`
+	SentAt      *time.Time     `json:"sent_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+func (BookingReminder) TableName() string { return "booking_reminders" }
+
+// ReminderConfig — настройки отправки напоминаний
+type ReminderConfig struct {
+	Interval        time.Duration // Как часто планировать и отправлять (default: 1m)
+	EnableScheduler bool
+}
+
+// DefaultReminderConfig возвращает настройки; интервал — BOOKING_REMINDER_INTERVAL
+func DefaultReminderConfig() ReminderConfig {
+	return ReminderConfig{
+		Interval:        utils.DurationFromEnv("BOOKING_REMINDER_INTERVAL", time.Minute),
+		EnableScheduler: true,
+	}
+}
+
+// buildReminders раскладывает расписание студии на напоминания брони.
+// Напоминания, время которых уже прошло, сохраняются как skipped, чтобы
+// планировщик не возвращался к брони.
+func (s *Service) buildReminders(ctx context.Context, b *Booking, now time.Time) []BookingReminder {
+	schedule, err := s.rooms.GetStudioReminderScheduleByRoomID(ctx, b.RoomID)
+	if err != nil {
+		log.Printf("Reminder schedule for room %d: %v", b.RoomID, err)
+	}
+	if schedule == nil {
+		schedule = catalog.DefaultReminderSchedule()
+	}
+
+	out := make([]BookingReminder, 0, len(schedule.HoursBefore))
+	for _, h := range schedule.HoursBefore {
+		r := BookingReminder{
+			BookingID:   b.ID,
+			UserID:      b.UserID,
+			HoursBefore: h,
+			RemindAt:    b.StartTime.Add(-time.Duration(h) * time.Hour).UTC(),
+			Status:      ReminderScheduled,
+		}
+		if !r.RemindAt.After(now) {
+			r.Status = ReminderSkipped
+		}
+		out = append(out, r)
+	}
+	if len(out) == 0 {
+		// студия выключила напоминания — отмечаем бронь как обработанную
+		out = append(out, BookingReminder{BookingID: b.ID, UserID: b.UserID, RemindAt: b.StartTime.UTC(), Status: ReminderSkipped})
+	}
+	return out
+}
+
+// replanReminders пересоздаёт ещё не отправленные напоминания брони
+// (после переноса). Для неподтверждённой брони напоминания просто снимаются.
+func (s *Service) replanReminders(ctx context.Context, b *Booking) {
+	var reminders []BookingReminder
+	if b.Status == BookingConfirmed && !b.IsGuest() {
+		reminders = s.buildReminders(ctx, b, time.Now().UTC())
+	}
+	if err := s.bookings.ReplaceReminders(ctx, b.ID, reminders); err != nil {
+		log.Printf("Replan reminders for booking %d: %v", b.ID, err)
+	}
+}
+
+// cancelReminders снимает неотправленные напоминания брони
+func (s *Service) cancelReminders(ctx context.Context, bookingID int64) {
+	if err := s.bookings.CancelReminders(ctx, bookingID); err != nil {
+		log.Printf("Cancel reminders for booking %d: %v", bookingID, err)
+	}
+}
+
+// ProcessReminders планирует напоминания для новых подтверждённых броней
+// и отправляет наступившие. Возвращает число отправленных.
+func (s *Service) ProcessReminders(ctx context.Context) (int, error) {
+	return s.processReminders(ctx, time.Now().UTC())
+}
+
+func (s *Service) processReminders(ctx context.Context, now time.Time) (int, error) {
+	// Подтверждённые брони без напоминаний: так подхватываются подтверждения
+	// из любых точек входа (менеджер, оплата, предоплата)
+	pending, err := s.bookings.ListBookingsWithoutReminders(ctx, now, now.Add(reminderPlanHorizon), reminderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range pending {
+		if err := s.bookings.CreateReminders(ctx, s.buildReminders(ctx, &pending[i], now)); err != nil {
+			log.Printf("Plan reminders for booking %d: %v", pending[i].ID, err)
+		}
+	}
+
+	due, err := s.bookings.ListDueReminders(ctx, now, reminderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, r := range due {
+		b, err := s.bookings.GetByID(ctx, r.BookingID)
+		if err != nil {
+			log.Printf("Reminder %d: booking %d: %v", r.ID, r.BookingID, err)
+			continue
+		}
+		// бронь могли отменить или перенести мимо сервиса — проверяем перед отправкой
+		valid := b.Status == BookingConfirmed && b.StartTime.After(now) &&
+			b.StartTime.Add(-time.Duration(r.HoursBefore)*time.Hour).Equal(r.RemindAt)
+		if !valid {
+			_, _ = s.bookings.SetReminderStatus(ctx, r.ID, ReminderScheduled, ReminderCancelled, nil)
+			continue
+		}
+
+		// сначала забираем напоминание, чтобы параллельный процесс не отправил его второй раз
+		ok, err := s.bookings.SetReminderStatus(ctx, r.ID, ReminderScheduled, ReminderSent, &now)
+		if err != nil || !ok {
+			continue
+		}
+		if s.notifs != nil {
+			start := b.StartTime
+			if loc, err := s.roomLocation(ctx, b.RoomID); err == nil {
+				start = start.In(loc)
+			}
+			if err := s.notifs.NotifyBookingReminder(ctx, b.UserID, b.ID, b.StudioID, start, r.HoursBefore); err != nil {
+				log.Printf("Reminder %d: notify: %v", r.ID, err)
+			}
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		log.Printf("Sent %d booking reminders", sent)
+	}
+	return sent, nil
+}
+
+// ScheduleReminders запускает фоновое планирование и отправку напоминаний
+func (s *Service) ScheduleReminders(ctx context.Context, config ReminderConfig) chan struct{} {
+	if !config.EnableScheduler {
+		log.Println("Booking reminders are disabled")
+		return nil
+	}
+
+	stopCh := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.ProcessReminders(ctx); err != nil {
+					log.Printf("Booking reminders error: %v", err)
+				}
+			case <-stopCh:
+				log.Println("Booking reminders stopped")
+				return
+			case <-ctx.Done():
+				log.Println("Booking reminders stopped (context Done)")
+				return
+			}
+		}
+	}()
+
+	log.Printf("Booking reminders started with interval %v", config.Interval)
+	return stopCh
+}
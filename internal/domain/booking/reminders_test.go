@@ -0,0 +1,125 @@
+package booking
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// reminderNotifs запоминает отправленные напоминания: booking_id -> hours_before
+type reminderNotifs struct {
+	waitlistNotifs
+	sent []int
+}
+
+func (n *reminderNotifs) NotifyBookingReminder(ctx context.Context, userID, bookingID, studioID int64, start time.Time, hoursBefore int) error {
+	n.sent = append(n.sent, hoursBefore)
+	return nil
+}
+
+func TestReminders_PlanSendReplanCancel(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+	if err := svc.bookings.DB().AutoMigrate(&BookingReminder{}); err != nil {
+		t.Fatal(err)
+	}
+	notifs := &reminderNotifs{}
+	svc.notifs = notifs
+
+	day := nextWeekday(time.Tuesday)
+	start := day.Add(12 * time.Hour)
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(2 * time.Hour),
+		Status: BookingConfirmed, PaymentStatus: PaymentPaid}
+	if err := svc.bookings.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	pending := &Booking{RoomID: 2, StudioID: 1, UserID: 8, StartTime: start, EndTime: start.Add(2 * time.Hour),
+		Status: BookingPending, PaymentStatus: PaymentUnpaid}
+	if err := svc.bookings.Create(ctx, pending); err != nil {
+		t.Fatal(err)
+	}
+
+	// планирование: за 24ч и 2ч, только для подтверждённой брони
+	if n, err := svc.processReminders(ctx, start.Add(-30*time.Hour)); err != nil || n != 0 {
+		t.Fatalf("nothing is due yet, got n=%d err=%v", n, err)
+	}
+	var planned []BookingReminder
+	svc.bookings.DB().Order("hours_before desc").Find(&planned)
+	if len(planned) != 2 || planned[0].BookingID != b.ID || planned[0].HoursBefore != 24 || planned[1].HoursBefore != 2 {
+		t.Fatalf("expected 24h and 2h reminders for the confirmed booking, got %+v", planned)
+	}
+
+	if n, err := svc.processReminders(ctx, start.Add(-23*time.Hour)); err != nil || n != 1 {
+		t.Fatalf("24h reminder must be sent, got n=%d err=%v", n, err)
+	}
+	if n, _ := svc.processReminders(ctx, start.Add(-23*time.Hour)); n != 0 {
+		t.Fatal("reminder must be sent only once")
+	}
+
+	// перенос: неотправленные напоминания пересчитываются от нового начала
+	newStart := start.Add(3 * time.Hour)
+	if _, err := svc.RescheduleBooking(ctx, b.ID, Actor{UserID: 7, Role: ActorClient},
+		RescheduleBookingRequest{StartTime: newStart, EndTime: newStart.Add(2 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	// старое 2ч напоминание снято, новое 24ч уже наступило
+	if n, _ := svc.processReminders(ctx, start.Add(-90*time.Minute)); n != 1 || notifs.sent[1] != 24 {
+		t.Fatalf("only the new 24h reminder must fire after reschedule, got %v", notifs.sent)
+	}
+
+	// отмена снимает оставшиеся напоминания
+	if _, err := svc.CancelBooking(ctx, b.ID, Actor{UserID: 7, Role: ActorClient}, "plans changed"); err != nil {
+		t.Fatal(err)
+	}
+	var scheduled int64
+	svc.bookings.DB().Model(&BookingReminder{}).Where("status = ?", ReminderScheduled).Count(&scheduled)
+	if scheduled != 0 {
+		t.Fatalf("cancel must drop scheduled reminders, %d left", scheduled)
+	}
+	if n, _ := svc.processReminders(ctx, newStart.Add(-90*time.Minute)); n != 0 || len(notifs.sent) != 2 {
+		t.Fatalf("no reminders after cancel, got %v", notifs.sent)
+	}
+}
+
+func TestRescheduleSeries_ReplansReminders(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+	if err := svc.bookings.DB().AutoMigrate(&BookingReminder{}, &BookingSeries{}); err != nil {
+		t.Fatal(err)
+	}
+
+	day := nextWeekday(time.Tuesday)
+	start := day.Add(12 * time.Hour)
+	series := &BookingSeries{UserID: 7, RoomID: 1, StudioID: 1, Frequency: SeriesWeekly, StartTime: start, EndTime: start.Add(2 * time.Hour),
+		Count: 2, Timezone: "UTC", Status: SeriesActive}
+	if err := svc.bookings.CreateSeries(ctx, series); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for i := 0; i < 2; i++ {
+		at := start.AddDate(0, 0, 7*i)
+		b := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: at, EndTime: at.Add(2 * time.Hour),
+			Status: BookingConfirmed, PaymentStatus: PaymentPaid, SeriesID: &series.ID}
+		if err := svc.bookings.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, b.ID)
+	}
+	if _, err := svc.processReminders(ctx, start.Add(-30*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.RescheduleSeries(ctx, series.ID, Actor{UserID: 7, Role: ActorClient}, RescheduleSeriesRequest{StartClock: "15:00", EndClock: "17:00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// напоминания каждого занятия пересчитаны от нового начала
+	for i, id := range ids {
+		var reminders []BookingReminder
+		svc.bookings.DB().Where("booking_id = ? AND status = ?", id, ReminderScheduled).Order("hours_before desc").Find(&reminders)
+		newStart := start.AddDate(0, 0, 7*i).Add(3 * time.Hour)
+		if len(reminders) != 2 || !reminders[0].RemindAt.Equal(newStart.Add(-24*time.Hour)) || !reminders[1].RemindAt.Equal(newStart.Add(-2*time.Hour)) {
+			t.Fatalf("booking %d: expected reminders replanned for %s, got %+v", id, newStart, reminders)
+		}
+	}
+}
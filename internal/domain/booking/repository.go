@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"photostudio/internal/domain/auth"
+	"photostudio/internal/domain/catalog"
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type bookingRepository struct {
@@ -31,6 +34,16 @@ type bookingModel struct {
 	CreatedAt     time.Time  `gorm:"column:created_at"`
 	UpdatedAt     time.Time  `gorm:"column:updated_at"`
 	CancelledAt   *time.Time `gorm:"column:cancelled_at"`
+	SeriesID      *int64     `gorm:"column:series_id"`
+	HoldExpiresAt *time.Time `gorm:"column:hold_expires_at"`
+
+	CancellationReason *string  `gorm:"column:cancellation_reason"`
+	DepositAmount      *float64 `gorm:"column:deposit_amount"`
+	RefundAmount       float64  `gorm:"column:refund_amount"`
+	GroupID            *int64   `gorm:"column:group_id"`
+	GuestName          *string  `gorm:"column:guest_name"`
+	GuestPhone         *string  `gorm:"column:guest_phone"`
+	GuestEmail         *string  `gorm:"column:guest_email"`
 }
 
 func (bookingModel) TableName() string { return "bookings" }
@@ -40,6 +53,14 @@ func toDomainBooking(m bookingModel) *Booking {
 	if m.Notes != nil {
 		notes = *m.Notes
 	}
+	var reason string
+	if m.CancellationReason != nil {
+		reason = *m.CancellationReason
+	}
+	var deposit float64
+	if m.DepositAmount != nil {
+		deposit = *m.DepositAmount
+	}
 
 	return &Booking{
 		ID:            m.ID,
@@ -55,6 +76,16 @@ func toDomainBooking(m bookingModel) *Booking {
 		CreatedAt:     m.CreatedAt,
 		UpdatedAt:     m.UpdatedAt,
 		CancelledAt:   m.CancelledAt,
+		SeriesID:      m.SeriesID,
+		HoldExpiresAt: m.HoldExpiresAt,
+
+		CancellationReason: reason,
+		DepositAmount:      deposit,
+		RefundAmount:       m.RefundAmount,
+		GroupID:            m.GroupID,
+		GuestName:          derefString(m.GuestName),
+		GuestPhone:         derefString(m.GuestPhone),
+		GuestEmail:         derefString(m.GuestEmail),
 	}
 }
 
@@ -64,6 +95,12 @@ func toBookingModel(b *Booking) bookingModel {
 		v := b.Notes
 		notes = &v
 	}
+	var reason *string
+	if b.CancellationReason != "" {
+		v := b.CancellationReason
+		reason = &v
+	}
+	deposit := b.DepositAmount
 
 	return bookingModel{
 		ID:            b.ID,
@@ -79,17 +116,62 @@ func toBookingModel(b *Booking) bookingModel {
 		CreatedAt:     b.CreatedAt,
 		UpdatedAt:     b.UpdatedAt,
 		CancelledAt:   b.CancelledAt,
+		SeriesID:      b.SeriesID,
+		HoldExpiresAt: b.HoldExpiresAt,
+
+		CancellationReason: reason,
+		DepositAmount:      &deposit,
+		RefundAmount:       b.RefundAmount,
+		GroupID:            b.GroupID,
+		GuestName:          optionalString(b.GuestName),
+		GuestPhone:         optionalString(b.GuestPhone),
+		GuestEmail:         optionalString(b.GuestEmail),
 	}
 }
 
+func derefString(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+func optionalString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
 func (r *bookingRepository) Create(ctx context.Context, booking *Booking) error {
-	// Проверяем пересечение времени (работает на обоих БД)
+	return r.reserve(ctx, func(tx *gorm.DB) error {
+		return createBookingTx(tx, booking)
+	})
+}
+
+// createBookingTx проверяет пересечения и оборудование и создаёт бронь
+// внутри транзакции резервирования (см. reserve)
+func createBookingTx(tx *gorm.DB, booking *Booking) error {
+	if err := lockRoomTx(tx, booking.RoomID); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := releaseExpiredHoldsTx(tx, booking.RoomID, booking.StartTime, booking.EndTime, now); err != nil {
+		return err
+	}
+
+	// Проверяем пересечение времени с учётом буферов комнаты (работает на обоих БД)
+	gap, err := roomBufferGap(tx, booking.RoomID)
+	if err != nil {
+		return err
+	}
 	var count int64
-	err := r.db.WithContext(ctx).
+	err = tx.
 		Model(&Booking{}).
 		Where("room_id = ?", booking.RoomID).
-		Where("status NOT IN ('cancelled', 'rejected')").
-		Where("start_time < ? AND end_time > ?", booking.EndTime, booking.StartTime).
+		Where("status NOT IN ?", slotReleasingStatuses).
+		Scopes(notExpiredHold(now)).
+		Where("start_time < ? AND end_time > ?", booking.EndTime.Add(gap), booking.StartTime.Add(-gap)).
 		Count(&count).Error
 
 	if err != nil {
@@ -97,10 +179,26 @@ func (r *bookingRepository) Create(ctx context.Context, booking *Booking) error
 	}
 
 	if count > 0 {
-		return errors.New("time slot is already booked")
+		return ErrNotAvailable
+	}
+	if busy, err := externalBusyTx(tx, booking.RoomID, booking.StartTime.Add(-gap), booking.EndTime.Add(gap)); err != nil || busy {
+		if err != nil {
+			return err
+		}
+		return ErrNotAvailable
+	}
+
+	// Аренда оборудования: остаток проверяется в той же транзакции
+	if err := checkEquipmentTx(tx, booking.Equipment, booking.StartTime, booking.EndTime, 0); err != nil {
+		return err
 	}
 
-	return r.db.WithContext(ctx).Create(booking).Error
+	// позиции оборудования создаются вместе с бронью (has-many)
+	if booking.IsGuest() {
+		// у гостя нет аккаунта: user_id остаётся NULL, а не 0
+		return tx.Omit("user_id").Create(booking).Error
+	}
+	return tx.Create(booking).Error
 }
 
 func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*Booking, error) {
@@ -115,21 +213,45 @@ func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*Booking, er
 // CheckAvailability - Fixed for SQLite compatibility (Problem #B3)
 // Uses standard time overlap check instead of PostgreSQL-specific tstzrange
 func (r *bookingRepository) CheckAvailability(ctx context.Context, roomID int64, start, end time.Time) (bool, error) {
+	return r.CheckAvailabilityExcluding(ctx, roomID, start, end, nil)
+}
+
+// CheckAvailabilityExcluding — то же, но не учитывает брони из excludeIDs
+// (нужно при переносе: бронь не должна конфликтовать сама с собой)
+func (r *bookingRepository) CheckAvailabilityExcluding(ctx context.Context, roomID int64, start, end time.Time, excludeIDs []int64) (bool, error) {
 	var cnt int64
 
+	// Между бронями должен оставаться зазор на уборку и подготовку
+	gap, err := roomBufferGap(r.db.WithContext(ctx), roomID)
+	if err != nil {
+		return false, err
+	}
+
 	// SQLite-compatible time overlap check
 	// Two time ranges overlap if: start1 < end2 AND end1 > start2
-	err := r.db.WithContext(ctx).
+	q := r.db.WithContext(ctx).
 		Model(&bookingModel{}).
 		Where("room_id = ?", roomID).
-		Where("status NOT IN ('cancelled')").
-		Where("start_time < ? AND end_time > ?", end, start).
-		Count(&cnt).Error
+		Where("status NOT IN ?", slotReleasingStatuses).
+		Scopes(notExpiredHold(time.Now())).
+		Where("start_time < ? AND end_time > ?", end.Add(gap), start.Add(-gap))
+	if len(excludeIDs) > 0 {
+		q = q.Where("id NOT IN ?", excludeIDs)
+	}
+
+	if err := q.Count(&cnt).Error; err != nil {
+		return false, err
+	}
+	if cnt > 0 {
+		return false, nil
+	}
 
+	// занятость из внешних календарей комнаты
+	busy, err := externalBusyTx(r.db.WithContext(ctx), roomID, start.Add(-gap), end.Add(gap))
 	if err != nil {
 		return false, err
 	}
-	return cnt == 0, nil
+	return !busy, nil
 }
 
 type BusySlot struct {
@@ -139,22 +261,100 @@ type BusySlot struct {
 
 // GetBusySlotsForRoom - Fixed for SQLite compatibility (Problem #B3)
 func (r *bookingRepository) GetBusySlotsForRoom(ctx context.Context, roomID int64, from, to time.Time) ([]BusySlot, error) {
-	var rows []BusySlot
+	byRoom, err := r.GetBusySlotsForRooms(ctx, []int64{roomID}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if rows := byRoom[roomID]; rows != nil {
+		return rows, nil
+	}
+	return []BusySlot{}, nil
+}
+
+// GetBusySlotsForRooms — занятое время нескольких комнат двумя запросами
+// (брони и внешние календари), по комнатам и по времени начала
+func (r *bookingRepository) GetBusySlotsForRooms(ctx context.Context, roomIDs []int64, from, to time.Time) (map[int64][]BusySlot, error) {
+	type roomBusy struct {
+		RoomID int64     `gorm:"column:room_id"`
+		Start  time.Time `gorm:"column:start"`
+		End    time.Time `gorm:"column:end"`
+	}
+	out := make(map[int64][]BusySlot, len(roomIDs))
+	if len(roomIDs) == 0 {
+		return out, nil
+	}
 
 	// SQLite-compatible query
-	err := r.db.WithContext(ctx).
+	var rows []roomBusy
+	if err := r.db.WithContext(ctx).
 		Model(&bookingModel{}).
-		Select("start_time AS start, end_time AS end").
-		Where("room_id = ?", roomID).
-		Where("status NOT IN ('cancelled')").
+		Select("room_id, start_time AS start, end_time AS end").
+		Where("room_id IN ?", roomIDs).
+		Where("status NOT IN ?", slotReleasingStatuses).
+		Scopes(notExpiredHold(time.Now())).
 		Where("start_time < ? AND end_time > ?", to, from).
-		Order("start_time").
-		Scan(&rows).Error
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
 
-	if err != nil {
+	// внешние календари комнаты занимают время так же, как брони
+	var external []roomBusy
+	if err := r.db.WithContext(ctx).
+		Model(&ExternalBusyBlock{}).
+		Select("room_id, start_time AS start, end_time AS end").
+		Where("room_id IN ? AND start_time < ? AND end_time > ?", roomIDs, to, from).
+		Scan(&external).Error; err != nil {
 		return nil, err
 	}
-	return rows, nil
+
+	for _, b := range append(rows, external...) {
+		out[b.RoomID] = append(out[b.RoomID], BusySlot{Start: b.Start, End: b.End})
+	}
+	for id := range out {
+		slots := out[id]
+		sort.Slice(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+	}
+	return out, nil
+}
+
+// ListSearchRooms — активные комнаты для поиска свободного времени и их студии.
+// Цена — базовая цена в час (как фильтр каталога).
+func (r *bookingRepository) ListSearchRooms(ctx context.Context, city, roomType string, minPrice, maxPrice float64, limit int) ([]catalog.Room, []SearchStudio, error) {
+	var studios []SearchStudio
+	sq := r.db.WithContext(ctx).
+		Table("studios").
+		Select("id, name, city, timezone").
+		Where("deleted_at IS NULL")
+	if city != "" {
+		sq = sq.Where("LOWER(city) = LOWER(?)", city)
+	}
+	if err := sq.Scan(&studios).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(studios) == 0 {
+		return nil, nil, nil
+	}
+	studioIDs := make([]int64, 0, len(studios))
+	for _, s := range studios {
+		studioIDs = append(studioIDs, s.ID)
+	}
+
+	q := r.db.WithContext(ctx).
+		Where("studio_id IN ? AND is_active = ?", studioIDs, true)
+	if roomType != "" {
+		q = q.Where("room_type = ?", roomType)
+	}
+	if minPrice > 0 {
+		q = q.Where("price_per_hour_min >= ?", minPrice)
+	}
+	if maxPrice > 0 {
+		q = q.Where("price_per_hour_min <= ?", maxPrice)
+	}
+	var rooms []catalog.Room
+	if err := q.Order("id").Limit(limit).Find(&rooms).Error; err != nil {
+		return nil, nil, err
+	}
+	return rooms, studios, nil
 }
 
 type UserBookingDetails struct {
@@ -205,6 +405,35 @@ LIMIT ? OFFSET ?
 	return rows, nil
 }
 
+// GetUserBookingDetails — одна бронь клиента с названиями комнаты и студии
+func (r *bookingRepository) GetUserBookingDetails(ctx context.Context, userID, bookingID int64) (*UserBookingDetails, error) {
+	var row UserBookingDetails
+	q := `
+SELECT
+  b.id,
+  b.status,
+  b.start_time,
+  b.end_time,
+  b.total_price,
+  b.room_id,
+  rm.name AS room_name,
+  b.studio_id,
+  s.name AS studio_name
+FROM bookings b
+JOIN rooms rm ON rm.id = b.room_id
+JOIN studios s ON s.id = b.studio_id
+WHERE b.user_id = ? AND b.id = ?
+`
+	tx := r.db.WithContext(ctx).Raw(q, userID, bookingID).Scan(&row)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &row, nil
+}
+
 func (r *bookingRepository) GetStudioOwnerForBooking(ctx context.Context, bookingID int64) (int64, string, error) {
 	type row struct {
 		OwnerID int64  `gorm:"column:owner_id"`
@@ -227,15 +456,133 @@ WHERE b.id = ?
 	return out.OwnerID, out.Status, nil
 }
 
+// UpdateStatus меняет статус от имени системы; переход проверяется по таблице
 func (r *bookingRepository) UpdateStatus(ctx context.Context, bookingID int64, newStatus string) error {
-	tx := r.db.WithContext(ctx).
-		Table("bookings").
-		Where("id = ?", bookingID).
-		Updates(map[string]any{
-			"status":     newStatus,
-			"updated_at": time.Now().UTC(),
-		})
-	return tx.Error
+	_, err := r.TransitionStatus(ctx, bookingID, BookingStatus(newStatus), SystemActor, "")
+	return err
+}
+
+// TransitionStatus атомарно проверяет переход, меняет статус и пишет историю.
+// Брони одной группы переходят вместе.
+func (r *bookingRepository) TransitionStatus(ctx context.Context, bookingID int64, to BookingStatus, actor Actor, reason string) (*Booking, error) {
+	var out *Booking
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		members, idx, err := lockBookingGroupTx(tx, bookingID)
+		if err != nil {
+			return err
+		}
+		for i := range members {
+			if err := transitionTx(tx, &members[i], to, actor, reason); err != nil {
+				return err
+			}
+		}
+		if err := syncGroupStatusTx(tx, members[idx].GroupID, to); err != nil {
+			return err
+		}
+		out = toDomainBooking(members[idx])
+		return nil
+	})
+	return out, err
+}
+
+// CancelWithRefund отменяет бронь и в той же транзакции записывает сумму к возврату:
+// refundPercent% от фактически оплаченного. payment_status не меняется — его
+// переводит ApplyRefund, когда провайдер подтвердит возврат.
+// Бронь из группы отменяется вместе со всей группой.
+func (r *bookingRepository) CancelWithRefund(ctx context.Context, bookingID int64, actor Actor, reason string, refundPercent int) (*Booking, error) {
+	var out *Booking
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		members, idx, err := lockBookingGroupTx(tx, bookingID)
+		if err != nil {
+			return err
+		}
+		for i := range members {
+			m := &members[i]
+			if err := transitionTx(tx, m, BookingCancelled, actor, reason); err != nil {
+				return err
+			}
+
+			if refund := computeRefund(paidAmount(*m), refundPercent); refund > 0 {
+				if err := tx.Model(&bookingModel{}).
+					Where("id = ?", m.ID).
+					Update("refund_amount", refund).Error; err != nil {
+					return err
+				}
+				m.RefundAmount = refund
+			}
+		}
+		if err := syncGroupStatusTx(tx, members[idx].GroupID, BookingCancelled); err != nil {
+			return err
+		}
+
+		out = toDomainBooking(members[idx])
+		return nil
+	})
+	return out, err
+}
+
+// transitionTx — общий шаг перехода внутри транзакции вызывающего
+func transitionTx(tx *gorm.DB, m *bookingModel, to BookingStatus, actor Actor, reason string) error {
+	from := BookingStatus(m.Status)
+	if !CanTransition(from, to) {
+		return ErrInvalidStatusTransition
+	}
+
+	now := time.Now().UTC()
+	updates := map[string]interface{}{
+		"status":     string(to),
+		"updated_at": now,
+	}
+	switch to {
+	case BookingCancelled:
+		updates["cancelled_at"] = now
+		updates["cancellation_reason"] = reason
+		m.CancelledAt, m.CancellationReason = &now, &reason
+	case BookingPending:
+		if from == BookingHeld {
+			updates["hold_expires_at"] = nil
+			m.HoldExpiresAt = nil
+		}
+	case BookingConfirmed:
+		// бронь с участниками подтверждается только оплаченной целиком
+		if err := sharesCoveredTx(tx, m); err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&bookingModel{}).Where("id = ?", m.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	m.Status, m.UpdatedAt = string(to), now
+	return historyTx(tx, m.ID, from, to, actor, reason, now)
+}
+
+// historyTx пишет строку booking_status_history
+func historyTx(tx *gorm.DB, bookingID int64, from, to BookingStatus, actor Actor, reason string, at time.Time) error {
+	h := &BookingStatusHistory{
+		BookingID:  bookingID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorRole:  actor.Role,
+		Reason:     reason,
+		CreatedAt:  at,
+	}
+	if actor.UserID > 0 {
+		id := actor.UserID
+		h.ActorID = &id
+	}
+	return tx.Create(h).Error
+}
+
+// GetStatusHistory возвращает историю статусов брони в хронологическом порядке
+func (r *bookingRepository) GetStatusHistory(ctx context.Context, bookingID int64) ([]BookingStatusHistory, error) {
+	var rows []BookingStatusHistory
+	if err := r.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Order("created_at, id").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
 }
 func (r *bookingRepository) HasCompletedBookingForStudio(ctx context.Context, userID, studioID int64) (bool, error) {
 	var cnt int64
@@ -370,27 +717,328 @@ func (r *bookingRepository) GetRecentByUserID(userID int64, limit int) ([]auth.R
 	return rows, nil
 }
 
-// CancelWithReason отменяет бронирование с сохранением причины
-// Block 9: Обязательная причина отмены
-func (r *bookingRepository) CancelWithReason(ctx context.Context, bookingID int64, reason string) error {
-	now := time.Now()
+// -------------------- Calendar feeds --------------------
+
+func (r *bookingRepository) CreateCalendarFeed(ctx context.Context, feed *CalendarFeed) error {
+	return r.db.WithContext(ctx).Create(feed).Error
+}
+
+func (r *bookingRepository) GetCalendarFeedByToken(ctx context.Context, token string) (*CalendarFeed, error) {
+	var feed CalendarFeed
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&feed).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (r *bookingRepository) ListCalendarFeeds(ctx context.Context, ownerID int64) ([]CalendarFeed, error) {
+	var out []CalendarFeed
+	err := r.db.WithContext(ctx).Where("owner_id = ?", ownerID).Order("id").Find(&out).Error
+	return out, err
+}
+
+// DeleteCalendarFeed отзывает ссылку владельца; false — такой ссылки у него нет
+func (r *bookingRepository) DeleteCalendarFeed(ctx context.Context, ownerID, id int64) (bool, error) {
+	res := r.db.WithContext(ctx).Where("id = ? AND owner_id = ?", id, ownerID).Delete(&CalendarFeed{})
+	return res.RowsAffected == 1, res.Error
+}
+
+// IsStudioOwnedByUser проверяет, что студия принадлежит пользователю
+func (r *bookingRepository) IsStudioOwnedByUser(ctx context.Context, studioID, userID int64) (bool, error) {
+	var cnt int64
+	err := r.db.WithContext(ctx).
+		Table("studios").
+		Where("id = ? AND owner_id = ?", studioID, userID).
+		Count(&cnt).Error
+	return cnt > 0, err
+}
+
+// LinkGuestBooking привязывает гостевую бронь к существующему аккаунту.
+// gorm.ErrRecordNotFound — нет такого пользователя, ErrValidation — бронь уже
+// чья-то (или удалена).
+func (r *bookingRepository) LinkGuestBooking(ctx context.Context, bookingID, userID int64) error {
+	var cnt int64
+	if err := r.db.WithContext(ctx).Table("users").Where("id = ?", userID).Count(&cnt).Error; err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	res := r.db.WithContext(ctx).
+		Model(&bookingModel{}).
+		Where("id = ? AND user_id IS NULL", bookingID).
+		Updates(map[string]interface{}{"user_id": userID, "updated_at": time.Now().UTC()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrValidation
+	}
+	return nil
+}
+
+// -------------------- Participants --------------------
+
+// sharesCoveredTx — ErrSharesNotCovered, если у брони есть участники и
+// оплаченные доли ещё не покрывают TotalPrice
+func sharesCoveredTx(tx *gorm.DB, m *bookingModel) error {
+	var participants []BookingParticipant
+	if err := tx.Where("booking_id = ?", m.ID).Find(&participants).Error; err != nil {
+		return err
+	}
+	if len(participants) == 0 {
+		return nil
+	}
+	var paid int64
+	for _, p := range participants {
+		if p.Status == ParticipantPaid {
+			paid += toCents(p.ShareAmount)
+		}
+	}
+	if paid < toCents(m.TotalPrice) {
+		return ErrSharesNotCovered
+	}
+	return nil
+}
+
+// ReplaceParticipants заменяет участников брони. ErrValidation — кто-то уже заплатил.
+func (r *bookingRepository) ReplaceParticipants(ctx context.Context, bookingID int64, participants []BookingParticipant) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var m bookingModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, bookingID).Error; err != nil {
+			return err
+		}
+		var paid int64
+		if err := tx.Model(&BookingParticipant{}).
+			Where("booking_id = ? AND status = ?", bookingID, ParticipantPaid).
+			Count(&paid).Error; err != nil {
+			return err
+		}
+		if paid > 0 {
+			return ErrValidation
+		}
+		if err := tx.Where("booking_id = ?", bookingID).Delete(&BookingParticipant{}).Error; err != nil {
+			return err
+		}
+		if len(participants) == 0 {
+			return nil
+		}
+		return tx.Create(&participants).Error
+	})
+}
+
+func (r *bookingRepository) ListParticipants(ctx context.Context, bookingID int64) ([]BookingParticipant, error) {
+	var rows []BookingParticipant
+	err := r.db.WithContext(ctx).Where("booking_id = ?", bookingID).Order("id").Find(&rows).Error
+	return rows, err
+}
+
+func (r *bookingRepository) GetParticipant(ctx context.Context, id int64) (*BookingParticipant, error) {
+	var p BookingParticipant
+	if err := r.db.WithContext(ctx).First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetParticipantInvoice запоминает последний счёт, выставленный участнику
+func (r *bookingRepository) SetParticipantInvoice(ctx context.Context, id, invID int64) error {
 	return r.db.WithContext(ctx).
-		Model(&Booking{}).
-		Where("id = ?", bookingID).
-		Updates(map[string]interface{}{
-			"status":              string(BookingCancelled),
-			"cancellation_reason": reason,
-			"cancelled_at":        &now,
-		}).Error
+		Model(&BookingParticipant{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"inv_id": invID, "updated_at": time.Now().UTC()}).Error
+}
+
+// MarkParticipantPaid отмечает долю участника оплаченной (идемпотентно).
+// Первая оплата переводит hold в обычную бронь; когда доли покрывают
+// TotalPrice, бронь становится оплаченной и подтверждается.
+func (r *bookingRepository) MarkParticipantPaid(ctx context.Context, participantID int64, paidAt time.Time) (*Booking, error) {
+	var out *Booking
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p BookingParticipant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, participantID).Error; err != nil {
+			return err
+		}
+		var m bookingModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, p.BookingID).Error; err != nil {
+			return err
+		}
+		if p.Status != ParticipantPaid {
+			if err := tx.Model(&BookingParticipant{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+				"status":     ParticipantPaid,
+				"paid_at":    paidAt,
+				"updated_at": paidAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if BookingStatus(m.Status) == BookingHeld {
+			if err := transitionTx(tx, &m, BookingPending, SystemActor, "payment received"); err != nil {
+				return err
+			}
+		}
+		if BookingStatus(m.Status) == BookingPending {
+			switch err := sharesCoveredTx(tx, &m); {
+			case err == nil:
+				if err := tx.Model(&bookingModel{}).Where("id = ?", m.ID).
+					Update("payment_status", string(PaymentPaid)).Error; err != nil {
+					return err
+				}
+				m.PaymentStatus = string(PaymentPaid)
+				if err := transitionTx(tx, &m, BookingConfirmed, SystemActor, "participant shares covered"); err != nil {
+					return err
+				}
+			case !errors.Is(err, ErrSharesNotCovered):
+				return err
+			}
+		}
+		out = toDomainBooking(m)
+		return nil
+	})
+	return out, err
+}
+
+// ApplyPayment добавляет поступившую оплату к внесённой сумме (deposit_amount).
+// Когда внесено не меньше TotalPrice, бронь считается оплаченной.
+func (r *bookingRepository) ApplyPayment(ctx context.Context, bookingID int64, amount float64) (*Booking, error) {
+	var out *Booking
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var m bookingModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, bookingID).Error; err != nil {
+			return err
+		}
+		var deposit float64
+		if m.DepositAmount != nil {
+			deposit = *m.DepositAmount
+		}
+		deposit = float64(toCents(deposit)+toCents(amount)) / 100
+
+		updates := map[string]interface{}{"deposit_amount": deposit}
+		if toCents(deposit) >= toCents(m.TotalPrice) {
+			updates["payment_status"] = string(PaymentPaid)
+			m.PaymentStatus = string(PaymentPaid)
+		}
+		if err := tx.Model(&bookingModel{}).Where("id = ?", m.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		m.DepositAmount = &deposit
+		out = toDomainBooking(m)
+		return nil
+	})
+	return out, err
+}
+
+// ApplyRefund отражает в брони деньги, фактически возвращённые через провайдера.
+// refund_amount не уменьшается: при отмене по политике там уже может стоять
+// положенная клиенту сумма.
+func (r *bookingRepository) ApplyRefund(ctx context.Context, bookingID int64, refunded float64) (*Booking, error) {
+	var out *Booking
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var m bookingModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, bookingID).Error; err != nil {
+			return err
+		}
+		if toCents(refunded) > toCents(m.RefundAmount) {
+			m.RefundAmount = refunded
+		}
+		paid := m.TotalPrice
+		if m.DepositAmount != nil && *m.DepositAmount > 0 {
+			paid = *m.DepositAmount
+		}
+		// refund_amount при отмене — сумма к возврату, статус считается по фактически возвращённому
+		status := PaymentPartiallyRefunded
+		if toCents(refunded) >= toCents(paid) {
+			status = PaymentRefunded
+		}
+		if err := tx.Model(&bookingModel{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+			"refund_amount":  m.RefundAmount,
+			"payment_status": string(status),
+		}).Error; err != nil {
+			return err
+		}
+		m.PaymentStatus = string(status)
+		out = toDomainBooking(m)
+		return nil
+	})
+	return out, err
+}
+
+// -------------------- External calendars --------------------
+
+// externalBusyTx — есть ли в окне занятое время из внешних календарей комнаты
+func externalBusyTx(tx *gorm.DB, roomID int64, start, end time.Time) (bool, error) {
+	var cnt int64
+	err := tx.Model(&ExternalBusyBlock{}).
+		Where("room_id = ? AND start_time < ? AND end_time > ?", roomID, end, start).
+		Count(&cnt).Error
+	return cnt > 0, err
+}
+
+func (r *bookingRepository) CreateExternalCalendar(ctx context.Context, cal *ExternalCalendar) error {
+	return r.db.WithContext(ctx).Create(cal).Error
+}
+
+func (r *bookingRepository) GetExternalCalendar(ctx context.Context, id int64) (*ExternalCalendar, error) {
+	var cal ExternalCalendar
+	if err := r.db.WithContext(ctx).First(&cal, id).Error; err != nil {
+		return nil, err
+	}
+	return &cal, nil
+}
+
+func (r *bookingRepository) ListExternalCalendars(ctx context.Context, roomID int64) ([]ExternalCalendar, error) {
+	var out []ExternalCalendar
+	err := r.db.WithContext(ctx).Where("room_id = ?", roomID).Order("id").Find(&out).Error
+	return out, err
+}
+
+// ListLinkedExternalCalendars — календари, подключённые по ссылке (их перечитывает импорт)
+func (r *bookingRepository) ListLinkedExternalCalendars(ctx context.Context) ([]ExternalCalendar, error) {
+	var out []ExternalCalendar
+	err := r.db.WithContext(ctx).Where("url <> ''").Order("id").Find(&out).Error
+	return out, err
+}
+
+// DeleteExternalCalendar удаляет календарь вместе с его занятым временем
+func (r *bookingRepository) DeleteExternalCalendar(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", id).Delete(&ExternalBusyBlock{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&ExternalCalendar{}, id).Error
+	})
 }
 
-// UpdateDeposit обновляет сумму предоплаты
-// Block 10: Управление предоплатой
-func (r *bookingRepository) UpdateDeposit(ctx context.Context, bookingID int64, amount float64) error {
+// ReplaceExternalBusyBlocks заменяет занятое время календаря результатом импорта
+func (r *bookingRepository) ReplaceExternalBusyBlocks(ctx context.Context, calendarID int64, blocks []ExternalBusyBlock, syncedAt time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", calendarID).Delete(&ExternalBusyBlock{}).Error; err != nil {
+			return err
+		}
+		if len(blocks) > 0 {
+			if err := tx.CreateInBatches(blocks, 200).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&ExternalCalendar{}).
+			Where("id = ?", calendarID).
+			Updates(map[string]interface{}{
+				"last_synced_at": syncedAt,
+				"last_error":     "",
+				"block_count":    len(blocks),
+				"updated_at":     syncedAt,
+			}).Error
+	})
+}
+
+// SetExternalCalendarError сохраняет ошибку импорта; прежние блоки остаются
+func (r *bookingRepository) SetExternalCalendarError(ctx context.Context, id int64, message string) error {
 	return r.db.WithContext(ctx).
-		Model(&Booking{}).
-		Where("id = ?", bookingID).
-		Update("deposit_amount", amount).Error
+		Model(&ExternalCalendar{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_error": message, "updated_at": time.Now().UTC()}).Error
 }
 
 // -------------------- Manager Bookings --------------------
@@ -416,6 +1064,7 @@ type ManagerBookingRow struct {
 	ClientName         string    `json:"client_name"`
 	ClientPhone        string    `json:"client_phone"`
 	ClientEmail        string    `json:"client_email"`
+	IsGuest            bool      `json:"is_guest"` // гость без аккаунта, client_id = 0
 	StartTime          time.Time `json:"start_time"`
 	EndTime            time.Time `json:"end_time"`
 	Status             string    `json:"status"`
@@ -455,10 +1104,11 @@ func (r *bookingRepository) GetManagerBookings(
 			r.name as room_name,
 			b.studio_id,
 			s.name as studio_name,
-			b.user_id as client_id,
-			u.name as client_name,
-			u.phone as client_phone,
-			u.email as client_email,
+			COALESCE(b.user_id, 0) as client_id,
+			COALESCE(u.name, b.guest_name) as client_name,
+			COALESCE(u.phone, b.guest_phone, '') as client_phone,
+			COALESCE(u.email, b.guest_email, '') as client_email,
+			b.user_id IS NULL as is_guest,
 			b.start_time,
 			b.end_time,
 			b.status,
@@ -471,7 +1121,7 @@ func (r *bookingRepository) GetManagerBookings(
 		`).
 		Joins("JOIN rooms r ON r.id = b.room_id").
 		Joins("JOIN studios s ON s.id = b.studio_id").
-		Joins("JOIN users u ON u.id = b.user_id").
+		Joins("LEFT JOIN users u ON u.id = b.user_id").
 		Where("b.studio_id IN ?", studioIDs)
 
 	// 3) фильтры
@@ -493,7 +1143,7 @@ func (r *bookingRepository) GetManagerBookings(
 
 	// ⚠️ чтобы работало и в SQLite, и в Postgres:
 	if filters.ClientName != "" {
-		query = query.Where("LOWER(u.name) LIKE LOWER(?)", "%"+filters.ClientName+"%")
+		query = query.Where("LOWER(COALESCE(u.name, b.guest_name)) LIKE LOWER(?)", "%"+filters.ClientName+"%")
 	}
 
 	// 4) total count
@@ -536,10 +1186,11 @@ func (r *bookingRepository) GetBookingForManager(ctx context.Context, ownerID, b
 			r.name as room_name,
 			b.studio_id,
 			s.name as studio_name,
-			b.user_id as client_id,
-			u.name as client_name,
-			u.phone as client_phone,
-			u.email as client_email,
+			COALESCE(b.user_id, 0) as client_id,
+			COALESCE(u.name, b.guest_name) as client_name,
+			COALESCE(u.phone, b.guest_phone, '') as client_phone,
+			COALESCE(u.email, b.guest_email, '') as client_email,
+			b.user_id IS NULL as is_guest,
 			b.start_time,
 			b.end_time,
 			b.status,
@@ -552,7 +1203,7 @@ func (r *bookingRepository) GetBookingForManager(ctx context.Context, ownerID, b
 		`).
 		Joins("JOIN rooms r ON r.id = b.room_id").
 		Joins("JOIN studios s ON s.id = b.studio_id").
-		Joins("JOIN users u ON u.id = b.user_id").
+		Joins("LEFT JOIN users u ON u.id = b.user_id").
 		Where("b.id = ?", bookingID).
 		Where("s.owner_id = ?", ownerID).
 		First(&row).Error
@@ -562,3 +1213,627 @@ func (r *bookingRepository) GetBookingForManager(ctx context.Context, ownerID, b
 	}
 	return &row, nil
 }
+
+// -------------------- Booking Series --------------------
+
+// BookingTimeChange — новое время и цена брони при переносе
+type BookingTimeChange struct {
+	BookingID  int64
+	StartTime  time.Time
+	EndTime    time.Time
+	TotalPrice float64
+}
+
+func (r *bookingRepository) CreateSeries(ctx context.Context, series *BookingSeries) error {
+	return r.db.WithContext(ctx).Create(series).Error
+}
+
+func (r *bookingRepository) GetSeriesByID(ctx context.Context, id int64) (*BookingSeries, error) {
+	var series BookingSeries
+	if err := r.db.WithContext(ctx).First(&series, id).Error; err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *bookingRepository) UpdateSeries(ctx context.Context, series *BookingSeries) error {
+	return r.db.WithContext(ctx).Save(series).Error
+}
+
+// GetBySeriesID возвращает все занятия серии по времени начала
+func (r *bookingRepository) GetBySeriesID(ctx context.Context, seriesID int64) ([]Booking, error) {
+	var rows []bookingModel
+	if err := r.db.WithContext(ctx).
+		Where("series_id = ?", seriesID).
+		Order("start_time").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]Booking, 0, len(rows))
+	for _, m := range rows {
+		out = append(out, *toDomainBooking(m))
+	}
+	return out, nil
+}
+
+// CancelSeries отменяет все активные занятия серии, начинающиеся после from,
+// и закрывает саму серию — в одной транзакции
+func (r *bookingRepository) CancelSeries(ctx context.Context, seriesID int64, from time.Time, actor Actor, reason string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []bookingModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("series_id = ? AND start_time >= ?", seriesID, from).
+			Where("status IN ?", []string{string(BookingHeld), string(BookingPending), string(BookingConfirmed)}).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			if err := transitionTx(tx, &rows[i], BookingCancelled, actor, reason); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&BookingSeries{}).
+			Where("id = ?", seriesID).
+			Updates(map[string]interface{}{
+				"status":       string(SeriesCancelled),
+				"cancelled_at": &now,
+				"updated_at":   now,
+			}).Error
+	})
+}
+
+// RescheduleBookings переносит брони атомарно: либо все, либо ни одной.
+// Каждая бронь проверяется на пересечения под блокировкой комнаты (кроме
+// переносимых вместе с ней) и получает запись в историю.
+func (r *bookingRepository) RescheduleBookings(ctx context.Context, changes []BookingTimeChange, actor Actor, reason string) ([]Booking, error) {
+	exclude := make([]int64, 0, len(changes))
+	for _, ch := range changes {
+		exclude = append(exclude, ch.BookingID)
+	}
+	out := make([]Booking, 0, len(changes))
+	err := r.reserve(ctx, func(tx *gorm.DB) error {
+		out = out[:0]
+		for _, ch := range changes {
+			m, err := rescheduleTx(tx, ch, exclude, actor, reason)
+			if err != nil {
+				return err
+			}
+			out = append(out, *toDomainBooking(*m))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// -------------------- Slot Holds --------------------
+
+// roomBufferGap — минимальный зазор между бронями комнаты (буфер после + буфер до).
+// Пересечение с учётом буферов: start < other.end + gap AND end > other.start - gap.
+func roomBufferGap(db *gorm.DB, roomID int64) (time.Duration, error) {
+	var room catalog.Room
+	if err := db.Table("rooms").
+		Select("buffer_before_minutes", "buffer_after_minutes").
+		Where("id = ?", roomID).
+		Limit(1).
+		Scan(&room).Error; err != nil {
+		return 0, err
+	}
+	return room.BufferGap(), nil
+}
+
+// notExpiredHold отбрасывает holds, у которых истёк TTL, но sweeper ещё не успел их снять
+func notExpiredHold(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(status <> ? OR hold_expires_at > ?)", string(BookingHeld), now)
+	}
+}
+
+// ConvertHold превращает hold в обычную бронь (pending) после успешной оплаты.
+// Идемпотентно: для уже конвертированной брони ничего не меняет.
+// Если hold успели снять по TTL, возвращает ErrHoldExpired — слот мог уйти другому.
+func (r *bookingRepository) ConvertHold(ctx context.Context, bookingID int64) (*Booking, error) {
+	var out *Booking
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var m bookingModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, bookingID).Error; err != nil {
+			return err
+		}
+
+		switch BookingStatus(m.Status) {
+		case BookingHeld:
+			if err := transitionTx(tx, &m, BookingPending, SystemActor, "payment received"); err != nil {
+				return err
+			}
+		case BookingCancelled:
+			if m.HoldExpiresAt != nil {
+				return ErrHoldExpired
+			}
+		}
+		out = toDomainBooking(m)
+		return nil
+	})
+	return out, err
+}
+
+// ReleaseExpiredHolds снимает holds с истёкшим TTL и возвращает их количество
+func (r *bookingRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int64, error) {
+	var released int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []bookingModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ? AND hold_expires_at <= ?", string(BookingHeld), now).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			if err := transitionTx(tx, &rows[i], BookingCancelled, SystemActor, holdExpiredReason); err != nil {
+				return err
+			}
+			released++
+		}
+		return nil
+	})
+	return released, err
+}
+
+// -------------------- Lifecycle jobs --------------------
+
+// ListFinishedConfirmed возвращает подтверждённые брони, закончившиеся до endedBefore
+func (r *bookingRepository) ListFinishedConfirmed(ctx context.Context, endedBefore time.Time, limit int) ([]Booking, error) {
+	var rows []bookingModel
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND end_time <= ?", string(BookingConfirmed), endedBefore).
+		Order("end_time").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]Booking, 0, len(rows))
+	for _, m := range rows {
+		out = append(out, *toDomainBooking(m))
+	}
+	return out, nil
+}
+
+// ListStalePending возвращает неподтверждённые брони без оплаты и предоплаты,
+// созданные до createdBefore или с началом до startBefore. Оплаченные брони
+// ждут решения студии и по таймеру не снимаются.
+func (r *bookingRepository) ListStalePending(ctx context.Context, createdBefore, startBefore time.Time, limit int) ([]Booking, error) {
+	var rows []bookingModel
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", string(BookingPending)).
+		Where("payment_status = ? AND COALESCE(deposit_amount, 0) = 0", string(PaymentUnpaid)).
+		Where("created_at <= ? OR start_time <= ?", createdBefore, startBefore).
+		Order("created_at").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]Booking, 0, len(rows))
+	for _, m := range rows {
+		out = append(out, *toDomainBooking(m))
+	}
+	return out, nil
+}
+
+// -------------------- Reminders --------------------
+
+// ListBookingsWithoutReminders возвращает подтверждённые брони с началом в
+// (startAfter, startBefore], для которых напоминания ещё не планировались.
+// У группы напоминания получает только первая бронь.
+func (r *bookingRepository) ListBookingsWithoutReminders(ctx context.Context, startAfter, startBefore time.Time, limit int) ([]Booking, error) {
+	var rows []bookingModel
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND start_time > ? AND start_time <= ?", string(BookingConfirmed), startAfter, startBefore).
+		Where("NOT EXISTS (SELECT 1 FROM booking_reminders br WHERE br.booking_id = bookings.id)").
+		Where("user_id IS NOT NULL"). // гостю без аккаунта напоминать некуда
+		Where("(group_id IS NULL OR id = (SELECT MIN(g.id) FROM bookings g WHERE g.group_id = bookings.group_id))").
+		Order("start_time").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]Booking, 0, len(rows))
+	for _, m := range rows {
+		out = append(out, *toDomainBooking(m))
+	}
+	return out, nil
+}
+
+func (r *bookingRepository) CreateReminders(ctx context.Context, reminders []BookingReminder) error {
+	if len(reminders) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&reminders).Error
+}
+
+// ReplaceReminders заменяет неотправленные напоминания брони новыми (в одной транзакции)
+func (r *bookingRepository) ReplaceReminders(ctx context.Context, bookingID int64, reminders []BookingReminder) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("booking_id = ? AND status <> ?", bookingID, ReminderSent).
+			Delete(&BookingReminder{}).Error; err != nil {
+			return err
+		}
+		if len(reminders) == 0 {
+			return nil
+		}
+		return tx.Create(&reminders).Error
+	})
+}
+
+// CancelReminders снимает запланированные напоминания брони
+func (r *bookingRepository) CancelReminders(ctx context.Context, bookingID int64) error {
+	return r.db.WithContext(ctx).
+		Model(&BookingReminder{}).
+		Where("booking_id = ? AND status = ?", bookingID, ReminderScheduled).
+		Updates(map[string]interface{}{"status": ReminderCancelled, "updated_at": time.Now().UTC()}).Error
+}
+
+// ListDueReminders возвращает запланированные напоминания, время которых наступило
+func (r *bookingRepository) ListDueReminders(ctx context.Context, now time.Time, limit int) ([]BookingReminder, error) {
+	var out []BookingReminder
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND remind_at <= ?", ReminderScheduled, now).
+		Order("remind_at").
+		Limit(limit).
+		Find(&out).Error
+	return out, err
+}
+
+// SetReminderStatus меняет статус, только если напоминание ещё в статусе from
+func (r *bookingRepository) SetReminderStatus(ctx context.Context, id int64, from, to ReminderStatus, sentAt *time.Time) (bool, error) {
+	updates := map[string]interface{}{"status": to, "updated_at": time.Now().UTC()}
+	if sentAt != nil {
+		updates["sent_at"] = *sentAt
+	}
+	res := r.db.WithContext(ctx).
+		Model(&BookingReminder{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	return res.RowsAffected == 1, res.Error
+}
+
+// RescheduleBooking переносит бронь на новое окно в одной транзакции:
+// проверка пересечений (без самой брони), новое время и цена, запись в историю.
+// Статус, заметки, предоплата и оплата не меняются.
+func (r *bookingRepository) RescheduleBooking(ctx context.Context, change BookingTimeChange, actor Actor, reason string) (*Booking, error) {
+	var out *Booking
+	err := r.reserve(ctx, func(tx *gorm.DB) error {
+		m, err := rescheduleTx(tx, change, []int64{change.BookingID}, actor, reason)
+		if err != nil {
+			return err
+		}
+		out = toDomainBooking(*m)
+		return nil
+	})
+	return out, err
+}
+
+// rescheduleTx — перенос одной брони внутри транзакции вызывающего; exclude —
+// брони, которые не считаются пересечением (сама бронь и переносимые вместе с ней)
+func rescheduleTx(tx *gorm.DB, change BookingTimeChange, exclude []int64, actor Actor, reason string) (*bookingModel, error) {
+	var m bookingModel
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, change.BookingID).Error; err != nil {
+		return nil, err
+	}
+	status := BookingStatus(m.Status)
+	if !CanTransition(status, BookingRescheduled) {
+		return nil, ErrInvalidStatusTransition
+	}
+
+	if err := lockRoomTx(tx, m.RoomID); err != nil {
+		return nil, err
+	}
+	if err := releaseExpiredHoldsTx(tx, m.RoomID, change.StartTime, change.EndTime, time.Now()); err != nil {
+		return nil, err
+	}
+	gap, err := roomBufferGap(tx, m.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	var cnt int64
+	if err := tx.Model(&bookingModel{}).
+		Where("room_id = ? AND id NOT IN ?", m.RoomID, exclude).
+		Where("status NOT IN ?", slotReleasingStatuses).
+		Scopes(notExpiredHold(time.Now())).
+		Where("start_time < ? AND end_time > ?", change.EndTime.Add(gap), change.StartTime.Add(-gap)).
+		Count(&cnt).Error; err != nil {
+		return nil, err
+	}
+	if cnt > 0 {
+		return nil, ErrNotAvailable
+	}
+	if busy, err := externalBusyTx(tx, m.RoomID, change.StartTime.Add(-gap), change.EndTime.Add(gap)); err != nil || busy {
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrNotAvailable
+	}
+
+	var equipment []BookingEquipment
+	if err := tx.Where("booking_id = ?", m.ID).Find(&equipment).Error; err != nil {
+		return nil, err
+	}
+	if err := checkEquipmentTx(tx, equipment, change.StartTime, change.EndTime, m.ID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := tx.Model(&bookingModel{}).
+		Where("id = ?", m.ID).
+		Updates(map[string]interface{}{
+			"start_time":  change.StartTime,
+			"end_time":    change.EndTime,
+			"total_price": change.TotalPrice,
+			"updated_at":  now,
+		}).Error; err != nil {
+		return nil, err
+	}
+	m.StartTime, m.EndTime, m.TotalPrice, m.UpdatedAt = change.StartTime, change.EndTime, change.TotalPrice, now
+
+	// статус брони не меняется — перенос виден в истории как status → rescheduled → status
+	if err := historyTx(tx, m.ID, status, BookingRescheduled, actor, reason, now); err != nil {
+		return nil, err
+	}
+	if err := historyTx(tx, m.ID, BookingRescheduled, status, actor, "", now); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// -------------------- Equipment rental --------------------
+
+// checkEquipmentTx проверяет, что в окне [start, end) хватает оборудования с учётом
+// пересекающихся броней (кроме excludeBookingID). Строки equipment блокируются до
+// конца транзакции, чтобы параллельные брони не разобрали один и тот же остаток.
+func checkEquipmentTx(tx *gorm.DB, items []BookingEquipment, start, end time.Time, excludeBookingID int64) error {
+	now := time.Now()
+	for _, it := range items {
+		var stock catalog.Equipment
+		if err := tx.Table("equipment").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", it.EquipmentID).
+			First(&stock).Error; err != nil {
+			return err
+		}
+
+		var reserved int64
+		if err := tx.Table("booking_equipment").
+			Select("COALESCE(SUM(booking_equipment.quantity), 0)").
+			Joins("JOIN bookings ON bookings.id = booking_equipment.booking_id").
+			Where("booking_equipment.equipment_id = ? AND bookings.id <> ?", it.EquipmentID, excludeBookingID).
+			Where("bookings.status NOT IN ?", slotReleasingStatuses).
+			Where("(bookings.status <> ? OR bookings.hold_expires_at > ?)", string(BookingHeld), now).
+			Where("bookings.start_time < ? AND bookings.end_time > ?", end, start).
+			Scan(&reserved).Error; err != nil {
+			return err
+		}
+
+		if int(reserved)+it.Quantity > stock.Quantity {
+			return ErrEquipmentUnavailable
+		}
+	}
+	return nil
+}
+
+// GetStudioEquipmentForRoom возвращает оборудование из ids, принадлежащее студии комнаты
+func (r *bookingRepository) GetStudioEquipmentForRoom(ctx context.Context, roomID int64, ids []int64) ([]catalog.Equipment, error) {
+	var out []catalog.Equipment
+	err := r.db.WithContext(ctx).
+		Table("equipment").
+		Select("equipment.*").
+		Joins("JOIN rooms er ON er.id = equipment.room_id").
+		Joins("JOIN rooms br ON br.studio_id = er.studio_id").
+		Where("br.id = ? AND equipment.id IN ?", roomID, ids).
+		Find(&out).Error
+	return out, err
+}
+
+// GetBookingEquipment возвращает арендованное в брони оборудование
+func (r *bookingRepository) GetBookingEquipment(ctx context.Context, bookingID int64) ([]BookingEquipment, error) {
+	var out []BookingEquipment
+	err := r.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Order("id").
+		Find(&out).Error
+	return out, err
+}
+
+func (r *bookingRepository) CreateWaitlistEntry(ctx context.Context, entry *WaitlistEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *bookingRepository) GetWaitlistEntry(ctx context.Context, id int64) (*WaitlistEntry, error) {
+	var e WaitlistEntry
+	if err := r.db.WithContext(ctx).First(&e, id).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *bookingRepository) GetWaitlistEntryByToken(ctx context.Context, token string) (*WaitlistEntry, error) {
+	var e WaitlistEntry
+	if err := r.db.WithContext(ctx).Where("claim_token = ?", token).First(&e).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// FindActiveWaitlistEntry ищет незакрытую запись клиента на то же окно, nil — если нет
+func (r *bookingRepository) FindActiveWaitlistEntry(ctx context.Context, userID, roomID int64, start, end time.Time) (*WaitlistEntry, error) {
+	var e WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND room_id = ? AND start_time = ? AND end_time = ?", userID, roomID, start.UTC(), end.UTC()).
+		Where("status IN ?", []string{string(WaitlistWaiting), string(WaitlistOffered)}).
+		First(&e).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *bookingRepository) ListWaitlistByUser(ctx context.Context, userID int64) ([]WaitlistEntry, error) {
+	var out []WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("start_time DESC, id DESC").
+		Find(&out).Error
+	return out, err
+}
+
+// ListWaitlistForWindow возвращает очередь комнаты (waiting и offered),
+// пересекающуюся с [start, end), в порядке записи
+func (r *bookingRepository) ListWaitlistForWindow(ctx context.Context, roomID int64, start, end time.Time) ([]WaitlistEntry, error) {
+	var out []WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Where("room_id = ? AND start_time < ? AND end_time > ?", roomID, end.UTC(), start.UTC()).
+		Where("status IN ?", []string{string(WaitlistWaiting), string(WaitlistOffered)}).
+		Order("created_at ASC, id ASC").
+		Find(&out).Error
+	return out, err
+}
+
+// OfferWaitlistEntry выдаёт записи ссылку на бронь, если она всё ещё ждёт
+func (r *bookingRepository) OfferWaitlistEntry(ctx context.Context, id int64, token string, expiresAt time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&WaitlistEntry{}).
+		Where("id = ? AND status = ?", id, string(WaitlistWaiting)).
+		Updates(map[string]interface{}{
+			"status":           string(WaitlistOffered),
+			"claim_token":      token,
+			"offer_expires_at": expiresAt,
+			"updated_at":       time.Now(),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// SetWaitlistStatus меняет статус записи только из ожидаемого from,
+// чтобы параллельные claim/отмена/sweeper не перетирали друг друга
+func (r *bookingRepository) SetWaitlistStatus(ctx context.Context, id int64, from, to WaitlistStatus, bookingID *int64) (bool, error) {
+	updates := map[string]interface{}{
+		"status":     string(to),
+		"updated_at": time.Now(),
+	}
+	if bookingID != nil {
+		updates["booking_id"] = *bookingID
+	}
+	res := r.db.WithContext(ctx).Model(&WaitlistEntry{}).
+		Where("id = ? AND status = ?", id, string(from)).
+		Updates(updates)
+	return res.RowsAffected == 1, res.Error
+}
+
+// ExpireWaitlistOffers закрывает просроченные предложения и записи на прошедшие окна.
+// Возвращает закрытые предложения — их окна нужно передать дальше по очереди.
+func (r *bookingRepository) ExpireWaitlistOffers(ctx context.Context, now time.Time) ([]WaitlistEntry, error) {
+	var expired []WaitlistEntry
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ? AND offer_expires_at <= ?", string(WaitlistOffered), now).
+			Find(&expired).Error; err != nil {
+			return err
+		}
+		if len(expired) > 0 {
+			ids := make([]int64, 0, len(expired))
+			for _, e := range expired {
+				ids = append(ids, e.ID)
+			}
+			if err := tx.Model(&WaitlistEntry{}).Where("id IN ?", ids).
+				Updates(map[string]interface{}{"status": string(WaitlistExpired), "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&WaitlistEntry{}).
+			Where("status = ? AND start_time <= ?", string(WaitlistWaiting), now).
+			Updates(map[string]interface{}{"status": string(WaitlistExpired), "updated_at": now}).Error
+	})
+	return expired, err
+}
+
+// lockBookingGroupTx блокирует бронь, а если она входит в группу — все брони группы
+// (по id, чтобы параллельные переходы не ловили deadlock). idx — позиция bookingID.
+func lockBookingGroupTx(tx *gorm.DB, bookingID int64) ([]bookingModel, int, error) {
+	var m bookingModel
+	if err := tx.Select("id", "group_id").First(&m, bookingID).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []bookingModel
+	q := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	if m.GroupID == nil {
+		q = q.Where("id = ?", bookingID)
+	} else {
+		q = q.Where("group_id = ?", *m.GroupID)
+	}
+	if err := q.Order("id").Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	for i := range rows {
+		if rows[i].ID == bookingID {
+			return rows, i, nil
+		}
+	}
+	return nil, 0, gorm.ErrRecordNotFound
+}
+
+// syncGroupStatusTx переносит общий статус броней на саму группу
+func syncGroupStatusTx(tx *gorm.DB, groupID *int64, status BookingStatus) error {
+	if groupID == nil {
+		return nil
+	}
+	return tx.Model(&BookingGroup{}).
+		Where("id = ?", *groupID).
+		Updates(map[string]interface{}{"status": string(status), "updated_at": time.Now().UTC()}).Error
+}
+
+// CreateGroup создаёт группу и все её брони в одной транзакции:
+// если хоть одна комната занята, не создаётся ничего
+func (r *bookingRepository) CreateGroup(ctx context.Context, group *BookingGroup, bookings []*Booking) error {
+	return r.reserve(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		for _, b := range bookings {
+			b.GroupID = &group.ID
+			if err := createBookingTx(tx, b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *bookingRepository) GetGroupByID(ctx context.Context, id int64) (*BookingGroup, error) {
+	var group BookingGroup
+	if err := r.db.WithContext(ctx).First(&group, id).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GetByGroupID возвращает брони группы по id
+func (r *bookingRepository) GetByGroupID(ctx context.Context, groupID int64) ([]Booking, error) {
+	var rows []bookingModel
+	if err := r.db.WithContext(ctx).
+		Where("group_id = ?", groupID).
+		Order("id").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]Booking, 0, len(rows))
+	for _, m := range rows {
+		out = append(out, *toDomainBooking(m))
+	}
+	return out, nil
+}
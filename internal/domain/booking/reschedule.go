@@ -0,0 +1,104 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RescheduleBooking переносит бронь на новое окно той же комнаты.
+// Перенос делают клиент брони, владелец студии или администратор.
+// Цена пересчитывается по комнате; статус, предоплата и оплата сохраняются.
+func (s *Service) RescheduleBooking(ctx context.Context, bookingID int64, actor Actor, req RescheduleBookingRequest) (*Booking, error) {
+	if !req.EndTime.After(req.StartTime) || req.StartTime.Before(time.Now()) {
+		return nil, ErrValidation
+	}
+
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if actor.Role != ActorAdmin && b.UserID != actor.UserID {
+		isOwner, err := s.IsBookingStudioOwner(ctx, actor.UserID, bookingID)
+		if err != nil {
+			return nil, err
+		}
+		if !isOwner {
+			return nil, ErrForbidden
+		}
+	}
+	if !isReschedulable(b) {
+		return nil, ErrInvalidStatusTransition
+	}
+	if b.GroupID != nil {
+		// комнаты группы переносятся только вместе
+		return nil, ErrGroupedBooking
+	}
+
+	if err := s.validateWithinWorkingHours(ctx, b.RoomID, req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+
+	// аренда оборудования переезжает вместе с бронью по зафиксированной цене
+	equipment, err := s.bookings.GetBookingEquipment(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.quoteWithEquipment(ctx, b.RoomID, req.StartTime, req.EndTime, equipment)
+	if err != nil {
+		return nil, err
+	}
+	total := quote.Total
+	// внесённая предоплата не может превышать новую стоимость
+	if b.DepositAmount > total {
+		return nil, ErrValidation
+	}
+	// доли участников посчитаны от прежней стоимости
+	if toCents(total) != toCents(b.TotalPrice) {
+		participants, err := s.bookings.ListParticipants(ctx, bookingID)
+		if err != nil {
+			return nil, err
+		}
+		if len(participants) > 0 {
+			return nil, ErrValidation
+		}
+	}
+
+	oldStart := b.StartTime
+	updated, err := s.bookings.RescheduleBooking(ctx, BookingTimeChange{
+		BookingID:  bookingID,
+		StartTime:  req.StartTime.UTC(),
+		EndTime:    req.EndTime.UTC(),
+		TotalPrice: total,
+	}, actor, fmt.Sprintf("rescheduled from %s", oldStart.UTC().Format(time.RFC3339)))
+	if err != nil {
+		return nil, err
+	}
+
+	s.replanReminders(ctx, updated)
+	s.notifyBookingRescheduled(ctx, updated, actor, oldStart)
+	return updated, nil
+}
+
+// notifyBookingRescheduled уведомляет «другую сторону»: клиент перенёс — владельца, иначе — клиента
+func (s *Service) notifyBookingRescheduled(ctx context.Context, b *Booking, actor Actor, oldStart time.Time) {
+	if s.notifs == nil || b.IsGuest() {
+		return // гостя переносит сама студия, уведомлять некого
+	}
+	recipient := b.UserID
+	if actor.UserID == b.UserID {
+		ownerID, _, err := s.bookings.GetStudioOwnerForBooking(ctx, b.ID)
+		if err != nil || ownerID <= 0 {
+			return
+		}
+		recipient = ownerID
+	}
+	_ = s.notifs.NotifyBookingRescheduled(ctx, recipient, b.ID, b.StudioID, oldStart, b.StartTime)
+}
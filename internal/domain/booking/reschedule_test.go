@@ -0,0 +1,98 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRescheduleBooking_Repository(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	deposit := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(time.Hour),
+		TotalPrice: 1000, DepositAmount: 300, Status: BookingConfirmed, PaymentStatus: PaymentPaid}
+	other := &Booking{RoomID: 1, StudioID: 1, UserID: 8, StartTime: start.Add(3 * time.Hour), EndTime: start.Add(4 * time.Hour),
+		TotalPrice: 1000, Status: BookingPending, PaymentStatus: PaymentUnpaid}
+	for _, b := range []*Booking{deposit, other} {
+		if err := repo.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	actor := Actor{UserID: 7, Role: ActorClient}
+
+	// Пересечение с самой собой не считается конфликтом
+	b, err := repo.RescheduleBooking(ctx, BookingTimeChange{
+		BookingID: deposit.ID, StartTime: start.Add(30 * time.Minute), EndTime: start.Add(2 * time.Hour), TotalPrice: 1500,
+	}, actor, "moved")
+	if err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	if b.TotalPrice != 1500 || !b.StartTime.Equal(start.Add(30*time.Minute)) {
+		t.Fatalf("unexpected booking after reschedule: %+v", b)
+	}
+	if b.Status != BookingConfirmed || b.PaymentStatus != PaymentPaid || b.DepositAmount != 300 {
+		t.Fatalf("status, payment and deposit must be kept: %+v", b)
+	}
+
+	// Чужая бронь — конфликт, ничего не меняется
+	_, err = repo.RescheduleBooking(ctx, BookingTimeChange{
+		BookingID: deposit.ID, StartTime: start.Add(3 * time.Hour), EndTime: start.Add(5 * time.Hour), TotalPrice: 2000,
+	}, actor, "moved")
+	if !errors.Is(err, ErrNotAvailable) {
+		t.Fatalf("expected ErrNotAvailable, got %v", err)
+	}
+	b, _ = repo.GetByID(ctx, deposit.ID)
+	if b.TotalPrice != 1500 {
+		t.Fatalf("failed reschedule must not change the booking, got price %v", b.TotalPrice)
+	}
+
+	history, err := repo.GetStatusHistory(ctx, deposit.ID)
+	if err != nil || len(history) != 2 || history[0].Reason != "moved" ||
+		history[0].FromStatus != BookingConfirmed || history[0].ToStatus != BookingRescheduled ||
+		history[1].FromStatus != BookingRescheduled || history[1].ToStatus != BookingConfirmed {
+		t.Fatalf("expected the reschedule recorded as confirmed -> rescheduled -> confirmed, got %+v err=%v", history, err)
+	}
+}
+
+func TestRescheduleBookings_RechecksInTransaction(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	first := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(time.Hour),
+		TotalPrice: 1000, Status: BookingConfirmed, PaymentStatus: PaymentUnpaid}
+	second := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start.Add(7 * 24 * time.Hour), EndTime: start.Add(7*24*time.Hour + time.Hour),
+		TotalPrice: 1000, Status: BookingConfirmed, PaymentStatus: PaymentUnpaid}
+	taken := &Booking{RoomID: 1, StudioID: 1, UserID: 8, StartTime: start.Add(7*24*time.Hour + 2*time.Hour), EndTime: start.Add(7*24*time.Hour + 3*time.Hour),
+		TotalPrice: 1000, Status: BookingPending, PaymentStatus: PaymentUnpaid}
+	for _, b := range []*Booking{first, second, taken} {
+		if err := repo.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	actor := Actor{UserID: 7, Role: ActorClient}
+	shift := func(b *Booking, d time.Duration) BookingTimeChange {
+		return BookingTimeChange{BookingID: b.ID, StartTime: b.StartTime.Add(d), EndTime: b.EndTime.Add(d), TotalPrice: 1000}
+	}
+
+	// второе занятие попадает на чужую бронь — не переносится ни одно
+	_, err := repo.RescheduleBookings(ctx, []BookingTimeChange{shift(first, 2*time.Hour), shift(second, 2*time.Hour)}, actor, "series moved")
+	if !errors.Is(err, ErrNotAvailable) {
+		t.Fatalf("expected ErrNotAvailable, got %v", err)
+	}
+	if b, _ := repo.GetByID(ctx, first.ID); !b.StartTime.Equal(start) {
+		t.Fatalf("failed series reschedule must not move the first booking, got %s", b.StartTime)
+	}
+
+	moved, err := repo.RescheduleBookings(ctx, []BookingTimeChange{shift(first, time.Hour), shift(second, time.Hour)}, actor, "series moved")
+	if err != nil || len(moved) != 2 || !moved[0].StartTime.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected both bookings moved, got %+v err=%v", moved, err)
+	}
+	history, err := repo.GetStatusHistory(ctx, second.ID)
+	if err != nil || len(history) != 2 || history[0].ToStatus != BookingRescheduled || history[0].Reason != "series moved" {
+		t.Fatalf("expected the reschedule in the history of the moved booking, got %+v err=%v", history, err)
+	}
+}
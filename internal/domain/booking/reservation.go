@@ -0,0 +1,73 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Защита от овербукинга на уровне БД.
+//
+// PostgreSQL: на время проверки и вставки блокируется строка комнаты, так что
+// параллельные резервирования одной комнаты идут по очереди (с учётом буферов),
+// а exclusion constraint bookings_no_overlap не даёт записать пересекающиеся
+// брони даже в обход приложения.
+// SQLite: транзакции открываются как BEGIN IMMEDIATE (_txlock, см.
+// database.Connect), поэтому проверка и вставка выполняются под блокировкой
+// записи файла — параллельные резервирования любых соединений и процессов идут
+// по очереди, а busy_timeout ждёт блокировку вместо немедленной ошибки.
+
+// overbookingConstraints — ограничения, нарушение которых означает занятый слот
+var overbookingConstraints = map[string]bool{
+	"idx_no_overbooking":  true, // до миграции 000045
+	"bookings_no_overlap": true,
+}
+
+// reserve выполняет fn в транзакции, которая занимает слоты комнат
+func (r *bookingRepository) reserve(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return asOverbooking(r.db.WithContext(ctx).Transaction(fn))
+}
+
+// lockRoomTx блокирует строку комнаты до конца транзакции
+// (в SQLite блокировка строк не нужна и игнорируется)
+func lockRoomTx(tx *gorm.DB, roomID int64) error {
+	var id int64
+	return tx.Table("rooms").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", roomID).
+		Scan(&id).Error
+}
+
+// releaseExpiredHoldsTx снимает просроченные holds в окне: sweeper мог ещё не
+// успеть, а ограничение БД считает их занятыми
+func releaseExpiredHoldsTx(tx *gorm.DB, roomID int64, start, end, now time.Time) error {
+	var rows []bookingModel
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("room_id = ? AND status = ? AND hold_expires_at <= ?", roomID, string(BookingHeld), now).
+		Where("start_time < ? AND end_time > ?", end, start).
+		Find(&rows).Error; err != nil {
+		return err
+	}
+	for i := range rows {
+		if err := transitionTx(tx, &rows[i], BookingCancelled, SystemActor, holdExpiredReason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// asOverbooking переводит нарушение ограничения пересечений в ErrOverbooking
+func asOverbooking(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) &&
+		(pgErr.Code == "23505" || pgErr.Code == "23P01") &&
+		overbookingConstraints[pgErr.ConstraintName] {
+		return ErrOverbooking
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"photostudio/internal/database"
+	"photostudio/internal/domain/catalog"
+)
+
+func TestCreateBooking_ConcurrentSameSlot(t *testing.T) {
+	ctx := context.Background()
+	// файл, а не :memory: — у каждого соединения пула своя in-memory база
+	path := filepath.Join(t.TempDir(), "race.db")
+	repo := openHoldTestRepo(t, path)
+	svc, _ := scheduleTestServiceFor(t, repo)
+	// второй пул соединений к тому же файлу — как второй процесс
+	db2, err := database.Connect(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc2 := NewService(&bookingRepository{db: db2}, scheduleTestRooms{}, nil, catalog.NewStudioWorkingHoursRepository(db2), nil)
+
+	day := nextWeekday(time.Tuesday)
+	start, end := day.Add(12*time.Hour), day.Add(14*time.Hour)
+
+	const attempts = 20
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+		errs    []error
+	)
+	ready := make(chan struct{})
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			<-ready
+			// окна пересекаются, но не совпадают
+			offset := time.Duration(userID%4) * 15 * time.Minute
+			target := svc
+			if userID%2 == 0 {
+				target = svc2
+			}
+			_, err := target.CreateBooking(ctx, CreateBookingRequest{
+				RoomID: 1, StudioID: 1, UserID: userID,
+				StartTime: start.Add(offset), EndTime: end.Add(offset),
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				created++
+			} else {
+				errs = append(errs, err)
+			}
+		}(int64(i + 1))
+	}
+	close(ready)
+	wg.Wait()
+
+	if created != 1 {
+		t.Fatalf("exactly one booking must win the slot, got %d", created)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrNotAvailable) && !errors.Is(err, ErrOverbooking) {
+			t.Fatalf("losers must get a slot conflict, got %v", err)
+		}
+	}
+
+	var count int64
+	repo.db.Model(&bookingModel{}).Where("room_id = ? AND status NOT IN ?", 1, slotReleasingStatuses).Count(&count)
+	if count != 1 {
+		t.Fatalf("expected 1 active booking in the room, got %d", count)
+	}
+}
+
+func TestCreateBooking_ReplacesExpiredHold(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	end := start.Add(2 * time.Hour)
+	expired := time.Now().Add(-time.Minute).UTC()
+	held := &Booking{RoomID: 1, StudioID: 1, UserID: 1, StartTime: start, EndTime: end,
+		Status: BookingHeld, PaymentStatus: PaymentUnpaid, HoldExpiresAt: &expired}
+	m := toBookingModel(held)
+	if err := repo.db.Create(&m).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 2, StartTime: start, EndTime: end,
+		Status: BookingPending, PaymentStatus: PaymentUnpaid}
+	if err := repo.Create(ctx, b); err != nil {
+		t.Fatalf("expired hold must not block the slot: %v", err)
+	}
+
+	// hold снят в той же транзакции, чтобы ограничение БД не видело пересечения
+	var status string
+	repo.db.Model(&bookingModel{}).Select("status").Where("user_id = ?", 1).Scan(&status)
+	if status != string(BookingCancelled) {
+		t.Fatalf("expired hold status = %s, want cancelled", status)
+	}
+}
@@ -12,10 +12,13 @@ type OwnershipMiddleware interface {
 // RegisterRoutes регистрирует все маршруты для бронирований
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.POST("/bookings", h.CreateBooking)
+	rg.POST("/bookings/holds", h.CreateHold)
+	rg.POST("/bookings/quote", h.QuoteBooking)
 
 	// Availability endpoints
 	rg.GET("/rooms/:id/availability", h.GetRoomAvailability)
 	rg.GET("/rooms/:id/busy-slots", h.GetBusySlots)
+	rg.GET("/availability/search", h.SearchAvailability)
 
 	// User booking history
 	rg.GET("/users/me/bookings", h.GetMyBookings)
@@ -24,11 +27,53 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.PATCH("/bookings/:id/status", h.UpdateBookingStatus)
 	rg.PATCH("/bookings/:id/confirm", h.ConfirmBooking)
 	rg.PATCH("/bookings/:id/cancel", h.CancelBooking)
+	rg.PATCH("/bookings/:id/reschedule", h.RescheduleBooking)
 	rg.PATCH("/bookings/:id/complete", h.CompleteBooking)
 	rg.PATCH("/bookings/:id/mark-paid", h.MarkBookingPaid)
+	rg.GET("/bookings/:id/history", h.GetStatusHistory)
+
+	// Split payments between participants
+	rg.PUT("/bookings/:id/participants", h.SetParticipants)
+	rg.GET("/bookings/:id/participants", h.GetParticipants)
 
 	// Deposit management
 	rg.PATCH("/bookings/:id/deposit", h.UpdateDeposit)
+
+	// Recurring series
+	rg.POST("/booking-series", h.CreateSeries)
+	rg.GET("/booking-series/:id", h.GetSeries)
+	rg.PATCH("/booking-series/:id/cancel", h.CancelSeries)
+	rg.PATCH("/booking-series/:id/reschedule", h.RescheduleSeries)
+
+	// Multi-room groups
+	rg.POST("/booking-groups", h.CreateGroup)
+	rg.GET("/booking-groups/:id", h.GetGroup)
+	rg.PATCH("/booking-groups/:id/cancel", h.CancelGroup)
+
+	// Waitlist
+	rg.POST("/waitlist", h.JoinWaitlist)
+	rg.GET("/users/me/waitlist", h.GetMyWaitlist)
+	rg.DELETE("/waitlist/:id", h.LeaveWaitlist)
+	rg.POST("/waitlist/claim/:token", h.ClaimWaitlist)
+
+	// Calendar export
+	rg.GET("/bookings/:id/ics", h.DownloadBookingICS)
+	rg.POST("/calendar-feeds", h.CreateCalendarFeed)
+	rg.GET("/calendar-feeds", h.ListCalendarFeeds)
+	rg.DELETE("/calendar-feeds/:id", h.DeleteCalendarFeed)
+
+	// External calendars (busy time imported from iCal)
+	rg.POST("/rooms/:id/external-calendars", h.AddExternalCalendar)
+	rg.POST("/rooms/:id/external-calendars/upload", h.UploadExternalCalendar)
+	rg.GET("/rooms/:id/external-calendars", h.ListExternalCalendars)
+	rg.POST("/external-calendars/:id/sync", h.SyncExternalCalendar)
+	rg.DELETE("/external-calendars/:id", h.DeleteExternalCalendar)
+}
+
+// RegisterPublicRoutes регистрирует маршруты без авторизации:
+// календарные приложения подписываются на ленту по секретному токену
+func (h *Handler) RegisterPublicRoutes(rg *gin.RouterGroup) {
+	rg.GET("/calendar/feeds/:token", h.GetCalendarFeed)
 }
 
 // RegisterStudioRoutes регистрирует маршруты для владельцев студий
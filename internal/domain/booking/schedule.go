@@ -0,0 +1,146 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"photostudio/internal/domain/catalog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// roomDay — рабочее окно комнаты на календарную дату в часовом поясе студии
+type roomDay struct {
+	Loc    *time.Location
+	Day    time.Time // 00:00 этой даты в Loc
+	Open   time.Time
+	Close  time.Time
+	IsOpen bool
+
+	// Буферы комнаты на подготовку/уборку вокруг каждой брони
+	BufferBefore time.Duration
+	BufferAfter  time.Duration
+
+	// Закрытия комнаты, обрезанные по рабочему окну
+	Blackouts []TimeSlot
+}
+
+// bufferGap — минимальный зазор между соседними бронями
+func (rd *roomDay) bufferGap() time.Duration {
+	return rd.BufferBefore + rd.BufferAfter
+}
+
+// roomLocation возвращает часовой пояс студии, которой принадлежит комната
+func (s *Service) roomLocation(ctx context.Context, roomID int64) (*time.Location, error) {
+	tz, err := s.rooms.GetStudioTimezoneByRoomID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := catalog.LoadTimezone(tz)
+	if err != nil {
+		// битое значение в БД не должно ломать расписание — считаем зону дефолтной
+		loc, _ = catalog.LoadTimezone(catalog.DefaultTimezone)
+	}
+	return loc, nil
+}
+
+// parseLocalDate разбирает "YYYY-MM-DD" как календарную дату в зоне loc
+func parseLocalDate(dateStr string, loc *time.Location) (time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", dateStr, loc)
+	if err != nil {
+		return time.Time{}, ErrValidation
+	}
+	return day, nil
+}
+
+// localDay возвращает начало календарного дня момента t в зоне loc
+func localDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// resolveRoomDay вычисляет часы работы комнаты на дату day (полночь в loc)
+func (s *Service) resolveRoomDay(ctx context.Context, roomID int64, day time.Time, loc *time.Location) (*roomDay, error) {
+	room, err := s.rooms.GetByID(ctx, roomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	wh, err := s.GetWorkingHoursForDate(ctx, room.StudioID, day)
+	if err != nil {
+		return nil, err
+	}
+
+	open, close, ok, err := openCloseOnDate(wh, day, loc)
+	if err != nil {
+		return nil, err
+	}
+	rd := &roomDay{
+		Loc: loc, Day: day, Open: open, Close: close, IsOpen: ok,
+		BufferBefore: time.Duration(room.BufferBeforeMinutes) * time.Minute,
+		BufferAfter:  time.Duration(room.BufferAfterMinutes) * time.Minute,
+	}
+	if ok && s.studioWorkingHoursRepo != nil {
+		blackouts, err := s.studioWorkingHoursRepo.ListRoomBlackouts(roomID, open, close)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range blackouts {
+			start, end := b.StartTime.In(loc), b.EndTime.In(loc)
+			if start.Before(open) {
+				start = open
+			}
+			if end.After(close) {
+				end = close
+			}
+			rd.Blackouts = append(rd.Blackouts, TimeSlot{Start: start, End: end})
+		}
+	}
+	return rd, nil
+}
+
+// openCloseOnDate переводит недельный шаблон "09:00"-"21:00" в моменты времени на дату day в зоне loc
+func openCloseOnDate(wh *catalog.WorkingHours, day time.Time, loc *time.Location) (time.Time, time.Time, bool, error) {
+	if wh == nil || wh.IsClosed || wh.OpenTime == "" || wh.CloseTime == "" {
+		return time.Time{}, time.Time{}, false, nil
+	}
+
+	open, err := catalog.ClockOnDate(day, wh.OpenTime, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	close, err := catalog.ClockOnDate(day, wh.CloseTime, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	if !close.After(open) {
+		return time.Time{}, time.Time{}, false, nil
+	}
+	return open, close, true, nil
+}
+
+// validateWithinWorkingHours проверяет, что [start, end) целиком попадает в часы работы студии
+// в её местный календарный день и не задевает закрытия комнаты
+func (s *Service) validateWithinWorkingHours(ctx context.Context, roomID int64, start, end time.Time) error {
+	loc, err := s.roomLocation(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	rd, err := s.resolveRoomDay(ctx, roomID, localDay(start, loc), loc)
+	if err != nil {
+		return err
+	}
+	if !rd.IsOpen || start.Before(rd.Open) || end.After(rd.Close) {
+		return ErrOutsideWorkingHours
+	}
+	for _, b := range rd.Blackouts {
+		if start.Before(b.End) && end.After(b.Start) {
+			return ErrClosedPeriod
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"photostudio/internal/domain/catalog"
+)
+
+// scheduleTestRooms — комнаты одной студии в UTC без буферов
+type scheduleTestRooms struct{}
+
+func (scheduleTestRooms) GetPriceByID(ctx context.Context, id int64) (float64, error) {
+	return 1000, nil
+}
+
+func (scheduleTestRooms) GetStudioTimezoneByRoomID(ctx context.Context, roomID int64) (string, error) {
+	return "UTC", nil
+}
+
+func (scheduleTestRooms) GetStudioCancellationPolicyByRoomID(ctx context.Context, roomID int64) (*catalog.CancellationPolicy, error) {
+	return nil, nil
+}
+
+func (scheduleTestRooms) GetStudioReminderScheduleByRoomID(ctx context.Context, roomID int64) (*catalog.ReminderSchedule, error) {
+	return nil, nil
+}
+
+func (scheduleTestRooms) GetByID(ctx context.Context, roomID int64) (*catalog.Room, error) {
+	return &catalog.Room{ID: roomID, StudioID: 1, IsActive: true, PricePerHourMin: 1000}, nil
+}
+
+func (scheduleTestRooms) GetByStudioID(ctx context.Context, studioID int64) ([]catalog.Room, error) {
+	return []catalog.Room{{ID: 1, StudioID: studioID, IsActive: true}, {ID: 2, StudioID: studioID, IsActive: true}, {ID: 3, StudioID: studioID, IsActive: true}}, nil
+}
+
+// nextWeekday — ближайшая будущая дата с днём недели wd (не раньше чем через неделю)
+func nextWeekday(wd time.Weekday) time.Time {
+	d := time.Now().UTC().AddDate(0, 0, 7)
+	d = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	for d.Weekday() != wd {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+func newScheduleTestService(t *testing.T) (*Service, catalog.StudioWorkingHoursRepository) {
+	t.Helper()
+	return scheduleTestServiceFor(t, newHoldTestRepo(t))
+}
+
+func scheduleTestServiceFor(t *testing.T, repo *bookingRepository) (*Service, catalog.StudioWorkingHoursRepository) {
+	t.Helper()
+	if err := repo.db.Exec(`CREATE TABLE studio_working_hours (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, studio_id INTEGER, hours TEXT
+	)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.db.AutoMigrate(&catalog.StudioDateOverride{}, &catalog.RoomBlackout{}); err != nil {
+		t.Fatal(err)
+	}
+	hours := catalog.NewStudioWorkingHoursRepository(repo.db)
+	return NewService(repo, scheduleTestRooms{}, nil, hours, nil), hours
+}
+
+func TestScheduleExceptions_OverrideWeeklyHours(t *testing.T) {
+	ctx := context.Background()
+	svc, hours := newScheduleTestService(t)
+
+	wed := nextWeekday(time.Wednesday)
+	thu := wed.AddDate(0, 0, 1)
+	if err := hours.UpsertDateOverride(&catalog.StudioDateOverride{StudioID: 1, Date: wed.Format("2006-01-02"), IsClosed: true, Reason: "Наурыз"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := hours.UpsertDateOverride(&catalog.StudioDateOverride{StudioID: 1, Date: thu.Format("2006-01-02"), OpenTime: "14:00", CloseTime: "23:00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	wh, err := svc.GetWorkingHoursForDate(ctx, 1, wed)
+	if err != nil || !wh.IsClosed {
+		t.Fatalf("closed override ignored: %+v err=%v", wh, err)
+	}
+	free, err := svc.GetRoomAvailability(ctx, 1, wed.Format("2006-01-02"))
+	if err != nil || len(free) != 0 {
+		t.Fatalf("closed day must have no free slots, got %+v err=%v", free, err)
+	}
+	err = svc.validateWithinWorkingHours(ctx, 1, wed.Add(12*time.Hour), wed.Add(13*time.Hour))
+	if !errors.Is(err, ErrOutsideWorkingHours) {
+		t.Fatalf("booking on a closed day: expected ErrOutsideWorkingHours, got %v", err)
+	}
+
+	avail, err := svc.GetAvailability(ctx, 1, thu.Format("2006-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if avail.WorkingHours.Open != "14:00" || avail.WorkingHours.Close != "23:00" {
+		t.Fatalf("special hours not applied: %+v", avail.WorkingHours)
+	}
+	if err := svc.validateWithinWorkingHours(ctx, 1, thu.Add(21*time.Hour), thu.Add(23*time.Hour)); err != nil {
+		t.Fatalf("evening slot within special hours rejected: %v", err)
+	}
+
+	// повторная запись на ту же дату заменяет исключение
+	if err := hours.UpsertDateOverride(&catalog.StudioDateOverride{StudioID: 1, Date: wed.Format("2006-01-02"), OpenTime: "12:00", CloseTime: "16:00"}); err != nil {
+		t.Fatal(err)
+	}
+	wh, err = svc.GetWorkingHoursForDate(ctx, 1, wed)
+	if err != nil || wh.IsClosed || wh.OpenTime != "12:00" {
+		t.Fatalf("override was not replaced: %+v err=%v", wh, err)
+	}
+}
+
+func TestWorkingHours_UnconfiguredStudioKeepsDefault(t *testing.T) {
+	ctx := context.Background()
+	svc, hours := newScheduleTestService(t)
+	sat := nextWeekday(time.Saturday)
+
+	// студия без сохранённого шаблона работает 09:00-21:00 каждый день
+	avail, err := svc.GetAvailability(ctx, 1, sat.Format("2006-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if avail.WorkingHours.Open != "09:00" || avail.WorkingHours.Close != "21:00" {
+		t.Fatalf("unconfigured studio: expected 09:00-21:00 on Saturday, got %+v", avail.WorkingHours)
+	}
+	if err := svc.validateWithinWorkingHours(ctx, 1, sat.Add(9*time.Hour), sat.Add(10*time.Hour)); err != nil {
+		t.Fatalf("unconfigured studio: Saturday 09:00 slot rejected: %v", err)
+	}
+
+	// сохранённый шаблон владельца применяется как есть
+	if err := hours.CreateOrUpdate(&catalog.StudioWorkingHours{StudioID: 1, Hours: catalog.DefaultWorkingHours()}); err != nil {
+		t.Fatal(err)
+	}
+	err = svc.validateWithinWorkingHours(ctx, 1, sat.Add(9*time.Hour), sat.Add(10*time.Hour))
+	if !errors.Is(err, ErrOutsideWorkingHours) {
+		t.Fatalf("configured closed Saturday: expected ErrOutsideWorkingHours, got %v", err)
+	}
+}
+
+func TestScheduleExceptions_RoomBlackout(t *testing.T) {
+	ctx := context.Background()
+	svc, hours := newScheduleTestService(t)
+
+	day := nextWeekday(time.Tuesday)
+	if err := hours.CreateRoomBlackout(&catalog.RoomBlackout{RoomID: 1, StartTime: day.Add(12 * time.Hour), EndTime: day.Add(15 * time.Hour), Reason: "ремонт"}); err != nil {
+		t.Fatal(err)
+	}
+
+	free, err := svc.GetRoomAvailability(ctx, 1, day.Format("2006-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(free) != 2 || !free[0].End.Equal(day.Add(12*time.Hour)) || !free[1].Start.Equal(day.Add(15*time.Hour)) {
+		t.Fatalf("blackout not subtracted from free slots: %+v", free)
+	}
+
+	avail, err := svc.GetAvailability(ctx, 1, day.Format("2006-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(avail.BookedSlots) != 1 || avail.BookedSlots[0].Status != SlotBlackout || avail.BookedSlots[0].Start != "12:00" {
+		t.Fatalf("expected one blackout slot, got %+v", avail.BookedSlots)
+	}
+
+	err = svc.validateWithinWorkingHours(ctx, 1, day.Add(14*time.Hour), day.Add(16*time.Hour))
+	if !errors.Is(err, ErrClosedPeriod) {
+		t.Fatalf("expected ErrClosedPeriod, got %v", err)
+	}
+	if err := svc.validateWithinWorkingHours(ctx, 1, day.Add(15*time.Hour), day.Add(16*time.Hour)); err != nil {
+		t.Fatalf("slot right after blackout rejected: %v", err)
+	}
+
+	// закрытие другой комнаты не мешает
+	if err := svc.validateWithinWorkingHours(ctx, 2, day.Add(12*time.Hour), day.Add(13*time.Hour)); err != nil {
+		t.Fatalf("blackout leaked to another room: %v", err)
+	}
+}
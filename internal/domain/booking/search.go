@@ -0,0 +1,259 @@
+package booking
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"photostudio/internal/domain/catalog"
+)
+
+const (
+	searchMaxDays    = 14
+	searchMaxRooms   = 200
+	searchMaxResults = 200
+	searchDefaultMax = 50
+
+	// searchSlotStep — окна сегодняшнего дня начинаются не раньше ближайшего получаса
+	searchSlotStep = 30 * time.Minute
+)
+
+// AvailabilitySearchRequest — поиск свободного времени по всем подходящим комнатам.
+// Даты — календарные дни в часовом поясе каждой студии, включительно.
+type AvailabilitySearchRequest struct {
+	City            string
+	RoomType        string
+	DurationMinutes int
+	DateFrom        string  // YYYY-MM-DD
+	DateTo          string  // YYYY-MM-DD
+	MinPrice        float64 // базовая цена в час, как в каталоге
+	MaxPrice        float64
+	Limit           int
+}
+
+// SearchStudio — студия, чьи комнаты участвуют в поиске
+type SearchStudio struct {
+	ID       int64
+	Name     string
+	City     string
+	Timezone string
+}
+
+// AvailableWindow — непрерывное свободное окно комнаты, в которое помещается
+// запрошенная длительность. Price — цена брони на эту длительность с начала окна.
+type AvailableWindow struct {
+	RoomID       int64     `json:"room_id"`
+	RoomName     string    `json:"room_name"`
+	RoomType     string    `json:"room_type"`
+	StudioID     int64     `json:"studio_id"`
+	StudioName   string    `json:"studio_name"`
+	City         string    `json:"city"`
+	Timezone     string    `json:"timezone"`
+	Date         string    `json:"date"`  // местная дата студии
+	Start        string    `json:"start"` // "10:00" по времени студии
+	End          string    `json:"end"`
+	StartAt      time.Time `json:"start_at"` // RFC3339 со смещением зоны студии
+	EndAt        time.Time `json:"end_at"`
+	PricePerHour float64   `json:"price_per_hour"`
+	Price        float64   `json:"price"`
+}
+
+// AvailabilitySearchResponse — найденные окна, по времени начала
+type AvailabilitySearchResponse struct {
+	DurationMinutes int               `json:"duration_minutes"`
+	RoomsChecked    int               `json:"rooms_checked"`
+	Windows         []AvailableWindow `json:"windows"`
+	Truncated       bool              `json:"truncated"`
+}
+
+// SearchAvailability находит свободные окна нужной длительности во всех
+// подходящих комнатах за диапазон дат. Комнаты, часы работы, исключения,
+// закрытия и занятость загружаются пакетно — по запросу на вид данных,
+// а не по запросу на комнату и день.
+func (s *Service) SearchAvailability(ctx context.Context, req AvailabilitySearchRequest) (*AvailabilitySearchResponse, error) {
+	return s.searchAvailability(ctx, req, time.Now())
+}
+
+func (s *Service) searchAvailability(ctx context.Context, req AvailabilitySearchRequest, now time.Time) (*AvailabilitySearchResponse, error) {
+	if req.DurationMinutes <= 0 || req.DurationMinutes > 24*60 {
+		return nil, ErrValidation
+	}
+	if req.RoomType != "" && !catalog.IsValidRoomType(req.RoomType) {
+		return nil, ErrValidation
+	}
+	if req.MinPrice < 0 || req.MaxPrice < 0 || (req.MaxPrice > 0 && req.MaxPrice < req.MinPrice) {
+		return nil, ErrValidation
+	}
+	// даты разбираем без зоны: у каждой студии свои местные сутки
+	fromDay, err := time.Parse("2006-01-02", req.DateFrom)
+	if err != nil {
+		return nil, ErrValidation
+	}
+	toDay, err := time.Parse("2006-01-02", req.DateTo)
+	if err != nil || toDay.Before(fromDay) {
+		return nil, ErrValidation
+	}
+	days := int(toDay.Sub(fromDay).Hours()/24) + 1
+	if days > searchMaxDays {
+		return nil, ErrValidation
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = searchDefaultMax
+	}
+	if limit > searchMaxResults {
+		limit = searchMaxResults
+	}
+	duration := time.Duration(req.DurationMinutes) * time.Minute
+
+	resp := &AvailabilitySearchResponse{DurationMinutes: req.DurationMinutes, Windows: []AvailableWindow{}}
+
+	rooms, studios, err := s.bookings.ListSearchRooms(ctx, strings.TrimSpace(req.City), req.RoomType, req.MinPrice, req.MaxPrice, searchMaxRooms)
+	if err != nil {
+		return nil, err
+	}
+	if len(rooms) == 0 {
+		return resp, nil
+	}
+	resp.RoomsChecked = len(rooms)
+
+	studioByID := make(map[int64]SearchStudio, len(studios))
+	locs := make(map[int64]*time.Location, len(studios))
+	studioIDs := make([]int64, 0, len(studios))
+	for _, st := range studios {
+		studioByID[st.ID] = st
+		loc, err := catalog.LoadTimezone(st.Timezone)
+		if err != nil {
+			loc, _ = catalog.LoadTimezone(catalog.DefaultTimezone)
+		}
+		locs[st.ID] = loc
+		studioIDs = append(studioIDs, st.ID)
+	}
+	roomIDs := make([]int64, 0, len(rooms))
+	for _, r := range rooms {
+		roomIDs = append(roomIDs, r.ID)
+	}
+
+	// общее окно в UTC с запасом на любые часовые пояса
+	rangeFrom := fromDay.Add(-24 * time.Hour)
+	rangeTo := toDay.Add(48 * time.Hour)
+	dateFrom, dateTo := fromDay.AddDate(0, 0, -1).Format("2006-01-02"), toDay.AddDate(0, 0, 1).Format("2006-01-02")
+
+	weekly := map[int64][]catalog.WorkingHours{}
+	overrides := map[int64]map[string]*catalog.StudioDateOverride{}
+	blackouts := map[int64][]catalog.RoomBlackout{}
+	if s.studioWorkingHoursRepo != nil {
+		if weekly, err = s.studioWorkingHoursRepo.ListHoursForStudios(studioIDs); err != nil {
+			return nil, err
+		}
+		list, err := s.studioWorkingHoursRepo.ListDateOverridesForStudios(studioIDs, dateFrom, dateTo)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			o := &list[i]
+			if overrides[o.StudioID] == nil {
+				overrides[o.StudioID] = map[string]*catalog.StudioDateOverride{}
+			}
+			overrides[o.StudioID][o.Date] = o
+		}
+		bl, err := s.studioWorkingHoursRepo.ListBlackoutsForRooms(roomIDs, rangeFrom, rangeTo)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range bl {
+			blackouts[b.RoomID] = append(blackouts[b.RoomID], b)
+		}
+	}
+
+	busyByRoom, err := s.bookings.GetBusySlotsForRooms(ctx, roomIDs, rangeFrom, rangeTo)
+	if err != nil {
+		return nil, err
+	}
+
+	earliest := ceilTime(now, searchSlotStep)
+	for i := range rooms {
+		room := &rooms[i]
+		st := studioByID[room.StudioID]
+		loc := locs[room.StudioID]
+		// свободное окно должно вмещать уборку после соседней брони и подготовку к своей
+		gap := time.Duration(room.BufferBeforeMinutes+room.BufferAfterMinutes) * time.Minute
+		busy := make([]TimeSlot, 0, len(busyByRoom[room.ID])+len(blackouts[room.ID]))
+		for _, b := range busyByRoom[room.ID] {
+			busy = append(busy, TimeSlot{Start: b.Start.Add(-gap).In(loc), End: b.End.Add(gap).In(loc)})
+		}
+		for _, b := range blackouts[room.ID] {
+			busy = append(busy, TimeSlot{Start: b.StartTime.In(loc), End: b.EndTime.In(loc)})
+		}
+
+		for d := 0; d < days; d++ {
+			date := fromDay.AddDate(0, 0, d)
+			day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+			dateStr := day.Format("2006-01-02")
+
+			wh := weeklyHoursOn(weekly[room.StudioID], int(day.Weekday()))
+			if o := overrides[room.StudioID][dateStr]; o != nil {
+				wh = o.WorkingHours(int(day.Weekday()))
+			}
+			open, close, ok, err := openCloseOnDate(wh, day, loc)
+			if err != nil || !ok {
+				continue
+			}
+			if open.Before(earliest) {
+				open = earliest.In(loc)
+			}
+			if close.Sub(open) < duration {
+				continue
+			}
+
+			for _, free := range subtractBusy(open, close, busy) {
+				if free.End.Sub(free.Start) < duration {
+					continue
+				}
+				quote, err := catalog.QuoteRoomPrice(room, free.Start, free.Start.Add(duration), loc)
+				if err != nil {
+					break // длительность меньше минимальной для комнаты
+				}
+				resp.Windows = append(resp.Windows, AvailableWindow{
+					RoomID:       room.ID,
+					RoomName:     room.Name,
+					RoomType:     string(room.RoomType),
+					StudioID:     st.ID,
+					StudioName:   st.Name,
+					City:         st.City,
+					Timezone:     loc.String(),
+					Date:         dateStr,
+					Start:        free.Start.In(loc).Format("15:04"),
+					End:          free.End.In(loc).Format("15:04"),
+					StartAt:      free.Start.In(loc),
+					EndAt:        free.End.In(loc),
+					PricePerHour: room.PricePerHourMin,
+					Price:        quote.Total,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(resp.Windows, func(i, j int) bool {
+		a, b := resp.Windows[i], resp.Windows[j]
+		if !a.StartAt.Equal(b.StartAt) {
+			return a.StartAt.Before(b.StartAt)
+		}
+		return a.Price < b.Price
+	})
+	if len(resp.Windows) > limit {
+		resp.Windows = resp.Windows[:limit]
+		resp.Truncated = true
+	}
+	return resp, nil
+}
+
+// ceilTime округляет t вверх до кратного step
+func ceilTime(t time.Time, step time.Duration) time.Time {
+	r := t.Truncate(step)
+	if r.Before(t) {
+		r = r.Add(step)
+	}
+	return r
+}
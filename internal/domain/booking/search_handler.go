@@ -0,0 +1,73 @@
+package booking
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"photostudio/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchAvailability ищет свободные окна по всем подходящим комнатам
+// @Summary		Поиск свободного времени по комнатам
+// @Description	Находит непрерывные свободные окна, в которые помещается duration_minutes, во всех активных комнатах, подходящих под фильтры, за период date_from..date_to (до 14 дней, даты — местные для каждой студии). Учитываются часы работы и их исключения, закрытия комнат, брони с буферами и внешние календари. price — цена брони на запрошенную длительность с начала окна по правилам комнаты; min_price/max_price фильтруют базовую цену в час, как в каталоге. Окна отсортированы по времени начала.
+// @Tags		Бронирования
+// @Security	BearerAuth
+// @Param		city query string false "Город" example("Алматы")
+// @Param		room_type query string false "Тип комнаты" Enums(Fashion, Portrait, Creative, Commercial)
+// @Param		duration_minutes query integer true "Длительность брони в минутах" example(180)
+// @Param		date_from query string true "Первая дата (YYYY-MM-DD)"
+// @Param		date_to query string true "Последняя дата (YYYY-MM-DD), включительно"
+// @Param		min_price query number false "Минимальная цена в час"
+// @Param		max_price query number false "Максимальная цена в час"
+// @Param		limit query integer false "Сколько окон вернуть (по умолчанию 50, максимум 200)"
+// @Success		200 {object} AvailabilitySearchResponse
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure		500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router		/availability/search [get]
+func (h *Handler) SearchAvailability(c *gin.Context) {
+	req := AvailabilitySearchRequest{
+		City:     c.Query("city"),
+		RoomType: c.Query("room_type"),
+		DateFrom: c.Query("date_from"),
+		DateTo:   c.Query("date_to"),
+	}
+	var err error
+	if req.DurationMinutes, err = strconv.Atoi(c.Query("duration_minutes")); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "duration_minutes is required")
+		return
+	}
+	if v := c.Query("min_price"); v != "" {
+		if req.MinPrice, err = strconv.ParseFloat(v, 64); err != nil {
+			response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid min_price")
+			return
+		}
+	}
+	if v := c.Query("max_price"); v != "" {
+		if req.MaxPrice, err = strconv.ParseFloat(v, 64); err != nil {
+			response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid max_price")
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if req.Limit, err = strconv.Atoi(v); err != nil {
+			response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid limit")
+			return
+		}
+	}
+
+	resp, err := h.service.SearchAvailability(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR",
+				"duration_minutes (1..1440), date_from <= date_to within 14 days, a valid room_type and min_price <= max_price are required")
+			return
+		}
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to search availability")
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
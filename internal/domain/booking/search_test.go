@@ -0,0 +1,124 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"photostudio/internal/domain/catalog"
+)
+
+func TestSearchAvailability(t *testing.T) {
+	ctx := context.Background()
+	svc, hours := newScheduleTestService(t)
+	db := svc.bookings.DB()
+	for _, q := range []string{
+		`CREATE TABLE studios (id INTEGER PRIMARY KEY, owner_id INTEGER, name TEXT, city TEXT, timezone TEXT, deleted_at DATETIME)`,
+		`INSERT INTO studios (id, name, city, timezone, deleted_at) VALUES
+			(1, 'Свет', 'Almaty', 'Asia/Almaty', NULL),
+			(2, 'Север', 'Astana', 'Asia/Almaty', NULL),
+			(3, 'Закрыта', 'Almaty', 'Asia/Almaty', '2020-01-01')`,
+		`ALTER TABLE rooms ADD COLUMN name TEXT`,
+		`ALTER TABLE rooms ADD COLUMN room_type TEXT`,
+		`ALTER TABLE rooms ADD COLUMN price_per_hour_min REAL`,
+		`ALTER TABLE rooms ADD COLUMN is_active BOOLEAN`,
+		`ALTER TABLE rooms ADD COLUMN pricing_rules TEXT`,
+		`INSERT INTO rooms (id, studio_id, name, room_type, price_per_hour_min, is_active) VALUES
+			(1, 1, 'Белый зал', 'Portrait', 1000, 1),
+			(2, 1, 'Лофт', 'Fashion', 1000, 1),
+			(3, 1, 'Архив', 'Portrait', 1000, 0),
+			(4, 2, 'Север', 'Portrait', 1000, 1),
+			(5, 3, 'Старый', 'Portrait', 1000, 1),
+			(6, 1, 'Премиум', 'Portrait', 5000, 1)`,
+	} {
+		if err := db.Exec(q).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	almaty, err := catalog.LoadTimezone("Asia/Almaty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fri := nextWeekday(time.Friday)
+	friLocal := time.Date(fri.Year(), fri.Month(), fri.Day(), 0, 0, 0, 0, almaty)
+	satLocal := friLocal.AddDate(0, 0, 1)
+	sun := fri.AddDate(0, 0, 2)
+
+	// без шаблона студия открыта 09:00-21:00 ежедневно, в субботу студия открыта 12:00-18:00
+	if err := hours.UpsertDateOverride(&catalog.StudioDateOverride{StudioID: 1, Date: satLocal.Format("2006-01-02"), OpenTime: "12:00", CloseTime: "18:00"}); err != nil {
+		t.Fatal(err)
+	}
+	// бронь в пятницу 12:00-16:00 и внешний блок в субботу 12:00-13:00
+	start := friLocal.Add(12 * time.Hour)
+	booked := bookingModel{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(4 * time.Hour), Status: string(BookingConfirmed), PaymentStatus: string(PaymentUnpaid)}
+	if err := db.Create(&booked).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&ExternalBusyBlock{CalendarID: 1, RoomID: 1, StartTime: satLocal.Add(12 * time.Hour), EndTime: satLocal.Add(13 * time.Hour)}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	req := AvailabilitySearchRequest{
+		City:            "almaty",
+		RoomType:        "Portrait",
+		DurationMinutes: 180,
+		DateFrom:        fri.Format("2006-01-02"),
+		DateTo:          sun.Format("2006-01-02"),
+		MaxPrice:        2000,
+	}
+	resp, err := svc.searchAvailability(ctx, req, fri.AddDate(0, 0, -2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// неактивная, дорогая, в другом городе и в удалённой студии комнаты не ищутся
+	if resp.RoomsChecked != 1 {
+		t.Fatalf("expected only room 1 to be checked, got %d", resp.RoomsChecked)
+	}
+
+	want := []struct{ date, start, end string }{
+		{friLocal.Format("2006-01-02"), "09:00", "12:00"},
+		{friLocal.Format("2006-01-02"), "16:00", "21:00"},
+		{satLocal.Format("2006-01-02"), "13:00", "18:00"},
+		{sun.Format("2006-01-02"), "09:00", "21:00"},
+	}
+	if len(resp.Windows) != len(want) {
+		t.Fatalf("expected %d windows, got %+v", len(want), resp.Windows)
+	}
+	for i, w := range want {
+		got := resp.Windows[i]
+		if got.RoomID != 1 || got.Date != w.date || got.Start != w.start || got.End != w.end {
+			t.Errorf("window %d = %s %s-%s (room %d), want %s %s-%s", i, got.Date, got.Start, got.End, got.RoomID, w.date, w.start, w.end)
+		}
+		if got.Price != 3000 || got.Timezone != "Asia/Almaty" || got.StudioName != "Свет" {
+			t.Errorf("window %d: unexpected details %+v", i, got)
+		}
+	}
+
+	req.Limit = 1
+	if resp, err = svc.searchAvailability(ctx, req, fri.AddDate(0, 0, -2)); err != nil || len(resp.Windows) != 1 || !resp.Truncated {
+		t.Fatalf("limit must truncate the result: %+v, %v", resp, err)
+	}
+}
+
+func TestSearchAvailability_Validation(t *testing.T) {
+	svc, _ := newScheduleTestService(t)
+	day := nextWeekday(time.Monday)
+	base := AvailabilitySearchRequest{DurationMinutes: 60, DateFrom: day.Format("2006-01-02"), DateTo: day.Format("2006-01-02")}
+
+	cases := map[string]func(r *AvailabilitySearchRequest){
+		"no duration":    func(r *AvailabilitySearchRequest) { r.DurationMinutes = 0 },
+		"bad room type":  func(r *AvailabilitySearchRequest) { r.RoomType = "Kitchen" },
+		"reversed dates": func(r *AvailabilitySearchRequest) { r.DateTo = day.AddDate(0, 0, -1).Format("2006-01-02") },
+		"too long range": func(r *AvailabilitySearchRequest) { r.DateTo = day.AddDate(0, 0, 14).Format("2006-01-02") },
+		"bad prices":     func(r *AvailabilitySearchRequest) { r.MinPrice, r.MaxPrice = 5000, 1000 },
+	}
+	for name, mutate := range cases {
+		req := base
+		mutate(&req)
+		if _, err := svc.SearchAvailability(context.Background(), req); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: expected ErrValidation, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"photostudio/internal/domain/catalog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SeriesFrequency string
+
+const (
+	SeriesWeekly   SeriesFrequency = "weekly"
+	SeriesBiweekly SeriesFrequency = "biweekly"
+	SeriesMonthly  SeriesFrequency = "monthly"
+)
+
+type SeriesStatus string
+
+const (
+	SeriesActive    SeriesStatus = "active"
+	SeriesCancelled SeriesStatus = "cancelled"
+)
+
+// maxSeriesOccurrences — верхняя граница занятий в одной серии (год еженедельно)
+const maxSeriesOccurrences = 52
+
+// BookingSeries — повторяющаяся бронь (аналог RRULE: FREQ + COUNT/UNTIL).
+// StartTime/EndTime — первое занятие; остальные раскладываются по
+// настенному времени студии, поэтому переход на летнее время их не сдвигает.
+type BookingSeries struct {
+	ID          int64           `json:"id"`
+	UserID      int64           `json:"user_id"`
+	RoomID      int64           `json:"room_id"`
+	StudioID    int64           `json:"studio_id"`
+	Frequency   SeriesFrequency `json:"frequency" gorm:"type:varchar(20)"`
+	StartTime   time.Time       `json:"start_time"`
+	EndTime     time.Time       `json:"end_time"`
+	Count       int             `json:"count,omitempty"`
+	Until       *time.Time      `json:"until,omitempty"`
+	Timezone    string          `json:"timezone" gorm:"type:varchar(64)"`
+	Status      SeriesStatus    `json:"status" gorm:"type:varchar(20);default:'active'"`
+	Notes       string          `json:"notes,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	CancelledAt *time.Time      `json:"cancelled_at,omitempty"`
+}
+
+func (BookingSeries) TableName() string { return "booking_series" }
+
+// Статусы занятия в ответе по серии
+const (
+	OccurrenceAvailable = "available" // свободно (предпросмотр/проверка переноса)
+	OccurrenceBooked    = "booked"
+	OccurrenceConflict  = "conflict"
+)
+
+// expandOccurrences раскладывает серию на слоты в зоне loc.
+// Для monthly даты, которых нет в месяце (31-е в апреле), пропускаются.
+func expandOccurrences(start, end time.Time, loc *time.Location, freq SeriesFrequency, count int, until *time.Time) ([]TimeSlot, error) {
+	ls, le := start.In(loc), end.In(loc)
+
+	var step func(t time.Time, i int) time.Time
+	switch freq {
+	case SeriesWeekly:
+		step = func(t time.Time, i int) time.Time { return t.AddDate(0, 0, 7*i) }
+	case SeriesBiweekly:
+		step = func(t time.Time, i int) time.Time { return t.AddDate(0, 0, 14*i) }
+	case SeriesMonthly:
+		step = func(t time.Time, i int) time.Time { return t.AddDate(0, i, 0) }
+	default:
+		return nil, ErrValidation
+	}
+
+	out := make([]TimeSlot, 0)
+	for i := 0; len(out) < maxSeriesOccurrences; i++ {
+		if count > 0 && len(out) >= count {
+			break
+		}
+		os, oe := step(ls, i), step(le, i)
+		if until != nil && os.After(*until) {
+			break
+		}
+		if freq == SeriesMonthly && os.Day() != ls.Day() {
+			// 31-е число "перетекло" в следующий месяц — такого занятия нет
+			continue
+		}
+		out = append(out, TimeSlot{Start: os, End: oe})
+	}
+	return out, nil
+}
+
+// occurrenceConflict возвращает причину, по которой слот нельзя занять,
+// или пустую строку. exclude — брони, которые переносятся и не считаются занятостью.
+func (s *Service) occurrenceConflict(ctx context.Context, roomID int64, slot TimeSlot, exclude []int64) (string, error) {
+	if slot.Start.Before(time.Now()) {
+		return "in_past", nil
+	}
+	if err := s.validateWithinWorkingHours(ctx, roomID, slot.Start, slot.End); err != nil {
+		if errors.Is(err, ErrOutsideWorkingHours) {
+			return "outside_working_hours", nil
+		}
+		if errors.Is(err, ErrClosedPeriod) {
+			return "closed_period", nil
+		}
+		return "", err
+	}
+	ok, err := s.bookings.CheckAvailabilityExcluding(ctx, roomID, slot.Start, slot.End, exclude)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "not_available", nil
+	}
+	return "", nil
+}
+
+// CreateSeries создаёт серию броней. Каждое занятие проверяется через
+// CheckAvailability; при конфликтах без SkipConflicts ничего не создаётся
+// и возвращается ErrSeriesConflict вместе с разбором по датам.
+func (s *Service) CreateSeries(ctx context.Context, req CreateSeriesRequest) (*SeriesResponse, error) {
+	if !req.EndTime.After(req.StartTime) || req.StartTime.Before(time.Now()) {
+		return nil, ErrValidation
+	}
+	if req.Count < 0 || req.Count > maxSeriesOccurrences || (req.Count == 0 && req.Until == "") {
+		return nil, ErrValidation
+	}
+
+	loc, err := s.roomLocation(ctx, req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var until *time.Time
+	if req.Until != "" {
+		day, err := parseLocalDate(req.Until, loc)
+		if err != nil {
+			return nil, err
+		}
+		// UNTIL включительно: до конца указанного дня
+		last := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		if last.Before(req.StartTime) {
+			return nil, ErrValidation
+		}
+		until = &last
+	}
+
+	slots, err := expandOccurrences(req.StartTime, req.EndTime, loc, req.Frequency, req.Count, until)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := make([]SeriesOccurrence, 0, len(slots))
+	free := 0
+	for _, slot := range slots {
+		reason, err := s.occurrenceConflict(ctx, req.RoomID, slot, nil)
+		if err != nil {
+			return nil, err
+		}
+		occ := newOccurrence(slot, loc)
+		if reason != "" {
+			occ.Status, occ.Reason = OccurrenceConflict, reason
+		} else {
+			free++
+		}
+		occurrences = append(occurrences, occ)
+	}
+
+	resp := &SeriesResponse{Occurrences: occurrences}
+	resp.count()
+	if free == 0 {
+		return resp, ErrNotAvailable
+	}
+	if resp.Conflicts > 0 && !req.SkipConflicts {
+		return resp, ErrSeriesConflict
+	}
+
+	series := &BookingSeries{
+		UserID:    req.UserID,
+		RoomID:    req.RoomID,
+		StudioID:  req.StudioID,
+		Frequency: req.Frequency,
+		StartTime: req.StartTime.UTC(),
+		EndTime:   req.EndTime.UTC(),
+		Count:     req.Count,
+		Until:     until,
+		Timezone:  loc.String(),
+		Status:    SeriesActive,
+		Notes:     req.Notes,
+	}
+	if err := s.bookings.CreateSeries(ctx, series); err != nil {
+		return nil, err
+	}
+
+	var first *Booking
+	for i := range occurrences {
+		occ := &occurrences[i]
+		if occ.Status == OccurrenceConflict {
+			continue
+		}
+		b, err := s.insertBooking(ctx, CreateBookingRequest{
+			RoomID:    req.RoomID,
+			StudioID:  req.StudioID,
+			UserID:    req.UserID,
+			StartTime: occ.StartTime,
+			EndTime:   occ.EndTime,
+			Notes:     req.Notes,
+			SeriesID:  &series.ID,
+		}, nil)
+		switch {
+		case err == nil:
+			occ.Status, occ.BookingID = OccurrenceBooked, b.ID
+			if first == nil {
+				first = b
+			}
+		case errors.Is(err, ErrNotAvailable), errors.Is(err, ErrOverbooking):
+			// слот заняли между проверкой и вставкой
+			occ.Status, occ.Reason = OccurrenceConflict, "not_available"
+		default:
+			return nil, err
+		}
+	}
+
+	// Владельцу — одно уведомление на серию, а не на каждое занятие
+	if first != nil {
+		s.notifyOwnerBookingCreated(ctx, first)
+	}
+
+	resp.Series = series
+	resp.count()
+	return resp, nil
+}
+
+// GetSeries возвращает серию со всеми её занятиями
+func (s *Service) GetSeries(ctx context.Context, seriesID, userID int64, role string) (*SeriesResponse, error) {
+	series, err := s.getSeriesFor(ctx, seriesID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	return s.seriesView(ctx, series)
+}
+
+// CancelSeries отменяет одно занятие (BookingID) или все будущие занятия серии
+func (s *Service) CancelSeries(ctx context.Context, seriesID int64, actor Actor, req CancelSeriesRequest) (*SeriesResponse, error) {
+	series, err := s.getSeriesFor(ctx, seriesID, actor.UserID, actor.Role)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status != SeriesActive {
+		return nil, ErrInvalidStatusTransition
+	}
+
+	if req.BookingID != nil {
+		b, err := s.seriesBooking(ctx, series, *req.BookingID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.CancelBooking(ctx, b.ID, actor, req.Reason); err != nil {
+			return nil, err
+		}
+		return s.seriesView(ctx, series)
+	}
+
+	if err := s.bookings.CancelSeries(ctx, series.ID, time.Now(), actor, req.Reason); err != nil {
+		return nil, err
+	}
+	return s.GetSeries(ctx, series.ID, actor.UserID, actor.Role)
+}
+
+// RescheduleSeries переносит одно занятие (BookingID + StartTime/EndTime)
+// или все будущие занятия серии на новое время суток (StartClock/EndClock).
+// Одно занятие переносится по правилам RescheduleBooking. Перенос серии
+// атомарный: при любом конфликте ничего не меняется.
+func (s *Service) RescheduleSeries(ctx context.Context, seriesID int64, actor Actor, req RescheduleSeriesRequest) (*SeriesResponse, error) {
+	series, err := s.getSeriesFor(ctx, seriesID, actor.UserID, actor.Role)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status != SeriesActive {
+		return nil, ErrInvalidStatusTransition
+	}
+
+	if req.BookingID != nil {
+		if req.StartTime == nil || req.EndTime == nil {
+			return nil, ErrValidation
+		}
+		b, err := s.seriesBooking(ctx, series, *req.BookingID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.RescheduleBooking(ctx, b.ID, actor, RescheduleBookingRequest{StartTime: *req.StartTime, EndTime: *req.EndTime}); err != nil {
+			return nil, err
+		}
+		return s.seriesView(ctx, series)
+	}
+
+	if req.StartClock == "" || req.EndClock == "" {
+		return nil, ErrValidation
+	}
+	loc, err := catalog.LoadTimezone(series.Timezone)
+	if err != nil {
+		loc, _ = catalog.LoadTimezone("")
+	}
+	all, err := s.bookings.GetBySeriesID(ctx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+	var targets []Booking
+	for _, b := range all {
+		if isReschedulable(&b) {
+			targets = append(targets, b)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, ErrInvalidStatusTransition
+	}
+
+	exclude := make([]int64, 0, len(targets))
+	for _, b := range targets {
+		exclude = append(exclude, b.ID)
+	}
+
+	changes := make([]BookingTimeChange, 0, len(targets))
+	occurrences := make([]SeriesOccurrence, 0, len(targets))
+	for _, b := range targets {
+		day := localDay(b.StartTime, loc)
+		slot := TimeSlot{}
+		if slot.Start, err = catalog.ClockOnDate(day, req.StartClock, loc); err != nil {
+			return nil, ErrValidation
+		}
+		if slot.End, err = catalog.ClockOnDate(day, req.EndClock, loc); err != nil {
+			return nil, ErrValidation
+		}
+		if !slot.End.After(slot.Start) {
+			return nil, ErrValidation
+		}
+
+		occ := newOccurrence(slot, loc)
+		occ.BookingID = b.ID
+		reason, err := s.occurrenceConflict(ctx, b.RoomID, slot, exclude)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			occ.Status, occ.Reason = OccurrenceConflict, reason
+		}
+		occurrences = append(occurrences, occ)
+
+		// аренда оборудования переезжает вместе с занятием по зафиксированной цене
+		equipment, err := s.bookings.GetBookingEquipment(ctx, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		quote, err := s.quoteWithEquipment(ctx, b.RoomID, slot.Start, slot.End, equipment)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, BookingTimeChange{
+			BookingID:  b.ID,
+			StartTime:  slot.Start.UTC(),
+			EndTime:    slot.End.UTC(),
+			TotalPrice: quote.Total,
+		})
+	}
+
+	check := &SeriesResponse{Occurrences: occurrences}
+	check.count()
+	if check.Conflicts > 0 {
+		return check, ErrSeriesConflict
+	}
+
+	// пересечения перепроверяются в транзакции: слот могли занять после проверки выше
+	moved, err := s.bookings.RescheduleBookings(ctx, changes, actor, fmt.Sprintf("series rescheduled to %s-%s", req.StartClock, req.EndClock))
+	if err != nil {
+		return nil, err
+	}
+	for i := range moved {
+		s.replanReminders(ctx, &moved[i])
+	}
+
+	// Шаблон серии следует за переносом всей серии
+	first := changes[0]
+	series.StartTime, series.EndTime = first.StartTime, first.EndTime
+	if err := s.bookings.UpdateSeries(ctx, series); err != nil {
+		return nil, err
+	}
+
+	return s.seriesView(ctx, series)
+}
+
+func isReschedulable(b *Booking) bool {
+	return (b.Status == BookingPending || b.Status == BookingConfirmed) && b.StartTime.After(time.Now())
+}
+
+func (s *Service) getSeriesFor(ctx context.Context, seriesID, userID int64, role string) (*BookingSeries, error) {
+	series, err := s.bookings.GetSeriesByID(ctx, seriesID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if series.UserID != userID && role != "admin" {
+		return nil, ErrForbidden
+	}
+	return series, nil
+}
+
+func (s *Service) seriesBooking(ctx context.Context, series *BookingSeries, bookingID int64) (*Booking, error) {
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if b.SeriesID == nil || *b.SeriesID != series.ID {
+		return nil, ErrNotFound
+	}
+	return b, nil
+}
+
+func (s *Service) seriesView(ctx context.Context, series *BookingSeries) (*SeriesResponse, error) {
+	bookings, err := s.bookings.GetBySeriesID(ctx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := catalog.LoadTimezone(series.Timezone)
+	if err != nil {
+		loc, _ = catalog.LoadTimezone("")
+	}
+
+	resp := &SeriesResponse{Series: series, Occurrences: make([]SeriesOccurrence, 0, len(bookings))}
+	for _, b := range bookings {
+		occ := newOccurrence(TimeSlot{Start: b.StartTime, End: b.EndTime}, loc)
+		occ.Status = OccurrenceBooked
+		occ.BookingID = b.ID
+		occ.BookingStatus = string(b.Status)
+		resp.Occurrences = append(resp.Occurrences, occ)
+	}
+	resp.count()
+	return resp, nil
+}
+
+func newOccurrence(slot TimeSlot, loc *time.Location) SeriesOccurrence {
+	start, end := slot.Start.In(loc), slot.End.In(loc)
+	return SeriesOccurrence{
+		Date:      start.Format("2006-01-02"),
+		StartTime: start,
+		EndTime:   end,
+		Status:    OccurrenceAvailable,
+	}
+}
+
+func (r *SeriesResponse) count() {
+	r.Booked, r.Conflicts = 0, 0
+	for _, o := range r.Occurrences {
+		switch o.Status {
+		case OccurrenceConflict:
+			r.Conflicts++
+		case OccurrenceBooked:
+			r.Booked++
+		}
+	}
+}
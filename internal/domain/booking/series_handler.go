@@ -0,0 +1,171 @@
+package booking
+
+import (
+	"errors"
+	"net/http"
+	"photostudio/internal/domain/catalog"
+	"photostudio/internal/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSeries создаёт повторяющуюся бронь
+// @Summary		Создать серию бронирований
+// @Description	Создаёт серию броней одной комнаты (weekly/biweekly/monthly) до даты until или на count занятий. Каждое занятие проверяется на доступность. Если есть конфликты и skip_conflicts=false — ничего не создаётся, в error.details возвращается разбор по датам.
+// @Tags		Бронирования - Серии
+// @Security	BearerAuth
+// @Param		body body CreateSeriesRequest true "Параметры серии"
+// @Success		201 {object} SeriesResponse "Серия создана"
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure		401 {object} map[string]interface{} "Ошибка аутентификации"
+// @Failure		409 {object} map[string]interface{} "Конфликты по датам"
+// @Failure		500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router		/booking-series [post]
+func (h *Handler) CreateSeries(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID <= 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Missing auth")
+		return
+	}
+
+	var req CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	req.UserID = userID
+
+	resp, err := h.service.CreateSeries(c.Request.Context(), req)
+	if err != nil {
+		h.handleSeriesError(c, resp, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, resp)
+}
+
+// GetSeries возвращает серию и её занятия
+// @Summary		Получить серию бронирований
+// @Tags		Бронирования - Серии
+// @Security	BearerAuth
+// @Param		id path integer true "ID серии"
+// @Success		200 {object} SeriesResponse
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Серия не найдена"
+// @Router		/booking-series/{id} [get]
+func (h *Handler) GetSeries(c *gin.Context) {
+	seriesID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || seriesID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid series id")
+		return
+	}
+
+	resp, err := h.service.GetSeries(c.Request.Context(), seriesID, c.GetInt64("user_id"), c.GetString("role"))
+	if err != nil {
+		h.handleSeriesError(c, nil, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+// CancelSeries отменяет одно занятие или всю серию
+// @Summary		Отменить серию или одно занятие
+// @Description	Без booking_id отменяет все будущие занятия и закрывает серию. С booking_id — только указанное занятие.
+// @Tags		Бронирования - Серии
+// @Security	BearerAuth
+// @Param		id path integer true "ID серии"
+// @Param		body body CancelSeriesRequest true "Причина и (опционально) занятие"
+// @Success		200 {object} SeriesResponse
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации или неверный статус"
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Серия или занятие не найдены"
+// @Router		/booking-series/{id}/cancel [patch]
+func (h *Handler) CancelSeries(c *gin.Context) {
+	seriesID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || seriesID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid series id")
+		return
+	}
+
+	var req CancelSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR",
+			"Причина отмены обязательна (минимум 10 символов)")
+		return
+	}
+
+	resp, err := h.service.CancelSeries(c.Request.Context(), seriesID, actorFromContext(c), req)
+	if err != nil {
+		h.handleSeriesError(c, nil, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+// RescheduleSeries переносит одно занятие или всю серию
+// @Summary		Перенести серию или одно занятие
+// @Description	С booking_id переносит занятие на start_time/end_time по правилам переноса брони (история, уведомление). Без booking_id переносит все будущие занятия на новое время суток start_clock/end_clock (в зоне студии). При конфликте ничего не меняется.
+// @Tags		Бронирования - Серии
+// @Security	BearerAuth
+// @Param		id path integer true "ID серии"
+// @Param		body body RescheduleSeriesRequest true "Новое время"
+// @Success		200 {object} SeriesResponse
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации или неверный статус"
+// @Failure		403 {object} map[string]interface{} "Нет доступа"
+// @Failure		404 {object} map[string]interface{} "Серия или занятие не найдены"
+// @Failure		409 {object} map[string]interface{} "Конфликты по датам"
+// @Router		/booking-series/{id}/reschedule [patch]
+func (h *Handler) RescheduleSeries(c *gin.Context) {
+	seriesID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || seriesID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid series id")
+		return
+	}
+
+	var req RescheduleSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	resp, err := h.service.RescheduleSeries(c.Request.Context(), seriesID, actorFromContext(c), req)
+	if err != nil {
+		h.handleSeriesError(c, resp, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+func (h *Handler) handleSeriesError(c *gin.Context, resp *SeriesResponse, err error) {
+	switch {
+	case errors.Is(err, ErrSeriesConflict), errors.Is(err, ErrNotAvailable), errors.Is(err, ErrOverbooking):
+		var details any
+		if resp != nil {
+			details = resp.Occurrences
+		}
+		response.ErrorWithDetails(c, http.StatusConflict, "SERIES_CONFLICT",
+			"Some occurrences are not available", details)
+	case errors.Is(err, ErrEquipmentUnavailable):
+		response.CustomError(c, http.StatusConflict, "EQUIPMENT_UNAVAILABLE", "Rented equipment is not available for selected time")
+	case errors.Is(err, ErrOutsideWorkingHours):
+		response.CustomError(c, http.StatusBadRequest, "OUTSIDE_WORKING_HOURS", "Occurrence is outside studio working hours")
+	case errors.Is(err, ErrClosedPeriod):
+		response.CustomError(c, http.StatusBadRequest, "CLOSED_PERIOD", "Room is closed for the selected time")
+	case errors.Is(err, ErrValidation):
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid series parameters")
+	case errors.Is(err, catalog.ErrBelowMinDuration):
+		response.CustomError(c, http.StatusBadRequest, "BELOW_MIN_DURATION", "Occurrence is shorter than the room minimum duration")
+	case errors.Is(err, ErrInvalidStatusTransition):
+		response.CustomError(c, http.StatusBadRequest, "INVALID_STATUS", "Series or occurrence cannot be changed")
+	case errors.Is(err, ErrForbidden):
+		response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Access denied")
+	case errors.Is(err, ErrNotFound):
+		response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Series or occurrence not found")
+	default:
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+	}
+}
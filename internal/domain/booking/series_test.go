@@ -0,0 +1,101 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExpandOccurrences_WeeklyKeepsWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("tzdata unavailable")
+	}
+	// Вторник 10:00–13:00, переход на летнее время 29.03.2026
+	start := time.Date(2026, 3, 17, 10, 0, 0, 0, loc)
+	end := time.Date(2026, 3, 17, 13, 0, 0, 0, loc)
+
+	slots, err := expandOccurrences(start, end, loc, SeriesWeekly, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 4 {
+		t.Fatalf("expected 4 occurrences, got %d", len(slots))
+	}
+	for _, s := range slots {
+		if s.Start.Weekday() != time.Tuesday || s.Start.Hour() != 10 || s.End.Hour() != 13 {
+			t.Fatalf("occurrence drifted: %s–%s", s.Start, s.End)
+		}
+	}
+}
+
+func TestExpandOccurrences_MonthlySkipsMissingDays(t *testing.T) {
+	start := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	until := time.Date(2026, 5, 31, 23, 59, 0, 0, time.UTC)
+
+	slots, err := expandOccurrences(start, end, time.UTC, SeriesMonthly, 0, &until)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 31 января, 31 марта, 31 мая — февраль и апрель пропущены
+	want := []string{"2026-01-31", "2026-03-31", "2026-05-31"}
+	if len(slots) != len(want) {
+		t.Fatalf("expected %d occurrences, got %d", len(want), len(slots))
+	}
+	for i, s := range slots {
+		if got := s.Start.Format("2006-01-02"); got != want[i] {
+			t.Fatalf("occurrence %d: want %s, got %s", i, want[i], got)
+		}
+	}
+}
+
+func TestExpandOccurrences_BiweeklyCount(t *testing.T) {
+	start := time.Date(2026, 6, 2, 10, 0, 0, 0, time.UTC)
+	slots, err := expandOccurrences(start, start.Add(time.Hour), time.UTC, SeriesBiweekly, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 3 || !slots[2].Start.Equal(start.AddDate(0, 0, 28)) {
+		t.Fatalf("unexpected biweekly expansion: %+v", slots)
+	}
+	if _, err := expandOccurrences(start, start.Add(time.Hour), time.UTC, "daily", 3, nil); err != ErrValidation {
+		t.Fatalf("expected ErrValidation for unknown frequency, got %v", err)
+	}
+}
+
+func TestCancelSeries_KeepsCallerRole(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+	if err := svc.bookings.DB().AutoMigrate(&BookingSeries{}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := nextWeekday(time.Tuesday).Add(12 * time.Hour)
+	series := &BookingSeries{UserID: 7, RoomID: 1, StudioID: 1, Frequency: SeriesWeekly, StartTime: start, EndTime: start.Add(2 * time.Hour),
+		Count: 1, Timezone: "UTC", Status: SeriesActive}
+	if err := svc.bookings.CreateSeries(ctx, series); err != nil {
+		t.Fatal(err)
+	}
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(2 * time.Hour),
+		Status: BookingConfirmed, PaymentStatus: PaymentUnpaid, SeriesID: &series.ID}
+	if err := svc.bookings.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.CancelSeries(ctx, series.ID, Actor{UserID: 8, Role: ActorClient}, CancelSeriesRequest{}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for another client, got %v", err)
+	}
+	resp, err := svc.CancelSeries(ctx, series.ID, Actor{UserID: 1, Role: ActorAdmin}, CancelSeriesRequest{Reason: "studio closed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Series.Status != SeriesCancelled {
+		t.Fatalf("expected cancelled series, got %s", resp.Series.Status)
+	}
+	history, _ := svc.bookings.GetStatusHistory(ctx, b.ID)
+	if len(history) == 0 || history[len(history)-1].ActorRole != ActorAdmin {
+		t.Fatalf("expected the cancellation recorded for the admin, got %+v", history)
+	}
+}
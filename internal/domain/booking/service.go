@@ -2,14 +2,13 @@ package booking
 
 import (
 	"context"
-	"encoding/json"
-	"math"
+	"errors"
 	"photostudio/internal/domain/auth"
 	"photostudio/internal/domain/catalog"
 	"sort"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
 )
 
 type TimeSlot struct {
@@ -36,6 +35,10 @@ type Service struct {
 	rooms                  RoomRepository
 	notifs                 NotificationSender
 	studioWorkingHoursRepo catalog.StudioWorkingHoursRepository // Добавляем поле
+	refunds                RefundIssuer
+	holdTTL                time.Duration
+	waitlistClaimTTL       time.Duration
+	calendarFetcher        CalendarFetcher
 }
 
 func NewService(
@@ -43,16 +46,35 @@ func NewService(
 	rooms RoomRepository,
 	notifs NotificationSender,
 	studioWorkingHoursRepo catalog.StudioWorkingHoursRepository, // Добавляем параметр
+	refunds RefundIssuer,
 ) *Service {
 	return &Service{
 		bookings:               bookings,
 		rooms:                  rooms,
 		notifs:                 notifs,
 		studioWorkingHoursRepo: studioWorkingHoursRepo, // Инициализируем
+		refunds:                refunds,
+		holdTTL:                DefaultHoldConfig().TTL,
+		waitlistClaimTTL:       DefaultWaitlistConfig().ClaimTTL,
+		calendarFetcher:        NewHTTPCalendarFetcher(calendarFetchTimeout),
 	}
 }
 
 func (s *Service) CreateBooking(ctx context.Context, req CreateBookingRequest) (*Booking, error) {
+	b, err := s.insertBooking(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// уведомление владельцу студии о новом бронировании (после Create, когда b.ID уже известен)
+	s.notifyOwnerBookingCreated(ctx, b)
+
+	return b, nil
+}
+
+// insertBooking проверяет слот и сохраняет бронь без уведомлений.
+// Используется для разовых броней, занятий серии и holds (holdUntil != nil).
+func (s *Service) insertBooking(ctx context.Context, req CreateBookingRequest, holdUntil *time.Time) (*Booking, error) {
 	if req.EndTime.Before(req.StartTime) || req.EndTime.Equal(req.StartTime) {
 		return nil, ErrValidation
 	}
@@ -62,6 +84,10 @@ func (s *Service) CreateBooking(ctx context.Context, req CreateBookingRequest) (
 		return nil, ErrValidation
 	}
 
+	if err := s.validateWithinWorkingHours(ctx, req.RoomID, req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+
 	ok, err := s.bookings.CheckAvailability(ctx, req.RoomID, req.StartTime, req.EndTime)
 	if err != nil {
 		return nil, err
@@ -70,45 +96,71 @@ func (s *Service) CreateBooking(ctx context.Context, req CreateBookingRequest) (
 		return nil, ErrNotAvailable
 	}
 
-	pricePerHour, err := s.rooms.GetPriceByID(ctx, req.RoomID)
+	equipment, err := s.resolveEquipment(ctx, req.RoomID, req.Equipment)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.quoteWithEquipment(ctx, req.RoomID, req.StartTime, req.EndTime, equipment)
 	if err != nil {
 		return nil, err
 	}
-
-	durationHours := req.EndTime.Sub(req.StartTime).Hours()
-	total := durationHours * pricePerHour
-	total = math.Round(total*100) / 100
 
 	b := &Booking{
 		RoomID:        req.RoomID,
 		StudioID:      req.StudioID,
 		UserID:        req.UserID,
-		StartTime:     req.StartTime,
-		EndTime:       req.EndTime,
-		TotalPrice:    total,
+		StartTime:     req.StartTime.UTC(),
+		EndTime:       req.EndTime.UTC(),
+		TotalPrice:    quote.Total,
 		Status:        BookingPending,
 		PaymentStatus: PaymentUnpaid,
 		Notes:         req.Notes,
+		SeriesID:      req.SeriesID,
+		Equipment:     equipment,
+	}
+	if req.Guest != nil {
+		b.GuestName, b.GuestPhone, b.GuestEmail = req.Guest.Name, req.Guest.Phone, req.Guest.Email
+	}
+	if holdUntil != nil {
+		b.Status = BookingHeld
+		b.HoldExpiresAt = holdUntil
 	}
 
+	// пересечение, пойманное ограничением БД, репозиторий возвращает как ErrOverbooking
 	if err := s.bookings.Create(ctx, b); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == "23505" && pgErr.ConstraintName == "idx_no_overbooking" {
-				return nil, ErrOverbooking
-			}
-		}
 		return nil, err
 	}
 
-	// уведомление владельцу студии о новом бронировании (после Create, когда b.ID уже известен)
-	if s.notifs != nil {
-		ownerID, _, err := s.bookings.GetStudioOwnerForBooking(ctx, b.ID)
-		if err == nil && ownerID > 0 {
-			_ = s.notifs.NotifyBookingCreated(ctx, ownerID, b.ID, b.StudioID, b.RoomID, b.StartTime)
-		}
+	return b, nil
+}
+
+// quotePrice считает стоимость слота по правилам цены комнаты
+func (s *Service) quotePrice(ctx context.Context, roomID int64, start, end time.Time) (float64, error) {
+	quote, err := s.quoteWithEquipment(ctx, roomID, start, end, nil)
+	if err != nil {
+		return 0, err
 	}
+	return quote.Total, nil
+}
 
-	return b, nil
+func (s *Service) notifyOwnerBookingCreated(ctx context.Context, b *Booking) {
+	if s.notifs == nil {
+		return
+	}
+	ownerID, _, err := s.bookings.GetStudioOwnerForBooking(ctx, b.ID)
+	if err != nil || ownerID <= 0 {
+		return
+	}
+	_ = s.notifs.NotifyBookingCreated(ctx, ownerID, b.ID, b.StudioID, b.RoomID, b.StartTime)
+
+	// hold конвертируется без позиций в памяти — подгружаем из БД
+	equipment := b.Equipment
+	if len(equipment) == 0 {
+		equipment, _ = s.bookings.GetBookingEquipment(ctx, b.ID)
+	}
+	for _, e := range equipment {
+		_ = s.notifs.NotifyEquipmentBooked(ctx, ownerID, e.EquipmentID, b.ID, e.Name)
+	}
 }
 
 func (s *Service) GetBusySlots(ctx context.Context, roomID int64, from, to time.Time) ([]BusySlot, error) {
@@ -125,122 +177,134 @@ func (s *Service) GetBusySlots(ctx context.Context, roomID int64, from, to time.
 }
 
 func (s *Service) GetRoomAvailability(ctx context.Context, roomID int64, dateStr string) ([]TimeSlot, error) {
-	day, err := time.Parse("2006-01-02", dateStr)
+	loc, err := s.roomLocation(ctx, roomID)
 	if err != nil {
-		return nil, ErrValidation
+		return nil, err
 	}
-	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
-	whRaw, err := s.rooms.GetStudioWorkingHoursByRoomID(ctx, roomID)
+	day, err := parseLocalDate(dateStr, loc)
 	if err != nil {
 		return nil, err
 	}
-	// ДОБАВИТЬ: Fallback если working_hours пустой
-	if len(whRaw) == 0 {
-		// Дефолтный график: 09:00-21:00
-		open := time.Date(day.Year(), day.Month(), day.Day(), 9, 0, 0, 0, time.UTC)
-		close := time.Date(day.Year(), day.Month(), day.Day(), 21, 0, 0, 0, time.UTC)
 
-		busyRepo, err := s.bookings.GetBusySlotsForRoom(ctx, roomID, open, close)
-		if err != nil {
-			return nil, err
-		}
-
-		busy := make([]TimeSlot, 0, len(busyRepo))
-		for _, b := range busyRepo {
-			busy = append(busy, TimeSlot{Start: b.Start, End: b.End})
-		}
-
-		return subtractBusy(open, close, busy), nil
-	}
-	// Остальной код без изменений...
-	open, close, ok, err := extractOpenCloseUTC(whRaw, day)
+	rd, err := s.resolveRoomDay(ctx, roomID, day, loc)
 	if err != nil {
 		return nil, err
 	}
-	if !ok || !close.After(open) {
+	if !rd.IsOpen {
 		return []TimeSlot{}, nil
 	}
-	busyRepo, err := s.bookings.GetBusySlotsForRoom(ctx, roomID, open, close)
+
+	// брони рядом с рабочим днём тоже съедают время буферами
+	gap := rd.bufferGap()
+	busyRepo, err := s.bookings.GetBusySlotsForRoom(ctx, roomID, rd.Open.Add(-gap), rd.Close.Add(gap))
 	if err != nil {
 		return nil, err
 	}
 	busy := make([]TimeSlot, 0, len(busyRepo))
 	for _, b := range busyRepo {
-		busy = append(busy, TimeSlot{Start: b.Start, End: b.End})
+		// свободное окно должно вмещать уборку после соседней брони и подготовку к своей
+		busy = append(busy, TimeSlot{Start: b.Start.Add(-gap).In(loc), End: b.End.Add(gap).In(loc)})
 	}
-	return subtractBusy(open, close, busy), nil
+	busy = append(busy, rd.Blackouts...)
+	return subtractBusy(rd.Open, rd.Close, busy), nil
 }
 
 // GetRoomAvailabilityV2 returns booked slots format as per Task 3.2
 func (s *Service) GetAvailability(ctx context.Context, roomID int64, dateStr string) (*AvailabilityResponse, error) {
-	day, err := time.Parse("2006-01-02", dateStr)
+	loc, err := s.roomLocation(ctx, roomID)
 	if err != nil {
-		return nil, ErrValidation
+		return nil, err
+	}
+	day, err := parseLocalDate(dateStr, loc)
+	if err != nil {
+		return nil, err
 	}
-	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
 
-	// Get working hours
-	whRaw, err := s.rooms.GetStudioWorkingHoursByRoomID(ctx, roomID)
+	rd, err := s.resolveRoomDay(ctx, roomID, day, loc)
 	if err != nil {
 		return nil, err
 	}
 
-	var workingHours WorkingHours
-	var open, close time.Time
+	resp := &AvailabilityResponse{
+		RoomID:       roomID,
+		Date:         dateStr,
+		Timezone:     loc.String(),
+		UTCOffset:    catalog.UTCOffset(day),
+		WorkingHours: WorkingHours{Open: "", Close: ""},
+		BookedSlots:  []BookedSlot{},
+	}
+	if !rd.IsOpen {
+		// Studio is closed on this day
+		return resp, nil
+	}
+	resp.WorkingHours = WorkingHours{
+		Open:  rd.Open.Format("15:04"),
+		Close: rd.Close.Format("15:04"),
+	}
 
-	if len(whRaw) == 0 {
-		// Default schedule: 09:00-21:00
-		workingHours = WorkingHours{Open: "09:00", Close: "21:00"}
-		open = time.Date(day.Year(), day.Month(), day.Day(), 9, 0, 0, 0, time.UTC)
-		close = time.Date(day.Year(), day.Month(), day.Day(), 21, 0, 0, 0, time.UTC)
-	} else {
-		var ok bool
-		open, close, ok, err = extractOpenCloseUTC(whRaw, day)
-		if err != nil {
-			return nil, err
-		}
-		if !ok || !close.After(open) {
-			// Studio is closed on this day
-			return &AvailabilityResponse{
-				RoomID:       roomID,
-				Date:         dateStr,
-				WorkingHours: WorkingHours{Open: "", Close: ""},
-				BookedSlots:  []BookedSlot{},
-			}, nil
+	// Busy slots за местные сутки студии
+	busyRepo, err := s.bookings.GetBusySlotsForRoom(ctx, roomID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range busyRepo {
+		if rd.BufferBefore > 0 {
+			resp.BookedSlots = append(resp.BookedSlots, newBookedSlot(b.Start.Add(-rd.BufferBefore), b.Start, loc, SlotBuffer))
 		}
-		workingHours = WorkingHours{
-			Open:  open.Format("15:04"),
-			Close: close.Format("15:04"),
+		resp.BookedSlots = append(resp.BookedSlots, newBookedSlot(b.Start, b.End, loc, SlotBooked))
+		if rd.BufferAfter > 0 {
+			resp.BookedSlots = append(resp.BookedSlots, newBookedSlot(b.End, b.End.Add(rd.BufferAfter), loc, SlotBuffer))
 		}
 	}
+	for _, bl := range rd.Blackouts {
+		resp.BookedSlots = append(resp.BookedSlots, newBookedSlot(bl.Start, bl.End, loc, SlotBlackout))
+	}
 
-	// Get busy slots
-	startOfDay := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	return resp, nil
+}
 
-	busyRepo, err := s.bookings.GetBusySlotsForRoom(ctx, roomID, startOfDay, endOfDay)
+// GetBusySlotsForDate возвращает занятые слоты комнаты за местные сутки студии
+func (s *Service) GetBusySlotsForDate(ctx context.Context, roomID int64, dateStr string) (*BusySlotsResponse, error) {
+	loc, err := s.roomLocation(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	day, err := parseLocalDate(dateStr, loc)
 	if err != nil {
 		return nil, err
 	}
 
-	// Format booked slots
-	bookedSlots := make([]BookedSlot, 0)
-	for _, b := range busyRepo {
-		// Get the booking to find its status
-		// We need to enhance this - for now we'll get all bookings
-		bookedSlots = append(bookedSlots, BookedSlot{
-			Start:  b.Start.Format("15:04"),
-			End:    b.End.Format("15:04"),
-			Status: "booked", // This could be enhanced to show actual status
+	rd, err := s.resolveRoomDay(ctx, roomID, day, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.GetBusySlots(ctx, roomID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make([]BusySlotDTO, 0, len(rows))
+	for _, r := range rows {
+		busy = append(busy, BusySlotDTO{
+			Start: r.Start.In(loc).Format("15:04"),
+			End:   r.End.In(loc).Format("15:04"),
 		})
 	}
 
-	return &AvailabilityResponse{
-		RoomID:       roomID,
-		Date:         dateStr,
-		WorkingHours: workingHours,
-		BookedSlots:  bookedSlots,
-	}, nil
+	resp := &BusySlotsResponse{
+		Date:      dateStr,
+		RoomID:    roomID,
+		Timezone:  loc.String(),
+		UTCOffset: catalog.UTCOffset(day),
+		BusySlots: busy,
+	}
+	if rd.IsOpen {
+		resp.OpenTime = rd.Open.Format("15:04")
+		resp.CloseTime = rd.Close.Format("15:04")
+	}
+	return resp, nil
 }
 
 func (s *Service) GetMyBookings(ctx context.Context, userID int64, limit, offset int) ([]BookingDetails, error) {
@@ -266,8 +330,17 @@ func (s *Service) GetMyBookings(ctx context.Context, userID int64, limit, offset
 	return out, nil
 }
 
+// manualStatuses — статусы, которые владелец/админ выставляет вручную.
+// held → pending делает только оплата.
+var manualStatuses = map[BookingStatus]bool{
+	BookingConfirmed: true,
+	BookingCancelled: true,
+	BookingCompleted: true,
+	BookingNoShow:    true,
+}
+
 func (s *Service) UpdateBookingStatus(ctx context.Context, bookingID, actorUserID int64, actorRole, newStatus string) (*Booking, error) {
-	if actorRole != string(auth.RoleStudioOwner) {
+	if actorRole != string(auth.RoleStudioOwner) && actorRole != ActorAdmin {
 		return nil, ErrForbidden
 	}
 
@@ -278,88 +351,45 @@ func (s *Service) UpdateBookingStatus(ctx context.Context, bookingID, actorUserI
 	if ownerID == 0 && currentStatus == "" {
 		return nil, ErrNotFound
 	}
-	if ownerID != actorUserID {
+	if actorRole != ActorAdmin && ownerID != actorUserID {
 		return nil, ErrForbidden
 	}
-	if s.notifs != nil {
-		b, err := s.bookings.GetByID(ctx, bookingID)
-		if err == nil && b != nil {
-			if newStatus == string(BookingConfirmed) {
-				_ = s.notifs.NotifyBookingConfirmed(ctx, b.UserID, b.ID, b.StudioID)
-			}
-			if newStatus == string(BookingCancelled) {
-				_ = s.notifs.NotifyBookingCancelled(ctx, b.UserID, b.ID, b.StudioID, "")
-			}
-		}
-	}
 
-	if !(currentStatus == "pending" && newStatus == "confirmed") {
+	to := BookingStatus(newStatus)
+	if !manualStatuses[to] {
 		return nil, ErrInvalidStatusTransition
 	}
 
-	if err := s.bookings.UpdateStatus(ctx, bookingID, newStatus); err != nil {
-		return nil, err
+	actor := Actor{UserID: actorUserID, Role: actorRole}
+	var b *Booking
+	if to == BookingCancelled {
+		// отмена студией или админом — полный возврат оплаченного
+		b, err = s.bookings.CancelWithRefund(ctx, bookingID, actor, "", 100)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrNotFound
+		}
+		if err == nil {
+			s.afterCancel(ctx, actor, *b, "")
+		}
+	} else {
+		b, err = s.TransitionStatus(ctx, bookingID, to, actor, "")
 	}
-
-	b, err := s.bookings.GetByID(ctx, bookingID)
 	if err != nil {
 		return nil, err
 	}
-	return b, nil
-}
-
-type workingHoursDay struct {
-	Open  string `json:"open"`
-	Close string `json:"close"`
-}
-
-func extractOpenCloseUTC(whRaw json.RawMessage, day time.Time) (time.Time, time.Time, bool, error) {
-	if len(whRaw) == 0 {
-		return time.Time{}, time.Time{}, false, nil
-	}
-
-	var wh map[string]workingHoursDay
-	if err := json.Unmarshal(whRaw, &wh); err != nil {
-		return time.Time{}, time.Time{}, false, err
-	}
-
-	key := weekdayKey(day.Weekday())
-	v, ok := wh[key]
-	if !ok || v.Open == "" || v.Close == "" {
-		return time.Time{}, time.Time{}, false, nil
-	}
 
-	openT, err := time.Parse("15:04", v.Open)
-	if err != nil {
-		return time.Time{}, time.Time{}, false, err
-	}
-	closeT, err := time.Parse("15:04", v.Close)
-	if err != nil {
-		return time.Time{}, time.Time{}, false, err
+	if s.notifs != nil && !b.IsGuest() {
+		switch to {
+		case BookingConfirmed:
+			_ = s.notifs.NotifyBookingConfirmed(ctx, b.UserID, b.ID, b.StudioID)
+		case BookingCancelled:
+			_ = s.notifs.NotifyBookingCancelled(ctx, b.UserID, b.ID, b.StudioID, "")
+		case BookingNoShow:
+			_ = s.notifs.NotifyBookingNoShow(ctx, b.UserID, b.ID, b.StudioID)
+		}
 	}
 
-	open := time.Date(day.Year(), day.Month(), day.Day(), openT.Hour(), openT.Minute(), 0, 0, time.UTC)
-	close := time.Date(day.Year(), day.Month(), day.Day(), closeT.Hour(), closeT.Minute(), 0, 0, time.UTC)
-	return open, close, true, nil
-}
-
-func weekdayKey(w time.Weekday) string {
-	switch w {
-	case time.Monday:
-		return "monday"
-	case time.Tuesday:
-		return "tuesday"
-	case time.Wednesday:
-		return "wednesday"
-	case time.Thursday:
-		return "thursday"
-	case time.Friday:
-		return "friday"
-	case time.Saturday:
-		return "saturday"
-	default:
-		return "sunday"
-	}
+	return b, nil
 }
 
 func subtractBusy(open, close time.Time, busy []TimeSlot) []TimeSlot {
@@ -443,9 +473,10 @@ func (s *Service) IsBookingStudioOwner(ctx context.Context, userID, bookingID in
 	return ownerID == userID, nil
 }
 
-// UpdateStatus updates the booking status
-func (s *Service) UpdateStatus(ctx context.Context, bookingID int64, status string) error {
-	return s.bookings.UpdateStatus(ctx, bookingID, status)
+// UpdateStatus updates the booking status (via the transition table)
+func (s *Service) UpdateStatus(ctx context.Context, bookingID int64, status string, actor Actor) error {
+	_, err := s.TransitionStatus(ctx, bookingID, BookingStatus(status), actor, "")
+	return err
 }
 
 // GetByID retrieves a booking by ID
@@ -455,58 +486,84 @@ func (s *Service) GetByID(ctx context.Context, bookingID int64) (*Booking, error
 
 // CancelBooking отменяет бронирование с причиной
 // Block 9: Обязательная причина отмены
-func (s *Service) CancelBooking(ctx context.Context, bookingID int64, reason string) (*Booking, error) {
-	booking, err := s.bookings.GetByID(ctx, bookingID)
+// Возврат считается по политике отмены студии (см. cancellationRefundPercent)
+func (s *Service) CancelBooking(ctx context.Context, bookingID int64, actor Actor, reason string) (*Booking, error) {
+	current, err := s.bookings.GetByID(ctx, bookingID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
-
-	// Проверяем, можно ли отменить
-	if booking.Status == BookingCancelled {
-		return nil, ErrInvalidStatusTransition
-	}
-
-	if booking.Status == BookingCompleted {
-		return nil, ErrInvalidStatusTransition
+	percent, err := s.cancellationRefundPercent(ctx, current, actor, time.Now())
+	if err != nil {
+		return nil, err
 	}
 
-	// Block 9: Обновляем статус и сохраняем причину
-	if err := s.bookings.CancelWithReason(ctx, bookingID, reason); err != nil {
+	// Block 9: Переход проверяется по таблице, причина пишется в бронь и историю
+	booking, err := s.bookings.CancelWithRefund(ctx, bookingID, actor, reason, percent)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 
 	// Отправляем уведомление
-	if s.notifs != nil {
+	if s.notifs != nil && !booking.IsGuest() {
 		_ = s.notifs.NotifyBookingCancelled(ctx, booking.UserID, booking.ID, booking.StudioID, reason)
 	}
 
+	s.afterCancel(ctx, actor, *booking, reason)
+
 	// Возвращаем обновлённое бронирование
 	return s.bookings.GetByID(ctx, bookingID)
 }
 
-// UpdateDeposit обновляет предоплату (Block 10)
-func (s *Service) UpdateDeposit(ctx context.Context, bookingID int64, amount float64) (*Booking, error) {
-	booking, err := s.bookings.GetByID(ctx, bookingID)
+// UpdateDeposit записывает оплату, которую студия приняла сама (наличные,
+// перевод на месте), через тот же ApplyPayment, что и оплату от провайдера:
+// сумма прибавляется к deposit_amount и при полной оплате бронь становится paid.
+// В учёт платформы не попадает — деньги прошли мимо неё (Block 10).
+func (s *Service) UpdateDeposit(ctx context.Context, bookingID int64, amount float64, actor Actor) (*Booking, error) {
+	if actor.Role != ActorOwner && actor.Role != ActorAdmin {
+		return nil, ErrForbidden
+	}
+	ownerID, _, err := s.bookings.GetStudioOwnerForBooking(ctx, bookingID)
 	if err != nil {
 		return nil, err
 	}
-
-	if amount < 0 {
-		return nil, ErrValidation
+	if ownerID == 0 {
+		return nil, ErrNotFound
+	}
+	if actor.Role != ActorAdmin && ownerID != actor.UserID {
+		return nil, ErrForbidden
 	}
 
-	if amount > booking.TotalPrice {
+	booking, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	switch booking.Status {
+	case BookingPending, BookingConfirmed, BookingCompleted:
+	default:
+		return nil, ErrInvalidStatusTransition
+	}
+	// deposit_amount — сумма всех поступивших оплат, поэтому оплата на месте
+	// прибавляется к ней так же, как оплата через провайдера, и не больше остатка
+	if amount <= 0 || toCents(amount) > toCents(booking.TotalPrice)-toCents(booking.DepositAmount) {
 		return nil, ErrValidation
 	}
 
-	// Обновляем deposit
-	if err := s.bookings.UpdateDeposit(ctx, bookingID, amount); err != nil {
+	if _, err := s.bookings.ApplyPayment(ctx, bookingID, amount); err != nil {
 		return nil, err
 	}
 
 	// Если есть предоплата — подтверждаем бронь
-	if amount > 0 && booking.Status == BookingPending {
-		if err := s.bookings.UpdateStatus(ctx, bookingID, string(BookingConfirmed)); err != nil {
+	if booking.Status == BookingPending {
+		if _, err := s.TransitionStatus(ctx, bookingID, BookingConfirmed, actor, "deposit received"); err != nil {
 			return nil, err
 		}
 	}
@@ -520,28 +577,45 @@ func (s *Service) GetRoomByID(ctx context.Context, roomID int64) (*catalog.Room,
 	return s.rooms.GetByID(ctx, roomID)
 }
 
-// GetWorkingHoursForDate получает рабочие часы на конкретную дату
+// GetWorkingHoursForDate получает рабочие часы на конкретную дату.
+// date должна быть выражена в часовом поясе студии — от неё берётся день недели.
 func (s *Service) GetWorkingHoursForDate(ctx context.Context, studioID int64, date time.Time) (*catalog.WorkingHours, error) {
-	// Используем studioWorkingHoursRepo
-	hours, err := s.studioWorkingHoursRepo.GetHoursForStudio(studioID)
-	if err != nil {
-		return nil, err
+	dayOfWeek := int(date.Weekday())
+
+	if s.studioWorkingHoursRepo != nil {
+		// выходной или особые часы на эту дату важнее недельного шаблона
+		override, err := s.studioWorkingHoursRepo.GetDateOverride(studioID, date.Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		if override != nil {
+			return override.WorkingHours(dayOfWeek), nil
+		}
+
+		hours, err := s.studioWorkingHoursRepo.GetConfiguredHours(studioID)
+		if err != nil {
+			return nil, err
+		}
+		return weeklyHoursOn(hours, dayOfWeek), nil
 	}
 
-	dayOfWeek := int(date.Weekday())
+	return weeklyHoursOn(nil, dayOfWeek), nil
+}
+
+// weeklyHoursOn выбирает часы дня недели из шаблона студии;
+// если дня в шаблоне нет — дефолтные часы
+func weeklyHoursOn(hours []catalog.WorkingHours, dayOfWeek int) *catalog.WorkingHours {
 	for _, h := range hours {
 		if h.DayOfWeek == dayOfWeek {
-			return &h, nil
+			return &h
 		}
 	}
-
-	// Дефолтные часы
 	return &catalog.WorkingHours{
 		DayOfWeek: dayOfWeek,
 		OpenTime:  "09:00",
 		CloseTime: "21:00",
 		IsClosed:  false,
-	}, nil
+	}
 }
 
 // UpdatePaymentStatusSystem updates payment status without user check (for system/webhooks)
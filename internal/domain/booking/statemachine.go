@@ -0,0 +1,106 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Кто меняет статус — пишется в booking_status_history
+const (
+	ActorClient = "client"
+	ActorOwner  = "studio_owner"
+	ActorAdmin  = "admin"
+	ActorSystem = "system" // платёжные callbacks, sweeper, фоновые задачи
+)
+
+// Actor — инициатор смены статуса
+type Actor struct {
+	UserID int64
+	Role   string
+}
+
+// SystemActor — изменения без пользователя (callbacks, фоновые задачи)
+var SystemActor = Actor{Role: ActorSystem}
+
+// bookingTransitions — единственная таблица допустимых переходов статуса.
+// Все точки входа (handlers, manager, payment, sweeper) идут через неё.
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	BookingHeld:      {BookingPending, BookingCancelled},
+	BookingPending:   {BookingConfirmed, BookingCancelled, BookingRescheduled},
+	BookingConfirmed: {BookingCompleted, BookingCancelled, BookingNoShow, BookingRescheduled},
+	// перенос идёт через rescheduled и в той же транзакции возвращает бронь
+	// в прежний статус: в истории остаётся отметка, слот не освобождается
+	BookingRescheduled: {BookingPending, BookingConfirmed},
+	// completed, cancelled, no_show — конечные
+}
+
+// slotReleasingStatuses — статусы, в которых бронь не занимает слот
+var slotReleasingStatuses = []string{string(BookingCancelled)}
+
+// CanTransition сообщает, разрешён ли переход from → to
+func CanTransition(from, to BookingStatus) bool {
+	for _, s := range bookingTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal — из статуса нет переходов
+func (s BookingStatus) IsTerminal() bool {
+	return len(bookingTransitions[s]) == 0
+}
+
+// BookingStatusHistory — запись аудита смены статуса
+type BookingStatusHistory struct {
+	ID         int64         `json:"id"`
+	BookingID  int64         `json:"booking_id" gorm:"index;not null"`
+	FromStatus BookingStatus `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   BookingStatus `json:"to_status" gorm:"type:varchar(20);not null"`
+	ActorID    *int64        `json:"actor_id,omitempty"`
+	ActorRole  string        `json:"actor_role" gorm:"type:varchar(20);not null"`
+	Reason     string        `json:"reason,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+func (BookingStatusHistory) TableName() string { return "booking_status_history" }
+
+// TransitionStatus меняет статус брони по таблице переходов и пишет историю
+func (s *Service) TransitionStatus(ctx context.Context, bookingID int64, to BookingStatus, actor Actor, reason string) (*Booking, error) {
+	b, err := s.bookings.TransitionStatus(ctx, bookingID, to, actor, reason)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetStatusHistory возвращает историю статусов брони.
+// Доступно клиенту брони, владельцу студии и администратору.
+func (s *Service) GetStatusHistory(ctx context.Context, bookingID, userID int64, role string) ([]BookingStatusHistory, error) {
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if role != ActorAdmin && b.UserID != userID {
+		isOwner, err := s.IsBookingStudioOwner(ctx, userID, bookingID)
+		if err != nil {
+			return nil, err
+		}
+		if !isOwner {
+			return nil, ErrForbidden
+		}
+	}
+
+	return s.bookings.GetStatusHistory(ctx, bookingID)
+}
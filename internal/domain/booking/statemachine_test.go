@@ -0,0 +1,75 @@
+package booking
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCanTransition(t *testing.T) {
+	cases := []struct {
+		from, to BookingStatus
+		ok       bool
+	}{
+		{BookingHeld, BookingPending, true},
+		{BookingPending, BookingConfirmed, true},
+		{BookingPending, BookingCompleted, false},
+		{BookingConfirmed, BookingNoShow, true},
+		{BookingConfirmed, BookingRescheduled, true},
+		{BookingPending, BookingRescheduled, true},
+		{BookingCompleted, BookingRescheduled, false},
+		{BookingCompleted, BookingCancelled, false},
+		{BookingCancelled, BookingPending, false},
+		{BookingNoShow, BookingCompleted, false},
+	}
+	for _, c := range cases {
+		if got := CanTransition(c.from, c.to); got != c.ok {
+			t.Errorf("%s -> %s: want %v, got %v", c.from, c.to, c.ok, got)
+		}
+	}
+	for _, terminal := range []BookingStatus{BookingCompleted, BookingCancelled, BookingNoShow} {
+		if !terminal.IsTerminal() {
+			t.Errorf("%s must be terminal", terminal)
+		}
+	}
+}
+
+func TestTransitionStatus_WritesHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := newHoldTestRepo(t)
+
+	start := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Hour)
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(time.Hour),
+		Status: BookingPending, PaymentStatus: PaymentUnpaid}
+	if err := repo.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	owner := Actor{UserID: 3, Role: ActorOwner}
+	if _, err := repo.TransitionStatus(ctx, b.ID, BookingConfirmed, owner, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.TransitionStatus(ctx, b.ID, BookingPending, owner, ""); err != ErrInvalidStatusTransition {
+		t.Fatalf("confirmed -> pending must be rejected, got %v", err)
+	}
+	updated, err := repo.TransitionStatus(ctx, b.ID, BookingCancelled, Actor{UserID: 7, Role: ActorClient}, "plans changed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != BookingCancelled || updated.CancelledAt == nil {
+		t.Fatalf("unexpected booking after cancel: %+v", updated)
+	}
+
+	history, err := repo.GetStatusHistory(ctx, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 history rows (rejected transition is not recorded), got %d", len(history))
+	}
+	last := history[1]
+	if last.FromStatus != BookingConfirmed || last.ToStatus != BookingCancelled ||
+		last.ActorRole != ActorClient || last.ActorID == nil || *last.ActorID != 7 || last.Reason != "plans changed" {
+		t.Fatalf("unexpected history row: %+v", last)
+	}
+}
@@ -0,0 +1,330 @@
+package booking
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"photostudio/internal/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WaitlistStatus — состояние записи в листе ожидания
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting   WaitlistStatus = "waiting"   // ждёт освобождения окна
+	WaitlistOffered   WaitlistStatus = "offered"   // окно освободилось, выдана ссылка на бронь
+	WaitlistClaimed   WaitlistStatus = "claimed"   // клиент забронировал окно по ссылке
+	WaitlistExpired   WaitlistStatus = "expired"   // ссылка или само окно истекли
+	WaitlistCancelled WaitlistStatus = "cancelled" // клиент вышел из очереди
+)
+
+// WaitlistEntry — интерес клиента к занятому окну комнаты.
+// Очередь по комнате упорядочена по времени записи.
+type WaitlistEntry struct {
+	ID             int64          `json:"id"`
+	RoomID         int64          `json:"room_id" gorm:"not null;index:idx_booking_waitlist_room_window"`
+	StudioID       int64          `json:"studio_id" gorm:"not null"`
+	UserID         int64          `json:"user_id" gorm:"not null;index"`
+	StartTime      time.Time      `json:"start_time" gorm:"not null;index:idx_booking_waitlist_room_window"`
+	EndTime        time.Time      `json:"end_time" gorm:"not null"`
+	Status         WaitlistStatus `json:"status" gorm:"type:varchar(20);not null;default:waiting"`
+	ClaimToken     *string        `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	OfferExpiresAt *time.Time     `json:"offer_expires_at,omitempty"`
+	BookingID      *int64         `json:"booking_id,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+func (WaitlistEntry) TableName() string { return "booking_waitlist" }
+
+// JoinWaitlistRequest — запрос на постановку в лист ожидания
+type JoinWaitlistRequest struct {
+	RoomID    int64     `json:"room_id" binding:"required"`
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+}
+
+// WaitlistConfig — настройки листа ожидания
+type WaitlistConfig struct {
+	ClaimTTL      time.Duration // Сколько действует ссылка на освободившийся слот (default: 30m)
+	SweepInterval time.Duration // Как часто передавать просроченные предложения дальше (default: 1m)
+	EnableSweeper bool
+}
+
+// DefaultWaitlistConfig возвращает настройки листа ожидания; TTL и интервал можно
+// переопределить через BOOKING_WAITLIST_CLAIM_TTL и BOOKING_WAITLIST_SWEEP_INTERVAL
+func DefaultWaitlistConfig() WaitlistConfig {
+	return WaitlistConfig{
+		ClaimTTL:      utils.DurationFromEnv("BOOKING_WAITLIST_CLAIM_TTL", 30*time.Minute),
+		SweepInterval: utils.DurationFromEnv("BOOKING_WAITLIST_SWEEP_INTERVAL", time.Minute),
+		EnableSweeper: true,
+	}
+}
+
+// waitlistClaimPath — ссылка на забор слота, которая уходит клиенту в уведомлении
+func waitlistClaimPath(token string) string {
+	return fmt.Sprintf("/api/v1/waitlist/claim/%s", token)
+}
+
+func newClaimToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// JoinWaitlist ставит клиента в очередь на занятое окно комнаты.
+// Если окно свободно, бронировать нужно напрямую — возвращается ErrSlotAvailable.
+// Повторная запись на то же окно возвращает уже существующую.
+func (s *Service) JoinWaitlist(ctx context.Context, userID int64, req JoinWaitlistRequest) (*WaitlistEntry, error) {
+	if !req.EndTime.After(req.StartTime) || req.StartTime.Before(time.Now()) {
+		return nil, ErrValidation
+	}
+	room, err := s.rooms.GetByID(ctx, req.RoomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := s.validateWithinWorkingHours(ctx, req.RoomID, req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+
+	free, err := s.bookings.CheckAvailability(ctx, req.RoomID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if free {
+		return nil, ErrSlotAvailable
+	}
+
+	existing, err := s.bookings.FindActiveWaitlistEntry(ctx, userID, req.RoomID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	entry := &WaitlistEntry{
+		RoomID:    req.RoomID,
+		StudioID:  room.StudioID,
+		UserID:    userID,
+		StartTime: req.StartTime.UTC(),
+		EndTime:   req.EndTime.UTC(),
+		Status:    WaitlistWaiting,
+	}
+	if err := s.bookings.CreateWaitlistEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetMyWaitlist возвращает записи клиента в листах ожидания
+func (s *Service) GetMyWaitlist(ctx context.Context, userID int64) ([]WaitlistEntry, error) {
+	return s.bookings.ListWaitlistByUser(ctx, userID)
+}
+
+// LeaveWaitlist убирает клиента из очереди. Если ему уже было предложено окно,
+// предложение уходит следующему.
+func (s *Service) LeaveWaitlist(ctx context.Context, userID, entryID int64) error {
+	entry, err := s.bookings.GetWaitlistEntry(ctx, entryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if entry.UserID != userID {
+		return ErrForbidden
+	}
+	if entry.Status != WaitlistWaiting && entry.Status != WaitlistOffered {
+		return ErrInvalidStatusTransition
+	}
+
+	ok, err := s.bookings.SetWaitlistStatus(ctx, entry.ID, entry.Status, WaitlistCancelled, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidStatusTransition
+	}
+	if entry.Status == WaitlistOffered {
+		s.offerWaitlist(ctx, entry.RoomID, entry.StartTime, entry.EndTime)
+	}
+	return nil
+}
+
+// ClaimWaitlist бронирует окно по ссылке из уведомления.
+// Ссылка одноразовая и действует до OfferExpiresAt.
+func (s *Service) ClaimWaitlist(ctx context.Context, userID int64, token string) (*Booking, error) {
+	entry, err := s.bookings.GetWaitlistEntryByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if entry.UserID != userID {
+		return nil, ErrForbidden
+	}
+	if entry.Status != WaitlistOffered {
+		return nil, ErrWaitlistOfferExpired
+	}
+	if entry.OfferExpiresAt == nil || !entry.OfferExpiresAt.After(time.Now()) {
+		// sweeper ещё не успел — отдаём окно следующему сами
+		if ok, err := s.bookings.SetWaitlistStatus(ctx, entry.ID, WaitlistOffered, WaitlistExpired, nil); err == nil && ok {
+			s.offerWaitlist(ctx, entry.RoomID, entry.StartTime, entry.EndTime)
+		}
+		return nil, ErrWaitlistOfferExpired
+	}
+
+	// переводим запись до создания брони, чтобы ссылкой нельзя было воспользоваться дважды
+	ok, err := s.bookings.SetWaitlistStatus(ctx, entry.ID, WaitlistOffered, WaitlistClaimed, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrWaitlistOfferExpired
+	}
+
+	b, err := s.CreateBooking(ctx, CreateBookingRequest{
+		RoomID:    entry.RoomID,
+		StudioID:  entry.StudioID,
+		UserID:    entry.UserID,
+		StartTime: entry.StartTime,
+		EndTime:   entry.EndTime,
+	})
+	if err != nil {
+		// окно снова заняли в обход очереди — предложение больше не имеет смысла
+		next := WaitlistOffered
+		if errors.Is(err, ErrNotAvailable) || errors.Is(err, ErrOverbooking) {
+			next = WaitlistExpired
+		}
+		_, _ = s.bookings.SetWaitlistStatus(ctx, entry.ID, WaitlistClaimed, next, nil)
+		return nil, err
+	}
+
+	if _, err := s.bookings.SetWaitlistStatus(ctx, entry.ID, WaitlistClaimed, WaitlistClaimed, &b.ID); err != nil {
+		log.Printf("waitlist: failed to link booking %d to entry %d: %v", b.ID, entry.ID, err)
+	}
+	return b, nil
+}
+
+// offerWaitlist предлагает освободившееся окно [start, end) очереди комнаты.
+// Записи обходятся по порядку: окно получает первый клиент, чьё желаемое время
+// теперь свободно и не пересекается с уже действующими предложениями.
+// Ошибки только логируются — отмена брони не должна от них падать.
+func (s *Service) offerWaitlist(ctx context.Context, roomID int64, start, end time.Time) {
+	entries, err := s.bookings.ListWaitlistForWindow(ctx, roomID, start, end)
+	if err != nil {
+		log.Printf("waitlist: failed to load queue for room %d: %v", roomID, err)
+		return
+	}
+
+	now := time.Now()
+	var offered []TimeSlot
+	overlapsOffered := func(e WaitlistEntry) bool {
+		for _, o := range offered {
+			if e.StartTime.Before(o.End) && e.EndTime.After(o.Start) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, e := range entries {
+		if e.Status == WaitlistOffered {
+			if e.OfferExpiresAt != nil && e.OfferExpiresAt.After(now) {
+				offered = append(offered, TimeSlot{Start: e.StartTime, End: e.EndTime})
+			}
+			continue
+		}
+		if !e.StartTime.After(now) || overlapsOffered(e) {
+			continue
+		}
+		free, err := s.bookings.CheckAvailability(ctx, e.RoomID, e.StartTime, e.EndTime)
+		if err != nil {
+			log.Printf("waitlist: availability check for entry %d failed: %v", e.ID, err)
+			return
+		}
+		if !free {
+			continue
+		}
+
+		token, err := newClaimToken()
+		if err != nil {
+			log.Printf("waitlist: failed to generate claim token: %v", err)
+			return
+		}
+		expiresAt := now.Add(s.waitlistClaimTTL).UTC()
+		ok, err := s.bookings.OfferWaitlistEntry(ctx, e.ID, token, expiresAt)
+		if err != nil {
+			log.Printf("waitlist: failed to offer entry %d: %v", e.ID, err)
+			return
+		}
+		if !ok {
+			continue
+		}
+		offered = append(offered, TimeSlot{Start: e.StartTime, End: e.EndTime})
+
+		if s.notifs != nil {
+			_ = s.notifs.NotifyWaitlistSlotAvailable(ctx, e.UserID, e.ID, e.StudioID, e.RoomID, e.StartTime, waitlistClaimPath(token), expiresAt)
+		}
+	}
+}
+
+// ProcessWaitlist закрывает просроченные предложения и передаёт окна следующим в очереди
+func (s *Service) ProcessWaitlist(ctx context.Context) (int, error) {
+	expired, err := s.bookings.ExpireWaitlistOffers(ctx, time.Now().UTC())
+	if err != nil {
+		log.Printf("Error expiring waitlist offers: %v", err)
+		return 0, err
+	}
+	for _, e := range expired {
+		s.offerWaitlist(ctx, e.RoomID, e.StartTime, e.EndTime)
+	}
+	if len(expired) > 0 {
+		log.Printf("Passed on %d expired waitlist offers", len(expired))
+	}
+	return len(expired), nil
+}
+
+// ScheduleWaitlistSweeper запускает фоновую передачу просроченных предложений
+func (s *Service) ScheduleWaitlistSweeper(ctx context.Context, config WaitlistConfig) chan struct{} {
+	if !config.EnableSweeper {
+		log.Println("Waitlist sweeper is disabled")
+		return nil
+	}
+
+	stopCh := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(config.SweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = s.ProcessWaitlist(ctx)
+			case <-stopCh:
+				log.Println("Waitlist sweeper stopped")
+				return
+			case <-ctx.Done():
+				log.Println("Waitlist sweeper stopped (context Done)")
+				return
+			}
+		}
+	}()
+
+	log.Printf("Waitlist sweeper started with interval %v (claim ttl %v)", config.SweepInterval, config.ClaimTTL)
+	return stopCh
+}
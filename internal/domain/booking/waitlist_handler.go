@@ -0,0 +1,150 @@
+package booking
+
+import (
+	"errors"
+	"net/http"
+	"photostudio/internal/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JoinWaitlist ставит клиента в лист ожидания занятого окна
+// @Summary		Встать в лист ожидания
+// @Description	Записывает интерес к занятому окну комнаты. Когда бронь в этом окне отменяют, клиенты из очереди по порядку получают уведомление со ссылкой на бронь, действующей ограниченное время (BOOKING_WAITLIST_CLAIM_TTL, по умолчанию 30 минут). Если окно свободно — возвращается 409 SLOT_AVAILABLE, бронируйте напрямую.
+// @Tags		Бронирования - Лист ожидания
+// @Security	BearerAuth
+// @Param		body body JoinWaitlistRequest true "Комната и желаемое окно"
+// @Success		201 {object} WaitlistEntry "Запись в листе ожидания"
+// @Failure		400 {object} map[string]interface{} "Ошибка валидации или нерабочее время"
+// @Failure		401 {object} map[string]interface{} "Ошибка аутентификации"
+// @Failure		404 {object} map[string]interface{} "Комната не найдена"
+// @Failure		409 {object} map[string]interface{} "Окно свободно"
+// @Router		/waitlist [post]
+func (h *Handler) JoinWaitlist(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID <= 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Missing auth")
+		return
+	}
+
+	var req JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	entry, err := h.service.JoinWaitlist(c.Request.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, ErrSlotAvailable) {
+			response.CustomError(c, http.StatusConflict, "SLOT_AVAILABLE", "The slot is free, book it directly")
+			return
+		}
+		writeCreateBookingError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, entry)
+}
+
+// GetMyWaitlist возвращает записи текущего пользователя в листах ожидания
+// @Summary		Мои листы ожидания
+// @Tags		Бронирования - Лист ожидания
+// @Security	BearerAuth
+// @Success		200 {array} WaitlistEntry
+// @Failure		401 {object} map[string]interface{} "Ошибка аутентификации"
+// @Router		/users/me/waitlist [get]
+func (h *Handler) GetMyWaitlist(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID <= 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Missing auth")
+		return
+	}
+
+	entries, err := h.service.GetMyWaitlist(c.Request.Context(), userID)
+	if err != nil {
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"entries": entries})
+}
+
+// LeaveWaitlist убирает запись из листа ожидания
+// @Summary		Выйти из листа ожидания
+// @Description	Если клиенту уже было предложено окно, предложение переходит следующему в очереди.
+// @Tags		Бронирования - Лист ожидания
+// @Security	BearerAuth
+// @Param		id path integer true "ID записи"
+// @Success		200 {object} map[string]interface{}
+// @Failure		400 {object} map[string]interface{} "Запись уже закрыта"
+// @Failure		403 {object} map[string]interface{} "Чужая запись"
+// @Failure		404 {object} map[string]interface{} "Запись не найдена"
+// @Router		/waitlist/{id} [delete]
+func (h *Handler) LeaveWaitlist(c *gin.Context) {
+	entryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || entryID <= 0 {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid waitlist entry id")
+		return
+	}
+
+	err = h.service.LeaveWaitlist(c.Request.Context(), c.GetInt64("user_id"), entryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Waitlist entry not found")
+		case errors.Is(err, ErrForbidden):
+			response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Cannot leave this waitlist entry")
+		case errors.Is(err, ErrInvalidStatusTransition):
+			response.CustomError(c, http.StatusBadRequest, "INVALID_STATUS", "Waitlist entry is already closed")
+		default:
+			response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		}
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"left": true})
+}
+
+// ClaimWaitlist бронирует освободившееся окно по ссылке из уведомления
+// @Summary		Забрать окно из листа ожидания
+// @Description	Создаёт бронь на окно из записи листа ожидания. Ссылка одноразовая и действует до claim_expires_at из уведомления; после этого окно предлагается следующему клиенту.
+// @Tags		Бронирования - Лист ожидания
+// @Security	BearerAuth
+// @Param		token path string true "Токен из ссылки"
+// @Success		201 {object} map[string]interface{} "Бронь создана"
+// @Failure		403 {object} map[string]interface{} "Ссылка выдана другому клиенту"
+// @Failure		404 {object} map[string]interface{} "Ссылка не найдена"
+// @Failure		409 {object} map[string]interface{} "Окно уже занято"
+// @Failure		410 {object} map[string]interface{} "Ссылка истекла или уже использована"
+// @Router		/waitlist/claim/{token} [post]
+func (h *Handler) ClaimWaitlist(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID <= 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Missing auth")
+		return
+	}
+
+	b, err := h.service.ClaimWaitlist(c.Request.Context(), userID, c.Param("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrWaitlistOfferExpired):
+			response.CustomError(c, http.StatusGone, "OFFER_EXPIRED", "The claim link has expired or was already used")
+		case errors.Is(err, ErrForbidden):
+			response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "This claim link belongs to another user")
+		case errors.Is(err, ErrNotFound):
+			response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Claim link not found")
+		default:
+			writeCreateBookingError(c, err)
+		}
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{
+		"booking": gin.H{
+			"id":          b.ID,
+			"status":      b.Status,
+			"total_price": b.TotalPrice,
+		},
+	})
+}
@@ -0,0 +1,201 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitlistNotifs запоминает выданные ссылки листа ожидания
+type waitlistNotifs struct {
+	offers map[int64]string // user_id -> claim URL
+}
+
+func (n *waitlistNotifs) NotifyBookingCreated(ctx context.Context, ownerUserID, bookingID, studioID, roomID int64, start time.Time) error {
+	return nil
+}
+
+func (n *waitlistNotifs) NotifyBookingConfirmed(ctx context.Context, clientUserID, bookingID, studioID int64) error {
+	return nil
+}
+
+func (n *waitlistNotifs) NotifyBookingCancelled(ctx context.Context, clientUserID, bookingID, studioID int64, reason string) error {
+	return nil
+}
+
+func (n *waitlistNotifs) NotifyBookingCompleted(ctx context.Context, userID, bookingID, studioID int64) error {
+	return nil
+}
+
+func (n *waitlistNotifs) NotifyBookingNoShow(ctx context.Context, userID, bookingID, studioID int64) error {
+	return nil
+}
+
+func (n *waitlistNotifs) NotifyBookingReminder(ctx context.Context, userID, bookingID, studioID int64, start time.Time, hoursBefore int) error {
+	return nil
+}
+
+func (n *waitlistNotifs) NotifyBookingRescheduled(ctx context.Context, userID, bookingID, studioID int64, oldStart, newStart time.Time) error {
+	return nil
+}
+
+func (n *waitlistNotifs) NotifyEquipmentBooked(ctx context.Context, ownerID, equipmentID, bookingID int64, equipmentName string) error {
+	return nil
+}
+
+func (n *waitlistNotifs) NotifyWaitlistSlotAvailable(ctx context.Context, userID, entryID, studioID, roomID int64, start time.Time, claimURL string, expiresAt time.Time) error {
+	n.offers[userID] = claimURL
+	return nil
+}
+
+func claimToken(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+func TestWaitlist_OfferInOrderAndClaim(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newScheduleTestService(t)
+	if err := svc.bookings.DB().AutoMigrate(&WaitlistEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	notifs := &waitlistNotifs{offers: map[int64]string{}}
+	svc.notifs = notifs
+
+	day := nextWeekday(time.Tuesday)
+	start, end := day.Add(12*time.Hour), day.Add(14*time.Hour)
+	b, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := JoinWaitlistRequest{RoomID: 1, StartTime: start, EndTime: end}
+	first, err := svc.JoinWaitlist(ctx, 8, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := svc.JoinWaitlist(ctx, 8, req); err != nil || again.ID != first.ID {
+		t.Fatalf("repeated join must return the same entry, got %+v err=%v", again, err)
+	}
+	if _, err := svc.JoinWaitlist(ctx, 9, req); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svc.JoinWaitlist(ctx, 9, JoinWaitlistRequest{RoomID: 1, StartTime: end.Add(time.Hour), EndTime: end.Add(2 * time.Hour)})
+	if !errors.Is(err, ErrSlotAvailable) {
+		t.Fatalf("free slot: expected ErrSlotAvailable, got %v", err)
+	}
+
+	if _, err := svc.CancelBooking(ctx, b.ID, Actor{UserID: 7, Role: ActorClient}, "plans changed"); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifs.offers) != 1 || notifs.offers[8] == "" {
+		t.Fatalf("only the first in line must get the offer, got %+v", notifs.offers)
+	}
+
+	// чужая ссылка не работает
+	if _, err := svc.ClaimWaitlist(ctx, 9, claimToken(notifs.offers[8])); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	claimed, err := svc.ClaimWaitlist(ctx, 8, claimToken(notifs.offers[8]))
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if claimed.UserID != 8 || !claimed.StartTime.Equal(start) {
+		t.Fatalf("unexpected booking from claim: %+v", claimed)
+	}
+	if _, err := svc.ClaimWaitlist(ctx, 8, claimToken(notifs.offers[8])); !errors.Is(err, ErrWaitlistOfferExpired) {
+		t.Fatalf("claim link must be single-use, got %v", err)
+	}
+
+	entry, _ := svc.bookings.GetWaitlistEntry(ctx, first.ID)
+	if entry.Status != WaitlistClaimed || entry.BookingID == nil || *entry.BookingID != claimed.ID {
+		t.Fatalf("entry must be claimed and linked to the booking: %+v", entry)
+	}
+}
+
+func TestWaitlist_ExpiredOfferPassesToNext(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newScheduleTestService(t)
+	if err := svc.bookings.DB().AutoMigrate(&WaitlistEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	notifs := &waitlistNotifs{offers: map[int64]string{}}
+	svc.notifs = notifs
+
+	day := nextWeekday(time.Tuesday)
+	start, end := day.Add(12*time.Hour), day.Add(14*time.Hour)
+	b, err := svc.CreateBooking(ctx, CreateBookingRequest{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []int64{8, 9} {
+		if _, err := svc.JoinWaitlist(ctx, userID, JoinWaitlistRequest{RoomID: 1, StartTime: start, EndTime: end}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc.waitlistClaimTTL = -time.Minute // ссылка истекает сразу
+	if _, err := svc.CancelBooking(ctx, b.ID, Actor{UserID: 7, Role: ActorClient}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if notifs.offers[8] == "" {
+		t.Fatal("first in line must be notified")
+	}
+
+	svc.waitlistClaimTTL = 30 * time.Minute
+	n, err := svc.ProcessWaitlist(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("expected one expired offer, got n=%d err=%v", n, err)
+	}
+	if notifs.offers[9] == "" {
+		t.Fatal("expired offer must pass to the next in line")
+	}
+	if _, err := svc.ClaimWaitlist(ctx, 8, claimToken(notifs.offers[8])); !errors.Is(err, ErrWaitlistOfferExpired) {
+		t.Fatalf("expected ErrWaitlistOfferExpired, got %v", err)
+	}
+	if _, err := svc.ClaimWaitlist(ctx, 9, claimToken(notifs.offers[9])); err != nil {
+		t.Fatalf("next in line claim: %v", err)
+	}
+}
+
+func TestUpdateBookingStatus_OwnerCancelReleasesSlot(t *testing.T) {
+	ctx := context.Background()
+	svc := newGroupTestService(t)
+	if err := svc.bookings.DB().AutoMigrate(&WaitlistEntry{}, &BookingReminder{}); err != nil {
+		t.Fatal(err)
+	}
+	notifs := &waitlistNotifs{offers: map[int64]string{}}
+	svc.notifs = notifs
+
+	start := nextWeekday(time.Tuesday).Add(12 * time.Hour)
+	b := &Booking{RoomID: 1, StudioID: 1, UserID: 7, StartTime: start, EndTime: start.Add(2 * time.Hour),
+		Status: BookingConfirmed, PaymentStatus: PaymentUnpaid}
+	if err := svc.bookings.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.processReminders(ctx, start.Add(-30*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	var scheduled int64
+	svc.bookings.DB().Model(&BookingReminder{}).Where("booking_id = ? AND status = ?", b.ID, ReminderScheduled).Count(&scheduled)
+	if scheduled == 0 {
+		t.Fatal("expected reminders planned for the confirmed booking")
+	}
+	if _, err := svc.JoinWaitlist(ctx, 8, JoinWaitlistRequest{RoomID: 1, StartTime: start, EndTime: start.Add(2 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// отмена студией снимает напоминания и отдаёт окно листу ожидания, как отмена клиентом
+	if _, err := svc.UpdateBookingStatus(ctx, b.ID, 100, ActorOwner, string(BookingCancelled)); err != nil {
+		t.Fatal(err)
+	}
+	svc.bookings.DB().Model(&BookingReminder{}).Where("booking_id = ? AND status = ?", b.ID, ReminderScheduled).Count(&scheduled)
+	if scheduled != 0 {
+		t.Fatalf("expected reminders of the cancelled booking removed, got %d scheduled", scheduled)
+	}
+	if notifs.offers[8] == "" {
+		t.Fatal("the freed slot must be offered to the waitlist")
+	}
+}
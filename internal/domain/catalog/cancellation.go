@@ -0,0 +1,70 @@
+package catalog
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrInvalidCancellationPolicy = errors.New("invalid cancellation policy")
+
+// maxCancellationTiers — больше ступеней на практике не нужно
+const maxCancellationTiers = 10
+
+// CancellationTier — «отмена не позднее чем за HoursBefore часов до начала
+// возвращает RefundPercent% оплаченного»
+type CancellationTier struct {
+	HoursBefore   int `json:"hours_before"`
+	RefundPercent int `json:"refund_percent"`
+}
+
+// CancellationPolicy — правила возврата при отмене брони клиентом.
+// Например: {48, 100}, {24, 50} — бесплатно до 48ч, 50% до 24ч, позже без возврата.
+type CancellationPolicy struct {
+	Tiers []CancellationTier `json:"tiers"`
+}
+
+// DefaultCancellationPolicy — полный возврат при отмене до начала брони
+// (поведение до появления политик)
+func DefaultCancellationPolicy() *CancellationPolicy {
+	return &CancellationPolicy{Tiers: []CancellationTier{{HoursBefore: 0, RefundPercent: 100}}}
+}
+
+// Normalize проверяет ступени и сортирует их от самой ранней отмены к поздней
+func (p *CancellationPolicy) Normalize() error {
+	if len(p.Tiers) == 0 || len(p.Tiers) > maxCancellationTiers {
+		return ErrInvalidCancellationPolicy
+	}
+	seen := make(map[int]bool, len(p.Tiers))
+	for _, t := range p.Tiers {
+		if t.HoursBefore < 0 || t.RefundPercent < 0 || t.RefundPercent > 100 || seen[t.HoursBefore] {
+			return ErrInvalidCancellationPolicy
+		}
+		seen[t.HoursBefore] = true
+	}
+	sort.Slice(p.Tiers, func(i, j int) bool { return p.Tiers[i].HoursBefore > p.Tiers[j].HoursBefore })
+	return nil
+}
+
+// RefundPercent возвращает процент возврата при отмене за hoursBefore часов до начала.
+// Если ни одна ступень не подходит (слишком поздно) — 0.
+func (p *CancellationPolicy) RefundPercent(hoursBefore float64) int {
+	if p == nil {
+		p = DefaultCancellationPolicy()
+	}
+	percent, threshold := 0, -1
+	for _, t := range p.Tiers {
+		// подходит ступень с наибольшим порогом, который ещё не прошёл
+		if hoursBefore >= float64(t.HoursBefore) && t.HoursBefore > threshold {
+			percent, threshold = t.RefundPercent, t.HoursBefore
+		}
+	}
+	return percent
+}
+
+// EffectiveCancellationPolicy — политика студии или политика по умолчанию
+func (s *Studio) EffectiveCancellationPolicy() *CancellationPolicy {
+	if s.CancellationPolicy == nil || len(s.CancellationPolicy.Tiers) == 0 {
+		return DefaultCancellationPolicy()
+	}
+	return s.CancellationPolicy
+}
@@ -0,0 +1,52 @@
+package catalog
+
+import "testing"
+
+func TestCancellationPolicy_RefundPercent(t *testing.T) {
+	p := &CancellationPolicy{Tiers: []CancellationTier{
+		{HoursBefore: 24, RefundPercent: 50},
+		{HoursBefore: 48, RefundPercent: 100},
+	}}
+	if err := p.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Tiers[0].HoursBefore != 48 {
+		t.Fatalf("tiers must be sorted by hours_before desc, got %+v", p.Tiers)
+	}
+
+	cases := []struct {
+		hours float64
+		want  int
+	}{
+		{72, 100},
+		{48, 100},
+		{47.5, 50},
+		{24, 50},
+		{23, 0},
+		{-1, 0},
+	}
+	for _, c := range cases {
+		if got := p.RefundPercent(c.hours); got != c.want {
+			t.Errorf("RefundPercent(%v) = %d, want %d", c.hours, got, c.want)
+		}
+	}
+
+	var none *CancellationPolicy
+	if got := none.RefundPercent(1); got != 100 {
+		t.Fatalf("default policy must refund everything before start, got %d", got)
+	}
+}
+
+func TestCancellationPolicy_Normalize_Rejects(t *testing.T) {
+	bad := []CancellationPolicy{
+		{},
+		{Tiers: []CancellationTier{{HoursBefore: 24, RefundPercent: 150}}},
+		{Tiers: []CancellationTier{{HoursBefore: -1, RefundPercent: 50}}},
+		{Tiers: []CancellationTier{{HoursBefore: 24, RefundPercent: 50}, {HoursBefore: 24, RefundPercent: 100}}},
+	}
+	for i, p := range bad {
+		if err := p.Normalize(); err != ErrInvalidCancellationPolicy {
+			t.Errorf("case %d: expected ErrInvalidCancellationPolicy, got %v", i, err)
+		}
+	}
+}
@@ -18,6 +18,11 @@ type CreateRoomRequest struct {
 	PricePerHourMax *float64 `json:"price_per_hour_max,omitempty"`
 	Amenities       []string `json:"amenities,omitempty"`
 	Photos          []string `json:"photos"`
+
+	BufferBeforeMinutes int `json:"buffer_before_minutes,omitempty"` // 0–240
+	BufferAfterMinutes  int `json:"buffer_after_minutes,omitempty"`  // 0–240
+
+	PricingRules *PricingRules `json:"pricing_rules,omitempty"`
 }
 
 // ---------- EQUIPMENT ----------
@@ -42,7 +47,11 @@ type CreateStudioRequest struct {
 	Phone        string                 `json:"phone"`
 	Email        string                 `json:"email"`
 	Website      string                 `json:"website"`
+	Timezone     string                 `json:"timezone,omitempty"` // IANA, по умолчанию Asia/Almaty
 	WorkingHours WorkingHoursMap `json:"working_hours,omitempty"`
+
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy,omitempty"` // nil — полный возврат до начала
+	ReminderSchedule   *ReminderSchedule   `json:"reminder_schedule,omitempty"`   // nil — напоминания за 24ч и 2ч
 }
 
 // ---------- STUDIO UPDATE ----------
@@ -57,6 +66,10 @@ type UpdateStudioRequest struct {
 	Website      string                 `json:"website"`
 	WorkingHours WorkingHoursMap `gorm:"type:jsonb" json:"working_hours,omitempty"`
 	District     string                 `json:"district,omitempty"`
+	Timezone     string                 `json:"timezone,omitempty"` // пусто — не менять
+
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy,omitempty"` // nil — не менять
+	ReminderSchedule   *ReminderSchedule   `json:"reminder_schedule,omitempty"`   // nil — не менять, [] — выключить
 }
 
 type UpdateRoomRequest struct {
@@ -69,6 +82,11 @@ type UpdateRoomRequest struct {
 	PricePerHourMax *float64  `json:"price_per_hour_max,omitempty"`
 	Amenities       *[]string `json:"amenities,omitempty"`
 	Photos          *[]string `json:"photos,omitempty"`
+
+	BufferBeforeMinutes *int `json:"buffer_before_minutes,omitempty"`
+	BufferAfterMinutes  *int `json:"buffer_after_minutes,omitempty"`
+
+	PricingRules *PricingRules `json:"pricing_rules,omitempty"` // nil — не менять
 }
 
 // ---------- WORKING HOURS ----------
@@ -76,6 +94,8 @@ type UpdateRoomRequest struct {
 // WorkingHoursResponse — ответ с часами работы
 type WorkingHoursResponse struct {
 	StudioID     int64                 `json:"studio_id"`
+	Timezone     string                `json:"timezone"`
+	UTCOffset    string                `json:"utc_offset"` // "+05:00"
 	Hours        []WorkingHours `json:"hours"`
 	CompactText  string                `json:"compact_text"` // "Пн-Пт: 10:00-20:00"
 	IsOpenNow    bool                  `json:"is_open_now"`
@@ -121,9 +141,9 @@ func FormatCompactHours(hours []WorkingHours) string {
 	return strings.Join(parts, ", ")
 }
 
-// CalculateLiveStatus вычисляет текущий статус (открыто/закрыто)
-func CalculateLiveStatus(hours []WorkingHours) (bool, string, string) {
-	now := time.Now()
+// CalculateLiveStatus вычисляет статус (открыто/закрыто) на момент now.
+// now должен быть переведён в часовой пояс студии.
+func CalculateLiveStatus(hours []WorkingHours, now time.Time) (bool, string, string) {
 	dayOfWeek := int(now.Weekday()) // 0=Вс, 1=Пн, ...
 	currentTime := now.Format("15:04")
 
@@ -111,7 +111,7 @@ func (h *Handler) GetStudios(c *gin.Context) {
 
 // GetStudioByID получение информации о студии по ID
 // @Summary Получить студию по ID
-// @Description Получает полную информацию о студии, включая все комнаты, оборудование и фотографии по уникальному идентификатору.
+// @Description Получает полную информацию о студии, включая все комнаты, оборудование, фотографии и политику отмены (cancellation_policy) по уникальному идентификатору.
 // @Tags Catalog - Студии
 // @Accept json
 // @Produce json
@@ -149,6 +149,9 @@ func (h *Handler) GetStudioByID(c *gin.Context) {
 		handleError(c, err)
 		return
 	}
+	// клиент видит условия возврата до бронирования, даже если владелец их не задавал
+	studio.CancellationPolicy = studio.EffectiveCancellationPolicy()
+	studio.ReminderSchedule = studio.EffectiveReminderSchedule()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -987,6 +990,54 @@ func handleError(c *gin.Context, err error) {
 				"message": "You don't have permission to perform this action",
 			},
 		})
+	case errors.Is(err, ErrInvalidTimezone):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid timezone, expected IANA name like Asia/Almaty",
+			},
+		})
+	case errors.Is(err, ErrInvalidBuffer):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Room buffers must be between 0 and 240 minutes",
+			},
+		})
+	case errors.Is(err, ErrInvalidPricingRules):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid pricing rules",
+			},
+		})
+	case errors.Is(err, ErrInvalidScheduleException):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid schedule exception: expected YYYY-MM-DD dates and open_time before close_time",
+			},
+		})
+	case errors.Is(err, ErrInvalidCancellationPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid cancellation policy: 1-10 tiers, unique hours_before >= 0, refund_percent 0-100",
+			},
+		})
+	case errors.Is(err, ErrInvalidReminderSchedule):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid reminder schedule: up to 5 unique hours_before between 1 and 168",
+			},
+		})
 	default:
 		// Generic server error
 		c.JSON(http.StatusInternalServerError, gin.H{
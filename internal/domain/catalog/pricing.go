@@ -0,0 +1,282 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	ErrInvalidPricingRules = errors.New("invalid pricing rules")
+	ErrBelowMinDuration    = errors.New("booking is shorter than minimum duration")
+)
+
+// Виды строк расчёта цены
+const (
+	PriceLineBase             = "base"
+	PriceLinePeak             = "peak"
+	PriceLineHoliday          = "holiday"
+	PriceLineWeekendSurcharge = "weekend_surcharge"
+	PriceLineDiscount         = "discount"
+	PriceLineEquipment        = "equipment" // аренда оборудования (добавляет booking)
+)
+
+// PeakWindow — пиковые часы в местном времени студии, [From, To)
+type PeakWindow struct {
+	Days         []int    `json:"days,omitempty"`           // 0=Вс, ..., 6=Сб; пусто — каждый день
+	From         string   `json:"from"`                     // "18:00"
+	To           string   `json:"to"`                       // "22:00", допускается "24:00"
+	PricePerHour *float64 `json:"price_per_hour,omitempty"` // nil — Room.PricePerHourMax
+}
+
+// DurationDiscount — скидка Percent% при брони от MinHours часов
+type DurationDiscount struct {
+	MinHours float64 `json:"min_hours"`
+	Percent  int     `json:"percent"`
+}
+
+// HolidayPrice — отдельная цена на весь день (YYYY-MM-DD в зоне студии)
+type HolidayPrice struct {
+	Date         string  `json:"date"`
+	PricePerHour float64 `json:"price_per_hour"`
+}
+
+// PricingRules — правила цены комнаты. Базовая ставка — Room.PricePerHourMin.
+// Праздник важнее пика, надбавка за выходные (Сб/Вс) начисляется поверх
+// базовой и пиковой ставки, скидка за длительность — на всю сумму.
+type PricingRules struct {
+	Peak                    []PeakWindow       `json:"peak,omitempty"`
+	WeekendSurchargePercent int                `json:"weekend_surcharge_percent,omitempty"`
+	MinDurationMinutes      int                `json:"min_duration_minutes,omitempty"`
+	Discounts               []DurationDiscount `json:"discounts,omitempty"`
+	Holidays                []HolidayPrice     `json:"holidays,omitempty"`
+}
+
+// PriceLine — строка расчёта цены
+type PriceLine struct {
+	Kind        string  `json:"kind"`
+	Description string  `json:"description"`
+	Hours       float64 `json:"hours,omitempty"`
+	Rate        float64 `json:"rate,omitempty"`
+	Amount      float64 `json:"amount"`
+}
+
+// PriceQuote — расчёт цены слота с разбивкой по строкам
+type PriceQuote struct {
+	Lines    []PriceLine `json:"lines"`
+	Subtotal float64     `json:"subtotal"`
+	Total    float64     `json:"total"`
+}
+
+// Validate проверяет правила до сохранения в комнату
+func (r *PricingRules) Validate() error {
+	if r.WeekendSurchargePercent < 0 || r.WeekendSurchargePercent > 100 || r.MinDurationMinutes < 0 {
+		return ErrInvalidPricingRules
+	}
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range r.Peak {
+		from, err1 := ClockOnDate(day, p.From, time.UTC)
+		to, err2 := ClockOnDate(day, p.To, time.UTC)
+		if err1 != nil || err2 != nil || !to.After(from) {
+			return ErrInvalidPricingRules
+		}
+		if p.PricePerHour != nil && *p.PricePerHour < 0 {
+			return ErrInvalidPricingRules
+		}
+		for _, d := range p.Days {
+			if d < 0 || d > 6 {
+				return ErrInvalidPricingRules
+			}
+		}
+	}
+	for _, d := range r.Discounts {
+		if d.MinHours <= 0 || d.Percent <= 0 || d.Percent > 100 {
+			return ErrInvalidPricingRules
+		}
+	}
+	for _, h := range r.Holidays {
+		if _, err := time.Parse("2006-01-02", h.Date); err != nil || h.PricePerHour < 0 {
+			return ErrInvalidPricingRules
+		}
+	}
+	return nil
+}
+
+// QuoteRoomPrice считает цену брони комнаты на [start, end) по её правилам.
+// Время делится на отрезки по границам суток и пиковых окон в зоне loc,
+// соседние отрезки с одинаковой ставкой сливаются в одну строку.
+func QuoteRoomPrice(room *Room, start, end time.Time, loc *time.Location) (*PriceQuote, error) {
+	rules := room.PricingRules
+	if rules == nil {
+		rules = &PricingRules{}
+	}
+	if rules.MinDurationMinutes > 0 && end.Sub(start) < time.Duration(rules.MinDurationMinutes)*time.Minute {
+		return nil, ErrBelowMinDuration
+	}
+
+	holidays := make(map[string]float64, len(rules.Holidays))
+	for _, h := range rules.Holidays {
+		holidays[h.Date] = h.PricePerHour
+	}
+
+	quote := &PriceQuote{}
+	var weekendBase float64
+	for _, seg := range priceSegments(rules, start, end, loc) {
+		kind, rate := segmentRate(room, rules, holidays, seg.start.In(loc))
+		hours := seg.end.Sub(seg.start).Hours()
+		amount := hours * rate
+
+		if kind != PriceLineHoliday && isWeekend(seg.start.In(loc)) {
+			weekendBase += amount
+		}
+
+		n := len(quote.Lines)
+		if n > 0 && quote.Lines[n-1].Kind == kind && quote.Lines[n-1].Rate == rate {
+			quote.Lines[n-1].Hours += hours
+			quote.Lines[n-1].Amount += amount
+			continue
+		}
+		quote.Lines = append(quote.Lines, PriceLine{
+			Kind:        kind,
+			Description: priceLineDescription(kind),
+			Hours:       hours,
+			Rate:        rate,
+			Amount:      amount,
+		})
+	}
+	for i := range quote.Lines {
+		quote.Lines[i].Hours = roundMoney(quote.Lines[i].Hours)
+		quote.Lines[i].Amount = roundMoney(quote.Lines[i].Amount)
+	}
+
+	if rules.WeekendSurchargePercent > 0 && weekendBase > 0 {
+		quote.Lines = append(quote.Lines, PriceLine{
+			Kind:        PriceLineWeekendSurcharge,
+			Description: fmt.Sprintf("Надбавка за выходные %d%%", rules.WeekendSurchargePercent),
+			Amount:      roundMoney(weekendBase * float64(rules.WeekendSurchargePercent) / 100),
+		})
+	}
+
+	for _, l := range quote.Lines {
+		quote.Subtotal += l.Amount
+	}
+	quote.Subtotal = roundMoney(quote.Subtotal)
+	quote.Total = quote.Subtotal
+
+	if d := bestDiscount(rules.Discounts, end.Sub(start).Hours()); d != nil {
+		discount := roundMoney(quote.Subtotal * float64(d.Percent) / 100)
+		quote.Lines = append(quote.Lines, PriceLine{
+			Kind:        PriceLineDiscount,
+			Description: fmt.Sprintf("Скидка %d%% от %g ч", d.Percent, d.MinHours),
+			Amount:      -discount,
+		})
+		quote.Total = roundMoney(quote.Subtotal - discount)
+	}
+
+	return quote, nil
+}
+
+type priceSegment struct {
+	start, end time.Time
+}
+
+// priceSegments режет [start, end) по полуночам и границам пиковых окон
+func priceSegments(rules *PricingRules, start, end time.Time, loc *time.Location) []priceSegment {
+	cuts := []time.Time{start, end}
+	add := func(t time.Time) {
+		if t.After(start) && t.Before(end) {
+			cuts = append(cuts, t)
+		}
+	}
+
+	s := start.In(loc)
+	for day := time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, loc); day.Before(end); day = day.AddDate(0, 0, 1) {
+		add(day.AddDate(0, 0, 1))
+		for _, p := range rules.Peak {
+			if from, err := ClockOnDate(day, p.From, loc); err == nil {
+				add(from)
+			}
+			if to, err := ClockOnDate(day, p.To, loc); err == nil {
+				add(to)
+			}
+		}
+	}
+
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i].Before(cuts[j]) })
+	segments := make([]priceSegment, 0, len(cuts)-1)
+	for i := 1; i < len(cuts); i++ {
+		if cuts[i].After(cuts[i-1]) {
+			segments = append(segments, priceSegment{start: cuts[i-1], end: cuts[i]})
+		}
+	}
+	return segments
+}
+
+// segmentRate определяет ставку отрезка по его началу t (в зоне студии)
+func segmentRate(room *Room, rules *PricingRules, holidays map[string]float64, t time.Time) (string, float64) {
+	if price, ok := holidays[t.Format("2006-01-02")]; ok {
+		return PriceLineHoliday, price
+	}
+	for _, p := range rules.Peak {
+		if !peakAppliesOn(p, t.Weekday()) {
+			continue
+		}
+		from, err1 := ClockOnDate(t, p.From, t.Location())
+		to, err2 := ClockOnDate(t, p.To, t.Location())
+		if err1 != nil || err2 != nil || t.Before(from) || !t.Before(to) {
+			continue
+		}
+		switch {
+		case p.PricePerHour != nil:
+			return PriceLinePeak, *p.PricePerHour
+		case room.PricePerHourMax != nil:
+			return PriceLinePeak, *room.PricePerHourMax
+		}
+	}
+	return PriceLineBase, room.PricePerHourMin
+}
+
+func peakAppliesOn(p PeakWindow, wd time.Weekday) bool {
+	if len(p.Days) == 0 {
+		return true
+	}
+	for _, d := range p.Days {
+		if time.Weekday(d) == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// bestDiscount — скидка с наибольшим порогом, который бронь набрала
+func bestDiscount(discounts []DurationDiscount, hours float64) *DurationDiscount {
+	var best *DurationDiscount
+	for i := range discounts {
+		d := &discounts[i]
+		if hours >= d.MinHours && (best == nil || d.MinHours > best.MinHours) {
+			best = d
+		}
+	}
+	return best
+}
+
+func priceLineDescription(kind string) string {
+	switch kind {
+	case PriceLinePeak:
+		return "Пиковые часы"
+	case PriceLineHoliday:
+		return "Праздничный тариф"
+	default:
+		return "Базовый тариф"
+	}
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,82 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuoteRoomPrice(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*3600)
+	peakMax := 1500.0
+	room := &Room{
+		PricePerHourMin: 1000,
+		PricePerHourMax: &peakMax,
+		PricingRules: &PricingRules{
+			Peak:                    []PeakWindow{{Days: []int{1, 2, 3, 4, 5}, From: "18:00", To: "22:00"}},
+			WeekendSurchargePercent: 20,
+			MinDurationMinutes:      60,
+			Discounts:               []DurationDiscount{{MinHours: 4, Percent: 10}},
+			Holidays:                []HolidayPrice{{Date: "2026-03-22", PricePerHour: 3000}},
+		},
+	}
+	at := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, loc) }
+
+	cases := []struct {
+		name       string
+		start, end time.Time
+		wantTotal  float64
+		wantKinds  []string
+	}{
+		// среда: час базовой ставки + час пика по PricePerHourMax
+		{"weekday into peak", at(18, 17), at(18, 19), 2500, []string{PriceLineBase, PriceLinePeak}},
+		// суббота: надбавка 20% отдельной строкой
+		{"weekend surcharge", at(21, 10), at(21, 12), 2400, []string{PriceLineBase, PriceLineWeekendSurcharge}},
+		// праздник важнее выходного, надбавки нет
+		{"holiday", at(22, 10), at(22, 11), 3000, []string{PriceLineHoliday}},
+		// 4 часа: (3×1000 + 1×1500) − 10%
+		{"long booking discount", at(18, 15), at(18, 19), 4050, []string{PriceLineBase, PriceLinePeak, PriceLineDiscount}},
+	}
+	for _, c := range cases {
+		q, err := QuoteRoomPrice(room, c.start, c.end, loc)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if q.Total != c.wantTotal {
+			t.Errorf("%s: total = %v, want %v (lines %+v)", c.name, q.Total, c.wantTotal, q.Lines)
+		}
+		if len(q.Lines) != len(c.wantKinds) {
+			t.Errorf("%s: lines = %+v, want kinds %v", c.name, q.Lines, c.wantKinds)
+			continue
+		}
+		for i, k := range c.wantKinds {
+			if q.Lines[i].Kind != k {
+				t.Errorf("%s: line %d kind = %s, want %s", c.name, i, q.Lines[i].Kind, k)
+			}
+		}
+	}
+
+	if _, err := QuoteRoomPrice(room, at(18, 10), at(18, 10).Add(30*time.Minute), loc); !errors.Is(err, ErrBelowMinDuration) {
+		t.Fatalf("expected ErrBelowMinDuration, got %v", err)
+	}
+
+	plain := &Room{PricePerHourMin: 1000}
+	if q, err := QuoteRoomPrice(plain, at(18, 10), at(18, 12).Add(30*time.Minute), loc); err != nil || q.Total != 2500 {
+		t.Fatalf("room without rules must be hours × base price, got %+v err=%v", q, err)
+	}
+}
+
+func TestPricingRules_Validate(t *testing.T) {
+	bad := []PricingRules{
+		{Peak: []PeakWindow{{From: "22:00", To: "18:00"}}},
+		{Peak: []PeakWindow{{From: "18:00", To: "22:00", Days: []int{7}}}},
+		{WeekendSurchargePercent: 150},
+		{Discounts: []DurationDiscount{{MinHours: 0, Percent: 10}}},
+		{Holidays: []HolidayPrice{{Date: "22.03.2026", PricePerHour: 100}}},
+	}
+	for i, r := range bad {
+		if err := r.Validate(); !errors.Is(err, ErrInvalidPricingRules) {
+			t.Errorf("case %d: expected ErrInvalidPricingRules, got %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package catalog
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrInvalidReminderSchedule = errors.New("invalid reminder schedule")
+
+const (
+	// maxReminders — сколько напоминаний можно настроить на одну бронь
+	maxReminders = 5
+	// maxReminderHoursBefore — не раньше чем за неделю до начала
+	maxReminderHoursBefore = 168
+)
+
+// ReminderSchedule — за сколько часов до начала подтверждённой брони клиенту
+// приходят напоминания. Пустой список выключает напоминания студии.
+type ReminderSchedule struct {
+	HoursBefore []int `json:"hours_before"`
+}
+
+// DefaultReminderSchedule — за сутки и за два часа до начала
+func DefaultReminderSchedule() *ReminderSchedule {
+	return &ReminderSchedule{HoursBefore: []int{24, 2}}
+}
+
+// Normalize проверяет расписание и сортирует его от самого раннего напоминания
+func (r *ReminderSchedule) Normalize() error {
+	if len(r.HoursBefore) > maxReminders {
+		return ErrInvalidReminderSchedule
+	}
+	seen := make(map[int]bool, len(r.HoursBefore))
+	for _, h := range r.HoursBefore {
+		if h < 1 || h > maxReminderHoursBefore || seen[h] {
+			return ErrInvalidReminderSchedule
+		}
+		seen[h] = true
+	}
+	if r.HoursBefore == nil {
+		r.HoursBefore = []int{}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(r.HoursBefore)))
+	return nil
+}
+
+// EffectiveReminderSchedule — расписание студии или расписание по умолчанию
+func (s *Studio) EffectiveReminderSchedule() *ReminderSchedule {
+	if s.ReminderSchedule == nil {
+		return DefaultReminderSchedule()
+	}
+	return s.ReminderSchedule
+}
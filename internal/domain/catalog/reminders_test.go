@@ -0,0 +1,28 @@
+package catalog
+
+import "testing"
+
+func TestReminderSchedule_Normalize(t *testing.T) {
+	r := &ReminderSchedule{HoursBefore: []int{2, 48, 24}}
+	if err := r.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if r.HoursBefore[0] != 48 || r.HoursBefore[2] != 2 {
+		t.Fatalf("hours must be sorted desc, got %v", r.HoursBefore)
+	}
+
+	off := &ReminderSchedule{}
+	if err := off.Normalize(); err != nil || off.HoursBefore == nil {
+		t.Fatalf("empty schedule turns reminders off, got %v err=%v", off.HoursBefore, err)
+	}
+
+	for _, bad := range [][]int{{0}, {169}, {24, 24}, {1, 2, 3, 4, 5, 6}} {
+		if err := (&ReminderSchedule{HoursBefore: bad}).Normalize(); err != ErrInvalidReminderSchedule {
+			t.Errorf("%v: expected ErrInvalidReminderSchedule, got %v", bad, err)
+		}
+	}
+
+	if got := (&Studio{}).EffectiveReminderSchedule().HoursBefore; len(got) != 2 || got[0] != 24 || got[1] != 2 {
+		t.Fatalf("default schedule must be 24h and 2h, got %v", got)
+	}
+}
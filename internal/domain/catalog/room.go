@@ -58,6 +58,13 @@ type Room struct {
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 
+	// Время на подготовку до и уборку после каждой брони (минуты)
+	BufferBeforeMinutes int `json:"buffer_before_minutes"`
+	BufferAfterMinutes  int `json:"buffer_after_minutes"`
+
+	// Правила цены (пик, выходные, минимум, скидки, праздники); nil — почасовая PricePerHourMin
+	PricingRules *PricingRules `json:"pricing_rules,omitempty" gorm:"serializer:json;type:jsonb"`
+
 	// Relations
 	Equipment []Equipment `json:"equipment,omitempty"`
 }
@@ -72,4 +79,17 @@ type Equipment struct {
 	Quantity    int       `json:"quantity" validate:"required,gt=0"`
 	RentalPrice float64   `json:"rental_price,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
-}
\ No newline at end of file
+}
+
+// MaxRoomBufferMinutes — верхняя граница буфера до/после брони
+const MaxRoomBufferMinutes = 240
+
+// BufferGap — минимальный зазор между соседними бронями комнаты:
+// уборка после предыдущей + подготовка к следующей
+func (r *Room) BufferGap() time.Duration {
+	return time.Duration(r.BufferBeforeMinutes+r.BufferAfterMinutes) * time.Minute
+}
+
+func validRoomBuffer(minutes int) bool {
+	return minutes >= 0 && minutes <= MaxRoomBufferMinutes
+}
@@ -101,6 +101,21 @@ func (r *RoomRepository) SetActive(ctx context.Context, id int64, active bool) e
 		Update("is_active", active).Error
 }
 
+// GetStudioTimezoneByRoomID возвращает IANA-зону студии, которой принадлежит комната
+func (r *RoomRepository) GetStudioTimezoneByRoomID(ctx context.Context, roomID int64) (string, error) {
+	var tz string
+	tx := r.db.WithContext(ctx).
+		Table("rooms").
+		Select("studios.timezone").
+		Joins("JOIN studios ON studios.id = rooms.studio_id").
+		Where("rooms.id = ?", roomID).
+		Scan(&tz)
+	if tx.Error != nil {
+		return "", tx.Error
+	}
+	return tz, nil
+}
+
 func (r *RoomRepository) GetStudioWorkingHoursByRoomID(ctx context.Context, roomID int64) ([]byte, error) {
 	// TODO: working_hours field doesn't exist in current schema
 	// For now, return empty/nil to unblock testing
@@ -118,3 +133,33 @@ func (r *RoomRepository) GetRoomWorkingHoursRawOld(ctx context.Context, roomID i
 	// Deprecated - use GetStudioWorkingHoursByRoomID instead
 	return nil, nil
 }
+
+// GetStudioCancellationPolicyByRoomID возвращает политику отмены студии, которой принадлежит комната.
+// nil — владелец политику не задавал.
+func (r *RoomRepository) GetStudioCancellationPolicyByRoomID(ctx context.Context, roomID int64) (*CancellationPolicy, error) {
+	var studio Studio
+	err := r.db.WithContext(ctx).
+		Select("studios.id", "studios.cancellation_policy").
+		Joins("JOIN rooms ON rooms.studio_id = studios.id").
+		Where("rooms.id = ?", roomID).
+		First(&studio).Error
+	if err != nil {
+		return nil, err
+	}
+	return studio.CancellationPolicy, nil
+}
+
+// GetStudioReminderScheduleByRoomID возвращает расписание напоминаний студии комнаты.
+// nil — владелец расписание не задавал.
+func (r *RoomRepository) GetStudioReminderScheduleByRoomID(ctx context.Context, roomID int64) (*ReminderSchedule, error) {
+	var studio Studio
+	err := r.db.WithContext(ctx).
+		Select("studios.id", "studios.reminder_schedule").
+		Joins("JOIN rooms ON rooms.studio_id = studios.id").
+		Where("rooms.id = ?", roomID).
+		First(&studio).Error
+	if err != nil {
+		return nil, err
+	}
+	return studio.ReminderSchedule, nil
+}
@@ -19,6 +19,7 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 		studios.GET("/:id", h.GetStudioByID)                            // Get studio details
 		studios.GET("/:id/working-hours", h.GetStudioWorkingHours)      // Get working hours
 		studios.GET("/:id/working-hours/v2", h.GetStudioWorkingHoursV2) // Get working hours v2
+		studios.GET("/:id/schedule-exceptions", h.GetStudioScheduleExceptions)
 	}
 
 	// Получение типов комнат
@@ -27,6 +28,7 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	// Public routes для комнат
 	r.GET("/rooms", h.GetRooms)
 	r.GET("/rooms/:id", h.GetRoomByID)
+	r.GET("/rooms/:id/blackouts", h.GetRoomBlackouts)
 }
 
 // RegisterProtectedRoutes регистрирует защищенные маршруты (требуется авторизация)
@@ -37,6 +39,8 @@ func (h *Handler) RegisterProtectedRoutes(r *gin.RouterGroup, ownershipChecker O
 		studios.POST("", h.CreateStudio)
 		studios.PUT("/:id", ownershipChecker.CheckStudioOwnership(), h.UpdateStudio)
 		studios.PUT("/:id/working-hours", ownershipChecker.CheckStudioOwnership(), h.UpdateStudioWorkingHours)
+		studios.PUT("/:id/schedule-exceptions/:date", ownershipChecker.CheckStudioOwnership(), h.SetStudioScheduleException)
+		studios.DELETE("/:id/schedule-exceptions/:date", ownershipChecker.CheckStudioOwnership(), h.DeleteStudioScheduleException)
 
 		// Room management within studio
 		studios.POST("/:id/rooms", ownershipChecker.CheckStudioOwnership(), h.CreateRoom)
@@ -49,6 +53,8 @@ func (h *Handler) RegisterProtectedRoutes(r *gin.RouterGroup, ownershipChecker O
 		rooms.PUT("/:id", ownershipChecker.CheckRoomOwnership(), h.UpdateRoom)
 		rooms.DELETE("/:id", ownershipChecker.CheckRoomOwnership(), h.DeleteRoom)
 		rooms.POST("/:id/equipment", ownershipChecker.CheckRoomOwnership(), h.AddEquipment)
+		rooms.POST("/:id/blackouts", ownershipChecker.CheckRoomOwnership(), h.CreateRoomBlackout)
+		rooms.DELETE("/:id/blackouts/:blackout_id", ownershipChecker.CheckRoomOwnership(), h.DeleteRoomBlackout)
 	}
 
 	// User's studios
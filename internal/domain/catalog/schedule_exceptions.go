@@ -0,0 +1,76 @@
+package catalog
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidScheduleException = errors.New("invalid schedule exception")
+
+// StudioDateOverride — исключение из недельного расписания студии на одну дату:
+// выходной (праздник) или особые часы работы. Дата — календарный день в зоне студии.
+type StudioDateOverride struct {
+	ID        int64     `json:"id" gorm:"primaryKey"`
+	StudioID  int64     `json:"studio_id" gorm:"not null;uniqueIndex:idx_studio_date_overrides_studio_date"`
+	Date      string    `json:"date" gorm:"type:varchar(10);not null;uniqueIndex:idx_studio_date_overrides_studio_date"` // "2026-03-22"
+	IsClosed  bool      `json:"is_closed"`
+	OpenTime  string    `json:"open_time,omitempty" gorm:"type:varchar(5)"`  // "12:00"
+	CloseTime string    `json:"close_time,omitempty" gorm:"type:varchar(5)"` // "18:00", допускается "24:00"
+	Reason    string    `json:"reason,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (StudioDateOverride) TableName() string { return "studio_date_overrides" }
+
+// Validate проверяет дату и, для рабочего дня, часы работы
+func (o *StudioDateOverride) Validate() error {
+	day, err := time.Parse("2006-01-02", o.Date)
+	if err != nil {
+		return ErrInvalidScheduleException
+	}
+	if o.IsClosed {
+		return nil
+	}
+	open, err1 := ClockOnDate(day, o.OpenTime, time.UTC)
+	close, err2 := ClockOnDate(day, o.CloseTime, time.UTC)
+	if err1 != nil || err2 != nil || !close.After(open) {
+		return ErrInvalidScheduleException
+	}
+	return nil
+}
+
+// WorkingHours переводит исключение в формат недельного расписания
+func (o *StudioDateOverride) WorkingHours(dayOfWeek int) *WorkingHours {
+	if o.IsClosed {
+		return &WorkingHours{DayOfWeek: dayOfWeek, IsClosed: true}
+	}
+	return &WorkingHours{DayOfWeek: dayOfWeek, OpenTime: o.OpenTime, CloseTime: o.CloseTime}
+}
+
+// RoomBlackout — период, когда комната недоступна для брони (ремонт, съёмка студии)
+type RoomBlackout struct {
+	ID        int64     `json:"id" gorm:"primaryKey"`
+	RoomID    int64     `json:"room_id" gorm:"not null;index"`
+	StartTime time.Time `json:"start_time" gorm:"not null"`
+	EndTime   time.Time `json:"end_time" gorm:"not null"`
+	Reason    string    `json:"reason,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (RoomBlackout) TableName() string { return "room_blackouts" }
+
+// SetDateOverrideRequest — запрос на выходной или особые часы на дату
+type SetDateOverrideRequest struct {
+	IsClosed  bool   `json:"is_closed"`
+	OpenTime  string `json:"open_time"`
+	CloseTime string `json:"close_time"`
+	Reason    string `json:"reason" binding:"max=255"`
+}
+
+// CreateRoomBlackoutRequest — запрос на закрытие комнаты на период
+type CreateRoomBlackoutRequest struct {
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+	Reason    string    `json:"reason" binding:"max=255"`
+}
@@ -0,0 +1,231 @@
+package catalog
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"photostudio/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetStudioScheduleExceptions список особых дат студии
+// @Summary Получить исключения из расписания студии
+// @Description Возвращает выходные и дни с особыми часами работы студии за период. Без параметров — на год вперёд от сегодняшнего дня студии.
+// @Tags Catalog - Часы работы
+// @Produce json
+// @Param id path integer true "Уникальный идентификатор студии" example(1)
+// @Param from query string false "Начальная дата (YYYY-MM-DD)" example(2026-03-01)
+// @Param to query string false "Конечная дата включительно (YYYY-MM-DD)" example(2026-03-31)
+// @Success 200 {object} map[string]interface{} "Список исключений"
+// @Failure 400 {object} map[string]interface{} "Некорректный ID или даты"
+// @Failure 404 {object} map[string]interface{} "Студия не найдена"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /api/v1/studios/{id}/schedule-exceptions [get]
+func (h *Handler) GetStudioScheduleExceptions(c *gin.Context) {
+	studioID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid studio ID")
+		return
+	}
+
+	overrides, err := h.service.ListStudioDateOverrides(c.Request.Context(), studioID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"exceptions": overrides})
+}
+
+// SetStudioScheduleException задать выходной или особые часы на дату
+// @Summary Задать исключение из расписания студии
+// @Description Закрывает студию на дату (is_closed=true) или задаёт особые часы работы. Исключение заменяет недельное расписание на эту дату. Только владелец студии.
+// @Tags Catalog - Часы работы
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path integer true "Уникальный идентификатор студии" example(1)
+// @Param date path string true "Дата в часовом поясе студии (YYYY-MM-DD)" example(2026-03-22)
+// @Param body body SetDateOverrideRequest true "Выходной или часы работы"
+// @Success 200 {object} map[string]interface{} "Исключение сохранено"
+// @Failure 400 {object} map[string]interface{} "Некорректная дата или часы"
+// @Failure 401 {object} map[string]interface{} "Требуется аутентификация"
+// @Failure 403 {object} map[string]interface{} "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Студия не найдена"
+// @Router /api/v1/studios/{id}/schedule-exceptions/{date} [put]
+func (h *Handler) SetStudioScheduleException(c *gin.Context) {
+	studioID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid studio ID")
+		return
+	}
+
+	var req SetDateOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", err)
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	override, err := h.service.SetStudioDateOverride(c.Request.Context(), userID, studioID, c.Param("date"), req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"exception": override})
+}
+
+// DeleteStudioScheduleException удалить исключение на дату
+// @Summary Удалить исключение из расписания студии
+// @Description Возвращает дату к обычному недельному расписанию. Только владелец студии.
+// @Tags Catalog - Часы работы
+// @Produce json
+// @Security BearerAuth
+// @Param id path integer true "Уникальный идентификатор студии" example(1)
+// @Param date path string true "Дата (YYYY-MM-DD)" example(2026-03-22)
+// @Success 200 {object} map[string]interface{} "Исключение удалено"
+// @Failure 401 {object} map[string]interface{} "Требуется аутентификация"
+// @Failure 403 {object} map[string]interface{} "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Исключение не найдено"
+// @Router /api/v1/studios/{id}/schedule-exceptions/{date} [delete]
+func (h *Handler) DeleteStudioScheduleException(c *gin.Context) {
+	studioID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid studio ID")
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	if err := h.service.DeleteStudioDateOverride(c.Request.Context(), userID, studioID, c.Param("date")); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"deleted": true})
+}
+
+// GetRoomBlackouts список закрытий комнаты
+// @Summary Получить закрытия комнаты
+// @Description Возвращает текущие и будущие периоды, когда комната недоступна для брони (ремонт, обслуживание).
+// @Tags Catalog - Комнаты
+// @Produce json
+// @Param id path integer true "Уникальный идентификатор комнаты" example(1)
+// @Success 200 {object} map[string]interface{} "Список закрытий"
+// @Failure 404 {object} map[string]interface{} "Комната не найдена"
+// @Router /api/v1/rooms/{id}/blackouts [get]
+func (h *Handler) GetRoomBlackouts(c *gin.Context) {
+	roomID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid room ID")
+		return
+	}
+
+	blackouts, err := h.service.ListRoomBlackouts(c.Request.Context(), roomID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"blackouts": blackouts})
+}
+
+// CreateRoomBlackout закрыть комнату на период
+// @Summary Закрыть комнату на период
+// @Description Делает комнату недоступной для брони на период [start_time, end_time). Уже существующие брони не отменяются. Только владелец студии.
+// @Tags Catalog - Комнаты
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path integer true "Уникальный идентификатор комнаты" example(1)
+// @Param body body CreateRoomBlackoutRequest true "Период закрытия"
+// @Success 201 {object} map[string]interface{} "Закрытие создано"
+// @Failure 400 {object} map[string]interface{} "Некорректный период"
+// @Failure 401 {object} map[string]interface{} "Требуется аутентификация"
+// @Failure 403 {object} map[string]interface{} "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Комната не найдена"
+// @Router /api/v1/rooms/{id}/blackouts [post]
+func (h *Handler) CreateRoomBlackout(c *gin.Context) {
+	roomID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid room ID")
+		return
+	}
+
+	var req CreateRoomBlackoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", err)
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	blackout, err := h.service.CreateRoomBlackout(c.Request.Context(), userID, roomID, req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{"blackout": blackout})
+}
+
+// DeleteRoomBlackout снять закрытие комнаты
+// @Summary Удалить закрытие комнаты
+// @Description Удаляет период недоступности комнаты. Только владелец студии.
+// @Tags Catalog - Комнаты
+// @Produce json
+// @Security BearerAuth
+// @Param id path integer true "Уникальный идентификатор комнаты" example(1)
+// @Param blackout_id path integer true "Идентификатор закрытия" example(1)
+// @Success 200 {object} map[string]interface{} "Закрытие удалено"
+// @Failure 401 {object} map[string]interface{} "Требуется аутентификация"
+// @Failure 403 {object} map[string]interface{} "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Закрытие не найдено"
+// @Router /api/v1/rooms/{id}/blackouts/{blackout_id} [delete]
+func (h *Handler) DeleteRoomBlackout(c *gin.Context) {
+	roomID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid room ID")
+		return
+	}
+	blackoutID, err := strconv.ParseInt(c.Param("blackout_id"), 10, 64)
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid blackout ID")
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		response.CustomError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	err = h.service.DeleteRoomBlackout(c.Request.Context(), userID, roomID, blackoutID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Blackout not found")
+			return
+		}
+		handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"deleted": true})
+}
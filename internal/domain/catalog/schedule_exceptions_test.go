@@ -0,0 +1,28 @@
+package catalog
+
+import "testing"
+
+func TestStudioDateOverride_Validate(t *testing.T) {
+	cases := []struct {
+		name string
+		o    StudioDateOverride
+		ok   bool
+	}{
+		{"closed day", StudioDateOverride{Date: "2026-03-22", IsClosed: true}, true},
+		{"special hours", StudioDateOverride{Date: "2026-03-22", OpenTime: "12:00", CloseTime: "24:00"}, true},
+		{"bad date", StudioDateOverride{Date: "22.03.2026", IsClosed: true}, false},
+		{"missing hours", StudioDateOverride{Date: "2026-03-22"}, false},
+		{"close before open", StudioDateOverride{Date: "2026-03-22", OpenTime: "18:00", CloseTime: "10:00"}, false},
+	}
+	for _, c := range cases {
+		err := c.o.Validate()
+		if (err == nil) != c.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", c.name, err, c.ok)
+		}
+	}
+
+	wh := (&StudioDateOverride{Date: "2026-03-22", IsClosed: true, OpenTime: "10:00"}).WorkingHours(0)
+	if !wh.IsClosed || wh.OpenTime != "" {
+		t.Fatalf("closed override must produce a closed day, got %+v", wh)
+	}
+}
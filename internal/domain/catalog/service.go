@@ -10,6 +10,8 @@ import (
 var (
 	ErrForbidden       = errors.New("forbidden")
 	ErrInvalidRoomType = errors.New("invalid room type")
+	ErrInvalidTimezone = errors.New("invalid timezone")
+	ErrInvalidBuffer   = errors.New("invalid room buffer")
 )
 
 type Service struct {
@@ -36,6 +38,21 @@ func (s *Service) CreateStudio(ctx context.Context, user *auth.User, req CreateS
 		return nil, ErrForbidden
 	}
 
+	loc, err := LoadTimezone(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if req.CancellationPolicy != nil {
+		if err := req.CancellationPolicy.Normalize(); err != nil {
+			return nil, err
+		}
+	}
+	if req.ReminderSchedule != nil {
+		if err := req.ReminderSchedule.Normalize(); err != nil {
+			return nil, err
+		}
+	}
+
 	studio := &Studio{
 		OwnerID:      user.ID,
 		Name:         req.Name,
@@ -46,7 +63,11 @@ func (s *Service) CreateStudio(ctx context.Context, user *auth.User, req CreateS
 		Phone:        req.Phone,
 		Email:        req.Email,
 		Website:      req.Website,
+		Timezone:     loc.String(),
 		WorkingHours: req.WorkingHours,
+
+		CancellationPolicy: req.CancellationPolicy,
+		ReminderSchedule:   req.ReminderSchedule,
 	}
 
 	if err := s.studioRepo.Create(ctx, studio); err != nil {
@@ -77,6 +98,25 @@ func (s *Service) UpdateStudio(ctx context.Context, userID, studioID int64, req
 	studio.Email = req.Email
 	studio.Website = req.Website
 	studio.WorkingHours = req.WorkingHours
+	if req.Timezone != "" {
+		loc, err := LoadTimezone(req.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		studio.Timezone = loc.String()
+	}
+	if req.CancellationPolicy != nil {
+		if err := req.CancellationPolicy.Normalize(); err != nil {
+			return nil, err
+		}
+		studio.CancellationPolicy = req.CancellationPolicy
+	}
+	if req.ReminderSchedule != nil {
+		if err := req.ReminderSchedule.Normalize(); err != nil {
+			return nil, err
+		}
+		studio.ReminderSchedule = req.ReminderSchedule
+	}
 
 	if err := s.studioRepo.Update(ctx, studio); err != nil {
 		return nil, err
@@ -126,6 +166,24 @@ func (s *Service) UpdateRoom(ctx context.Context, roomID int64, req UpdateRoomRe
 	if req.Photos != nil {
 		room.Photos = *req.Photos
 	}
+	if req.BufferBeforeMinutes != nil {
+		if !validRoomBuffer(*req.BufferBeforeMinutes) {
+			return nil, ErrInvalidBuffer
+		}
+		room.BufferBeforeMinutes = *req.BufferBeforeMinutes
+	}
+	if req.BufferAfterMinutes != nil {
+		if !validRoomBuffer(*req.BufferAfterMinutes) {
+			return nil, ErrInvalidBuffer
+		}
+		room.BufferAfterMinutes = *req.BufferAfterMinutes
+	}
+	if req.PricingRules != nil {
+		if err := req.PricingRules.Validate(); err != nil {
+			return nil, err
+		}
+		room.PricingRules = req.PricingRules
+	}
 
 	if err := s.roomRepo.Update(ctx, room); err != nil {
 		return nil, err
@@ -154,6 +212,14 @@ func (s *Service) CreateRoom(ctx context.Context, userID, studioID int64, req Cr
 	if err != nil {
 		return nil, ErrInvalidRoomType
 	}
+	if !validRoomBuffer(req.BufferBeforeMinutes) || !validRoomBuffer(req.BufferAfterMinutes) {
+		return nil, ErrInvalidBuffer
+	}
+	if req.PricingRules != nil {
+		if err := req.PricingRules.Validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	room := &Room{
 		StudioID:        studioID,
@@ -167,6 +233,10 @@ func (s *Service) CreateRoom(ctx context.Context, userID, studioID int64, req Cr
 		Amenities:       req.Amenities,
 		Photos:          req.Photos,
 		IsActive:        true,
+		PricingRules:    req.PricingRules,
+
+		BufferBeforeMinutes: req.BufferBeforeMinutes,
+		BufferAfterMinutes:  req.BufferAfterMinutes,
 	}
 
 	if err := s.roomRepo.Create(ctx, room); err != nil {
@@ -237,10 +307,12 @@ func (s *Service) AddStudioPhotos(ctx context.Context, userID, studioID int64, u
 
 // WorkingStatusResponse представляет статус работы студии
 type WorkingStatusResponse struct {
-	IsOpen       bool                   `json:"is_open"`
-	Message      string                 `json:"message"`
-	OpenTime     string                 `json:"open_time,omitempty"`
-	CloseTime    string                 `json:"close_time,omitempty"`
+	IsOpen       bool            `json:"is_open"`
+	Message      string          `json:"message"`
+	OpenTime     string          `json:"open_time,omitempty"`
+	CloseTime    string          `json:"close_time,omitempty"`
+	Timezone     string          `json:"timezone"`
+	UTCOffset    string          `json:"utc_offset"`
 	WorkingHours WorkingHoursMap `json:"working_hours,omitempty"`
 }
 
@@ -251,12 +323,17 @@ func (s *Service) GetStudioWorkingStatus(ctx context.Context, studioID int64) (*
 		return nil, err
 	}
 
-	return s.calculateWorkingStatus(studio), nil
+	return s.calculateWorkingStatus(studio, time.Now()), nil
 }
 
-func (s *Service) calculateWorkingStatus(studio *Studio) *WorkingStatusResponse {
+func (s *Service) calculateWorkingStatus(studio *Studio, at time.Time) *WorkingStatusResponse {
+	loc := studio.Location()
+	now := at.In(loc)
+
 	response := &WorkingStatusResponse{
 		IsOpen:       false,
+		Timezone:     loc.String(),
+		UTCOffset:    UTCOffset(now),
 		WorkingHours: studio.WorkingHours,
 	}
 
@@ -266,10 +343,7 @@ func (s *Service) calculateWorkingStatus(studio *Studio) *WorkingStatusResponse
 		return response
 	}
 
-	// Получаем текущее время в Алматы (UTC+5 для Казахстана)
-	now := time.Now()
-
-	// Определяем день недели
+	// День недели определяется по местному времени студии, а не сервера
 	weekday := now.Weekday().String()
 	schedule, exists := studio.WorkingHours[weekday]
 	if !exists {
@@ -277,27 +351,21 @@ func (s *Service) calculateWorkingStatus(studio *Studio) *WorkingStatusResponse
 		return response
 	}
 
-	// Парсим время открытия и закрытия
-	openTime, err1 := time.Parse("15:04", schedule.Open)
-	closeTime, err2 := time.Parse("15:04", schedule.Close)
+	// Парсим время открытия и закрытия на сегодняшнюю дату в зоне студии
+	openAt, err1 := ClockOnDate(now, schedule.Open, loc)
+	closeAt, err2 := ClockOnDate(now, schedule.Close, loc)
 	if err1 != nil || err2 != nil {
 		response.Message = "Ошибка в формате рабочих часов"
 		return response
 	}
 
-	// Проверяем, открыта ли студия сейчас
-	currentTime := now.Format("15:04")
-	currentParsed, _ := time.Parse("15:04", currentTime)
-
-	if currentParsed.After(openTime) && currentParsed.Before(closeTime) {
+	response.OpenTime = schedule.Open
+	response.CloseTime = schedule.Close
+	if !now.Before(openAt) && now.Before(closeAt) {
 		response.IsOpen = true
 		response.Message = "Открыто"
-		response.OpenTime = schedule.Open
-		response.CloseTime = schedule.Close
 	} else {
 		response.Message = "Закрыто"
-		response.OpenTime = schedule.Open
-		response.CloseTime = schedule.Close
 	}
 
 	return response
@@ -307,8 +375,7 @@ func (s *Service) calculateWorkingStatus(studio *Studio) *WorkingStatusResponse
 
 // GetStudioWorkingHours возвращает полную информацию о часах работы
 func (s *Service) GetStudioWorkingHours(ctx context.Context, studioID int64) (*WorkingHoursResponse, error) {
-	// Проверяем существование студии, но не используем результат
-	_, err := s.studioRepo.GetByID(ctx, studioID)
+	studio, err := s.studioRepo.GetByID(ctx, studioID)
 	if err != nil {
 		return nil, err
 	}
@@ -319,11 +386,15 @@ func (s *Service) GetStudioWorkingHours(ctx context.Context, studioID int64) (*W
 		return nil, err
 	}
 
-	// Рассчитываем live статус
-	isOpen, statusText, nextOpen := CalculateLiveStatus(hours)
+	// Рассчитываем live статус в часовом поясе студии
+	loc := studio.Location()
+	now := time.Now().In(loc)
+	isOpen, statusText, nextOpen := CalculateLiveStatus(hours, now)
 
 	response := &WorkingHoursResponse{
 		StudioID:     studioID,
+		Timezone:     loc.String(),
+		UTCOffset:    UTCOffset(now),
 		Hours:        hours,
 		CompactText:  FormatCompactHours(hours),
 		IsOpenNow:    isOpen,
@@ -365,8 +436,17 @@ func (s *Service) UpdateStudioWorkingHours(ctx context.Context, userID, studioID
 	return s.studioWorkingHoursRepo.CreateOrUpdate(studioHours)
 }
 
-// GetWorkingHoursForDate возвращает часы работы на конкретную дату
+// GetWorkingHoursForDate возвращает часы работы на конкретную дату.
+// date должна быть выражена в часовом поясе студии — от неё берётся день недели.
 func (s *Service) GetWorkingHoursForDate(ctx context.Context, studioID int64, date time.Time) (*WorkingHours, error) {
+	override, err := s.studioWorkingHoursRepo.GetDateOverride(studioID, date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	if override != nil {
+		return override.WorkingHours(int(date.Weekday())), nil
+	}
+
 	hours, err := s.studioWorkingHoursRepo.GetHoursForStudio(studioID)
 	if err != nil {
 		return nil, err
@@ -386,4 +466,125 @@ func (s *Service) GetWorkingHoursForDate(ctx context.Context, studioID int64, da
 		CloseTime: "21:00",
 		IsClosed:  false,
 	}, nil
-}
\ No newline at end of file
+}
+
+/* ---------- SCHEDULE EXCEPTIONS ---------- */
+
+// SetStudioDateOverride задаёт выходной или особые часы студии на дату "YYYY-MM-DD"
+func (s *Service) SetStudioDateOverride(ctx context.Context, userID, studioID int64, date string, req SetDateOverrideRequest) (*StudioDateOverride, error) {
+	studio, err := s.studioRepo.GetByID(ctx, studioID)
+	if err != nil {
+		return nil, err
+	}
+	if studio.OwnerID != userID {
+		return nil, ErrForbidden
+	}
+
+	override := &StudioDateOverride{
+		StudioID: studioID,
+		Date:     date,
+		IsClosed: req.IsClosed,
+		Reason:   req.Reason,
+	}
+	if !req.IsClosed {
+		override.OpenTime = req.OpenTime
+		override.CloseTime = req.CloseTime
+	}
+	if err := override.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.studioWorkingHoursRepo.UpsertDateOverride(override); err != nil {
+		return nil, err
+	}
+	return s.studioWorkingHoursRepo.GetDateOverride(studioID, date)
+}
+
+// DeleteStudioDateOverride возвращает дату к недельному расписанию
+func (s *Service) DeleteStudioDateOverride(ctx context.Context, userID, studioID int64, date string) error {
+	studio, err := s.studioRepo.GetByID(ctx, studioID)
+	if err != nil {
+		return err
+	}
+	if studio.OwnerID != userID {
+		return ErrForbidden
+	}
+	return s.studioWorkingHoursRepo.DeleteDateOverride(studioID, date)
+}
+
+// ListStudioDateOverrides возвращает исключения студии за даты [from, to].
+// Без границ — на год вперёд от сегодняшнего дня студии.
+func (s *Service) ListStudioDateOverrides(ctx context.Context, studioID int64, from, to string) ([]StudioDateOverride, error) {
+	studio, err := s.studioRepo.GetByID(ctx, studioID)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == "" {
+		from = time.Now().In(studio.Location()).Format("2006-01-02")
+	}
+	fromDay, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, ErrInvalidScheduleException
+	}
+	if to == "" {
+		to = fromDay.AddDate(1, 0, 0).Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", to); err != nil || to < from {
+		return nil, ErrInvalidScheduleException
+	}
+
+	return s.studioWorkingHoursRepo.ListDateOverrides(studioID, from, to)
+}
+
+// CreateRoomBlackout закрывает комнату для брони на период [start, end)
+func (s *Service) CreateRoomBlackout(ctx context.Context, userID, roomID int64, req CreateRoomBlackoutRequest) (*RoomBlackout, error) {
+	if err := s.checkRoomOwner(ctx, userID, roomID); err != nil {
+		return nil, err
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, ErrInvalidScheduleException
+	}
+
+	blackout := &RoomBlackout{
+		RoomID:    roomID,
+		StartTime: req.StartTime.UTC(),
+		EndTime:   req.EndTime.UTC(),
+		Reason:    req.Reason,
+	}
+	if err := s.studioWorkingHoursRepo.CreateRoomBlackout(blackout); err != nil {
+		return nil, err
+	}
+	return blackout, nil
+}
+
+// ListRoomBlackouts возвращает закрытия комнаты, которые ещё не закончились
+func (s *Service) ListRoomBlackouts(ctx context.Context, roomID int64) ([]RoomBlackout, error) {
+	if _, err := s.roomRepo.GetByID(ctx, roomID); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	return s.studioWorkingHoursRepo.ListRoomBlackouts(roomID, now, now.AddDate(10, 0, 0))
+}
+
+func (s *Service) DeleteRoomBlackout(ctx context.Context, userID, roomID, blackoutID int64) error {
+	if err := s.checkRoomOwner(ctx, userID, roomID); err != nil {
+		return err
+	}
+	return s.studioWorkingHoursRepo.DeleteRoomBlackout(roomID, blackoutID)
+}
+
+func (s *Service) checkRoomOwner(ctx context.Context, userID, roomID int64) error {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	studio, err := s.studioRepo.GetByID(ctx, room.StudioID)
+	if err != nil {
+		return err
+	}
+	if studio.OwnerID != userID {
+		return ErrForbidden
+	}
+	return nil
+}
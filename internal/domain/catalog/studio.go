@@ -63,7 +63,12 @@ type Studio struct {
 	Phone        string          `json:"phone,omitempty"`
 	Email        string          `json:"email,omitempty"`
 	Website      string          `json:"website,omitempty"`
+	Timezone     string          `json:"timezone" gorm:"type:varchar(64);default:'Asia/Almaty'"` // IANA, например "Asia/Almaty"
 	WorkingHours WorkingHoursMap `gorm:"-" json:"working_hours,omitempty"`
+
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy,omitempty" gorm:"serializer:json;type:jsonb"` // nil — политика по умолчанию
+	ReminderSchedule   *ReminderSchedule   `json:"reminder_schedule,omitempty" gorm:"serializer:json;type:jsonb"`   // nil — за 24ч и 2ч
+
 	DeletedAt    *time.Time      `json:"-"`
 	CreatedAt    time.Time       `json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
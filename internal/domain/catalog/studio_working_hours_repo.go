@@ -2,13 +2,32 @@ package catalog
 
 import (
 	"errors"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type StudioWorkingHoursRepository interface {
 	GetByStudioID(studioID int64) (*StudioWorkingHours, error)
 	CreateOrUpdate(hours *StudioWorkingHours) error
 	GetHoursForStudio(studioID int64) ([]WorkingHours, error)
+	// GetConfiguredHours — сохранённый владельцем шаблон; nil, если его нет
+	GetConfiguredHours(studioID int64) ([]WorkingHours, error)
+
+	// Исключения из расписания: особые даты студии и закрытия комнат
+	GetDateOverride(studioID int64, date string) (*StudioDateOverride, error)
+	ListDateOverrides(studioID int64, from, to string) ([]StudioDateOverride, error)
+	UpsertDateOverride(override *StudioDateOverride) error
+	DeleteDateOverride(studioID int64, date string) error
+	ListRoomBlackouts(roomID int64, from, to time.Time) ([]RoomBlackout, error)
+	CreateRoomBlackout(blackout *RoomBlackout) error
+	DeleteRoomBlackout(roomID, blackoutID int64) error
+
+	// Пакетные выборки для поиска свободного времени по многим комнатам
+	ListHoursForStudios(studioIDs []int64) (map[int64][]WorkingHours, error)
+	ListDateOverridesForStudios(studioIDs []int64, from, to string) ([]StudioDateOverride, error)
+	ListBlackoutsForRooms(roomIDs []int64, from, to time.Time) ([]RoomBlackout, error)
 }
 
 type studioWorkingHoursRepository struct {
@@ -44,6 +63,21 @@ func (r *studioWorkingHoursRepository) CreateOrUpdate(hours *StudioWorkingHours)
 	return r.db.Save(hours).Error
 }
 
+// GetConfiguredHours возвращает недельный шаблон студии или nil, если владелец
+// его не сохранял. Бронирование для таких студий использует свои часы по
+// умолчанию, а не DefaultWorkingHours.
+func (r *studioWorkingHoursRepository) GetConfiguredHours(studioID int64) ([]WorkingHours, error) {
+	var hours StudioWorkingHours
+	err := r.db.Where("studio_id = ?", studioID).First(&hours).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return hours.Hours, nil
+}
+
 func (r *studioWorkingHoursRepository) GetHoursForStudio(studioID int64) ([]WorkingHours, error) {
 	// Получаем запись из базы
 	var hours StudioWorkingHours
@@ -68,4 +102,112 @@ func (r *studioWorkingHoursRepository) GetHoursForStudio(studioID int64) ([]Work
 
 	// Запасной вариант: если Hours пустой, возвращаем дефолтные
 	return DefaultWorkingHours(), nil
-}
\ No newline at end of file
+}
+
+// GetDateOverride возвращает исключение на дату "YYYY-MM-DD" или nil, если его нет
+func (r *studioWorkingHoursRepository) GetDateOverride(studioID int64, date string) (*StudioDateOverride, error) {
+	var o StudioDateOverride
+	err := r.db.Where("studio_id = ? AND date = ?", studioID, date).First(&o).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+// ListDateOverrides возвращает исключения за даты [from, to] включительно
+func (r *studioWorkingHoursRepository) ListDateOverrides(studioID int64, from, to string) ([]StudioDateOverride, error) {
+	var out []StudioDateOverride
+	err := r.db.Where("studio_id = ? AND date >= ? AND date <= ?", studioID, from, to).
+		Order("date ASC").
+		Find(&out).Error
+	return out, err
+}
+
+// UpsertDateOverride создаёт исключение или заменяет существующее на ту же дату
+func (r *studioWorkingHoursRepository) UpsertDateOverride(override *StudioDateOverride) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "studio_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"is_closed", "open_time", "close_time", "reason", "updated_at"}),
+	}).Create(override).Error
+}
+
+func (r *studioWorkingHoursRepository) DeleteDateOverride(studioID int64, date string) error {
+	res := r.db.Where("studio_id = ? AND date = ?", studioID, date).Delete(&StudioDateOverride{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListRoomBlackouts возвращает закрытия комнаты, пересекающиеся с [from, to)
+func (r *studioWorkingHoursRepository) ListRoomBlackouts(roomID int64, from, to time.Time) ([]RoomBlackout, error) {
+	var out []RoomBlackout
+	err := r.db.Where("room_id = ? AND start_time < ? AND end_time > ?", roomID, to, from).
+		Order("start_time ASC").
+		Find(&out).Error
+	return out, err
+}
+
+func (r *studioWorkingHoursRepository) CreateRoomBlackout(blackout *RoomBlackout) error {
+	return r.db.Create(blackout).Error
+}
+
+func (r *studioWorkingHoursRepository) DeleteRoomBlackout(roomID, blackoutID int64) error {
+	res := r.db.Where("id = ? AND room_id = ?", blackoutID, roomID).Delete(&RoomBlackout{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListHoursForStudios возвращает сохранённые недельные часы студий; студий
+// без настроенного расписания в результате нет (см. GetConfiguredHours)
+func (r *studioWorkingHoursRepository) ListHoursForStudios(studioIDs []int64) (map[int64][]WorkingHours, error) {
+	out := make(map[int64][]WorkingHours, len(studioIDs))
+	if len(studioIDs) == 0 {
+		return out, nil
+	}
+	var rows []StudioWorkingHours
+	if err := r.db.Where("studio_id IN ?", studioIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, h := range rows {
+		if h.Hours != nil {
+			out[h.StudioID] = h.Hours
+		}
+	}
+	return out, nil
+}
+
+// ListDateOverridesForStudios возвращает исключения студий за даты [from, to] включительно
+func (r *studioWorkingHoursRepository) ListDateOverridesForStudios(studioIDs []int64, from, to string) ([]StudioDateOverride, error) {
+	var out []StudioDateOverride
+	if len(studioIDs) == 0 {
+		return out, nil
+	}
+	err := r.db.Where("studio_id IN ? AND date >= ? AND date <= ?", studioIDs, from, to).
+		Order("date ASC").
+		Find(&out).Error
+	return out, err
+}
+
+// ListBlackoutsForRooms возвращает закрытия комнат, пересекающиеся с [from, to)
+func (r *studioWorkingHoursRepository) ListBlackoutsForRooms(roomIDs []int64, from, to time.Time) ([]RoomBlackout, error) {
+	var out []RoomBlackout
+	if len(roomIDs) == 0 {
+		return out, nil
+	}
+	err := r.db.Where("room_id IN ? AND start_time < ? AND end_time > ?", roomIDs, to, from).
+		Order("start_time ASC").
+		Find(&out).Error
+	return out, err
+}
@@ -0,0 +1,55 @@
+package catalog
+
+import (
+	"strings"
+	"time"
+	_ "time/tzdata" // runtime-образ (alpine) собирается без zoneinfo
+)
+
+// DefaultTimezone — часовой пояс студий, у которых он не задан явно
+const DefaultTimezone = "Asia/Almaty"
+
+// LoadTimezone проверяет и загружает IANA-зону. Пустая строка означает DefaultTimezone.
+// "Local" запрещён: расписание студии не должно зависеть от часового пояса сервера.
+func LoadTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = DefaultTimezone
+	}
+	if name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// Location возвращает часовой пояс студии (DefaultTimezone, если значение пустое или битое)
+func (s *Studio) Location() *time.Location {
+	if loc, err := LoadTimezone(s.Timezone); err == nil {
+		return loc
+	}
+	loc, _ := LoadTimezone(DefaultTimezone)
+	return loc
+}
+
+// ClockOnDate переводит время "HH:MM" на календарную дату day в зоне loc.
+// "24:00" означает полночь следующего дня.
+func ClockOnDate(day time.Time, clock string, loc *time.Location) (time.Time, error) {
+	day = day.In(loc)
+	if clock == "24:00" {
+		return time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc), nil
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, loc), nil
+}
+
+// UTCOffset возвращает смещение момента t в формате "+05:00"
+func UTCOffset(t time.Time) string {
+	return t.Format("-07:00")
+}
@@ -0,0 +1,65 @@
+package catalog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	loc, err := LoadTimezone("")
+	if err != nil || loc.String() != DefaultTimezone {
+		t.Fatalf("expected default timezone, got %v err=%v", loc, err)
+	}
+	if _, err := LoadTimezone("Local"); err != ErrInvalidTimezone {
+		t.Fatalf("expected Local to be rejected, got %v", err)
+	}
+	if _, err := LoadTimezone("Mars/Olympus"); err != ErrInvalidTimezone {
+		t.Fatalf("expected unknown zone to be rejected, got %v", err)
+	}
+}
+
+func TestClockOnDate_UsesStudioZone(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*3600)
+	day := time.Date(2026, 3, 20, 0, 0, 0, 0, loc)
+
+	open, err := ClockOnDate(day, "09:00", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := open.UTC().Format(time.RFC3339); got != "2026-03-20T04:00:00Z" {
+		t.Fatalf("09:00 at UTC+5 should be 04:00Z, got %s", got)
+	}
+
+	midnight, err := ClockOnDate(day, "24:00", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !midnight.Equal(day.AddDate(0, 0, 1)) {
+		t.Fatalf("24:00 should be next midnight, got %s", midnight)
+	}
+}
+
+func TestCalculateWorkingStatus_StudioLocalTime(t *testing.T) {
+	s := &Service{}
+	studio := &Studio{
+		Timezone:     "Asia/Almaty",
+		WorkingHours: WorkingHoursMap{"Friday": {Open: "09:00", Close: "21:00"}},
+	}
+	loc := studio.Location()
+
+	// 03:30Z — это 08:30 в Алматы: ещё закрыто, хотя по UTC-серверу "ночь"
+	at := time.Date(2026, 3, 20, 3, 30, 0, 0, time.UTC)
+	if st := s.calculateWorkingStatus(studio, at); st.IsOpen {
+		t.Fatalf("expected closed at %s local", at.In(loc).Format("15:04"))
+	}
+
+	// 04:30Z — 09:30 в Алматы: открыто
+	at = time.Date(2026, 3, 20, 4, 30, 0, 0, time.UTC)
+	st := s.calculateWorkingStatus(studio, at)
+	if !st.IsOpen {
+		t.Fatalf("expected open at %s local", at.In(loc).Format("15:04"))
+	}
+	if st.Timezone != "Asia/Almaty" || st.UTCOffset == "" {
+		t.Fatalf("expected timezone info in response, got %+v", st)
+	}
+}
@@ -0,0 +1,33 @@
+package ledger
+
+// AdjustmentRequest — ручная корректировка: amount > 0 начисляет владельцу, < 0 удерживает
+type AdjustmentRequest struct {
+	OwnerID int64   `json:"owner_id" binding:"required" example:"12"`
+	Amount  float64 `json:"amount" binding:"required" example:"-1500"`
+	Reason  string  `json:"reason" binding:"required,max=500" example:"Штраф за отмену брони студией"`
+}
+
+type CommissionRateRequest struct {
+	Percent *float64 `json:"percent" binding:"required,min=0,max=100" example:"10"`
+}
+
+// CreatePayoutBatchRequest — в пакет попадают владельцы с доступным балансом от min_amount
+type CreatePayoutBatchRequest struct {
+	MinAmount float64 `json:"min_amount" binding:"min=0" example:"5000"`
+}
+
+type BalanceListResponse struct {
+	Balances []OwnerBalance `json:"balances"`
+	Total    int64          `json:"total"`
+}
+
+type StatementResponse struct {
+	Balance *OwnerBalance   `json:"balance"`
+	Lines   []StatementLine `json:"lines"`
+	Total   int64           `json:"total"`
+}
+
+type PayoutBatchListResponse struct {
+	Batches []PayoutBatch `json:"batches"`
+	Total   int64         `json:"total"`
+}
@@ -0,0 +1,137 @@
+package ledger
+
+import "time"
+
+// Account — счёт двойной записи
+type Account string
+
+const (
+	// Деньги, полученные платформой через платёжных провайдеров
+	AccountCash Account = "platform_cash"
+	// Долг платформы перед владельцем студии (ведётся по owner_id)
+	AccountOwnerPayable Account = "owner_payable"
+	// Комиссия платформы
+	AccountCommission Account = "platform_commission"
+)
+
+// TransactionKind — источник проводки
+type TransactionKind string
+
+const (
+	KindPayment    TransactionKind = "payment"    // оплаченный счёт брони
+	KindRefund     TransactionKind = "refund"     // подтверждённый возврат
+	KindAdjustment TransactionKind = "adjustment" // ручная корректировка администратора
+	KindPayout     TransactionKind = "payout"     // выплата владельцу
+)
+
+// Transaction — одна бизнес-операция; сумма дебетов её проводок равна сумме
+// кредитов. Reference уникален и защищает от повторного учёта одной операции.
+type Transaction struct {
+	ID             int64           `gorm:"primaryKey" json:"id"`
+	Kind           TransactionKind `gorm:"type:varchar(20);not null;index" json:"kind"`
+	Reference      string          `gorm:"type:varchar(128);not null;uniqueIndex" json:"reference"`
+	OwnerID        int64           `gorm:"not null;index" json:"owner_id"`
+	BookingID      *int64          `gorm:"index" json:"booking_id,omitempty"`
+	PaymentID      *int64          `gorm:"index" json:"payment_id,omitempty"`
+	RefundID       *int64          `json:"refund_id,omitempty"`
+	PayoutBatchID  *int64          `gorm:"index" json:"payout_batch_id,omitempty"`
+	Amount         float64         `gorm:"type:decimal(12,2);not null" json:"amount"`
+	CommissionRate float64         `gorm:"type:decimal(5,2);not null;default:0" json:"commission_rate"`
+	Commission     float64         `gorm:"type:decimal(12,2);not null;default:0" json:"commission"`
+	Description    string          `gorm:"type:text" json:"description,omitempty"`
+	CreatedBy      string          `gorm:"type:varchar(64)" json:"created_by,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Entries        []Entry         `gorm:"foreignKey:TransactionID" json:"entries,omitempty"`
+}
+
+func (Transaction) TableName() string { return "ledger_transactions" }
+
+// Entry — проводка по одному счёту
+type Entry struct {
+	ID            int64     `gorm:"primaryKey" json:"id"`
+	TransactionID int64     `gorm:"not null;index" json:"transaction_id"`
+	Account       Account   `gorm:"type:varchar(32);not null;index" json:"account"`
+	OwnerID       *int64    `gorm:"index" json:"owner_id,omitempty"`
+	Debit         float64   `gorm:"type:decimal(12,2);not null;default:0" json:"debit"`
+	Credit        float64   `gorm:"type:decimal(12,2);not null;default:0" json:"credit"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (Entry) TableName() string { return "ledger_entries" }
+
+// CommissionRate — процент комиссии платформы для тарифа владельца
+type CommissionRate struct {
+	PlanID    string    `gorm:"type:varchar(50);primaryKey" json:"plan_id"`
+	Percent   float64   `gorm:"type:decimal(5,2);not null" json:"percent"`
+	UpdatedBy string    `gorm:"type:varchar(64)" json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (CommissionRate) TableName() string { return "commission_rates" }
+
+type PayoutStatus string
+
+const (
+	PayoutDraft     PayoutStatus = "draft"     // сформирован, ждёт подтверждения
+	PayoutApproved  PayoutStatus = "approved"  // подтверждён, долг владельцам списан
+	PayoutCancelled PayoutStatus = "cancelled" // отменён до подтверждения
+)
+
+// PayoutBatch — пакет выплат владельцам, который подтверждает администратор
+type PayoutBatch struct {
+	ID          int64        `gorm:"primaryKey" json:"id"`
+	Status      PayoutStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Total       float64      `gorm:"type:decimal(12,2);not null" json:"total"`
+	ItemsCount  int          `gorm:"not null" json:"items_count"`
+	CreatedBy   string       `gorm:"type:varchar(64)" json:"created_by,omitempty"`
+	ApprovedBy  string       `gorm:"type:varchar(64)" json:"approved_by,omitempty"`
+	ApprovedAt  *time.Time   `json:"approved_at,omitempty"`
+	CancelledAt *time.Time   `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Items       []PayoutItem `gorm:"foreignKey:BatchID" json:"items,omitempty"`
+}
+
+func (PayoutBatch) TableName() string { return "payout_batches" }
+
+// PayoutItem — выплата одному владельцу в пакете
+type PayoutItem struct {
+	ID            int64     `gorm:"primaryKey" json:"id"`
+	BatchID       int64     `gorm:"not null;index" json:"batch_id"`
+	OwnerID       int64     `gorm:"not null;index" json:"owner_id"`
+	Amount        float64   `gorm:"type:decimal(12,2);not null" json:"amount"`
+	TransactionID *int64    `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (PayoutItem) TableName() string { return "payout_items" }
+
+// OwnerBalance — сколько платформа должна владельцу
+type OwnerBalance struct {
+	OwnerID   int64   `json:"owner_id"`
+	Balance   float64 `json:"balance"`   // остаток на owner_payable
+	InPayout  float64 `json:"in_payout"` // уже включено в неподтверждённые пакеты
+	Available float64 `json:"available"` // можно включить в новый пакет
+}
+
+// StatementLine — операция в выписке владельца
+type StatementLine struct {
+	TransactionID int64           `json:"transaction_id"`
+	Kind          TransactionKind `json:"kind"`
+	BookingID     *int64          `json:"booking_id,omitempty"`
+	Amount        float64         `json:"amount"`     // сумма операции
+	Commission    float64         `json:"commission"` // комиссия платформы в ней
+	Net           float64         `json:"net"`        // изменение долга перед владельцем
+	Description   string          `json:"description,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// PayoutRecipient — строка выгрузки пакета выплат для банка
+type PayoutRecipient struct {
+	OwnerID     int64
+	Name        string
+	Email       string
+	CompanyName string
+	BIN         string
+	Amount      float64
+}
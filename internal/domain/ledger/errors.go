@@ -0,0 +1,14 @@
+package ledger
+
+import "errors"
+
+var (
+	ErrOwnerNotFound       = errors.New("studio owner of the booking not found")
+	ErrUnbalanced          = errors.New("ledger transaction is not balanced")
+	ErrInvalidAmount       = errors.New("amount must be non-zero")
+	ErrInvalidRate         = errors.New("commission percent must be between 0 and 100")
+	ErrBatchNotFound       = errors.New("payout batch not found")
+	ErrBatchNotDraft       = errors.New("payout batch is not a draft")
+	ErrNothingToPayOut     = errors.New("no owner has a balance to pay out")
+	ErrInsufficientBalance = errors.New("owner balance is lower than the payout, cancel and recreate the batch")
+)
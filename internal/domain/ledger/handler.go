@@ -0,0 +1,389 @@
+package ledger
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"photostudio/internal/pkg/response"
+)
+
+// Handler handles ledger and payout HTTP requests
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates ledger handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetMyBalance handles GET /api/v1/owner/ledger/balance
+// @Summary Get my payout balance
+// @Description Amount the platform owes the studio owner after commission, refunds and payouts
+// @Tags Ledger
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=OwnerBalance}
+// @Failure 500 {object} response.Response
+// @Router /owner/ledger/balance [get]
+func (h *Handler) GetMyBalance(c *gin.Context) {
+	balance, err := h.service.GetBalance(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		return
+	}
+	response.Success(c, http.StatusOK, balance)
+}
+
+// GetMyStatement handles GET /api/v1/owner/ledger/statement
+// @Summary Get my ledger statement
+// @Description Payments, refunds, adjustments and payouts with the commission withheld
+// @Tags Ledger
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit (default 50, max 100)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} response.Response{data=StatementResponse}
+// @Failure 500 {object} response.Response
+// @Router /owner/ledger/statement [get]
+func (h *Handler) GetMyStatement(c *gin.Context) {
+	h.statement(c, c.GetInt64("user_id"))
+}
+
+// GetOwnerStatement handles GET /api/v1/admin/ledger/owners/:owner_id/statement
+// @Summary Get owner ledger statement
+// @Tags Admin Ledger
+// @Produce json
+// @Security BearerAuth
+// @Param owner_id path int true "Owner user ID"
+// @Param limit query int false "Limit (default 50, max 100)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} response.Response{data=StatementResponse}
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/ledger/owners/{owner_id}/statement [get]
+func (h *Handler) GetOwnerStatement(c *gin.Context) {
+	ownerID, err := strconv.ParseInt(c.Param("owner_id"), 10, 64)
+	if err != nil || ownerID <= 0 {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid owner ID")
+		return
+	}
+	h.statement(c, ownerID)
+}
+
+func (h *Handler) statement(c *gin.Context, ownerID int64) {
+	limit, offset := pagination(c)
+	balance, err := h.service.GetBalance(c.Request.Context(), ownerID)
+	if err != nil {
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		return
+	}
+	lines, total, err := h.service.Statement(c.Request.Context(), ownerID, limit, offset)
+	if err != nil {
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		return
+	}
+	response.Success(c, http.StatusOK, StatementResponse{Balance: balance, Lines: lines, Total: total})
+}
+
+// ListBalances handles GET /api/v1/admin/ledger/balances
+// @Summary List owner balances
+// @Description Owners the platform owes money to (or who owe the platform after refunds)
+// @Tags Admin Ledger
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit (default 50, max 100)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} response.Response{data=BalanceListResponse}
+// @Failure 500 {object} response.Response
+// @Router /admin/ledger/balances [get]
+func (h *Handler) ListBalances(c *gin.Context) {
+	limit, offset := pagination(c)
+	balances, total, err := h.service.ListBalances(c.Request.Context(), limit, offset)
+	if err != nil {
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		return
+	}
+	response.Success(c, http.StatusOK, BalanceListResponse{Balances: balances, Total: total})
+}
+
+// AddAdjustment handles POST /api/v1/admin/ledger/adjustments
+// @Summary Add manual ledger adjustment
+// @Description Positive amount credits the owner at the platform's expense, negative withholds from the owner. Repeat with the same Idempotency-Key to get the existing adjustment.
+// @Tags Admin Ledger
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param Idempotency-Key header string false "Idempotency key"
+// @Param request body AdjustmentRequest true "Adjustment"
+// @Success 201 {object} response.Response{data=Transaction}
+// @Success 200 {object} response.Response{data=Transaction} "Existing adjustment for the idempotency key"
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/ledger/adjustments [post]
+func (h *Handler) AddAdjustment(c *gin.Context) {
+	var req AdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", err)
+		return
+	}
+	t, created, err := h.service.AddAdjustment(c.Request.Context(), req, c.GetHeader("Idempotency-Key"), c.GetString("admin_id"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidAmount) {
+			response.CustomError(c, http.StatusBadRequest, "INVALID_AMOUNT", err)
+			return
+		}
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	response.Success(c, status, t)
+}
+
+// ListCommissionRates handles GET /api/v1/admin/ledger/commission-rates
+// @Summary List commission rates per plan
+// @Description Plans without a rate use PLATFORM_COMMISSION_PERCENT (10% by default)
+// @Tags Admin Ledger
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]CommissionRate}
+// @Failure 500 {object} response.Response
+// @Router /admin/ledger/commission-rates [get]
+func (h *Handler) ListCommissionRates(c *gin.Context) {
+	rates, err := h.service.ListCommissionRates(c.Request.Context())
+	if err != nil {
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		return
+	}
+	response.Success(c, http.StatusOK, rates)
+}
+
+// SetCommissionRate handles PUT /api/v1/admin/ledger/commission-rates/:plan_id
+// @Summary Set commission rate for a plan
+// @Description Applies to payments received after the change; posted payments keep their rate
+// @Tags Admin Ledger
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id path string true "Subscription plan ID" example(pro)
+// @Param request body CommissionRateRequest true "Commission percent"
+// @Success 200 {object} response.Response{data=CommissionRate}
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/ledger/commission-rates/{plan_id} [put]
+func (h *Handler) SetCommissionRate(c *gin.Context) {
+	var req CommissionRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", err)
+		return
+	}
+	rate, err := h.service.SetCommissionRate(c.Request.Context(), c.Param("plan_id"), *req.Percent, c.GetString("admin_id"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidRate) {
+			response.CustomError(c, http.StatusBadRequest, "INVALID_RATE", err)
+			return
+		}
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		return
+	}
+	response.Success(c, http.StatusOK, rate)
+}
+
+// CreatePayoutBatch handles POST /api/v1/admin/ledger/payouts
+// @Summary Create payout batch
+// @Description Drafts a payout for every owner whose available balance is at least min_amount. Nothing is posted until the batch is approved.
+// @Tags Admin Ledger
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreatePayoutBatchRequest false "Batch options"
+// @Success 201 {object} response.Response{data=PayoutBatch}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/ledger/payouts [post]
+func (h *Handler) CreatePayoutBatch(c *gin.Context) {
+	var req CreatePayoutBatchRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", err)
+			return
+		}
+	}
+	batch, err := h.service.CreatePayoutBatch(c.Request.Context(), req.MinAmount, c.GetString("admin_id"))
+	if err != nil {
+		h.payoutError(c, err)
+		return
+	}
+	response.Success(c, http.StatusCreated, batch)
+}
+
+// ListPayoutBatches handles GET /api/v1/admin/ledger/payouts
+// @Summary List payout batches
+// @Tags Admin Ledger
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "draft, approved or cancelled"
+// @Param limit query int false "Limit (default 50, max 100)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} response.Response{data=PayoutBatchListResponse}
+// @Failure 500 {object} response.Response
+// @Router /admin/ledger/payouts [get]
+func (h *Handler) ListPayoutBatches(c *gin.Context) {
+	limit, offset := pagination(c)
+	batches, total, err := h.service.ListPayoutBatches(c.Request.Context(), PayoutStatus(c.Query("status")), limit, offset)
+	if err != nil {
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+		return
+	}
+	response.Success(c, http.StatusOK, PayoutBatchListResponse{Batches: batches, Total: total})
+}
+
+// GetPayoutBatch handles GET /api/v1/admin/ledger/payouts/:id
+// @Summary Get payout batch
+// @Tags Admin Ledger
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Batch ID"
+// @Success 200 {object} response.Response{data=PayoutBatch}
+// @Failure 404 {object} response.Response
+// @Router /admin/ledger/payouts/{id} [get]
+func (h *Handler) GetPayoutBatch(c *gin.Context) {
+	id, ok := batchID(c)
+	if !ok {
+		return
+	}
+	batch, err := h.service.GetPayoutBatch(c.Request.Context(), id)
+	if err != nil {
+		h.payoutError(c, err)
+		return
+	}
+	response.Success(c, http.StatusOK, batch)
+}
+
+// ApprovePayoutBatch handles POST /api/v1/admin/ledger/payouts/:id/approve
+// @Summary Approve payout batch
+// @Description Confirms the money was transferred and writes the payouts off the owners' balances
+// @Tags Admin Ledger
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Batch ID"
+// @Success 200 {object} response.Response{data=PayoutBatch}
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response "Batch is not a draft or an owner balance dropped below the payout"
+// @Router /admin/ledger/payouts/{id}/approve [post]
+func (h *Handler) ApprovePayoutBatch(c *gin.Context) {
+	id, ok := batchID(c)
+	if !ok {
+		return
+	}
+	batch, err := h.service.ApprovePayoutBatch(c.Request.Context(), id, c.GetString("admin_id"))
+	if err != nil {
+		h.payoutError(c, err)
+		return
+	}
+	response.Success(c, http.StatusOK, batch)
+}
+
+// CancelPayoutBatch handles POST /api/v1/admin/ledger/payouts/:id/cancel
+// @Summary Cancel draft payout batch
+// @Tags Admin Ledger
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Batch ID"
+// @Success 200 {object} response.Response{data=PayoutBatch}
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /admin/ledger/payouts/{id}/cancel [post]
+func (h *Handler) CancelPayoutBatch(c *gin.Context) {
+	id, ok := batchID(c)
+	if !ok {
+		return
+	}
+	batch, err := h.service.CancelPayoutBatch(c.Request.Context(), id)
+	if err != nil {
+		h.payoutError(c, err)
+		return
+	}
+	response.Success(c, http.StatusOK, batch)
+}
+
+// ExportPayoutBatch handles GET /api/v1/admin/ledger/payouts/:id/export
+// @Summary Export payout batch as CSV
+// @Description One row per owner with company details for the bank transfer
+// @Tags Admin Ledger
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path int true "Batch ID"
+// @Success 200 {string} string "CSV file"
+// @Failure 404 {object} response.Response
+// @Router /admin/ledger/payouts/{id}/export [get]
+func (h *Handler) ExportPayoutBatch(c *gin.Context) {
+	id, ok := batchID(c)
+	if !ok {
+		return
+	}
+	recipients, err := h.service.ListPayoutRecipients(c.Request.Context(), id)
+	if err != nil {
+		h.payoutError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=payout-batch-%d.csv", id))
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"batch_id", "owner_id", "name", "email", "company_name", "bin", "amount"})
+	for _, r := range recipients {
+		_ = w.Write([]string{
+			strconv.FormatInt(id, 10),
+			strconv.FormatInt(r.OwnerID, 10),
+			r.Name,
+			r.Email,
+			r.CompanyName,
+			r.BIN,
+			strconv.FormatFloat(r.Amount, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+}
+
+func (h *Handler) payoutError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBatchNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, ErrBatchNotDraft), errors.Is(err, ErrNothingToPayOut), errors.Is(err, ErrInsufficientBalance):
+		response.Error(c, http.StatusConflict, "CONFLICT", err.Error())
+	default:
+		response.CustomError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err)
+	}
+}
+
+func batchID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid batch ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func pagination(c *gin.Context) (int, int) {
+	limit := 50
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+	offset := 0
+	if v, err := strconv.Atoi(c.Query("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	return limit, offset
+}
@@ -0,0 +1,365 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// OwnerByBooking — владелец студии, в которой сделана бронь
+func (r *Repository) OwnerByBooking(ctx context.Context, bookingID int64) (int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).
+		Table("bookings AS b").
+		Joins("JOIN studios s ON s.id = b.studio_id").
+		Where("b.id = ?", bookingID).
+		Pluck("s.owner_id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, ErrOwnerNotFound
+	}
+	return ids[0], nil
+}
+
+// GetByReference — проводка операции или nil, если операция ещё не учтена
+func (r *Repository) GetByReference(ctx context.Context, reference string) (*Transaction, error) {
+	var t Transaction
+	err := r.db.WithContext(ctx).Where("reference = ?", reference).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Post сохраняет операцию вместе с проводками. Повтор той же операции
+// (тот же reference) ничего не меняет и возвращает false.
+func (r *Repository) Post(ctx context.Context, t *Transaction) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, err = post(tx, t)
+		return err
+	})
+	return created, err
+}
+
+func post(tx *gorm.DB, t *Transaction) (bool, error) {
+	var cnt int64
+	if err := tx.Model(&Transaction{}).Where("reference = ?", t.Reference).Count(&cnt).Error; err != nil {
+		return false, err
+	}
+	if cnt > 0 {
+		return false, nil
+	}
+	return true, tx.Create(t).Error
+}
+
+// RefundedCommission — комиссия, уже возвращённая по счёту
+func (r *Repository) RefundedCommission(ctx context.Context, paymentID int64) (float64, error) {
+	var sum float64
+	err := r.db.WithContext(ctx).Model(&Transaction{}).
+		Where("kind = ? AND payment_id = ?", KindRefund, paymentID).
+		Select("COALESCE(SUM(commission), 0)").
+		Scan(&sum).Error
+	return sum, err
+}
+
+// -------------------- Commission rates --------------------
+
+// GetCommissionRate — ставка тарифа или nil, если она не задана
+func (r *Repository) GetCommissionRate(ctx context.Context, planID string) (*CommissionRate, error) {
+	var rate CommissionRate
+	err := r.db.WithContext(ctx).Where("plan_id = ?", planID).First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *Repository) ListCommissionRates(ctx context.Context) ([]CommissionRate, error) {
+	var out []CommissionRate
+	err := r.db.WithContext(ctx).Order("plan_id").Find(&out).Error
+	return out, err
+}
+
+func (r *Repository) SaveCommissionRate(ctx context.Context, rate *CommissionRate) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "plan_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"percent", "updated_by", "updated_at"}),
+	}).Create(rate).Error
+}
+
+// -------------------- Balances --------------------
+
+const ownerBalanceQuery = `
+SELECT owner_id, SUM(credit - debit) AS balance
+FROM ledger_entries
+WHERE account = 'owner_payable' %s
+GROUP BY owner_id
+HAVING SUM(credit - debit) <> 0
+ORDER BY owner_id`
+
+func (r *Repository) ListBalances(ctx context.Context, limit, offset int) ([]OwnerBalance, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM (" + fmt.Sprintf(ownerBalanceQuery, "") + ") AS balances").Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []OwnerBalance
+	if err := r.db.WithContext(ctx).Raw(fmt.Sprintf(ownerBalanceQuery, "")+" LIMIT ? OFFSET ?", limit, offset).Scan(&out).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := fillInPayout(r.db.WithContext(ctx), out); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+func (r *Repository) GetBalance(ctx context.Context, ownerID int64) (*OwnerBalance, error) {
+	balances, err := ownerBalances(r.db.WithContext(ctx), &ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(balances) == 0 {
+		return &OwnerBalance{OwnerID: ownerID}, nil
+	}
+	return &balances[0], nil
+}
+
+// ownerBalances — балансы всех владельцев (или одного) с учётом черновиков выплат
+func ownerBalances(tx *gorm.DB, ownerID *int64) ([]OwnerBalance, error) {
+	var out []OwnerBalance
+	var err error
+	if ownerID != nil {
+		err = tx.Raw(fmt.Sprintf(ownerBalanceQuery, "AND owner_id = ?"), *ownerID).Scan(&out).Error
+	} else {
+		err = tx.Raw(fmt.Sprintf(ownerBalanceQuery, "")).Scan(&out).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, fillInPayout(tx, out)
+}
+
+func fillInPayout(tx *gorm.DB, balances []OwnerBalance) error {
+	if len(balances) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(balances))
+	for i, b := range balances {
+		ids[i] = b.OwnerID
+	}
+	var drafts []struct {
+		OwnerID int64
+		Amount  float64
+	}
+	err := tx.Table("payout_items AS pi").
+		Joins("JOIN payout_batches pb ON pb.id = pi.batch_id").
+		Where("pb.status = ? AND pi.owner_id IN ?", PayoutDraft, ids).
+		Group("pi.owner_id").
+		Select("pi.owner_id, SUM(pi.amount) AS amount").
+		Scan(&drafts).Error
+	if err != nil {
+		return err
+	}
+	inPayout := map[int64]float64{}
+	for _, d := range drafts {
+		inPayout[d.OwnerID] = d.Amount
+	}
+	for i := range balances {
+		b := &balances[i]
+		b.Balance = roundCents(b.Balance)
+		b.InPayout = roundCents(inPayout[b.OwnerID])
+		b.Available = roundCents(b.Balance - b.InPayout)
+	}
+	return nil
+}
+
+// Statement — операции владельца с их влиянием на долг платформы перед ним
+func (r *Repository) Statement(ctx context.Context, ownerID int64, limit, offset int) ([]StatementLine, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&Transaction{}).Where("owner_id = ?", ownerID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []StatementLine
+	err := r.db.WithContext(ctx).
+		Table("ledger_transactions AS t").
+		Joins("JOIN ledger_entries e ON e.transaction_id = t.id AND e.account = ?", AccountOwnerPayable).
+		Where("t.owner_id = ?", ownerID).
+		Group("t.id, t.kind, t.booking_id, t.amount, t.commission, t.description, t.created_at").
+		Select("t.id AS transaction_id, t.kind, t.booking_id, t.amount, t.commission, t.description, t.created_at, SUM(e.credit - e.debit) AS net").
+		Order("t.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&out).Error
+	for i := range out {
+		out[i].Net = roundCents(out[i].Net)
+	}
+	return out, total, err
+}
+
+// -------------------- Payouts --------------------
+
+// CreatePayoutBatch собирает черновик выплат: каждому владельцу — всё, что ещё
+// не включено в другие черновики, если это не меньше minAmount
+func (r *Repository) CreatePayoutBatch(ctx context.Context, minAmount float64, createdBy string) (*PayoutBatch, error) {
+	var batch *PayoutBatch
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		balances, err := ownerBalances(tx, nil)
+		if err != nil {
+			return err
+		}
+		b := &PayoutBatch{Status: PayoutDraft, CreatedBy: createdBy}
+		var total int64
+		for _, ob := range balances {
+			amount := toCents(ob.Available)
+			if amount <= 0 || amount < toCents(minAmount) {
+				continue
+			}
+			b.Items = append(b.Items, PayoutItem{OwnerID: ob.OwnerID, Amount: float64(amount) / 100})
+			total += amount
+		}
+		if len(b.Items) == 0 {
+			return ErrNothingToPayOut
+		}
+		b.Total, b.ItemsCount = float64(total)/100, len(b.Items)
+		if err := tx.Create(b).Error; err != nil {
+			return err
+		}
+		batch = b
+		return nil
+	})
+	return batch, err
+}
+
+func (r *Repository) GetPayoutBatch(ctx context.Context, id int64) (*PayoutBatch, error) {
+	var b PayoutBatch
+	err := r.db.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("owner_id") }).First(&b, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *Repository) ListPayoutBatches(ctx context.Context, status PayoutStatus, limit, offset int) ([]PayoutBatch, int64, error) {
+	q := r.db.WithContext(ctx).Model(&PayoutBatch{})
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []PayoutBatch
+	err := q.Order("id DESC").Limit(limit).Offset(offset).Find(&out).Error
+	return out, total, err
+}
+
+// ApprovePayoutBatch проводит выплаты пакета: списывает долг перед владельцами.
+// Если после формирования пакета баланс владельца уменьшился (возврат),
+// подтверждение отклоняется целиком.
+func (r *Repository) ApprovePayoutBatch(ctx context.Context, id int64, approvedBy string, at time.Time) (*PayoutBatch, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var b PayoutBatch
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&b, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBatchNotFound
+			}
+			return err
+		}
+		if b.Status != PayoutDraft {
+			return ErrBatchNotDraft
+		}
+		for _, item := range b.Items {
+			var balance float64
+			if err := tx.Model(&Entry{}).
+				Where("account = ? AND owner_id = ?", AccountOwnerPayable, item.OwnerID).
+				Select("COALESCE(SUM(credit - debit), 0)").
+				Scan(&balance).Error; err != nil {
+				return err
+			}
+			if toCents(balance) < toCents(item.Amount) {
+				return fmt.Errorf("%w: owner_id=%d balance=%.2f payout=%.2f", ErrInsufficientBalance, item.OwnerID, balance, item.Amount)
+			}
+			t := payoutTransaction(b.ID, item, approvedBy)
+			if _, err := post(tx, t); err != nil {
+				return err
+			}
+			if err := tx.Model(&PayoutItem{}).Where("id = ?", item.ID).Update("transaction_id", t.ID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&PayoutBatch{}).Where("id = ?", b.ID).Updates(map[string]interface{}{
+			"status":      PayoutApproved,
+			"approved_by": approvedBy,
+			"approved_at": at,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetPayoutBatch(ctx, id)
+}
+
+func (r *Repository) CancelPayoutBatch(ctx context.Context, id int64, at time.Time) (*PayoutBatch, error) {
+	res := r.db.WithContext(ctx).Model(&PayoutBatch{}).
+		Where("id = ? AND status = ?", id, PayoutDraft).
+		Updates(map[string]interface{}{"status": PayoutCancelled, "cancelled_at": at})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	b, err := r.GetPayoutBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrBatchNotDraft
+	}
+	return b, nil
+}
+
+// ListPayoutRecipients — получатели пакета с реквизитами владельца
+func (r *Repository) ListPayoutRecipients(ctx context.Context, batchID int64) ([]PayoutRecipient, error) {
+	var out []PayoutRecipient
+	err := r.db.WithContext(ctx).
+		Table("payout_items AS pi").
+		Joins("LEFT JOIN users u ON u.id = pi.owner_id").
+		Joins("LEFT JOIN studio_owners so ON so.user_id = pi.owner_id").
+		Where("pi.batch_id = ?", batchID).
+		Select("pi.owner_id, COALESCE(u.name, '') AS name, COALESCE(u.email, '') AS email, " +
+			"COALESCE(so.company_name, '') AS company_name, COALESCE(so.bin, '') AS bin, pi.amount").
+		Order("pi.owner_id").
+		Scan(&out).Error
+	return out, err
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func roundCents(v float64) float64 {
+	return float64(toCents(v)) / 100
+}
@@ -0,0 +1,30 @@
+package ledger
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOwnerRoutes registers the studio owner's balance and statement
+func RegisterOwnerRoutes(r *gin.RouterGroup, handler *Handler) {
+	ledger := r.Group("/owner/ledger")
+	{
+		ledger.GET("/balance", handler.GetMyBalance)
+		ledger.GET("/statement", handler.GetMyStatement)
+	}
+}
+
+// RegisterAdminRoutes registers admin ledger and payout routes
+func RegisterAdminRoutes(r *gin.RouterGroup, handler *Handler) {
+	ledger := r.Group("/ledger")
+	{
+		ledger.GET("/balances", handler.ListBalances)
+		ledger.GET("/owners/:owner_id/statement", handler.GetOwnerStatement)
+		ledger.POST("/adjustments", handler.AddAdjustment)
+		ledger.GET("/commission-rates", handler.ListCommissionRates)
+		ledger.PUT("/commission-rates/:plan_id", handler.SetCommissionRate)
+		ledger.GET("/payouts", handler.ListPayoutBatches)
+		ledger.POST("/payouts", handler.CreatePayoutBatch)
+		ledger.GET("/payouts/:id", handler.GetPayoutBatch)
+		ledger.POST("/payouts/:id/approve", handler.ApprovePayoutBatch)
+		ledger.POST("/payouts/:id/cancel", handler.CancelPayoutBatch)
+		ledger.GET("/payouts/:id/export", handler.ExportPayoutBatch)
+	}
+}
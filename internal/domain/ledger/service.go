@@ -0,0 +1,277 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"photostudio/internal/domain/subscription"
+)
+
+// defaultCommissionPercent — ставка для тарифа, у которого она не задана
+const defaultCommissionPercent = 10
+
+// planResolver — текущий тариф владельца (subscription.Service)
+type planResolver interface {
+	GetPlan(ctx context.Context, ownerID int64) (*subscription.Plan, error)
+}
+
+// Service ведёт учёт того, что платформа должна владельцам студий: оплаты
+// броней, возвраты, корректировки и выплаты проводятся двойной записью.
+type Service struct {
+	repo              *Repository
+	plans             planResolver
+	loggerf           func(format string, args ...interface{})
+	defaultCommission float64
+}
+
+func NewService(repo *Repository, plans planResolver, loggerf func(format string, args ...interface{})) *Service {
+	if loggerf == nil {
+		loggerf = func(string, ...interface{}) {}
+	}
+	commission := float64(defaultCommissionPercent)
+	if v, err := strconv.ParseFloat(os.Getenv("PLATFORM_COMMISSION_PERCENT"), 64); err == nil && v >= 0 && v <= 100 {
+		commission = v
+	}
+	return &Service{repo: repo, plans: plans, loggerf: loggerf, defaultCommission: commission}
+}
+
+// RecordPayment учитывает оплаченный счёт: деньги поступили платформе, владельцу
+// причитается сумма за вычетом комиссии его тарифа. Повторный вызов для того же
+// счёта ничего не меняет.
+func (s *Service) RecordPayment(ctx context.Context, paymentID, bookingID int64, amount float64) error {
+	ownerID, err := s.repo.OwnerByBooking(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	rate, err := s.commissionRate(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	gross := toCents(amount)
+	commission := int64(math.Round(float64(gross) * rate / 100))
+	t := &Transaction{
+		Kind:           KindPayment,
+		Reference:      fmt.Sprintf("payment:%d", paymentID),
+		OwnerID:        ownerID,
+		BookingID:      &bookingID,
+		PaymentID:      &paymentID,
+		Amount:         cents(gross),
+		CommissionRate: rate,
+		Commission:     cents(commission),
+		Entries: []Entry{
+			{Account: AccountCash, Debit: cents(gross)},
+			{Account: AccountOwnerPayable, OwnerID: &ownerID, Credit: cents(gross - commission)},
+			{Account: AccountCommission, Credit: cents(commission)},
+		},
+	}
+	_, err = s.post(ctx, t)
+	return err
+}
+
+// RecordRefund учитывает подтверждённый возврат. Комиссия возвращается по той же
+// ставке, что была удержана с оплаты, но не больше удержанной.
+func (s *Service) RecordRefund(ctx context.Context, refundID, paymentID, bookingID int64, amount float64) error {
+	ownerID, rate := int64(0), 0.0
+	var maxCommission int64 = -1
+	original, err := s.repo.GetByReference(ctx, fmt.Sprintf("payment:%d", paymentID))
+	if err != nil {
+		return err
+	}
+	if original != nil {
+		refunded, err := s.repo.RefundedCommission(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+		ownerID, rate = original.OwnerID, original.CommissionRate
+		maxCommission = toCents(original.Commission) - toCents(refunded)
+	} else {
+		// оплата прошла до появления учёта — берём текущую ставку владельца
+		if ownerID, err = s.repo.OwnerByBooking(ctx, bookingID); err != nil {
+			return err
+		}
+		if rate, err = s.commissionRate(ctx, ownerID); err != nil {
+			return err
+		}
+	}
+
+	gross := toCents(amount)
+	commission := int64(math.Round(float64(gross) * rate / 100))
+	if maxCommission >= 0 && commission > maxCommission {
+		commission = maxCommission
+	}
+	t := &Transaction{
+		Kind:           KindRefund,
+		Reference:      fmt.Sprintf("refund:%d", refundID),
+		OwnerID:        ownerID,
+		BookingID:      &bookingID,
+		PaymentID:      &paymentID,
+		RefundID:       &refundID,
+		Amount:         cents(gross),
+		CommissionRate: rate,
+		Commission:     cents(commission),
+		Entries: []Entry{
+			{Account: AccountOwnerPayable, OwnerID: &ownerID, Debit: cents(gross - commission)},
+			{Account: AccountCommission, Debit: cents(commission)},
+			{Account: AccountCash, Credit: cents(gross)},
+		},
+	}
+	_, err = s.post(ctx, t)
+	return err
+}
+
+// AddAdjustment — ручная корректировка долга перед владельцем за счёт комиссии
+// платформы: amount > 0 — начислить владельцу (компенсация), amount < 0 — удержать.
+// Повтор с тем же ключом идемпотентности возвращает уже созданную корректировку.
+func (s *Service) AddAdjustment(ctx context.Context, req AdjustmentRequest, idempotencyKey, adminID string) (*Transaction, bool, error) {
+	amount := toCents(req.Amount)
+	if amount == 0 {
+		return nil, false, ErrInvalidAmount
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = uuid.NewString()
+	}
+	ownerID := req.OwnerID
+	owner, platform := Entry{Account: AccountOwnerPayable, OwnerID: &ownerID}, Entry{Account: AccountCommission}
+	if amount > 0 {
+		owner.Credit, platform.Debit = cents(amount), cents(amount)
+	} else {
+		owner.Debit, platform.Credit = cents(-amount), cents(-amount)
+	}
+	t := &Transaction{
+		Kind:        KindAdjustment,
+		Reference:   "adjustment:" + idempotencyKey,
+		OwnerID:     ownerID,
+		Amount:      cents(amount),
+		Description: req.Reason,
+		CreatedBy:   adminID,
+		Entries:     []Entry{owner, platform},
+	}
+	created, err := s.post(ctx, t)
+	if err != nil || created {
+		return t, created, err
+	}
+	existing, err := s.repo.GetByReference(ctx, t.Reference)
+	return existing, false, err
+}
+
+func (s *Service) post(ctx context.Context, t *Transaction) (bool, error) {
+	var debit, credit int64
+	for _, e := range t.Entries {
+		debit += toCents(e.Debit)
+		credit += toCents(e.Credit)
+	}
+	if debit != credit {
+		return false, fmt.Errorf("%w: %s debit=%d credit=%d", ErrUnbalanced, t.Reference, debit, credit)
+	}
+	created, err := s.repo.Post(ctx, t)
+	if err == nil && !created {
+		s.loggerf("level=info msg=ledger transaction already posted reference=%s", t.Reference)
+	}
+	return created, err
+}
+
+// commissionRate — процент комиссии по текущему тарифу владельца
+func (s *Service) commissionRate(ctx context.Context, ownerID int64) (float64, error) {
+	planID := string(subscription.PlanFree)
+	if s.plans != nil {
+		plan, err := s.plans.GetPlan(ctx, ownerID)
+		if err != nil {
+			return 0, err
+		}
+		if plan != nil {
+			planID = string(plan.ID)
+		}
+	}
+	rate, err := s.repo.GetCommissionRate(ctx, planID)
+	if err != nil {
+		return 0, err
+	}
+	if rate == nil {
+		return s.defaultCommission, nil
+	}
+	return rate.Percent, nil
+}
+
+func (s *Service) ListCommissionRates(ctx context.Context) ([]CommissionRate, error) {
+	return s.repo.ListCommissionRates(ctx)
+}
+
+// SetCommissionRate меняет ставку тарифа; уже учтённые оплаты не пересчитываются
+func (s *Service) SetCommissionRate(ctx context.Context, planID string, percent float64, adminID string) (*CommissionRate, error) {
+	if percent < 0 || percent > 100 {
+		return nil, ErrInvalidRate
+	}
+	rate := &CommissionRate{PlanID: planID, Percent: percent, UpdatedBy: adminID, UpdatedAt: time.Now().UTC()}
+	if err := s.repo.SaveCommissionRate(ctx, rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *Service) GetBalance(ctx context.Context, ownerID int64) (*OwnerBalance, error) {
+	return s.repo.GetBalance(ctx, ownerID)
+}
+
+func (s *Service) ListBalances(ctx context.Context, limit, offset int) ([]OwnerBalance, int64, error) {
+	return s.repo.ListBalances(ctx, limit, offset)
+}
+
+func (s *Service) Statement(ctx context.Context, ownerID int64, limit, offset int) ([]StatementLine, int64, error) {
+	return s.repo.Statement(ctx, ownerID, limit, offset)
+}
+
+// CreatePayoutBatch формирует черновик выплат всем владельцам с балансом от minAmount
+func (s *Service) CreatePayoutBatch(ctx context.Context, minAmount float64, adminID string) (*PayoutBatch, error) {
+	return s.repo.CreatePayoutBatch(ctx, minAmount, adminID)
+}
+
+func (s *Service) GetPayoutBatch(ctx context.Context, id int64) (*PayoutBatch, error) {
+	return s.repo.GetPayoutBatch(ctx, id)
+}
+
+func (s *Service) ListPayoutBatches(ctx context.Context, status PayoutStatus, limit, offset int) ([]PayoutBatch, int64, error) {
+	return s.repo.ListPayoutBatches(ctx, status, limit, offset)
+}
+
+// ApprovePayoutBatch подтверждает пакет: деньги переведены владельцам
+func (s *Service) ApprovePayoutBatch(ctx context.Context, id int64, adminID string) (*PayoutBatch, error) {
+	return s.repo.ApprovePayoutBatch(ctx, id, adminID, time.Now().UTC())
+}
+
+func (s *Service) CancelPayoutBatch(ctx context.Context, id int64) (*PayoutBatch, error) {
+	return s.repo.CancelPayoutBatch(ctx, id, time.Now().UTC())
+}
+
+func (s *Service) ListPayoutRecipients(ctx context.Context, batchID int64) ([]PayoutRecipient, error) {
+	if _, err := s.repo.GetPayoutBatch(ctx, batchID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPayoutRecipients(ctx, batchID)
+}
+
+// payoutTransaction — выплата владельцу: долг платформы перед ним гасится деньгами
+func payoutTransaction(batchID int64, item PayoutItem, approvedBy string) *Transaction {
+	ownerID := item.OwnerID
+	return &Transaction{
+		Kind:          KindPayout,
+		Reference:     fmt.Sprintf("payout:%d:%d", batchID, ownerID),
+		OwnerID:       ownerID,
+		PayoutBatchID: &batchID,
+		Amount:        item.Amount,
+		CreatedBy:     approvedBy,
+		Entries: []Entry{
+			{Account: AccountOwnerPayable, OwnerID: &ownerID, Debit: item.Amount},
+			{Account: AccountCash, Credit: item.Amount},
+		},
+	}
+}
+
+func cents(v int64) float64 {
+	return float64(v) / 100
+}
@@ -0,0 +1,181 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"photostudio/internal/database"
+	"photostudio/internal/domain/subscription"
+)
+
+type mockPlans struct {
+	plans map[int64]subscription.PlanID
+}
+
+func (m *mockPlans) GetPlan(ctx context.Context, ownerID int64) (*subscription.Plan, error) {
+	if id, ok := m.plans[ownerID]; ok {
+		return &subscription.Plan{ID: id}, nil
+	}
+	return &subscription.Plan{ID: subscription.PlanFree}, nil
+}
+
+func newTestService(t *testing.T) (*Service, *Repository) {
+	t.Helper()
+	db, err := database.Connect(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Transaction{}, &Entry{}, &CommissionRate{}, &PayoutBatch{}, &PayoutItem{}); err != nil {
+		t.Fatal(err)
+	}
+	// только поля, которые читает учёт
+	for _, q := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT)`,
+		`CREATE TABLE studio_owners (user_id INTEGER, company_name TEXT, bin TEXT)`,
+		`CREATE TABLE studios (id INTEGER PRIMARY KEY, owner_id INTEGER)`,
+		`CREATE TABLE bookings (id INTEGER PRIMARY KEY, studio_id INTEGER)`,
+		`INSERT INTO users VALUES (1, 'Aida', 'aida@example.com'), (2, 'Timur', 'timur@example.com')`,
+		`INSERT INTO studio_owners VALUES (1, 'Light Room LLP', '123456789012')`,
+		`INSERT INTO studios VALUES (100, 1), (200, 2)`,
+		`INSERT INTO bookings VALUES (10, 100), (20, 200)`,
+	} {
+		if err := db.Exec(q).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := NewRepository(db)
+	return NewService(repo, &mockPlans{plans: map[int64]subscription.PlanID{1: subscription.PlanPro}}, nil), repo
+}
+
+func balanceOf(t *testing.T, svc *Service, ownerID int64) float64 {
+	t.Helper()
+	b, err := svc.GetBalance(context.Background(), ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.Balance
+}
+
+func TestLedger_PaymentsRefundsAdjustments(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestService(t)
+	if _, err := svc.SetCommissionRate(ctx, "pro", 8, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	// pro — 8%, у free ставка не задана — 10% по умолчанию
+	if err := svc.RecordPayment(ctx, 1, 10, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.RecordPayment(ctx, 1, 10, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.RecordPayment(ctx, 2, 20, 500); err != nil {
+		t.Fatal(err)
+	}
+	if got := balanceOf(t, svc, 1); got != 920 {
+		t.Fatalf("expected 920 owed to owner 1 once, got %.2f", got)
+	}
+	if got := balanceOf(t, svc, 2); got != 450 {
+		t.Fatalf("expected 450 owed to owner 2, got %.2f", got)
+	}
+
+	// возврат забирает и долю владельца, и комиссию по ставке оплаты
+	if _, err := svc.SetCommissionRate(ctx, "pro", 50, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.RecordRefund(ctx, 1, 1, 10, 250); err != nil {
+		t.Fatal(err)
+	}
+	if got := balanceOf(t, svc, 1); got != 690 {
+		t.Fatalf("expected 690 after refund, got %.2f", got)
+	}
+
+	adj, created, err := svc.AddAdjustment(ctx, AdjustmentRequest{OwnerID: 2, Amount: -50, Reason: "penalty"}, "adj-1", "admin")
+	if err != nil || !created {
+		t.Fatalf("expected adjustment created, got %v err=%v", created, err)
+	}
+	again, created, err := svc.AddAdjustment(ctx, AdjustmentRequest{OwnerID: 2, Amount: -50, Reason: "penalty"}, "adj-1", "admin")
+	if err != nil || created || again.ID != adj.ID {
+		t.Fatalf("expected idempotent adjustment, got %+v created=%v err=%v", again, created, err)
+	}
+	if got := balanceOf(t, svc, 2); got != 400 {
+		t.Fatalf("expected 400 after adjustment, got %.2f", got)
+	}
+	if _, _, err := svc.AddAdjustment(ctx, AdjustmentRequest{OwnerID: 2, Reason: "zero"}, "", "admin"); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount, got %v", err)
+	}
+
+	var debit, credit float64
+	repo.db.Model(&Entry{}).Select("SUM(debit)").Scan(&debit)
+	repo.db.Model(&Entry{}).Select("SUM(credit)").Scan(&credit)
+	if toCents(debit) != toCents(credit) {
+		t.Fatalf("ledger is not balanced: debit=%.2f credit=%.2f", debit, credit)
+	}
+
+	lines, total, err := svc.Statement(ctx, 1, 50, 0)
+	if err != nil || total != 2 || lines[0].Kind != KindRefund || lines[0].Net != -230 || lines[1].Net != 920 {
+		t.Fatalf("unexpected statement %+v total=%d err=%v", lines, total, err)
+	}
+}
+
+func TestLedger_PayoutBatches(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t)
+	_ = svc.RecordPayment(ctx, 1, 10, 1000) // owner 1: 900
+	_ = svc.RecordPayment(ctx, 2, 20, 300)  // owner 2: 270
+
+	first, err := svc.CreatePayoutBatch(ctx, 500, "admin")
+	if err != nil || first.ItemsCount != 1 || first.Items[0].OwnerID != 1 || first.Total != 900 {
+		t.Fatalf("expected a batch for owner 1 only, got %+v err=%v", first, err)
+	}
+	if b, _ := svc.GetBalance(ctx, 1); b.InPayout != 900 || b.Available != 0 {
+		t.Fatalf("expected the balance reserved by the draft, got %+v", b)
+	}
+	second, err := svc.CreatePayoutBatch(ctx, 0, "admin")
+	if err != nil || second.ItemsCount != 1 || second.Items[0].OwnerID != 2 {
+		t.Fatalf("expected owner 1 excluded from the second batch, got %+v err=%v", second, err)
+	}
+	if _, err := svc.CreatePayoutBatch(ctx, 0, "admin"); !errors.Is(err, ErrNothingToPayOut) {
+		t.Fatalf("expected ErrNothingToPayOut, got %v", err)
+	}
+
+	// возврат после формирования пакета — подтверждать нельзя
+	_ = svc.RecordRefund(ctx, 1, 1, 10, 100)
+	if _, err := svc.ApprovePayoutBatch(ctx, first.ID, "admin"); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+	if _, err := svc.CancelPayoutBatch(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	redo, err := svc.CreatePayoutBatch(ctx, 0, "admin")
+	if err != nil || redo.Total != 810 {
+		t.Fatalf("expected a new batch for the reduced balance, got %+v err=%v", redo, err)
+	}
+	approved, err := svc.ApprovePayoutBatch(ctx, redo.ID, "admin")
+	if err != nil || approved.Status != PayoutApproved || approved.Items[0].TransactionID == nil {
+		t.Fatalf("expected approved batch, got %+v err=%v", approved, err)
+	}
+	if got := balanceOf(t, svc, 1); got != 0 {
+		t.Fatalf("expected owner 1 paid out, got %.2f", got)
+	}
+	if _, err := svc.ApprovePayoutBatch(ctx, redo.ID, "admin"); !errors.Is(err, ErrBatchNotDraft) {
+		t.Fatalf("expected ErrBatchNotDraft, got %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterAdminRoutes(r.Group("/admin"), NewHandler(svc))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/ledger/payouts/"+strconv.FormatInt(redo.ID, 10)+"/export", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "1,Aida,aida@example.com,Light Room LLP,123456789012,810.00") {
+		t.Fatalf("unexpected export %d %q", w.Code, w.Body.String())
+	}
+}
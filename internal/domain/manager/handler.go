@@ -1,9 +1,11 @@
 package manager
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"photostudio/internal/domain/booking"
+	"photostudio/internal/domain/catalog"
 	"photostudio/internal/domain/owner"
 	"photostudio/internal/pkg/response"
 	"strconv"
@@ -11,14 +13,16 @@ import (
 )
 
 type Handler struct {
-	bookingRepo booking.BookingRepository
-	ownerRepo   *owner.OwnerCRMRepository
+	bookingRepo    booking.BookingRepository
+	ownerRepo      *owner.OwnerCRMRepository
+	bookingService *booking.Service
 }
 
-func NewHandler(bookingRepo booking.BookingRepository, ownerRepo *owner.OwnerCRMRepository) *Handler {
+func NewHandler(bookingRepo booking.BookingRepository, ownerRepo *owner.OwnerCRMRepository, bookingService *booking.Service) *Handler {
 	return &Handler{
-		bookingRepo: bookingRepo,
-		ownerRepo:   ownerRepo,
+		bookingRepo:    bookingRepo,
+		ownerRepo:      ownerRepo,
+		bookingService: bookingService,
 	}
 }
 
@@ -126,16 +130,16 @@ func (h *Handler) GetBooking(c *gin.Context) {
 }
 
 type UpdateDepositRequest struct {
-	DepositAmount float64 `json:"deposit_amount" binding:"required,min=0"`
+	DepositAmount float64 `json:"deposit_amount" binding:"required,gt=0"`
 }
 
-// UpdateDeposit обновляет внесённою стоимость на бронировании.
-// @Summary		Обновить рассчётные средства
-// @Description	Обновляет внесённые средства (залог или авансовые платежи) для бронирования.
+// UpdateDeposit записывает оплату, принятую студией на месте.
+// @Summary		Записать оплату на месте
+// @Description	Прибавляет принятую студией сумму (наличные, перевод) к внесённой по брони. Сумма не может превышать остаток; при полной оплате бронь становится оплаченной.
 // @Tags		Менеджер - Управление бронированиями
 // @Security	BearerAuth
 // @Param		id		path	int						true	"ID бронирования"
-// @Param		request	body	UpdateDepositRequest		true	"Новая сумма залога"
+// @Param		request	body	UpdateDepositRequest		true	"Принятая сумма"
 // @Success		200	{object}		map[string]interface{} "Намавка депозита обновлена"
 // @Failure		400	{object}		map[string]interface{} "Ошибка: неверные данные"
 // @Failure		401	{object}		map[string]interface{} "Ошибка аутентификации"
@@ -164,7 +168,16 @@ func (h *Handler) UpdateDeposit(c *gin.Context) {
 		return
 	}
 
-	if err := h.bookingRepo.UpdateDeposit(c.Request.Context(), bookingID, req.DepositAmount); err != nil {
+	actor := booking.Actor{UserID: ownerID, Role: booking.ActorOwner}
+	if _, err := h.bookingService.UpdateDeposit(c.Request.Context(), bookingID, req.DepositAmount, actor); err != nil {
+		if errors.Is(err, booking.ErrValidation) {
+			response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Amount must not exceed the outstanding balance")
+			return
+		}
+		if errors.Is(err, booking.ErrInvalidStatusTransition) {
+			response.CustomError(c, http.StatusBadRequest, "INVALID_STATUS", "Booking cannot be paid in its current status")
+			return
+		}
 		response.CustomError(c, http.StatusInternalServerError, "UPDATE_FAILED", err)
 		return
 	}
@@ -173,16 +186,17 @@ func (h *Handler) UpdateDeposit(c *gin.Context) {
 }
 
 type UpdateStatusRequest struct {
-	Status string `json:"status" binding:"required,oneof=pending confirmed cancelled completed"`
+	Status string `json:"status" binding:"required,oneof=confirmed cancelled completed no_show"`
+	Reason string `json:"reason,omitempty"`
 }
 
 // UpdateBookingStatus обновляет статус бронирования.
 // @Summary		Обновить статус бронирования
-// @Description	Меняет статус бронирования: pending -> confirmed -> completed или cancelled.
+// @Description	Меняет статус бронирования по таблице переходов: pending -> confirmed -> completed / no_show, либо cancelled. Изменение пишется в историю статусов.
 // @Tags		Менеджер - Управление бронированиями
 // @Security	BearerAuth
 // @Param		id		path	int						true	"ID бронирования"
-// @Param		request	body	UpdateStatusRequest		true	"Новый статус (pending, confirmed, cancelled, completed)"
+// @Param		request	body	UpdateStatusRequest		true	"Новый статус (confirmed, cancelled, completed, no_show) и причина"
 // @Success		200	{object}		map[string]interface{} "Статус бронирования обновлен"
 // @Failure		400	{object}		map[string]interface{} "Ошибка: неверные данные"
 // @Failure		401	{object}		map[string]interface{} "Ошибка аутентификации"
@@ -211,7 +225,22 @@ func (h *Handler) UpdateBookingStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.bookingRepo.UpdateStatus(c.Request.Context(), bookingID, req.Status); err != nil {
+	actor := booking.Actor{UserID: ownerID, Role: booking.ActorOwner}
+	if booking.BookingStatus(req.Status) == booking.BookingCancelled {
+		// отмена студией — полный возврат оплаченного, политика к ней не применяется
+		_, err = h.bookingService.CancelBooking(c.Request.Context(), bookingID, actor, req.Reason)
+	} else {
+		_, err = h.bookingRepo.TransitionStatus(c.Request.Context(), bookingID, booking.BookingStatus(req.Status), actor, req.Reason)
+	}
+	if err != nil {
+		if errors.Is(err, booking.ErrInvalidStatusTransition) {
+			response.CustomError(c, http.StatusBadRequest, "INVALID_STATUS_TRANSITION", "Invalid status transition")
+			return
+		}
+		if errors.Is(err, booking.ErrSharesNotCovered) {
+			response.CustomError(c, http.StatusBadRequest, "SHARES_NOT_COVERED", "Not all participant shares are paid yet")
+			return
+		}
 		response.CustomError(c, http.StatusInternalServerError, "UPDATE_FAILED", err)
 		return
 	}
@@ -219,9 +248,114 @@ func (h *Handler) UpdateBookingStatus(c *gin.Context) {
 	response.Success(c, http.StatusOK, gin.H{"message": "Status updated"})
 }
 
+// CreateGuestBooking создаёт бронь за гостя без аккаунта.
+// @Summary		Записать гостя
+// @Description	Бронь по телефону или на стойке для человека без аккаунта: имя и телефон или email гостя сохраняются в брони. Слот проверяется как при обычном бронировании (часы работы, закрытия, буферы, оборудование), бронь сразу подтверждается. Гость виден в списке броней и клиентов; позже бронь можно привязать к аккаунту.
+// @Tags		Менеджер - Управление бронированиями
+// @Security	BearerAuth
+// @Param		request	body	booking.GuestBookingRequest	true	"Комната, время и контакты гостя"
+// @Success		201	{object}		map[string]interface{} "Бронь создана"
+// @Failure		400	{object}		map[string]interface{} "Ошибка: неверные данные или время вне часов работы"
+// @Failure		401	{object}		map[string]interface{} "Ошибка аутентификации"
+// @Failure		403	{object}		map[string]interface{} "Комната не принадлежит владельцу"
+// @Failure		404	{object}		map[string]interface{} "Комната не найдена"
+// @Failure		409	{object}		map[string]interface{} "Слот занят"
+// @Failure		500	{object}		map[string]interface{} "Ошибка сервера"
+// @Router		/manager/bookings [POST]
+func (h *Handler) CreateGuestBooking(c *gin.Context) {
+	ownerID := c.GetInt64("user_id")
+
+	var req booking.GuestBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_REQUEST", err)
+		return
+	}
+
+	actor := booking.Actor{UserID: ownerID, Role: booking.ActorOwner}
+	b, err := h.bookingService.CreateGuestBooking(c.Request.Context(), actor, req)
+	if err != nil {
+		writeGuestBookingError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{"booking": b})
+}
+
+type LinkGuestBookingRequest struct {
+	UserID int64 `json:"user_id" binding:"required,min=1"`
+}
+
+// LinkGuestBooking привязывает гостевую бронь к аккаунту клиента.
+// @Summary		Привязать гостя к аккаунту
+// @Description	Гость зарегистрировался — бронь переходит в его аккаунт и историю. Контакты гостя в брони сохраняются. Привязать можно только бронь без клиента.
+// @Tags		Менеджер - Управление бронированиями
+// @Security	BearerAuth
+// @Param		id		path	int						true	"ID бронирования"
+// @Param		request	body	LinkGuestBookingRequest	true	"ID пользователя"
+// @Success		200	{object}		map[string]interface{} "Бронь привязана"
+// @Failure		400	{object}		map[string]interface{} "Ошибка: неверные данные или бронь уже привязана"
+// @Failure		401	{object}		map[string]interface{} "Ошибка аутентификации"
+// @Failure		404	{object}		map[string]interface{} "Бронирование или пользователь не найдены"
+// @Failure		500	{object}		map[string]interface{} "Ошибка сервера"
+// @Router		/manager/bookings/:id/client [PATCH]
+func (h *Handler) LinkGuestBooking(c *gin.Context) {
+	ownerID := c.GetInt64("user_id")
+
+	bookingID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_ID", "Invalid booking ID")
+		return
+	}
+
+	var req LinkGuestBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.CustomError(c, http.StatusBadRequest, "INVALID_REQUEST", err)
+		return
+	}
+
+	actor := booking.Actor{UserID: ownerID, Role: booking.ActorOwner}
+	b, err := h.bookingService.LinkGuestBooking(c.Request.Context(), actor, bookingID, req.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, booking.ErrValidation):
+			response.CustomError(c, http.StatusBadRequest, "NOT_A_GUEST_BOOKING", "Booking is already linked to a client")
+		case errors.Is(err, booking.ErrNotFound), errors.Is(err, booking.ErrForbidden):
+			response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Booking or user not found")
+		default:
+			response.CustomError(c, http.StatusInternalServerError, "UPDATE_FAILED", err)
+		}
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"booking": b})
+}
+
+func writeGuestBookingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, booking.ErrValidation):
+		response.CustomError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Guest name, a phone or email and a future time range are required")
+	case errors.Is(err, booking.ErrOutsideWorkingHours):
+		response.CustomError(c, http.StatusBadRequest, "OUTSIDE_WORKING_HOURS", "Booking must fit within studio working hours (studio local time)")
+	case errors.Is(err, booking.ErrClosedPeriod):
+		response.CustomError(c, http.StatusBadRequest, "CLOSED_PERIOD", "Room is closed for the selected time")
+	case errors.Is(err, catalog.ErrBelowMinDuration):
+		response.CustomError(c, http.StatusBadRequest, "BELOW_MIN_DURATION", "Booking is shorter than the room minimum duration")
+	case errors.Is(err, booking.ErrForbidden):
+		response.CustomError(c, http.StatusForbidden, "FORBIDDEN", "Room does not belong to your studios")
+	case errors.Is(err, booking.ErrNotFound):
+		response.CustomError(c, http.StatusNotFound, "NOT_FOUND", "Room not found")
+	case errors.Is(err, booking.ErrNotAvailable), errors.Is(err, booking.ErrOverbooking):
+		response.CustomError(c, http.StatusConflict, "BOOKING_CONFLICT", "Room is not available for selected time")
+	case errors.Is(err, booking.ErrEquipmentUnavailable):
+		response.CustomError(c, http.StatusConflict, "EQUIPMENT_UNAVAILABLE", "Rented equipment is not available for selected time")
+	default:
+		response.CustomError(c, http.StatusInternalServerError, "CREATE_FAILED", err)
+	}
+}
+
 // GetClients получает список клиентов студий владельца.
 // @Summary		Получить список клиентов
-// @Description	Выводит список всех клиентов, которые бронировали студии владельца, с возможностью поиска по имени.
+// @Description	Выводит список всех клиентов, которые бронировали студии владельца, с возможностью поиска по имени, email или телефону. Гости без аккаунта (is_guest, id = 0) группируются по телефону, без телефона — по email.
 // @Tags		Менеджер - Отношения с клиентами
 // @Security	BearerAuth
 // @Param		search	query	string	false	"Поиск по имени клиента"
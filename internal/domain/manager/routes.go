@@ -6,9 +6,11 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	mgr := rg.Group("/manager")
 	{
 		mgr.GET("/bookings", h.GetBookings)
+		mgr.POST("/bookings", h.CreateGuestBooking)
 		mgr.GET("/bookings/:id", h.GetBooking)
 		mgr.PATCH("/bookings/:id/deposit", h.UpdateDeposit)
 		mgr.PATCH("/bookings/:id/status", h.UpdateBookingStatus)
+		mgr.PATCH("/bookings/:id/client", h.LinkGuestBooking)
 		mgr.GET("/clients", h.GetClients)
 	}
 }
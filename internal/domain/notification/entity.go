@@ -11,10 +11,19 @@ type Type string
 
 const (
 	// Booking notifications
-	TypeBookingCreated   Type = "booking_created"   // Owner: новое бронирование
-	TypeBookingConfirmed Type = "booking_confirmed" // Client: бронирование подтверждено
-	TypeBookingCancelled Type = "booking_cancelled" // Client: бронирование отменено
-	TypeBookingCompleted Type = "booking_completed" // Both: бронирование завершено
+	TypeBookingCreated     Type = "booking_created"     // Owner: новое бронирование
+	TypeBookingConfirmed   Type = "booking_confirmed"   // Client: бронирование подтверждено
+	TypeBookingCancelled   Type = "booking_cancelled"   // Client: бронирование отменено
+	TypeBookingCompleted   Type = "booking_completed"   // Both: бронирование завершено
+	TypeBookingRescheduled Type = "booking_rescheduled" // Both: бронирование перенесено
+	TypeBookingNoShow      Type = "booking_no_show"     // Both: клиент не пришёл
+	TypeBookingReminder    Type = "booking_reminder"    // Client: скоро начало сеанса
+
+	// Payments
+	TypePaymentRefunded Type = "payment_refunded" // Client: деньги возвращены
+
+	// Waitlist
+	TypeWaitlistSlotAvailable Type = "waitlist_slot_available" // Client: освободилось время из листа ожидания
 
 	// Verification notifications
 	TypeVerificationApproved Type = "verification_approved" // Owner: верификация студии одобрена
@@ -67,6 +76,12 @@ type NotificationData struct {
 	StartTime          *string `json:"start_time,omitempty"` // ISO8601 format
 	EndTime            *string `json:"end_time,omitempty"`   // ISO8601 format
 	CancellationReason *string `json:"cancellation_reason,omitempty"`
+
+	RefundAmount *float64 `json:"refund_amount,omitempty"` // возврат оплаты
+
+	WaitlistEntryID *int64  `json:"waitlist_entry_id,omitempty"`
+	ClaimURL        *string `json:"claim_url,omitempty"`        // ссылка, по которой клиент забирает слот
+	ClaimExpiresAt  *string `json:"claim_expires_at,omitempty"` // ISO8601 format
 }
 
 // SetData encodes data to JSON
@@ -171,6 +171,94 @@ func (s *Service) NotifyBookingCompleted(ctx context.Context, userID int64, book
 	return err
 }
 
+// NotifyBookingNoShow notifies that booking was marked as no-show
+func (s *Service) NotifyBookingNoShow(ctx context.Context, userID int64, bookingID, studioID int64) error {
+	_, err := s.Create(ctx, userID, TypeBookingNoShow,
+		"Неявка на бронирование",
+		"Бронирование отмечено как неявка: оплата не поступила",
+		&NotificationData{
+			BookingID: &bookingID,
+			StudioID:  &studioID,
+		},
+	)
+	return err
+}
+
+// NotifyPaymentRefunded notifies client that money was returned for a booking
+func (s *Service) NotifyPaymentRefunded(ctx context.Context, clientID int64, bookingID, studioID int64, amount float64) error {
+	_, err := s.Create(ctx, clientID, TypePaymentRefunded,
+		"Возврат оплаты",
+		fmt.Sprintf("Студия оформила возврат %.2f по бронированию. Деньги поступят в течение нескольких рабочих дней", amount),
+		&NotificationData{
+			BookingID:    &bookingID,
+			StudioID:     &studioID,
+			RefundAmount: &amount,
+		},
+	)
+	return err
+}
+
+// NotifyBookingReminder reminds client about upcoming session.
+// Respects user preferences: nothing is created if in-app reminders are turned off.
+func (s *Service) NotifyBookingReminder(ctx context.Context, userID int64, bookingID, studioID int64, startTime time.Time, hoursBefore int) error {
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !prefs.GetChannelSettings(TypeBookingReminder).InApp {
+		return nil
+	}
+
+	startStr := startTime.Format(time.RFC3339)
+	_, err = s.Create(ctx, userID, TypeBookingReminder,
+		"Напоминание о бронировании",
+		fmt.Sprintf("Сеанс начнётся через %d ч — %s", hoursBefore, startTime.Format("02.01.2006 15:04")),
+		&NotificationData{
+			BookingID: &bookingID,
+			StudioID:  &studioID,
+			StartTime: &startStr,
+		},
+	)
+	return err
+}
+
+// NotifyBookingRescheduled notifies the other party that booking time was changed
+func (s *Service) NotifyBookingRescheduled(ctx context.Context, userID int64, bookingID, studioID int64, oldStart, newStart time.Time) error {
+	newStartStr := newStart.Format(time.RFC3339)
+	_, err := s.Create(ctx, userID, TypeBookingRescheduled,
+		"Бронирование перенесено",
+		fmt.Sprintf("Бронирование перенесено с %s на %s",
+			oldStart.Format("02.01.2006 15:04"), newStart.Format("02.01.2006 15:04")),
+		&NotificationData{
+			BookingID: &bookingID,
+			StudioID:  &studioID,
+			StartTime: &newStartStr,
+		},
+	)
+	return err
+}
+
+// NotifyWaitlistSlotAvailable offers a freed slot to a waitlisted client.
+// The claim link works until expiresAt, after that the slot goes to the next client.
+func (s *Service) NotifyWaitlistSlotAvailable(ctx context.Context, userID int64, entryID, studioID, roomID int64, startTime time.Time, claimURL string, expiresAt time.Time) error {
+	startTimeStr := startTime.Format(time.RFC3339)
+	expiresAtStr := expiresAt.Format(time.RFC3339)
+	_, err := s.Create(ctx, userID, TypeWaitlistSlotAvailable,
+		"Освободилось время",
+		fmt.Sprintf("Освободилось время на %s. Забронируйте его до %s",
+			startTime.Format("02.01.2006 15:04"), expiresAt.Format("15:04")),
+		&NotificationData{
+			StudioID:        &studioID,
+			RoomID:          &roomID,
+			StartTime:       &startTimeStr,
+			WaitlistEntryID: &entryID,
+			ClaimURL:        &claimURL,
+			ClaimExpiresAt:  &expiresAtStr,
+		},
+	)
+	return err
+}
+
 // NotifyVerificationApproved notifies owner that studio was verified
 func (s *Service) NotifyVerificationApproved(ctx context.Context, ownerID int64, studioID int64) error {
 	_, err := s.Create(ctx, ownerID, TypeVerificationApproved,
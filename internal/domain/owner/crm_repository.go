@@ -346,15 +346,18 @@ func (r *OwnerCRMRepository) ReorderPortfolio(ctx context.Context, ownerID int64
 }
 
 type ClientInfo struct {
-	ID            int64      `json:"id"`
+	ID            int64      `json:"id"` // 0 у гостя без аккаунта
 	Name          string     `json:"name"`
 	Email         string     `json:"email"`
 	Phone         string     `json:"phone"`
+	IsGuest       bool       `json:"is_guest"`
 	TotalBookings int64      `json:"total_bookings"`
 	TotalSpent    float64    `json:"total_spent"`
 	LastBookingAt *time.Time `json:"last_booking_at,omitempty"`
 }
 
+// GetClients — клиенты студий владельца: аккаунты и гости, записанные без
+// аккаунта (гость — один телефон, без телефона — один email).
 func (r *OwnerCRMRepository) GetClients(ctx context.Context, ownerID int64, search string, page, perPage int) ([]ClientInfo, int64, error) {
 	if perPage == 0 {
 		perPage = 20
@@ -376,14 +379,15 @@ func (r *OwnerCRMRepository) GetClients(ctx context.Context, ownerID int64, sear
 		return []ClientInfo{}, 0, nil
 	}
 
-	// 2) query
-	query := r.db.WithContext(ctx).
+	// 2) query: клиенты с аккаунтом + гости
+	users := r.db.
 		Table("users u").
 		Select(`
 			u.id,
 			u.name,
 			u.email,
 			u.phone,
+			false as is_guest,
 			COUNT(b.id) as total_bookings,
 			COALESCE(SUM(b.total_price), 0) as total_spent,
 			MAX(b.created_at) as last_booking_at
@@ -392,18 +396,34 @@ func (r *OwnerCRMRepository) GetClients(ctx context.Context, ownerID int64, sear
 		Where("b.studio_id IN ?", studioIDs).
 		Group("u.id, u.name, u.email, u.phone")
 
+	guests := r.db.
+		Table("bookings b").
+		Select(`
+			0 as id,
+			MAX(b.guest_name) as name,
+			COALESCE(MAX(b.guest_email), '') as email,
+			COALESCE(MAX(b.guest_phone), '') as phone,
+			true as is_guest,
+			COUNT(b.id) as total_bookings,
+			COALESCE(SUM(b.total_price), 0) as total_spent,
+			MAX(b.created_at) as last_booking_at
+		`).
+		Where("b.user_id IS NULL AND b.studio_id IN ?", studioIDs).
+		Group("COALESCE(NULLIF(b.guest_phone, ''), LOWER(b.guest_email), LOWER(b.guest_name))")
+
+	query := r.db.WithContext(ctx).Table("(? UNION ALL ?) as c", users, guests)
+
 	// ⚠️ SQLite+PG совместимый поиск
 	if search != "" {
 		query = query.Where(`
-			LOWER(u.name) LIKE LOWER(?) OR LOWER(u.email) LIKE LOWER(?) OR LOWER(u.phone) LIKE LOWER(?)
+			LOWER(c.name) LIKE LOWER(?) OR LOWER(c.email) LIKE LOWER(?) OR LOWER(c.phone) LIKE LOWER(?)
 		`, "%"+search+"%", "%"+search+"%", "%"+search+"%")
 	}
 
-	// 3) count через subquery
+	// 3) count
 	var total int64
-	countQ := r.db.WithContext(ctx).Table("(?) as subquery", query).Count(&total)
-	if countQ.Error != nil {
-		return nil, 0, countQ.Error
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
 
 	// 4) fetch
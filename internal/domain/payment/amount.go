@@ -0,0 +1,114 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"photostudio/internal/domain/booking"
+	"strconv"
+	"strings"
+)
+
+// Kind — за что выставлен счёт
+type Kind string
+
+const (
+	KindFull    Kind = "full"    // вся стоимость брони
+	KindDeposit Kind = "deposit" // предоплата, остаток доплачивается отдельным счётом
+	KindBalance Kind = "balance" // остаток после внесённых оплат
+	KindShare   Kind = "share"   // доля участника разделённой брони
+)
+
+const defaultDepositPercent = 30
+
+// invoiceAmount считает сумму счёта по брони. Сумма клиента (out_sum) задаёт
+// только размер предоплаты; для остальных видов она должна совпасть с расчётом.
+// Внесённые оплаты копятся в DepositAmount, поэтому остаток = TotalPrice - DepositAmount.
+func (s *Service) invoiceAmount(ctx context.Context, b *booking.Booking, req InitPaymentRequest) (Kind, float64, error) {
+	switch b.Status {
+	case booking.BookingCancelled, booking.BookingNoShow:
+		return "", 0, ErrNotPayable
+	}
+	if b.PaymentStatus != booking.PaymentUnpaid && b.PaymentStatus != "" {
+		return "", 0, ErrNothingToPay
+	}
+	outstanding := toCents(b.TotalPrice) - toCents(b.DepositAmount)
+	if outstanding <= 0 {
+		return "", 0, ErrNothingToPay
+	}
+
+	var requested int64
+	if strings.TrimSpace(req.OutSum) != "" {
+		v, err := strconv.ParseFloat(strings.TrimSpace(req.OutSum), 64)
+		if err != nil || v <= 0 {
+			return "", 0, fmt.Errorf("%w: out_sum must be a positive number", ErrInvalidAmount)
+		}
+		requested = toCents(v)
+	}
+
+	if req.ParticipantID > 0 {
+		share, err := s.participantShare(ctx, req)
+		if err != nil {
+			return "", 0, err
+		}
+		if requested > 0 && requested != toCents(share) {
+			return "", 0, fmt.Errorf("%w: out_sum must equal the participant share %.2f", ErrInvalidShare, share)
+		}
+		return KindShare, share, nil
+	}
+
+	kind := Kind(req.Kind)
+	if kind == "" {
+		kind = KindBalance
+		if b.DepositAmount <= 0 {
+			kind = KindFull
+		}
+	}
+
+	var amount int64
+	switch kind {
+	case KindFull:
+		if b.DepositAmount > 0 {
+			return "", 0, fmt.Errorf("%w: booking is partially paid, pay the balance instead", ErrInvalidAmount)
+		}
+		amount = outstanding
+	case KindBalance:
+		amount = outstanding
+	case KindDeposit:
+		// предоплата не меньше depositPercent% стоимости (или всего остатка, если он меньше)
+		minimum := int64(math.Round(float64(toCents(b.TotalPrice)) * float64(s.depositPercent) / 100))
+		if minimum > outstanding {
+			minimum = outstanding
+		}
+		amount = requested
+		if amount == 0 {
+			amount = minimum
+		}
+		if amount < minimum || amount > outstanding {
+			return "", 0, fmt.Errorf("%w: deposit must be between %.2f and the outstanding balance %.2f", ErrInvalidAmount, float64(minimum)/100, float64(outstanding)/100)
+		}
+		return kind, float64(amount) / 100, nil
+	default:
+		return "", 0, fmt.Errorf("%w: unknown kind %q", ErrInvalidAmount, req.Kind)
+	}
+	if requested > 0 && requested != amount {
+		return "", 0, fmt.Errorf("%w: out_sum must equal %.2f", ErrInvalidAmount, float64(amount)/100)
+	}
+	return kind, float64(amount) / 100, nil
+}
+
+// participantShare — доля участника из этой брони, ещё не оплаченная
+func (s *Service) participantShare(ctx context.Context, req InitPaymentRequest) (float64, error) {
+	pt, err := s.bookings.GetParticipant(ctx, req.ParticipantID)
+	if err != nil {
+		return 0, fmt.Errorf("%w: participant not found", ErrInvalidShare)
+	}
+	if pt.BookingID != req.BookingID || pt.Status == booking.ParticipantPaid {
+		return 0, fmt.Errorf("%w: participant does not belong to the booking or has already paid", ErrInvalidShare)
+	}
+	return pt.ShareAmount, nil
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
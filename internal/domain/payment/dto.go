@@ -1,19 +1,50 @@
 package payment
 
 type InitPaymentRequest struct {
-	BookingID   int64             `json:"booking_id" binding:"required" example:"123"`
-	OutSum      string            `json:"out_sum" binding:"required" example:"2500.00"`
+	BookingID int64 `json:"booking_id" binding:"required" example:"123"`
+	// Вид счёта: full, deposit или balance. По умолчанию — остаток к оплате
+	Kind string `json:"kind,omitempty" binding:"omitempty,oneof=full deposit balance" example:"deposit"`
+	// Сумма считается по брони; out_sum задаёт размер предоплаты (kind=deposit,
+	// не меньше PAYMENT_DEPOSIT_PERCENT стоимости), для остальных видов должна совпасть с расчётом
+	OutSum      string            `json:"out_sum,omitempty" example:"2500.00"`
 	Description string            `json:"description" example:"Room booking #123"`
 	ShpParams   map[string]string `json:"shp_params" example:"{\"booking_id\":\"123\"}"`
+	// Счёт на долю участника брони: out_sum должен совпадать с его долей
+	ParticipantID int64 `json:"participant_id,omitempty" example:"7"`
+	// Платёжный провайдер; пусто — провайдер по умолчанию
+	Provider string `json:"provider,omitempty" example:"robokassa"`
 }
 
 type InitPaymentResponse struct {
+	PaymentID  int64  `json:"payment_id" example:"42"`
+	Provider   string `json:"provider" example:"robokassa"`
+	Kind       string `json:"kind" example:"deposit"`
+	Amount     string `json:"amount" example:"750.00"`
+	Balance    string `json:"balance" example:"1750.00"` // останется доплатить после этого счёта
 	InvID      int64  `json:"inv_id" example:"1700000000000000000"`
 	PaymentURL string `json:"payment_url" example:"https://auth.robokassa.ru/Merchant/Index.aspx?..."`
 	Signature  string `json:"signature" example:"ABCDEF1234567890ABCDEF1234567890"`
 	Status     string `json:"status" example:"created"`
 }
 
+// RefundRequest — возврат по счёту; amount = 0 — весь ещё не возвращённый остаток
+type RefundRequest struct {
+	PaymentID int64   `json:"payment_id" binding:"required" example:"42"`
+	Amount    float64 `json:"amount" binding:"min=0" example:"1500"`
+	Reason    string  `json:"reason" binding:"max=500" example:"Студия отменила съёмку"`
+}
+
+// ResolveDiscrepancyRequest — действие администратора над расхождением сверки
+type ResolveDiscrepancyRequest struct {
+	Action string `json:"action" binding:"required,oneof=recheck mark_failed apply_payment dismiss" example:"apply_payment"`
+	Note   string `json:"note" binding:"max=500" example:"Оплата пришла, callback потерялся"`
+}
+
+type DiscrepancyListResponse struct {
+	Items []Discrepancy `json:"items"`
+	Total int64         `json:"total" example:"3"`
+}
+
 type ErrorResponse struct {
 	Error string `json:"error" example:"invalid request"`
 }
@@ -0,0 +1,167 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// FakeProvider — шлюз в памяти процесса для тестов и локальной разработки.
+// Callback-и подписываются HMAC-SHA256 секретом провайдера; Callback собирает
+// подписанное уведомление так, как его прислал бы настоящий шлюз.
+type FakeProvider struct {
+	name   string
+	secret string
+
+	mu           sync.Mutex
+	invoices     map[int64]*fakeInvoice
+	refunds      []ProviderRefundRequest
+	refundStates map[string]RefundStatus
+	asyncRefunds bool
+}
+
+type fakeInvoice struct {
+	amount string
+	status Status
+}
+
+func NewFakeProvider(name string) *FakeProvider {
+	return &FakeProvider{name: name, secret: "fake-secret", invoices: map[int64]*fakeInvoice{}, refundStates: map[string]RefundStatus{}}
+}
+
+func (f *FakeProvider) Name() string { return f.name }
+
+func (f *FakeProvider) Init(ctx context.Context, req ProviderInitRequest) (*ProviderInitResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invoices[req.InvoiceID] = &fakeInvoice{amount: req.Amount, status: StatusCreated}
+	inv := strconv.FormatInt(req.InvoiceID, 10)
+	return &ProviderInitResult{
+		PaymentURL: "https://pay.fake.local/" + f.name + "/" + inv,
+		ExternalID: f.name + "-" + inv,
+		Signature:  f.sign(inv, req.Amount, string(StatusCreated)),
+	}, nil
+}
+
+func (f *FakeProvider) VerifyCallback(ctx context.Context, kind CallbackKind, req CallbackRequest) (*CallbackResult, error) {
+	inv := req.Form.Get("invoice_id")
+	invID, err := strconv.ParseInt(inv, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid invoice_id", ErrInvalidCallback)
+	}
+	res := &CallbackResult{
+		InvoiceID:  invID,
+		Amount:     req.Form.Get("amount"),
+		Status:     Status(req.Form.Get("status")),
+		ExternalID: f.name + "-" + inv,
+		Ack:        "OK",
+	}
+	if !hmac.Equal([]byte(req.Form.Get("signature")), []byte(f.sign(inv, res.Amount, string(res.Status)))) {
+		return res, ErrInvalidSignature
+	}
+	if kind == CallbackReturn {
+		res.Status = StatusPending
+	}
+	return res, nil
+}
+
+func (f *FakeProvider) Refund(ctx context.Context, req ProviderRefundRequest) (*ProviderRefundResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refunds = append(f.refunds, req)
+	externalID := fmt.Sprintf("%s-refund-%d", f.name, len(f.refunds))
+	if f.asyncRefunds {
+		f.refundStates[externalID] = RefundPending
+		return &ProviderRefundResult{ExternalID: externalID, Status: StatusPending}, nil
+	}
+	f.refundStates[externalID] = RefundSucceeded
+	if inv, ok := f.invoices[req.InvoiceID]; ok && amountEqual(inv.amount, req.Amount) {
+		inv.status = StatusRefunded
+	}
+	return &ProviderRefundResult{ExternalID: externalID, Status: StatusRefunded}, nil
+}
+
+func (f *FakeProvider) RefundStatus(ctx context.Context, externalID string) (RefundStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st, ok := f.refundStates[externalID]
+	if !ok {
+		return "", fmt.Errorf("fake provider: refund %s not found", externalID)
+	}
+	return st, nil
+}
+
+func (f *FakeProvider) Status(ctx context.Context, invoiceID int64) (*ProviderStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	inv, ok := f.invoices[invoiceID]
+	if !ok {
+		return nil, fmt.Errorf("fake provider: invoice %d not found", invoiceID)
+	}
+	return &ProviderStatus{
+		InvoiceID:  invoiceID,
+		Status:     inv.status,
+		Amount:     inv.amount,
+		ExternalID: f.name + "-" + strconv.FormatInt(invoiceID, 10),
+	}, nil
+}
+
+// Callback отмечает счёт у шлюза и возвращает подписанное уведомление о нём
+func (f *FakeProvider) Callback(invoiceID int64, amount string, status Status) CallbackRequest {
+	inv := strconv.FormatInt(invoiceID, 10)
+	f.mu.Lock()
+	if existing, ok := f.invoices[invoiceID]; ok {
+		existing.status = status
+	}
+	f.mu.Unlock()
+
+	form := url.Values{}
+	form.Set("invoice_id", inv)
+	form.Set("amount", amount)
+	form.Set("status", string(status))
+	form.Set("signature", f.sign(inv, amount, string(status)))
+	return CallbackRequest{Form: form, RawBody: form.Encode()}
+}
+
+// SetStatus меняет состояние счёта у шлюза без уведомления (потерянный callback)
+func (f *FakeProvider) SetStatus(invoiceID int64, status Status) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if inv, ok := f.invoices[invoiceID]; ok {
+		inv.status = status
+	}
+}
+
+// SetAsyncRefunds — возвраты подтверждаются позже, как у Robokassa
+func (f *FakeProvider) SetAsyncRefunds(async bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.asyncRefunds = async
+}
+
+// SetRefundStatus меняет состояние операции возврата у шлюза
+func (f *FakeProvider) SetRefundStatus(externalID string, status RefundStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refundStates[externalID] = status
+}
+
+// Refunds — запросы на возврат, которые получил шлюз
+func (f *FakeProvider) Refunds() []ProviderRefundRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ProviderRefundRequest(nil), f.refunds...)
+}
+
+func (f *FakeProvider) sign(parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(f.secret))
+	for _, p := range parts {
+		mac.Write([]byte(p + ":"))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,11 +1,14 @@
 package payment
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"io"
 	"net/http"
+	"photostudio/internal/domain/booking"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Handler struct {
@@ -21,38 +24,70 @@ func NewHandler(service *Service, loggerf func(format string, args ...interface{
 }
 
 // InitPayment godoc
-// @Summary      Initialize Robokassa payment
-// @Description  Creates Robokassa payment link and signature for a booking
+// @Summary      Initialize payment
+// @Description  Creates a payment link for a booking through the selected provider (robokassa by default; see provider). The amount is computed from the booking: kind=full charges the total price, kind=balance the outstanding balance (total_price - deposit_amount), kind=deposit a prepayment (out_sum, at least PAYMENT_DEPOSIT_PERCENT of the price, which is also the default). Paid invoices accumulate in deposit_amount until the booking is fully paid. For a booking split between participants pass participant_id: the invoice is for that participant's share, and the booking is confirmed once all shares are paid.
 // @Tags         Payments
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
 // @Param        body body InitPaymentRequest true "Payment init payload"
 // @Success      200 {object} InitPaymentResponse
-// @Failure      400 {object} ErrorResponse
+// @Failure      400 {object} ErrorResponse "Invalid payload, amount, participant share or unknown provider"
+// @Failure      409 {object} ErrorResponse "Booking hold expired, already paid, cancelled or paid by participant shares"
 // @Failure      500 {object} ErrorResponse
+// @Failure      503 {object} ErrorResponse "Provider is not configured"
+// @Router       /payments/init [post]
 // @Router       /payments/robokassa/init [post]
 func (h *Handler) InitPayment(c *gin.Context) {
 	var req InitPaymentRequest
 	body, _ := io.ReadAll(c.Request.Body)
 	c.Request.Body = io.NopCloser(strings.NewReader(string(body)))
-	h.loggerf("level=info msg=robokassa init request request_body=%s", string(body))
+	h.loggerf("level=info msg=payment init request path=%s request_body=%s", c.FullPath(), string(body))
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.loggerf("level=error msg=invalid robokassa init payload err=%v", err)
+		h.loggerf("level=error msg=invalid payment init payload err=%v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if strings.HasSuffix(c.FullPath(), "/robokassa/init") {
+		req.Provider = ProviderRobokassa
+	}
 	resp, err := h.service.InitPayment(c.Request.Context(), req)
 	if err != nil {
-		h.loggerf("level=error msg=robokassa init failed request=%+v err=%v", req, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.loggerf("level=error msg=payment init failed request=%+v err=%v", req, err)
+		switch {
+		case errors.Is(err, ErrHoldExpired), errors.Is(err, ErrSplitPayment),
+			errors.Is(err, ErrNothingToPay), errors.Is(err, ErrNotPayable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrInvalidShare), errors.Is(err, ErrInvalidAmount), errors.Is(err, ErrUnknownProvider):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrProviderNotReady):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
-	h.loggerf("level=info msg=robokassa init response response=%+v", resp)
+	h.loggerf("level=info msg=payment init response response=%+v", resp)
 	c.JSON(http.StatusOK, resp)
 }
 
+// ProviderCallback godoc
+// @Summary      Payment provider notification
+// @Description  Server-to-server notification from a payment provider. The provider verifies the signature; a paid notification marks the payment and booking paid (idempotent). The response body is the acknowledgement the provider expects (Robokassa: OK{InvId}).
+// @Tags         Payments
+// @Produce      plain
+// @Param        provider path string true "Provider name" example(robokassa)
+// @Success      200 {string} string "Provider acknowledgement"
+// @Failure      400 {string} string "bad request"
+// @Failure      403 {string} string "forbidden"
+// @Failure      404 {string} string "unknown provider"
+// @Failure      500 {string} string "internal error"
+// @Router       /payments/{provider}/callback [post]
+func (h *Handler) ProviderCallback(c *gin.Context) {
+	h.handleCallback(c, c.Param("provider"))
+}
+
 // ResultCallback godoc
 // @Summary      Robokassa ResultURL callback
 // @Description  Validates callback signature and marks payment as paid (idempotent)
@@ -67,34 +102,48 @@ func (h *Handler) InitPayment(c *gin.Context) {
 // @Failure      500 {string} string "internal error"
 // @Router       /payments/robokassa/result [post]
 func (h *Handler) ResultCallback(c *gin.Context) {
-	rawBody, _ := io.ReadAll(c.Request.Body)
-	c.Request.Body = io.NopCloser(strings.NewReader(string(rawBody)))
-	_ = c.Request.ParseForm()
-	h.loggerf("level=info msg=robokassa result callback raw_body=%s form=%v", string(rawBody), c.Request.PostForm)
+	h.handleCallback(c, ProviderRobokassa)
+}
 
-	outSum := c.PostForm("OutSum")
-	invID, err := strconv.ParseInt(c.PostForm("InvId"), 10, 64)
-	if err != nil {
-		c.String(http.StatusBadRequest, "bad request")
-		return
-	}
-	signature := c.PostForm("SignatureValue")
-	shp := collectShp(c)
+func (h *Handler) handleCallback(c *gin.Context, provider string) {
+	req := readCallback(c)
+	h.loggerf("level=info msg=payment callback provider=%s raw_body=%s form=%v", provider, req.RawBody, req.Form)
 
-	ack, err := h.service.HandleResultCallback(c.Request.Context(), outSum, invID, signature, shp, string(rawBody))
+	ack, err := h.service.HandleCallback(c.Request.Context(), provider, req)
 	if err != nil {
-		h.loggerf("level=error msg=robokassa result callback failed inv_id=%d err=%v", invID, err)
-		if err == ErrInvalidSignature || err == ErrAmountMismatch {
+		h.loggerf("level=error msg=payment callback failed provider=%s err=%v", provider, err)
+		switch {
+		case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrAmountMismatch):
 			c.String(http.StatusForbidden, "forbidden")
-			return
+		case errors.Is(err, ErrInvalidCallback):
+			c.String(http.StatusBadRequest, "bad request")
+		case errors.Is(err, ErrUnknownProvider):
+			c.String(http.StatusNotFound, "unknown provider")
+		default:
+			c.String(http.StatusInternalServerError, "internal error")
 		}
-		c.String(http.StatusInternalServerError, "internal error")
 		return
 	}
-	h.loggerf("level=info msg=robokassa result callback handled inv_id=%d ack=%s", invID, ack)
+	h.loggerf("level=info msg=payment callback handled provider=%s ack=%s", provider, ack)
 	c.String(http.StatusOK, ack)
 }
 
+// ProviderReturn godoc
+// @Summary      Customer return from a payment provider
+// @Description  Validates the signed return redirect and moves the payment to pending until the provider notification arrives
+// @Tags         Payments
+// @Produce      json
+// @Param        provider path string true "Provider name" example(robokassa)
+// @Success      200 {object} SuccessCallbackResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /payments/{provider}/return [get]
+func (h *Handler) ProviderReturn(c *gin.Context) {
+	h.handleReturn(c, c.Param("provider"))
+}
+
 // SuccessCallback godoc
 // @Summary      Robokassa SuccessURL callback
 // @Description  Validates customer return callback signature
@@ -109,48 +158,218 @@ func (h *Handler) ResultCallback(c *gin.Context) {
 // @Failure      500 {object} ErrorResponse
 // @Router       /payments/robokassa/success [get]
 func (h *Handler) SuccessCallback(c *gin.Context) {
-	raw := c.Request.URL.RawQuery
-	h.loggerf("level=info msg=robokassa success callback raw_query=%s query=%v", raw, c.Request.URL.Query())
-	outSum := c.Query("OutSum")
-	invID, err := strconv.ParseInt(c.Query("InvId"), 10, 64)
+	h.handleReturn(c, ProviderRobokassa)
+}
+
+func (h *Handler) handleReturn(c *gin.Context, provider string) {
+	req := CallbackRequest{Form: c.Request.URL.Query(), RawBody: c.Request.URL.RawQuery}
+	h.loggerf("level=info msg=payment return provider=%s raw_query=%s", provider, req.RawBody)
+
+	ok, err := h.service.HandleReturn(c.Request.Context(), provider, req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid InvId"})
+		h.loggerf("level=error msg=payment return failed provider=%s err=%v", provider, err)
+		switch {
+		case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrAmountMismatch):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrInvalidCallback):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrUnknownProvider):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
 		return
 	}
-	signature := c.Query("SignatureValue")
-	shp := collectShp(c)
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "validated": ok})
+}
 
-	ok, err := h.service.HandleSuccessCallback(c.Request.Context(), outSum, invID, signature, shp, raw)
+// readCallback собирает тело и параметры (форма + query) обращения шлюза
+func readCallback(c *gin.Context) CallbackRequest {
+	rawBody, _ := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(strings.NewReader(string(rawBody)))
+	_ = c.Request.ParseForm()
+	return CallbackRequest{Form: c.Request.Form, RawBody: string(rawBody)}
+}
+
+// RefundPayment godoc
+// @Summary      Refund a paid invoice
+// @Description  Full or partial refund through the invoice's payment provider (amount = 0 refunds the whole remaining sum). Available to the studio owner and admins. Repeat the request with the same Idempotency-Key to get the existing refund instead of a second one. Providers that confirm refunds asynchronously (Robokassa) leave the refund pending. Once the refund succeeds the booking payment status becomes refunded or partially_refunded and the client is notified.
+// @Tags         Payments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key header string false "Idempotency key"
+// @Param        body body RefundRequest true "Refund payload"
+// @Success      200 {object} Refund "Existing refund for the idempotency key"
+// @Success      201 {object} Refund
+// @Failure      400 {object} ErrorResponse "Invalid payload or amount above the refundable sum"
+// @Failure      403 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse "Payment not paid, already refunded, refunds unsupported or idempotency key reused"
+// @Failure      502 {object} ErrorResponse "Provider rejected the refund"
+// @Router       /payments/refunds [post]
+func (h *Handler) RefundPayment(c *gin.Context) {
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	refund, created, err := h.service.RefundPayment(c.Request.Context(), actorFromContext(c), req, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		h.loggerf("level=error msg=refund failed request=%+v err=%v", req, err)
+		writeRefundError(c, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, refund)
+}
+
+// ListRefunds godoc
+// @Summary      Refunds of a booking
+// @Tags         Payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        booking_id query integer true "Booking ID"
+// @Success      200 {array} Refund
+// @Failure      400 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Router       /payments/refunds [get]
+func (h *Handler) ListRefunds(c *gin.Context) {
+	bookingID, err := strconv.ParseInt(c.Query("booking_id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking_id"})
+		return
+	}
+	refunds, err := h.service.ListRefunds(c.Request.Context(), actorFromContext(c), bookingID)
+	if err != nil {
+		writeRefundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, refunds)
+}
+
+func writeRefundError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrRefundExceeds):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the studio owner or an admin can refund payments"})
+	case errors.Is(err, ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotRefundable), errors.Is(err, ErrNothingToRefund),
+		errors.Is(err, ErrRefundNotSupported), errors.Is(err, ErrIdempotencyConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRefundFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+// ListDiscrepancies godoc
+// @Summary      Payment reconciliation report
+// @Description  Discrepancies found by the reconciliation job between payments, the provider and booking payment status
+// @Tags         Admin Payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        status query string false "open, resolved or dismissed (all by default)"
+// @Param        kind query string false "amount_mismatch, stale_invoice, booking_unpaid, paid_without_payment or missed_callback"
+// @Param        limit query integer false "Page size (default 50, max 200)"
+// @Param        offset query integer false "Offset"
+// @Success      200 {object} DiscrepancyListResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /admin/payments/discrepancies [get]
+func (h *Handler) ListDiscrepancies(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+	items, total, err := h.service.ListDiscrepancies(c.Request.Context(), DiscrepancyStatus(c.Query("status")), DiscrepancyKind(c.Query("kind")), limit, offset)
 	if err != nil {
-		h.loggerf("level=error msg=robokassa success callback failed inv_id=%d err=%v", invID, err)
-		if err == ErrInvalidSignature || err == ErrAmountMismatch {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": "ok", "validated": ok})
+	c.JSON(http.StatusOK, DiscrepancyListResponse{Items: items, Total: total})
 }
 
-func collectShp(c *gin.Context) map[string]string {
-	res := map[string]string{}
-	for k, v := range c.Request.Form {
-		if strings.HasPrefix(strings.ToLower(k), "shp_") && len(v) > 0 {
-			res[trimShpKey(k)] = v[0]
-		}
+// ResolveDiscrepancy godoc
+// @Summary      Resolve a reconciliation discrepancy
+// @Description  recheck asks the provider again, mark_failed closes a stale invoice, apply_payment adds paid invoices missing from the booking, dismiss closes an expected discrepancy (e.g. cash payment)
+// @Tags         Admin Payments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path integer true "Discrepancy ID"
+// @Param        body body ResolveDiscrepancyRequest true "Action"
+// @Success      200 {object} Discrepancy
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse "Already resolved or the action does not apply"
+// @Failure      502 {object} ErrorResponse "Provider status query failed"
+// @Router       /admin/payments/discrepancies/{id}/resolve [post]
+func (h *Handler) ResolveDiscrepancy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req ResolveDiscrepancyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	for k, v := range c.Request.URL.Query() {
-		if strings.HasPrefix(strings.ToLower(k), "shp_") && len(v) > 0 {
-			res[trimShpKey(k)] = v[0]
+	resolvedBy := c.GetString("admin_id")
+	if resolvedBy == "" {
+		resolvedBy = strconv.FormatInt(c.GetInt64("user_id"), 10)
+	}
+	d, err := h.service.ResolveDiscrepancy(c.Request.Context(), id, req.Action, req.Note, resolvedBy)
+	if err != nil {
+		h.loggerf("level=error msg=resolve discrepancy failed id=%d action=%s err=%v", id, req.Action, err)
+		switch {
+		case errors.Is(err, ErrDiscrepancyNotFound), errors.Is(err, ErrPaymentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrDiscrepancyClosed), errors.Is(err, ErrInvalidAction), errors.Is(err, ErrAmountMismatch):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrUnknownProvider), errors.Is(err, ErrProviderNotReady):
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		}
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// RunReconciliation godoc
+// @Summary      Run payment reconciliation now
+// @Tags         Admin Payments
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} ReconcileResult
+// @Failure      500 {object} ErrorResponse
+// @Router       /admin/payments/reconcile [post]
+func (h *Handler) RunReconciliation(c *gin.Context) {
+	res, err := h.service.RunReconciliation(c.Request.Context(), DefaultReconcileConfig(), time.Now().UTC())
+	if err != nil {
+		h.loggerf("level=error msg=manual reconciliation failed err=%v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
 	}
-	return res
+	c.JSON(http.StatusOK, res)
 }
 
-func trimShpKey(k string) string {
-	if len(k) < 4 {
-		return k
+func actorFromContext(c *gin.Context) booking.Actor {
+	role := c.GetString("role")
+	if role == "" {
+		role = booking.ActorClient
 	}
-	return k[4:]
+	return booking.Actor{UserID: c.GetInt64("user_id"), Role: role}
 }
@@ -8,18 +8,62 @@ import (
 
 type bookingReader interface {
 	GetByID(ctx context.Context, id int64) (*booking.Booking, error)
+	GetParticipant(ctx context.Context, id int64) (*booking.BookingParticipant, error)
+	ListParticipants(ctx context.Context, bookingID int64) ([]booking.BookingParticipant, error)
+	IsStudioOwnedByUser(ctx context.Context, studioID, userID int64) (bool, error)
 }
 
 type paymentRepo interface {
-	Create(ctx context.Context, p *RobokassaPayment) error
-	GetByInvID(ctx context.Context, invID int64) (*RobokassaPayment, error)
-	UpdateStatus(ctx context.Context, invID int64, status RobokassaPaymentStatus, rawBody, reason string, paidAt *time.Time) error
-	UpdateStatusPendingIfNotPaid(ctx context.Context, invID int64, rawBody string) error
-	SaveSuccessRawBody(ctx context.Context, invID int64, rawBody string) error
-	MarkPaidIdempotent(ctx context.Context, invID int64, rawBody string, paidAt time.Time) (bool, error)
+	Create(ctx context.Context, p *Payment) error
+	GetByInvoice(ctx context.Context, provider string, invoiceID int64) (*Payment, error)
+	MarkFailed(ctx context.Context, id int64, rawBody, reason string) error
+	SaveFailureReason(ctx context.Context, id int64, rawBody, reason string) error
+	UpdateStatusPendingIfNotPaid(ctx context.Context, id int64, rawBody string) error
+	SaveReturnRawBody(ctx context.Context, id int64, rawBody string) error
+	MarkPaidIdempotent(ctx context.Context, id int64, externalID, rawBody string, paidAt time.Time) (bool, error)
+
+	// Возвраты
+	GetByID(ctx context.Context, id int64) (*Payment, error)
+	CreateRefund(ctx context.Context, refund *Refund) (*Refund, bool, error)
+	FailRefund(ctx context.Context, id int64, reason string) error
+	SetRefundExternalID(ctx context.Context, id int64, externalID string) error
+	CompleteRefund(ctx context.Context, id int64, externalID string, at time.Time) (*Refund, error)
+	RefundedAmount(ctx context.Context, bookingID int64) (float64, error)
+	ListRefunds(ctx context.Context, bookingID int64) ([]Refund, error)
+	ListPaidByBooking(ctx context.Context, bookingID int64) ([]Payment, error)
+
+	// Сверка
+	ListUnsettled(ctx context.Context, createdBefore, createdAfter time.Time, limit int) ([]Payment, error)
+	ListPendingRefunds(ctx context.Context, limit int) ([]Refund, error)
+	ListBookingPaymentGaps(ctx context.Context, limit int) ([]BookingPaymentGap, error)
+	GetBookingPaymentGap(ctx context.Context, bookingID int64) (*BookingPaymentGap, error)
+	ListPaidBookingsWithoutPayment(ctx context.Context, since time.Time, limit int) ([]int64, error)
+	FlagDiscrepancy(ctx context.Context, d *Discrepancy) (bool, error)
+	ListDiscrepancies(ctx context.Context, status DiscrepancyStatus, kind DiscrepancyKind, limit, offset int) ([]Discrepancy, int64, error)
+	GetDiscrepancy(ctx context.Context, id int64) (*Discrepancy, error)
+	ResolveDiscrepancy(ctx context.Context, id int64, status DiscrepancyStatus, resolution, resolvedBy string, at time.Time) (*Discrepancy, error)
 }
 
 type bookingPaymentWriter interface {
 	UpdatePaymentStatus(ctx context.Context, bookingID int64, status booking.PaymentStatus) (*booking.Booking, error)
 	UpdatePaymentStatusSystem(ctx context.Context, bookingID int64, status booking.PaymentStatus) (*booking.Booking, error)
+	// ConvertHold переводит оплаченный hold (status=held) в обычную бронь
+	ConvertHold(ctx context.Context, bookingID int64) (*booking.Booking, error)
+	// ApplyPayment добавляет оплату к внесённой сумме; при покрытии TotalPrice бронь становится paid
+	ApplyPayment(ctx context.Context, bookingID int64, amount float64) (*booking.Booking, error)
+	// ApplyRefund записывает в бронь, сколько всего по ней возвращено
+	ApplyRefund(ctx context.Context, bookingID int64, refunded float64) (*booking.Booking, error)
+	// Доли участников: счёт и оплата; бронь подтверждается, когда доли покрыты
+	SetParticipantInvoice(ctx context.Context, id, invID int64) error
+	MarkParticipantPaid(ctx context.Context, participantID int64, paidAt time.Time) (*booking.Booking, error)
+}
+
+type refundNotifier interface {
+	NotifyPaymentRefunded(ctx context.Context, clientID int64, bookingID, studioID int64, amount float64) error
+}
+
+// ledgerRecorder — учёт долга перед владельцами студий и комиссии (ledger.Service)
+type ledgerRecorder interface {
+	RecordPayment(ctx context.Context, paymentID, bookingID int64, amount float64) error
+	RecordRefund(ctx context.Context, refundID, paymentID, bookingID int64, amount float64) error
 }
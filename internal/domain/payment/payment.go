@@ -0,0 +1,38 @@
+package payment
+
+import "time"
+
+// Status — состояние платежа в таблице payments (одинаково для всех шлюзов)
+type Status string
+
+const (
+	StatusCreated  Status = "created"
+	StatusPending  Status = "pending"
+	StatusPaid     Status = "paid"
+	StatusFailed   Status = "failed"
+	StatusRefunded Status = "refunded"
+)
+
+// Payment — счёт, выставленный через платёжного провайдера
+type Payment struct {
+	ID              int64      `gorm:"primaryKey" json:"id"`
+	BookingID       int64      `gorm:"index;not null" json:"booking_id"`
+	ParticipantID   *int64     `gorm:"index" json:"participant_id,omitempty"`
+	Provider        string     `gorm:"type:varchar(32);not null;uniqueIndex:idx_payments_provider_invoice" json:"provider"`
+	InvoiceID       int64      `gorm:"not null;uniqueIndex:idx_payments_provider_invoice" json:"invoice_id"`
+	ExternalID      string     `gorm:"type:varchar(128)" json:"external_id,omitempty"`
+	Kind            Kind       `gorm:"type:varchar(20);not null;default:'full'" json:"kind"`
+	Amount          float64    `gorm:"type:decimal(12,2);not null" json:"amount"`
+	Description     string     `gorm:"type:text" json:"description"`
+	Status          Status     `gorm:"type:varchar(20);not null;default:'created';index" json:"status"`
+	PaymentURL      string     `gorm:"type:text" json:"payment_url"`
+	Params          string     `gorm:"type:text" json:"params"` // JSON доп. параметров счёта
+	CallbackRawBody string     `gorm:"type:text" json:"-"`
+	ReturnRawBody   string     `gorm:"type:text" json:"-"`
+	FailureReason   string     `gorm:"type:text" json:"failure_reason,omitempty"`
+	PaidAt          *time.Time `json:"paid_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (Payment) TableName() string { return "payments" }
@@ -0,0 +1,98 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+var (
+	ErrUnknownProvider    = errors.New("unknown payment provider")
+	ErrProviderNotReady   = errors.New("payment provider is not configured")
+	ErrRefundNotSupported = errors.New("refunds are not supported by the provider")
+	ErrInvalidCallback    = errors.New("malformed provider callback")
+)
+
+// Provider — платёжный шлюз (Robokassa, Kaspi, Halyk ePay, CloudPayments...).
+// Сервис хранит платежи в общей таблице payments и обращается к шлюзу только
+// через этот интерфейс.
+type Provider interface {
+	// Name — ключ провайдера в запросах, маршрутах callback-ов и таблице payments
+	Name() string
+	// Init выставляет счёт и возвращает ссылку на оплату
+	Init(ctx context.Context, req ProviderInitRequest) (*ProviderInitResult, error)
+	// VerifyCallback проверяет подпись уведомления (CallbackNotify, server-to-server)
+	// или возврата покупателя (CallbackReturn) и разбирает его. При неверной
+	// подписи возвращает ErrInvalidSignature и, если удалось, номер счёта.
+	VerifyCallback(ctx context.Context, kind CallbackKind, req CallbackRequest) (*CallbackResult, error)
+	// Refund возвращает деньги по оплаченному счёту (целиком или частично)
+	Refund(ctx context.Context, req ProviderRefundRequest) (*ProviderRefundResult, error)
+	// Status запрашивает у шлюза текущее состояние счёта
+	Status(ctx context.Context, invoiceID int64) (*ProviderStatus, error)
+	// RefundStatus запрашивает у шлюза состояние операции возврата по её
+	// идентификатору (ProviderRefundResult.ExternalID). Частичный возврат не
+	// меняет статус счёта, поэтому сверка смотрит на саму операцию.
+	RefundStatus(ctx context.Context, externalID string) (RefundStatus, error)
+}
+
+// CallbackKind — какое обращение шлюза проверяется
+type CallbackKind int
+
+const (
+	CallbackNotify CallbackKind = iota // уведомление об оплате (ResultURL у Robokassa)
+	CallbackReturn                     // покупатель вернулся на сайт (SuccessURL)
+)
+
+type ProviderInitRequest struct {
+	InvoiceID   int64
+	Amount      string // "2500.00"
+	Description string
+	Params      map[string]string // доп. параметры, которые шлюз вернёт в callback
+}
+
+type ProviderInitResult struct {
+	PaymentURL string
+	ExternalID string // идентификатор платежа у шлюза, если он свой
+	Signature  string
+}
+
+// CallbackRequest — обращение шлюза как есть: форма и query вместе, сырое тело
+type CallbackRequest struct {
+	Form    url.Values
+	RawBody string
+}
+
+type CallbackResult struct {
+	InvoiceID  int64
+	Amount     string
+	Status     Status // paid, failed или pending
+	ExternalID string
+	Params     map[string]string
+	Ack        string // что ответить шлюзу (Robokassa ждёт "OK<InvId>")
+}
+
+type ProviderRefundRequest struct {
+	InvoiceID  int64
+	ExternalID string
+	Amount     string
+}
+
+type ProviderRefundResult struct {
+	ExternalID string // идентификатор возврата у шлюза
+	Status     Status // refunded или pending, если шлюз подтвердит позже
+}
+
+// ProviderStatus — состояние счёта у шлюза
+type ProviderStatus struct {
+	InvoiceID  int64
+	Status     Status
+	Amount     string
+	ExternalID string
+}
+
+// formatAmount — сумма в формате шлюзов: две цифры после точки
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', 2, 64)
+}
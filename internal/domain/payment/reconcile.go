@@ -0,0 +1,389 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"photostudio/internal/domain/booking"
+	"photostudio/internal/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDiscrepancyNotFound = errors.New("discrepancy not found")
+	ErrDiscrepancyClosed   = errors.New("discrepancy is already resolved")
+	ErrInvalidAction       = errors.New("action does not apply to this discrepancy")
+)
+
+// DiscrepancyKind — какое расхождение нашла сверка
+type DiscrepancyKind string
+
+const (
+	// Шлюз считает счёт оплаченным, но сумма не совпадает с нашей
+	DiscrepancyAmountMismatch DiscrepancyKind = "amount_mismatch"
+	// Счёт давно в created/pending, шлюз оплату не подтвердил
+	DiscrepancyStaleInvoice DiscrepancyKind = "stale_invoice"
+	// Оплаченные счета не отражены в брони (deposit_amount меньше оплаченного)
+	DiscrepancyBookingUnpaid DiscrepancyKind = "booking_unpaid"
+	// Бронь отмечена paid, а оплаченного счёта нет (например, оплата вручную)
+	DiscrepancyPaidWithoutPayment DiscrepancyKind = "paid_without_payment"
+	// Счёт оплачен по данным шлюза, уведомление потерялось — исправлено сверкой
+	DiscrepancyMissedCallback DiscrepancyKind = "missed_callback"
+)
+
+type DiscrepancyStatus string
+
+const (
+	DiscrepancyOpen      DiscrepancyStatus = "open"
+	DiscrepancyResolved  DiscrepancyStatus = "resolved"
+	DiscrepancyDismissed DiscrepancyStatus = "dismissed"
+)
+
+// Действия администратора над расхождением
+const (
+	ActionRecheck      = "recheck"       // ещё раз спросить шлюз
+	ActionMarkFailed   = "mark_failed"   // закрыть зависший счёт
+	ActionApplyPayment = "apply_payment" // довнести оплаченное в бронь
+	ActionDismiss      = "dismiss"       // расхождение ожидаемое (оплата наличными и т.п.)
+)
+
+// Discrepancy — расхождение между payments, шлюзом и bookings.payment_status
+type Discrepancy struct {
+	ID         int64             `gorm:"primaryKey" json:"id"`
+	Kind       DiscrepancyKind   `gorm:"type:varchar(32);not null;index" json:"kind"`
+	BookingID  int64             `gorm:"index;not null" json:"booking_id"`
+	PaymentID  *int64            `gorm:"index" json:"payment_id,omitempty"`
+	Provider   string            `gorm:"type:varchar(32)" json:"provider,omitempty"`
+	Details    string            `gorm:"type:text" json:"details"`
+	Status     DiscrepancyStatus `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	Resolution string            `gorm:"type:text" json:"resolution,omitempty"`
+	ResolvedBy string            `gorm:"type:varchar(64)" json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time        `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+func (Discrepancy) TableName() string { return "payment_discrepancies" }
+
+// BookingPaymentGap — бронь, в которой учтено меньше, чем оплачено по счетам
+type BookingPaymentGap struct {
+	BookingID int64
+	Deposit   float64
+	Paid      float64
+}
+
+// ReconcileConfig — настройки сверки платежей
+type ReconcileConfig struct {
+	Interval        time.Duration // как часто запускать (default: 30m)
+	StaleAfter      time.Duration // с какого возраста спрашивать шлюз о неоплаченном счёте (default: 30m)
+	ExpireAfter     time.Duration // с какого возраста неоплаченный счёт считается зависшим (default: 24h)
+	Lookback        time.Duration // насколько старые счета и брони проверять (default: 7 дней)
+	BatchSize       int
+	EnableScheduler bool
+}
+
+// DefaultReconcileConfig возвращает настройки; интервал — PAYMENT_RECONCILE_INTERVAL
+func DefaultReconcileConfig() ReconcileConfig {
+	return ReconcileConfig{
+		Interval:        utils.DurationFromEnv("PAYMENT_RECONCILE_INTERVAL", 30*time.Minute),
+		StaleAfter:      30 * time.Minute,
+		ExpireAfter:     24 * time.Hour,
+		Lookback:        7 * 24 * time.Hour,
+		BatchSize:       200,
+		EnableScheduler: true,
+	}
+}
+
+// ReconcileResult — итог одного прогона сверки
+type ReconcileResult struct {
+	Checked        int `json:"checked"`         // счетов сверено со шлюзом
+	Fixed          int `json:"fixed"`           // исправлено автоматически
+	Flagged        int `json:"flagged"`         // новых расхождений для администратора
+	RefundsSettled int `json:"refunds_settled"` // подтверждённых шлюзом возвратов
+	ProviderErrors int `json:"provider_errors"` // шлюз не ответил
+}
+
+// RunReconciliation сверяет неоплаченные счета и ожидающие возвраты со шлюзом
+// (оплату, подтверждённую шлюзом, применяет сразу) и ищет брони, чей
+// payment_status не сходится с оплаченными счетами
+func (s *Service) RunReconciliation(ctx context.Context, config ReconcileConfig, now time.Time) (ReconcileResult, error) {
+	var res ReconcileResult
+
+	unsettled, err := s.payments.ListUnsettled(ctx, now.Add(-config.StaleAfter), now.Add(-config.Lookback), config.BatchSize)
+	if err != nil {
+		return res, err
+	}
+	for i := range unsettled {
+		s.reconcilePayment(ctx, &unsettled[i], config, now, &res)
+	}
+
+	refunds, err := s.payments.ListPendingRefunds(ctx, config.BatchSize)
+	if err != nil {
+		return res, err
+	}
+	for i := range refunds {
+		s.reconcileRefund(ctx, &refunds[i], &res)
+	}
+
+	gaps, err := s.payments.ListBookingPaymentGaps(ctx, config.BatchSize)
+	if err != nil {
+		return res, err
+	}
+	for _, g := range gaps {
+		s.flag(ctx, &res, &Discrepancy{
+			Kind:      DiscrepancyBookingUnpaid,
+			BookingID: g.BookingID,
+			Details:   fmt.Sprintf("paid invoices %.2f, booking deposit_amount %.2f", g.Paid, g.Deposit),
+		})
+	}
+
+	orphans, err := s.payments.ListPaidBookingsWithoutPayment(ctx, now.Add(-config.Lookback), config.BatchSize)
+	if err != nil {
+		return res, err
+	}
+	for _, bookingID := range orphans {
+		s.flag(ctx, &res, &Discrepancy{
+			Kind:      DiscrepancyPaidWithoutPayment,
+			BookingID: bookingID,
+			Details:   "booking payment_status is paid but no paid invoice exists",
+		})
+	}
+
+	s.loggerf("level=info msg=payment reconciliation finished checked=%d fixed=%d flagged=%d refunds_settled=%d provider_errors=%d",
+		res.Checked, res.Fixed, res.Flagged, res.RefundsSettled, res.ProviderErrors)
+	return res, nil
+}
+
+func (s *Service) reconcilePayment(ctx context.Context, p *Payment, config ReconcileConfig, now time.Time, res *ReconcileResult) {
+	prov, err := s.provider(p.Provider)
+	if err != nil {
+		return
+	}
+	st, err := prov.Status(ctx, p.InvoiceID)
+	if err != nil {
+		res.ProviderErrors++
+		s.loggerf("level=error msg=reconciliation status query failed provider=%s inv_id=%d err=%v", p.Provider, p.InvoiceID, err)
+		return
+	}
+	res.Checked++
+
+	switch st.Status {
+	case StatusPaid:
+		if !amountEqual(st.Amount, formatAmount(p.Amount)) {
+			s.flag(ctx, res, &Discrepancy{
+				Kind: DiscrepancyAmountMismatch, BookingID: p.BookingID, PaymentID: &p.ID, Provider: p.Provider,
+				Details: fmt.Sprintf("provider paid %s, expected %s", st.Amount, formatAmount(p.Amount)),
+			})
+			return
+		}
+		if err := s.applyPaid(ctx, p, st.ExternalID, ""); err != nil {
+			s.loggerf("level=error msg=reconciliation failed to apply payment inv_id=%d err=%v", p.InvoiceID, err)
+			return
+		}
+		res.Fixed++
+		// фиксируем в отчёте, что уведомление шлюза не дошло
+		resolvedAt := time.Now().UTC()
+		_, _ = s.payments.FlagDiscrepancy(ctx, &Discrepancy{
+			Kind: DiscrepancyMissedCallback, BookingID: p.BookingID, PaymentID: &p.ID, Provider: p.Provider,
+			Details: "provider reported the invoice paid, callback was not received",
+			Status:  DiscrepancyResolved, Resolution: "marked paid automatically", ResolvedBy: "reconciliation", ResolvedAt: &resolvedAt,
+		})
+	case StatusFailed:
+		if err := s.payments.MarkFailed(ctx, p.ID, "", "declined by provider (reconciliation)"); err == nil {
+			res.Fixed++
+		}
+	default:
+		if p.CreatedAt.Before(now.Add(-config.ExpireAfter)) {
+			s.flag(ctx, res, &Discrepancy{
+				Kind: DiscrepancyStaleInvoice, BookingID: p.BookingID, PaymentID: &p.ID, Provider: p.Provider,
+				Details: fmt.Sprintf("invoice is %s since %s, provider status %s", p.Status, p.CreatedAt.Format(time.RFC3339), st.Status),
+			})
+		}
+	}
+}
+
+// reconcileRefund завершает возврат, который шлюз подтвердил асинхронно.
+// Смотрим на операцию возврата, а не на счёт: после частичного возврата
+// счёт у шлюза остаётся оплаченным.
+func (s *Service) reconcileRefund(ctx context.Context, r *Refund, res *ReconcileResult) {
+	prov, err := s.provider(r.Provider)
+	if err != nil {
+		return
+	}
+	st, err := s.refundOperationStatus(ctx, prov, r)
+	if err != nil {
+		res.ProviderErrors++
+		return
+	}
+	switch st {
+	case RefundSucceeded:
+	case RefundFailed:
+		if err := s.payments.FailRefund(ctx, r.ID, "declined by provider (reconciliation)"); err == nil {
+			res.Fixed++
+		}
+		return
+	default:
+		return
+	}
+	b, err := s.bookings.GetByID(ctx, r.BookingID)
+	if err != nil {
+		return
+	}
+	if _, err := s.completeRefund(ctx, r, "", b); err != nil {
+		s.loggerf("level=error msg=reconciliation failed to complete refund refund_id=%d err=%v", r.ID, err)
+		return
+	}
+	res.RefundsSettled++
+}
+
+// refundOperationStatus — состояние возврата у шлюза. Без идентификатора
+// операции остаётся только статус счёта: refunded означает возврат целиком.
+func (s *Service) refundOperationStatus(ctx context.Context, prov Provider, r *Refund) (RefundStatus, error) {
+	if r.ExternalID != "" {
+		return prov.RefundStatus(ctx, r.ExternalID)
+	}
+	p, err := s.payments.GetByID(ctx, r.PaymentID)
+	if err != nil {
+		return "", err
+	}
+	st, err := prov.Status(ctx, p.InvoiceID)
+	if err != nil {
+		return "", err
+	}
+	if st.Status == StatusRefunded {
+		return RefundSucceeded, nil
+	}
+	return RefundPending, nil
+}
+
+func (s *Service) flag(ctx context.Context, res *ReconcileResult, d *Discrepancy) {
+	d.Status = DiscrepancyOpen
+	created, err := s.payments.FlagDiscrepancy(ctx, d)
+	if err != nil {
+		s.loggerf("level=error msg=failed to save discrepancy kind=%s booking_id=%d err=%v", d.Kind, d.BookingID, err)
+		return
+	}
+	if created {
+		res.Flagged++
+	}
+}
+
+// ListDiscrepancies — отчёт для администратора; status пустой — все
+func (s *Service) ListDiscrepancies(ctx context.Context, status DiscrepancyStatus, kind DiscrepancyKind, limit, offset int) ([]Discrepancy, int64, error) {
+	return s.payments.ListDiscrepancies(ctx, status, kind, limit, offset)
+}
+
+// ResolveDiscrepancy выполняет действие администратора над открытым расхождением
+func (s *Service) ResolveDiscrepancy(ctx context.Context, id int64, action, note, resolvedBy string) (*Discrepancy, error) {
+	d, err := s.payments.GetDiscrepancy(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDiscrepancyNotFound
+		}
+		return nil, err
+	}
+	if d.Status != DiscrepancyOpen {
+		return nil, ErrDiscrepancyClosed
+	}
+
+	status, resolution := DiscrepancyResolved, action
+	switch action {
+	case ActionDismiss:
+		status = DiscrepancyDismissed
+	case ActionRecheck:
+		p, err := s.discrepancyPayment(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		if p.Status != StatusPaid {
+			if p, err = s.RefreshStatus(ctx, p.Provider, p.InvoiceID); err != nil {
+				return nil, err
+			}
+		}
+		if p.Status != StatusPaid && p.Status != StatusFailed {
+			// шлюз по-прежнему не подтверждает — расхождение остаётся открытым
+			return d, nil
+		}
+		resolution = "recheck: payment " + string(p.Status)
+	case ActionMarkFailed:
+		p, err := s.discrepancyPayment(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		if p.Status == StatusPaid || p.Status == StatusRefunded {
+			return nil, ErrInvalidAction
+		}
+		if err := s.payments.MarkFailed(ctx, p.ID, "", "closed by admin"); err != nil {
+			return nil, err
+		}
+	case ActionApplyPayment:
+		if d.Kind != DiscrepancyBookingUnpaid {
+			return nil, ErrInvalidAction
+		}
+		gap, err := s.payments.GetBookingPaymentGap(ctx, d.BookingID)
+		if err != nil {
+			return nil, err
+		}
+		if gap != nil {
+			if _, err := s.bookingWriter.ApplyPayment(ctx, d.BookingID, gap.Paid-gap.Deposit); err != nil {
+				return nil, err
+			}
+			if _, err := s.bookingWriter.ConvertHold(ctx, d.BookingID); err != nil && !errors.Is(err, booking.ErrHoldExpired) {
+				return nil, err
+			}
+		}
+	default:
+		return nil, ErrInvalidAction
+	}
+	if note != "" {
+		resolution += ": " + note
+	}
+	return s.payments.ResolveDiscrepancy(ctx, id, status, resolution, resolvedBy, time.Now().UTC())
+}
+
+func (s *Service) discrepancyPayment(ctx context.Context, d *Discrepancy) (*Payment, error) {
+	if d.PaymentID == nil {
+		return nil, ErrInvalidAction
+	}
+	p, err := s.payments.GetByID(ctx, *d.PaymentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPaymentNotFound
+	}
+	return p, err
+}
+
+// ScheduleReconciliation запускает периодическую сверку платежей
+func (s *Service) ScheduleReconciliation(ctx context.Context, config ReconcileConfig) chan struct{} {
+	if !config.EnableScheduler {
+		log.Println("Payment reconciliation is disabled")
+		return nil
+	}
+
+	stopCh := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.RunReconciliation(ctx, config, time.Now().UTC()); err != nil {
+					log.Printf("Payment reconciliation error: %v", err)
+				}
+			case <-stopCh:
+				log.Println("Payment reconciliation stopped")
+				return
+			case <-ctx.Done():
+				log.Println("Payment reconciliation stopped (context Done)")
+				return
+			}
+		}
+	}()
+
+	log.Printf("Payment reconciliation started with interval %v", config.Interval)
+	return stopCh
+}
@@ -0,0 +1,110 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"photostudio/internal/database"
+)
+
+func TestRunReconciliation_FixesAndFlags(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Connect(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Payment{}, &Refund{}, &Discrepancy{}); err != nil {
+		t.Fatal(err)
+	}
+	// только поля брони, которые читает сверка
+	if err := db.Exec(`CREATE TABLE bookings (id INTEGER PRIMARY KEY, total_price REAL, payment_status TEXT, deposit_amount REAL, updated_at DATETIME)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	db.Exec(`INSERT INTO bookings (id, total_price, payment_status, deposit_amount, updated_at) VALUES (?, ?, ?, ?, ?), (?, ?, ?, ?, ?)`,
+		4, 300, "unpaid", 0, now, 5, 800, "paid", 800, now)
+
+	fake := NewFakeProvider("kaspi")
+	repo := NewPaymentRepository(db)
+	paidAt := now.Add(-time.Hour)
+	for _, p := range []*Payment{
+		{BookingID: 1, InvoiceID: 1, Amount: 1000, Status: StatusPending, CreatedAt: now.Add(-2 * time.Hour)},          // шлюз: оплачен, callback потерялся
+		{BookingID: 2, InvoiceID: 2, Amount: 500, Status: StatusCreated, CreatedAt: now.Add(-48 * time.Hour)},          // зависший счёт
+		{BookingID: 3, InvoiceID: 3, Amount: 700, Status: StatusCreated, CreatedAt: now.Add(-time.Hour)},               // шлюз: отклонён
+		{BookingID: 6, InvoiceID: 6, Amount: 100, Status: StatusCreated, CreatedAt: now.Add(-5 * time.Minute)},         // слишком свежий
+		{BookingID: 4, InvoiceID: 4, Amount: 300, Status: StatusPaid, CreatedAt: now.Add(-time.Hour), PaidAt: &paidAt}, // не учтён в брони
+	} {
+		p.Provider, p.Kind = "kaspi", KindFull
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = fake.Init(ctx, ProviderInitRequest{InvoiceID: p.InvoiceID, Amount: formatAmount(p.Amount)})
+	}
+	fake.SetStatus(1, StatusPaid)
+	fake.SetStatus(3, StatusFailed)
+
+	writer := &mockBookingWriter{}
+	svc := NewService(repo, &mockBookingReader{}, writer, nil, nil, nil, fake)
+	config := DefaultReconcileConfig()
+
+	res, err := svc.RunReconciliation(ctx, config, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Checked != 3 || res.Fixed != 2 || res.Flagged != 3 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if p, _ := repo.GetByInvoice(ctx, "kaspi", 1); p.Status != StatusPaid || len(writer.applied) != 1 || writer.applied[0] != 1000 {
+		t.Fatalf("expected missed payment applied, got status=%s applied=%v", p.Status, writer.applied)
+	}
+	if p, _ := repo.GetByInvoice(ctx, "kaspi", 3); p.Status != StatusFailed {
+		t.Fatalf("expected declined invoice failed, got %s", p.Status)
+	}
+
+	open, total, err := svc.ListDiscrepancies(ctx, DiscrepancyOpen, "", 50, 0)
+	if err != nil || total != 3 {
+		t.Fatalf("expected 3 open discrepancies, got %d err=%v", total, err)
+	}
+	if _, missed, _ := svc.ListDiscrepancies(ctx, DiscrepancyResolved, DiscrepancyMissedCallback, 50, 0); missed != 1 {
+		t.Fatalf("expected the missed callback recorded as resolved, got %d", missed)
+	}
+	byKind := map[DiscrepancyKind]Discrepancy{}
+	for _, d := range open {
+		byKind[d.Kind] = d
+	}
+
+	// повторный прогон не дублирует расхождения
+	if res, _ := svc.RunReconciliation(ctx, config, now); res.Flagged != 0 {
+		t.Fatalf("expected no new discrepancies on rerun, got %+v", res)
+	}
+
+	gap := byKind[DiscrepancyBookingUnpaid]
+	d, err := svc.ResolveDiscrepancy(ctx, gap.ID, ActionApplyPayment, "callback lost", "1")
+	if err != nil || d.Status != DiscrepancyResolved || writer.applied[len(writer.applied)-1] != 300 {
+		t.Fatalf("expected gap applied to booking, got %+v applied=%v err=%v", d, writer.applied, err)
+	}
+	db.Exec(`UPDATE bookings SET deposit_amount = 300, payment_status = 'paid' WHERE id = 4`) // то, что сделал бы ApplyPayment
+	if _, err := svc.ResolveDiscrepancy(ctx, gap.ID, ActionDismiss, "", "1"); !errors.Is(err, ErrDiscrepancyClosed) {
+		t.Fatalf("expected ErrDiscrepancyClosed, got %v", err)
+	}
+
+	orphan := byKind[DiscrepancyPaidWithoutPayment]
+	if _, err := svc.ResolveDiscrepancy(ctx, orphan.ID, ActionApplyPayment, "", "1"); !errors.Is(err, ErrInvalidAction) {
+		t.Fatalf("expected ErrInvalidAction, got %v", err)
+	}
+	if d, err := svc.ResolveDiscrepancy(ctx, orphan.ID, ActionDismiss, "paid in cash", "1"); err != nil || d.Status != DiscrepancyDismissed {
+		t.Fatalf("expected dismissed, got %+v err=%v", d, err)
+	}
+
+	stale := byKind[DiscrepancyStaleInvoice]
+	if d, err := svc.ResolveDiscrepancy(ctx, stale.ID, ActionMarkFailed, "", "1"); err != nil || d.Status != DiscrepancyResolved {
+		t.Fatalf("expected stale invoice closed, got %+v err=%v", d, err)
+	}
+
+	// отклонённое администратором расхождение не поднимается снова
+	if res, _ := svc.RunReconciliation(ctx, config, now); res.Flagged != 0 {
+		t.Fatalf("expected dismissed discrepancy to stay closed, got %+v", res)
+	}
+}
@@ -0,0 +1,229 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"photostudio/internal/domain/booking"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPaymentNotFound     = errors.New("payment not found")
+	ErrForbidden           = errors.New("forbidden")
+	ErrNotRefundable       = errors.New("payment is not paid")
+	ErrNothingToRefund     = errors.New("payment is already fully refunded")
+	ErrRefundExceeds       = errors.New("refund exceeds the refundable amount")
+	ErrIdempotencyConflict = errors.New("idempotency key was used for another refund")
+	ErrRefundFailed        = errors.New("provider rejected the refund")
+)
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"   // отправлен шлюзу, ждём подтверждения
+	RefundSucceeded RefundStatus = "succeeded" // деньги возвращены
+	RefundFailed    RefundStatus = "failed"
+)
+
+// Refund — возврат (полный или частичный) по оплаченному счёту. Ключ
+// идемпотентности не даёт вернуть деньги дважды при повторе запроса.
+type Refund struct {
+	ID             int64        `gorm:"primaryKey" json:"id"`
+	PaymentID      int64        `gorm:"index;not null" json:"payment_id"`
+	BookingID      int64        `gorm:"index;not null" json:"booking_id"`
+	Provider       string       `gorm:"type:varchar(32);not null" json:"provider"`
+	Amount         float64      `gorm:"type:decimal(12,2);not null" json:"amount"`
+	Reason         string       `gorm:"type:text" json:"reason,omitempty"`
+	Status         RefundStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ExternalID     string       `gorm:"type:varchar(128)" json:"external_id,omitempty"`
+	IdempotencyKey string       `gorm:"type:varchar(128);not null;uniqueIndex" json:"idempotency_key"`
+	RequestedBy    int64        `gorm:"not null" json:"requested_by"`
+	FailureReason  string       `gorm:"type:text" json:"failure_reason,omitempty"`
+	CompletedAt    *time.Time   `json:"completed_at,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+func (Refund) TableName() string { return "payment_refunds" }
+
+// RefundPayment возвращает деньги по оплаченному счёту через его провайдера.
+// amount = 0 — весь ещё не возвращённый остаток. Повтор с тем же ключом
+// идемпотентности возвращает уже созданный возврат без обращения к шлюзу.
+// Доступно владельцу студии и администратору.
+func (s *Service) RefundPayment(ctx context.Context, actor booking.Actor, req RefundRequest, idempotencyKey string) (*Refund, bool, error) {
+	p, err := s.payments.GetByID(ctx, req.PaymentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrPaymentNotFound
+		}
+		return nil, false, err
+	}
+	b, err := s.bookings.GetByID(ctx, p.BookingID)
+	if err != nil {
+		return nil, false, fmt.Errorf("booking check failed: %w", err)
+	}
+	if err := s.requireStudioManager(ctx, actor, b); err != nil {
+		return nil, false, err
+	}
+	return s.refundPayment(ctx, p, b, req, idempotencyKey, actor.UserID)
+}
+
+// RefundCancelledBooking возвращает amount за отменённую бронь через провайдеров
+// её оплаченных счетов, начиная со старого. Ключ идемпотентности выводится из
+// брони и счёта, поэтому повторный вызов не вернёт деньги дважды. Права уже
+// проверены при отмене; бронь без счетов (оплата на месте) пропускается.
+func (s *Service) RefundCancelledBooking(ctx context.Context, actor booking.Actor, bookingID int64, amount float64, reason string) error {
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		return fmt.Errorf("booking check failed: %w", err)
+	}
+	payments, err := s.payments.ListPaidByBooking(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	left := toCents(amount)
+	var errs []error
+	for i := range payments {
+		if left <= 0 {
+			break
+		}
+		p := &payments[i]
+		req := RefundRequest{PaymentID: p.ID, Reason: reason} // amount = 0 — весь остаток счёта
+		part := toCents(p.Amount)
+		if left < part {
+			part = left
+			req.Amount = float64(part) / 100
+		}
+		key := fmt.Sprintf("booking-cancel:%d:%d", bookingID, p.ID)
+		if _, _, err := s.refundPayment(ctx, p, b, req, key, actor.UserID); err != nil {
+			errs = append(errs, fmt.Errorf("payment %d: %w", p.ID, err))
+			continue
+		}
+		left -= part
+	}
+	return errors.Join(errs...)
+}
+
+// refundPayment создаёт возврат по счёту и отправляет его шлюзу; права вызывающего проверены
+func (s *Service) refundPayment(ctx context.Context, p *Payment, b *booking.Booking, req RefundRequest, idempotencyKey string, requestedBy int64) (*Refund, bool, error) {
+	switch p.Status {
+	case StatusPaid:
+	case StatusRefunded:
+		return nil, false, ErrNothingToRefund
+	default:
+		return nil, false, ErrNotRefundable
+	}
+	prov, err := s.provider(p.Provider)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if idempotencyKey == "" {
+		idempotencyKey = uuid.NewString()
+	}
+	r, created, err := s.payments.CreateRefund(ctx, &Refund{
+		PaymentID:      p.ID,
+		BookingID:      p.BookingID,
+		Provider:       p.Provider,
+		Amount:         req.Amount,
+		Reason:         req.Reason,
+		Status:         RefundPending,
+		IdempotencyKey: idempotencyKey,
+		RequestedBy:    requestedBy,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if !created {
+		if r.PaymentID != p.ID || (req.Amount > 0 && toCents(req.Amount) != toCents(r.Amount)) {
+			return nil, false, ErrIdempotencyConflict
+		}
+		return r, false, nil
+	}
+
+	res, err := prov.Refund(ctx, ProviderRefundRequest{InvoiceID: p.InvoiceID, ExternalID: p.ExternalID, Amount: formatAmount(r.Amount)})
+	if err != nil {
+		s.loggerf("level=error msg=provider refund failed provider=%s payment_id=%d refund_id=%d err=%v", p.Provider, p.ID, r.ID, err)
+		if failErr := s.payments.FailRefund(ctx, r.ID, err.Error()); failErr != nil {
+			s.loggerf("level=error msg=failed to mark refund failed refund_id=%d err=%v", r.ID, failErr)
+		}
+		if errors.Is(err, ErrRefundNotSupported) {
+			return nil, false, err
+		}
+		return nil, false, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+	if res.Status != StatusRefunded {
+		// шлюз подтвердит возврат позже (Robokassa — асинхронно)
+		if err := s.payments.SetRefundExternalID(ctx, r.ID, res.ExternalID); err != nil {
+			return nil, false, err
+		}
+		r.ExternalID = res.ExternalID
+		return r, true, nil
+	}
+	r, err = s.completeRefund(ctx, r, res.ExternalID, b)
+	return r, true, err
+}
+
+// ListRefunds — возвраты по брони: клиенту брони, владельцу студии и администратору
+func (s *Service) ListRefunds(ctx context.Context, actor booking.Actor, bookingID int64) ([]Refund, error) {
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	if b.IsGuest() || b.UserID != actor.UserID {
+		if err := s.requireStudioManager(ctx, actor, b); err != nil {
+			return nil, err
+		}
+	}
+	return s.payments.ListRefunds(ctx, bookingID)
+}
+
+// completeRefund фиксирует подтверждённый шлюзом возврат: счёт, бронь, уведомление клиенту
+func (s *Service) completeRefund(ctx context.Context, r *Refund, externalID string, b *booking.Booking) (*Refund, error) {
+	done, err := s.payments.CompleteRefund(ctx, r.ID, externalID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	refunded, err := s.payments.RefundedAmount(ctx, r.BookingID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.bookingWriter.ApplyRefund(ctx, r.BookingID, refunded); err != nil {
+		s.loggerf("level=error msg=failed to apply refund to booking booking_id=%d refund_id=%d err=%v", r.BookingID, r.ID, err)
+	}
+	if s.ledger != nil {
+		if err := s.ledger.RecordRefund(ctx, done.ID, done.PaymentID, done.BookingID, done.Amount); err != nil {
+			s.loggerf("level=error msg=failed to record refund in ledger booking_id=%d refund_id=%d err=%v", r.BookingID, r.ID, err)
+		}
+	}
+	if s.notifications != nil && b != nil && !b.IsGuest() {
+		if err := s.notifications.NotifyPaymentRefunded(ctx, b.UserID, b.ID, b.StudioID, done.Amount); err != nil {
+			s.loggerf("level=error msg=failed to notify client about refund booking_id=%d refund_id=%d err=%v", b.ID, r.ID, err)
+		}
+	}
+	return done, nil
+}
+
+func (s *Service) requireStudioManager(ctx context.Context, actor booking.Actor, b *booking.Booking) error {
+	if actor.Role == booking.ActorAdmin {
+		return nil
+	}
+	if actor.Role != booking.ActorOwner {
+		return ErrForbidden
+	}
+	owns, err := s.bookings.IsStudioOwnedByUser(ctx, b.StudioID, actor.UserID)
+	if err != nil {
+		return err
+	}
+	if !owns {
+		return ErrForbidden
+	}
+	return nil
+}
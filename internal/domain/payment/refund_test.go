@@ -0,0 +1,228 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"photostudio/internal/database"
+	"photostudio/internal/domain/booking"
+)
+
+type mockRefundNotifier struct {
+	amounts []float64
+}
+
+func (m *mockRefundNotifier) NotifyPaymentRefunded(ctx context.Context, clientID int64, bookingID, studioID int64, amount float64) error {
+	m.amounts = append(m.amounts, amount)
+	return nil
+}
+
+type mockLedger struct {
+	payments []int64
+	refunds  []float64
+}
+
+func (m *mockLedger) RecordPayment(ctx context.Context, paymentID, bookingID int64, amount float64) error {
+	m.payments = append(m.payments, paymentID)
+	return nil
+}
+
+func (m *mockLedger) RecordRefund(ctx context.Context, refundID, paymentID, bookingID int64, amount float64) error {
+	m.refunds = append(m.refunds, amount)
+	return nil
+}
+
+func newRefundTestService(t *testing.T, prov Provider) (*Service, *PaymentRepository, *mockBookingWriter, *mockRefundNotifier, *Payment) {
+	t.Helper()
+	db, err := database.Connect(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Payment{}, &Refund{}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewPaymentRepository(db)
+	paidAt := time.Now().UTC()
+	p := &Payment{BookingID: 5, Provider: prov.Name(), InvoiceID: 501, ExternalID: "op-501", Kind: KindFull, Amount: 1000, Status: StatusPaid, PaidAt: &paidAt}
+	if err := repo.Create(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &booking.Booking{ID: 5, StudioID: 1, UserID: 9, TotalPrice: 1000, PaymentStatus: booking.PaymentPaid}
+	writer := &mockBookingWriter{}
+	notifier := &mockRefundNotifier{}
+	svc := NewService(repo, &mockBookingReader{booking: b}, writer, notifier, &mockLedger{}, nil, prov)
+	return svc, repo, writer, notifier, p
+}
+
+func TestRefundPayment_PartialThenFull(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeProvider("kaspi")
+	svc, repo, writer, notifier, p := newRefundTestService(t, fake)
+	owner := booking.Actor{UserID: 100, Role: booking.ActorOwner}
+
+	if _, _, err := svc.RefundPayment(ctx, booking.Actor{UserID: 9, Role: booking.ActorClient}, RefundRequest{PaymentID: p.ID}, ""); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for the client, got %v", err)
+	}
+
+	r, created, err := svc.RefundPayment(ctx, owner, RefundRequest{PaymentID: p.ID, Amount: 400, Reason: "late start"}, "k1")
+	if err != nil || !created || r.Status != RefundSucceeded || r.Amount != 400 {
+		t.Fatalf("expected succeeded partial refund, got %+v created=%v err=%v", r, created, err)
+	}
+	if len(writer.refunded) != 1 || writer.refunded[0] != 400 || len(notifier.amounts) != 1 {
+		t.Fatalf("expected booking and client updated, got refunded=%v notified=%v", writer.refunded, notifier.amounts)
+	}
+	if l := svc.ledger.(*mockLedger); len(l.refunds) != 1 || l.refunds[0] != 400 {
+		t.Fatalf("expected the refund posted to the ledger, got %v", l.refunds)
+	}
+
+	// повтор того же запроса не возвращает деньги второй раз
+	again, created, err := svc.RefundPayment(ctx, owner, RefundRequest{PaymentID: p.ID, Amount: 400}, "k1")
+	if err != nil || created || again.ID != r.ID || len(fake.Refunds()) != 1 {
+		t.Fatalf("expected idempotent replay, got %+v created=%v err=%v provider_calls=%d", again, created, err, len(fake.Refunds()))
+	}
+	if _, _, err := svc.RefundPayment(ctx, owner, RefundRequest{PaymentID: p.ID, Amount: 300}, "k1"); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Fatalf("expected ErrIdempotencyConflict, got %v", err)
+	}
+	if _, _, err := svc.RefundPayment(ctx, owner, RefundRequest{PaymentID: p.ID, Amount: 600.01}, "k2"); !errors.Is(err, ErrRefundExceeds) {
+		t.Fatalf("expected ErrRefundExceeds, got %v", err)
+	}
+
+	// amount = 0 — весь остаток
+	rest, _, err := svc.RefundPayment(ctx, booking.Actor{UserID: 1, Role: booking.ActorAdmin}, RefundRequest{PaymentID: p.ID}, "k3")
+	if err != nil || rest.Amount != 600 {
+		t.Fatalf("expected the remaining 600 refunded, got %+v err=%v", rest, err)
+	}
+	stored, _ := repo.GetByID(ctx, p.ID)
+	if stored.Status != StatusRefunded || writer.refunded[len(writer.refunded)-1] != 1000 {
+		t.Fatalf("expected fully refunded payment, got status=%s refunded=%v", stored.Status, writer.refunded)
+	}
+	if _, _, err := svc.RefundPayment(ctx, owner, RefundRequest{PaymentID: p.ID}, "k4"); !errors.Is(err, ErrNothingToRefund) {
+		t.Fatalf("expected ErrNothingToRefund, got %v", err)
+	}
+
+	list, err := svc.ListRefunds(ctx, booking.Actor{UserID: 9, Role: booking.ActorClient}, 5)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("expected two refunds visible to the client, got %d err=%v", len(list), err)
+	}
+}
+
+func TestRefundCancelledBooking(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeProvider("kaspi")
+	svc, repo, writer, _, p := newRefundTestService(t, fake)
+	client := booking.Actor{UserID: 9, Role: booking.ActorClient}
+
+	// отмена клиентом по политике студии: 50% — без проверки прав владельца
+	if err := svc.RefundCancelledBooking(ctx, client, 5, 500, "changed my plans"); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := repo.ListRefunds(ctx, 5)
+	if len(list) != 1 || list[0].Amount != 500 || list[0].IdempotencyKey != "booking-cancel:5:1" || list[0].Status != RefundSucceeded {
+		t.Fatalf("expected one provider refund for the booking, got %+v", list)
+	}
+	if len(writer.refunded) != 1 || writer.refunded[0] != 500 {
+		t.Fatalf("expected the refund applied to the booking, got %v", writer.refunded)
+	}
+
+	// повторный вызов не возвращает деньги второй раз
+	if err := svc.RefundCancelledBooking(ctx, client, 5, 500, "changed my plans"); err != nil || len(fake.Refunds()) != 1 {
+		t.Fatalf("expected idempotent replay, err=%v provider_calls=%d", err, len(fake.Refunds()))
+	}
+	if stored, _ := repo.GetByID(ctx, p.ID); stored.Status != StatusPaid {
+		t.Fatalf("expected the payment partially refunded, got %s", stored.Status)
+	}
+}
+
+func TestReconcileRefund_PartialAsync(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeProvider("kaspi")
+	fake.SetAsyncRefunds(true)
+	svc, repo, writer, _, p := newRefundTestService(t, fake)
+	owner := booking.Actor{UserID: 100, Role: booking.ActorOwner}
+
+	first, _, err := svc.RefundPayment(ctx, owner, RefundRequest{PaymentID: p.ID, Amount: 400}, "k1")
+	if err != nil || first.Status != RefundPending || first.ExternalID == "" {
+		t.Fatalf("expected pending refund, got %+v err=%v", first, err)
+	}
+	second, _, err := svc.RefundPayment(ctx, owner, RefundRequest{PaymentID: p.ID, Amount: 100}, "k2")
+	if err != nil || second.Status != RefundPending {
+		t.Fatalf("expected pending refund, got %+v err=%v", second, err)
+	}
+
+	// счёт у шлюза остаётся оплаченным — сверка смотрит на операцию возврата
+	fake.SetRefundStatus(first.ExternalID, RefundSucceeded)
+	fake.SetRefundStatus(second.ExternalID, RefundFailed)
+	pending, _ := repo.ListPendingRefunds(ctx, 10)
+	var res ReconcileResult
+	for i := range pending {
+		svc.reconcileRefund(ctx, &pending[i], &res)
+	}
+	if res.RefundsSettled != 1 || res.Fixed != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if len(writer.refunded) != 1 || writer.refunded[0] != 400 {
+		t.Fatalf("expected the partial refund applied to the booking, got %v", writer.refunded)
+	}
+	list, _ := repo.ListRefunds(ctx, 5)
+	for _, r := range list {
+		if r.ID == first.ID && r.Status != RefundSucceeded || r.ID == second.ID && r.Status != RefundFailed {
+			t.Fatalf("unexpected refund state %+v", r)
+		}
+	}
+	if stored, _ := repo.GetByID(ctx, p.ID); stored.Status != StatusPaid {
+		t.Fatalf("expected the payment partially refunded, got %s", stored.Status)
+	}
+}
+
+func TestRefundPayment_RobokassaStubServer(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		parts := strings.Split(string(body), ".")
+		if len(parts) != 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mac := hmac.New(sha256.New, []byte("p3"))
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		if base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) != parts[2] {
+			_, _ = w.Write([]byte(`{"success":false,"message":"bad signature"}`))
+			return
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		_ = json.Unmarshal(payload, &got)
+		_, _ = w.Write([]byte(`{"success":true,"requestId":"req-1"}`))
+	}))
+	defer srv.Close()
+
+	rk := &RobokassaProvider{merchantLogin: "m", password1: "p1", password2: "p2", password3: "p3", refundURL: srv.URL, client: srv.Client()}
+	svc, _, writer, notifier, p := newRefundTestService(t, rk)
+
+	r, _, err := svc.RefundPayment(context.Background(), booking.Actor{UserID: 100, Role: booking.ActorOwner}, RefundRequest{PaymentID: p.ID, Amount: 250}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["OpKey"] != "op-501" || got["RefundSum"] != 250.0 {
+		t.Fatalf("unexpected refund payload %v", got)
+	}
+	// Robokassa подтверждает возврат асинхронно — бронь пока не меняется
+	if r.Status != RefundPending || r.ExternalID != "req-1" || len(writer.refunded) != 0 || len(notifier.amounts) != 0 {
+		t.Fatalf("expected pending refund, got %+v refunded=%v", r, writer.refunded)
+	}
+
+	rk.password3 = ""
+	if _, _, err := svc.RefundPayment(context.Background(), booking.Actor{UserID: 100, Role: booking.ActorOwner}, RefundRequest{PaymentID: p.ID, Amount: 100}, ""); !errors.Is(err, ErrRefundNotSupported) {
+		t.Fatalf("expected ErrRefundNotSupported without the refund key, got %v", err)
+	}
+}
@@ -0,0 +1,367 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type PaymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+func (r *PaymentRepository) Create(ctx context.Context, p *Payment) error {
+	return r.db.WithContext(ctx).Create(p).Error
+}
+
+func (r *PaymentRepository) GetByInvoice(ctx context.Context, provider string, invoiceID int64) (*Payment, error) {
+	var p Payment
+	if err := r.db.WithContext(ctx).Where("provider = ? AND invoice_id = ?", provider, invoiceID).First(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetByInvoiceID ищет счёт по номеру без провайдера (номера уникальны по времени выставления)
+func (r *PaymentRepository) GetByInvoiceID(ctx context.Context, invoiceID int64) (*Payment, error) {
+	var p Payment
+	if err := r.db.WithContext(ctx).Where("invoice_id = ?", invoiceID).First(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// MarkFailed отмечает неудачный callback, не трогая уже оплаченный счёт
+func (r *PaymentRepository) MarkFailed(ctx context.Context, id int64, rawBody, reason string) error {
+	return r.db.WithContext(ctx).
+		Model(&Payment{}).
+		Where("id = ? AND status <> ?", id, StatusPaid).
+		Updates(map[string]interface{}{
+			"status":            StatusFailed,
+			"callback_raw_body": rawBody,
+			"failure_reason":    reason,
+		}).Error
+}
+
+// SaveFailureReason записывает причину отклонённого callback, статус не меняется
+func (r *PaymentRepository) SaveFailureReason(ctx context.Context, id int64, rawBody, reason string) error {
+	return r.db.WithContext(ctx).
+		Model(&Payment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"callback_raw_body": rawBody,
+			"failure_reason":    reason,
+		}).Error
+}
+
+func (r *PaymentRepository) UpdateStatusPendingIfNotPaid(ctx context.Context, id int64, rawBody string) error {
+	res := r.db.WithContext(ctx).
+		Model(&Payment{}).
+		Where("id = ? AND status IN ?", id, []Status{StatusCreated, StatusFailed}).
+		Updates(map[string]interface{}{
+			"status":          StatusPending,
+			"return_raw_body": rawBody,
+		})
+	return res.Error
+}
+
+func (r *PaymentRepository) SaveReturnRawBody(ctx context.Context, id int64, rawBody string) error {
+	return r.db.WithContext(ctx).Model(&Payment{}).Where("id = ?", id).Update("return_raw_body", rawBody).Error
+}
+
+func (r *PaymentRepository) MarkPaidIdempotent(ctx context.Context, id int64, externalID, rawBody string, paidAt time.Time) (bool, error) {
+	var changed bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, id).Error; err != nil {
+			return err
+		}
+		if p.Status == StatusPaid || p.Status == StatusRefunded {
+			changed = false
+			return nil
+		}
+		updates := map[string]interface{}{
+			"status":            StatusPaid,
+			"callback_raw_body": rawBody,
+			"failure_reason":    "",
+			"paid_at":           paidAt,
+		}
+		if externalID != "" {
+			updates["external_id"] = externalID
+		}
+		res := tx.Model(&Payment{}).Where("id = ?", id).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("payment row not updated")
+		}
+		changed = true
+		return nil
+	})
+	return changed, err
+}
+
+func (r *PaymentRepository) GetByID(ctx context.Context, id int64) (*Payment, error) {
+	var p Payment
+	if err := r.db.WithContext(ctx).First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateRefund резервирует сумму возврата под блокировкой счёта: pending и
+// succeeded возвраты вместе не превышают сумму счёта. Amount = 0 — весь остаток.
+// Если ключ идемпотентности уже встречался, возвращает существующий возврат и false.
+func (r *PaymentRepository) CreateRefund(ctx context.Context, refund *Refund) (*Refund, bool, error) {
+	var out *Refund
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing Refund
+		err := tx.Where("idempotency_key = ?", refund.IdempotencyKey).First(&existing).Error
+		if err == nil {
+			out = &existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var p Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, refund.PaymentID).Error; err != nil {
+			return err
+		}
+		var reserved float64
+		if err := tx.Model(&Refund{}).
+			Where("payment_id = ? AND status IN ?", p.ID, []RefundStatus{RefundPending, RefundSucceeded}).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&reserved).Error; err != nil {
+			return err
+		}
+		remaining := toCents(p.Amount) - toCents(reserved)
+		if remaining <= 0 {
+			return ErrNothingToRefund
+		}
+		if refund.Amount == 0 {
+			refund.Amount = float64(remaining) / 100
+		}
+		if toCents(refund.Amount) > remaining {
+			return fmt.Errorf("%w: at most %.2f can be refunded", ErrRefundExceeds, float64(remaining)/100)
+		}
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+		out, created = refund, true
+		return nil
+	})
+	return out, created, err
+}
+
+func (r *PaymentRepository) FailRefund(ctx context.Context, id int64, reason string) error {
+	return r.db.WithContext(ctx).Model(&Refund{}).Where("id = ? AND status = ?", id, RefundPending).
+		Updates(map[string]interface{}{"status": RefundFailed, "failure_reason": reason}).Error
+}
+
+func (r *PaymentRepository) SetRefundExternalID(ctx context.Context, id int64, externalID string) error {
+	return r.db.WithContext(ctx).Model(&Refund{}).Where("id = ?", id).Update("external_id", externalID).Error
+}
+
+// CompleteRefund отмечает возврат выполненным (идемпотентно); счёт, возвращённый
+// целиком, получает статус refunded
+func (r *PaymentRepository) CompleteRefund(ctx context.Context, id int64, externalID string, at time.Time) (*Refund, error) {
+	var out Refund
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&out, id).Error; err != nil {
+			return err
+		}
+		if out.Status != RefundSucceeded {
+			updates := map[string]interface{}{"status": RefundSucceeded, "completed_at": at, "failure_reason": ""}
+			if externalID != "" {
+				updates["external_id"] = externalID
+				out.ExternalID = externalID
+			}
+			if err := tx.Model(&Refund{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+				return err
+			}
+			out.Status, out.CompletedAt = RefundSucceeded, &at
+		}
+
+		var p Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, out.PaymentID).Error; err != nil {
+			return err
+		}
+		var refunded float64
+		if err := tx.Model(&Refund{}).
+			Where("payment_id = ? AND status = ?", p.ID, RefundSucceeded).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&refunded).Error; err != nil {
+			return err
+		}
+		if toCents(refunded) >= toCents(p.Amount) && p.Status != StatusRefunded {
+			return tx.Model(&Payment{}).Where("id = ?", p.ID).Update("status", StatusRefunded).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RefundedAmount — сколько всего возвращено по брони
+func (r *PaymentRepository) RefundedAmount(ctx context.Context, bookingID int64) (float64, error) {
+	var sum float64
+	err := r.db.WithContext(ctx).Model(&Refund{}).
+		Where("booking_id = ? AND status = ?", bookingID, RefundSucceeded).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&sum).Error
+	return sum, err
+}
+
+// ListPaidByBooking — оплаченные (ещё не возвращённые целиком) счета брони, старые первыми
+func (r *PaymentRepository) ListPaidByBooking(ctx context.Context, bookingID int64) ([]Payment, error) {
+	var out []Payment
+	err := r.db.WithContext(ctx).Where("booking_id = ? AND status = ?", bookingID, StatusPaid).Order("id").Find(&out).Error
+	return out, err
+}
+
+func (r *PaymentRepository) ListRefunds(ctx context.Context, bookingID int64) ([]Refund, error) {
+	var out []Refund
+	err := r.db.WithContext(ctx).Where("booking_id = ?", bookingID).Order("id").Find(&out).Error
+	return out, err
+}
+
+// -------------------- Reconciliation --------------------
+
+// ListUnsettled — счета в created/pending, созданные в окне [createdAfter, createdBefore]
+func (r *PaymentRepository) ListUnsettled(ctx context.Context, createdBefore, createdAfter time.Time, limit int) ([]Payment, error) {
+	var out []Payment
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND created_at <= ? AND created_at >= ?", []Status{StatusCreated, StatusPending}, createdBefore, createdAfter).
+		Order("id").
+		Limit(limit).
+		Find(&out).Error
+	return out, err
+}
+
+func (r *PaymentRepository) ListPendingRefunds(ctx context.Context, limit int) ([]Refund, error) {
+	var out []Refund
+	err := r.db.WithContext(ctx).Where("status = ?", RefundPending).Order("id").Limit(limit).Find(&out).Error
+	return out, err
+}
+
+// bookingGapQuery — брони без оплаты, в которых оплаченных счетов (кроме долей
+// участников, они учитываются отдельно) больше, чем внесено в deposit_amount
+const bookingGapQuery = `
+SELECT b.id AS booking_id, COALESCE(b.deposit_amount, 0) AS deposit, SUM(p.amount) AS paid
+FROM bookings b
+JOIN payments p ON p.booking_id = b.id AND p.status = 'paid' AND p.participant_id IS NULL
+WHERE b.payment_status = 'unpaid' %s
+GROUP BY b.id, b.deposit_amount
+HAVING COALESCE(b.deposit_amount, 0) < SUM(p.amount)
+ORDER BY b.id`
+
+func (r *PaymentRepository) ListBookingPaymentGaps(ctx context.Context, limit int) ([]BookingPaymentGap, error) {
+	var out []BookingPaymentGap
+	err := r.db.WithContext(ctx).Raw(fmt.Sprintf(bookingGapQuery, "")+" LIMIT ?", limit).Scan(&out).Error
+	return out, err
+}
+
+// GetBookingPaymentGap — расхождение по одной брони или nil, если его уже нет
+func (r *PaymentRepository) GetBookingPaymentGap(ctx context.Context, bookingID int64) (*BookingPaymentGap, error) {
+	var out []BookingPaymentGap
+	if err := r.db.WithContext(ctx).Raw(fmt.Sprintf(bookingGapQuery, "AND b.id = ?"), bookingID).Scan(&out).Error; err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return &out[0], nil
+}
+
+// ListPaidBookingsWithoutPayment — брони с payment_status = paid, изменённые
+// после since, у которых нет ни одного оплаченного счёта
+func (r *PaymentRepository) ListPaidBookingsWithoutPayment(ctx context.Context, since time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).
+		Table("bookings AS b").
+		Where("b.payment_status = ? AND b.updated_at >= ?", "paid", since).
+		Where("NOT EXISTS (SELECT 1 FROM payments p WHERE p.booking_id = b.id AND p.status IN ?)", []Status{StatusPaid, StatusRefunded}).
+		Order("b.id").
+		Limit(limit).
+		Pluck("b.id", &ids).Error
+	return ids, err
+}
+
+// FlagDiscrepancy сохраняет расхождение, если такое же (вид, бронь, счёт) ещё
+// открыто или было отклонено администратором
+func (r *PaymentRepository) FlagDiscrepancy(ctx context.Context, d *Discrepancy) (bool, error) {
+	q := r.db.WithContext(ctx).Model(&Discrepancy{}).
+		Where("kind = ? AND booking_id = ? AND status IN ?", d.Kind, d.BookingID, []DiscrepancyStatus{DiscrepancyOpen, DiscrepancyDismissed})
+	if d.Kind == DiscrepancyMissedCallback {
+		q = r.db.WithContext(ctx).Model(&Discrepancy{}).Where("kind = ? AND booking_id = ?", d.Kind, d.BookingID)
+	}
+	if d.PaymentID != nil {
+		q = q.Where("payment_id = ?", *d.PaymentID)
+	} else {
+		q = q.Where("payment_id IS NULL")
+	}
+	var cnt int64
+	if err := q.Count(&cnt).Error; err != nil {
+		return false, err
+	}
+	if cnt > 0 {
+		return false, nil
+	}
+	return true, r.db.WithContext(ctx).Create(d).Error
+}
+
+func (r *PaymentRepository) ListDiscrepancies(ctx context.Context, status DiscrepancyStatus, kind DiscrepancyKind, limit, offset int) ([]Discrepancy, int64, error) {
+	q := r.db.WithContext(ctx).Model(&Discrepancy{})
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if kind != "" {
+		q = q.Where("kind = ?", kind)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []Discrepancy
+	err := q.Order("id DESC").Limit(limit).Offset(offset).Find(&out).Error
+	return out, total, err
+}
+
+func (r *PaymentRepository) GetDiscrepancy(ctx context.Context, id int64) (*Discrepancy, error) {
+	var d Discrepancy
+	if err := r.db.WithContext(ctx).First(&d, id).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *PaymentRepository) ResolveDiscrepancy(ctx context.Context, id int64, status DiscrepancyStatus, resolution, resolvedBy string, at time.Time) (*Discrepancy, error) {
+	res := r.db.WithContext(ctx).Model(&Discrepancy{}).
+		Where("id = ? AND status = ?", id, DiscrepancyOpen).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolution":  resolution,
+			"resolved_by": resolvedBy,
+			"resolved_at": at,
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrDiscrepancyClosed
+	}
+	return r.GetDiscrepancy(ctx, id)
+}
@@ -0,0 +1,323 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ProviderRobokassa = "robokassa"
+
+// RobokassaProvider — подписи MD5, доп. параметры Shp_*, ответ на ResultURL "OK<InvId>"
+type RobokassaProvider struct {
+	merchantLogin  string
+	password1      string
+	password2      string
+	password3      string // ключ Refund API; без него возвраты делаются в личном кабинете
+	baseURL        string
+	opStateURL     string
+	refundURL      string
+	refundStateURL string
+	resultURL      string
+	successURL     string
+	isTest         string
+	client         *http.Client
+}
+
+func NewRobokassaProvider() *RobokassaProvider {
+	return &RobokassaProvider{
+		merchantLogin:  os.Getenv("ROBOKASSA_MERCHANT_LOGIN"),
+		password1:      os.Getenv("ROBOKASSA_PASSWORD1"),
+		password2:      os.Getenv("ROBOKASSA_PASSWORD2"),
+		password3:      os.Getenv("ROBOKASSA_PASSWORD3"),
+		baseURL:        envOrDefault("ROBOKASSA_BASE_URL", "https://auth.robokassa.ru/Merchant/Index.aspx"),
+		opStateURL:     envOrDefault("ROBOKASSA_OPSTATE_URL", "https://auth.robokassa.ru/Merchant/WebService/Service.asmx/OpStateExt"),
+		refundURL:      envOrDefault("ROBOKASSA_REFUND_URL", "https://services.robokassa.ru/RefundService/Refund/Create"),
+		refundStateURL: envOrDefault("ROBOKASSA_REFUND_STATE_URL", "https://services.robokassa.ru/RefundService/Refund/GetState"),
+		resultURL:      os.Getenv("ROBOKASSA_RESULT_URL"),
+		successURL:     os.Getenv("ROBOKASSA_SUCCESS_URL"),
+		isTest:         envOrDefault("ROBOKASSA_IS_TEST", "1"),
+		client:         &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func envOrDefault(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func (p *RobokassaProvider) Name() string { return ProviderRobokassa }
+
+func (p *RobokassaProvider) configured() bool {
+	return p.merchantLogin != "" && p.password1 != "" && p.password2 != ""
+}
+
+func (p *RobokassaProvider) Init(ctx context.Context, req ProviderInitRequest) (*ProviderInitResult, error) {
+	if !p.configured() {
+		return nil, fmt.Errorf("%w: robokassa credentials are not set", ErrProviderNotReady)
+	}
+	signature := p.signInit(req.Amount, req.InvoiceID, req.Params)
+
+	u := url.Values{}
+	u.Set("MerchantLogin", p.merchantLogin)
+	u.Set("OutSum", req.Amount)
+	u.Set("InvId", strconv.FormatInt(req.InvoiceID, 10))
+	u.Set("Description", req.Description)
+	u.Set("SignatureValue", signature)
+	u.Set("IsTest", p.isTest)
+	if p.resultURL != "" {
+		u.Set("ResultURL", p.resultURL)
+	}
+	if p.successURL != "" {
+		u.Set("SuccessURL", p.successURL)
+	}
+	for k, v := range req.Params {
+		u.Set("Shp_"+k, v)
+	}
+	return &ProviderInitResult{PaymentURL: p.baseURL + "?" + u.Encode(), Signature: signature}, nil
+}
+
+// VerifyCallback: ResultURL подписан паролем #2, SuccessURL — паролем #1
+func (p *RobokassaProvider) VerifyCallback(ctx context.Context, kind CallbackKind, req CallbackRequest) (*CallbackResult, error) {
+	invID, err := strconv.ParseInt(req.Form.Get("InvId"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid InvId", ErrInvalidCallback)
+	}
+	res := &CallbackResult{
+		InvoiceID: invID,
+		Amount:    req.Form.Get("OutSum"),
+		Params:    collectShp(req.Form),
+		Status:    StatusPaid,
+		Ack:       "OK" + strconv.FormatInt(invID, 10),
+	}
+
+	expected := p.signResult(res.Amount, invID, res.Params)
+	if kind == CallbackReturn {
+		expected = p.signSuccess(res.Amount, invID, res.Params)
+		// возврат покупателя ещё не подтверждает оплату
+		res.Status = StatusPending
+	}
+	if !strings.EqualFold(req.Form.Get("SignatureValue"), expected) {
+		return res, ErrInvalidSignature
+	}
+	return res, nil
+}
+
+// opStateResponse — ответ OpStateExt; State.Code: 5 создан, 10 отменён,
+// 50 деньги получены, 60 возвращены, 80 приостановлен, 100 оплачен
+type opStateResponse struct {
+	Result struct {
+		Code        int    `xml:"Code"`
+		Description string `xml:"Description"`
+	} `xml:"Result"`
+	State struct {
+		Code int `xml:"Code"`
+	} `xml:"State"`
+	Info struct {
+		OutSum string `xml:"OutSum"`
+		OpKey  string `xml:"OpKey"`
+	} `xml:"Info"`
+}
+
+func (p *RobokassaProvider) Status(ctx context.Context, invoiceID int64) (*ProviderStatus, error) {
+	if !p.configured() {
+		return nil, fmt.Errorf("%w: robokassa credentials are not set", ErrProviderNotReady)
+	}
+	inv := strconv.FormatInt(invoiceID, 10)
+	q := url.Values{}
+	q.Set("MerchantLogin", p.merchantLogin)
+	q.Set("InvoiceID", inv)
+	q.Set("Signature", md5Hex(p.merchantLogin+":"+inv+":"+p.password2))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.opStateURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("robokassa op state request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("robokassa op state returned %d", resp.StatusCode)
+	}
+
+	var st opStateResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return nil, fmt.Errorf("robokassa op state decode failed: %w", err)
+	}
+	if st.Result.Code != 0 {
+		return nil, fmt.Errorf("robokassa op state error %d: %s", st.Result.Code, st.Result.Description)
+	}
+
+	out := &ProviderStatus{InvoiceID: invoiceID, Amount: st.Info.OutSum, ExternalID: st.Info.OpKey}
+	switch st.State.Code {
+	case 100:
+		out.Status = StatusPaid
+	case 60:
+		out.Status = StatusRefunded
+	case 10:
+		out.Status = StatusFailed
+	default:
+		out.Status = StatusPending
+	}
+	return out, nil
+}
+
+// Refund вызывает Refund API: тело — JWT (HS256) с OpKey операции, подписанный паролем #3
+func (p *RobokassaProvider) Refund(ctx context.Context, req ProviderRefundRequest) (*ProviderRefundResult, error) {
+	if p.password3 == "" {
+		return nil, ErrRefundNotSupported
+	}
+	opKey := req.ExternalID
+	if opKey == "" {
+		st, err := p.Status(ctx, req.InvoiceID)
+		if err != nil {
+			return nil, err
+		}
+		opKey = st.ExternalID
+	}
+	if opKey == "" {
+		return nil, fmt.Errorf("robokassa refund: no OpKey for invoice %d", req.InvoiceID)
+	}
+	sum, err := strconv.ParseFloat(req.Amount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("robokassa refund: invalid amount %q", req.Amount)
+	}
+
+	token, err := signJWT(map[string]interface{}{"OpKey": opKey, "RefundSum": sum}, p.password3)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.refundURL, bytes.NewBufferString(token))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("robokassa refund request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	var out struct {
+		Success   bool   `json:"success"`
+		Message   string `json:"message"`
+		RequestID string `json:"requestId"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("robokassa refund returned %d: %s", resp.StatusCode, string(body))
+	}
+	if !out.Success {
+		return nil, fmt.Errorf("robokassa refund rejected: %s", out.Message)
+	}
+	// Robokassa проводит возврат асинхронно, итог виден в OpStateExt
+	return &ProviderRefundResult{ExternalID: out.RequestID, Status: StatusPending}, nil
+}
+
+// RefundStatus запрашивает состояние возврата по requestId из Refund API
+func (p *RobokassaProvider) RefundStatus(ctx context.Context, externalID string) (RefundStatus, error) {
+	if externalID == "" {
+		return "", fmt.Errorf("robokassa refund state: empty request id")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.refundStateURL+"?"+url.Values{"id": {externalID}}.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("robokassa refund state request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	var out struct {
+		RequestID string `json:"requestId"`
+		Label     string `json:"label"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil || resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("robokassa refund state returned %d: %s", resp.StatusCode, string(body))
+	}
+	switch out.Label {
+	case "finished":
+		return RefundSucceeded, nil
+	case "canceled":
+		return RefundFailed, nil
+	default:
+		return RefundPending, nil
+	}
+}
+
+func (p *RobokassaProvider) signInit(outSum string, invID int64, shp map[string]string) string {
+	parts := []string{p.merchantLogin, outSum, strconv.FormatInt(invID, 10), p.password1}
+	return md5Hex(strings.Join(append(parts, flattenShpParams(shp)...), ":"))
+}
+
+func (p *RobokassaProvider) signResult(outSum string, invID int64, shp map[string]string) string {
+	parts := []string{outSum, strconv.FormatInt(invID, 10), p.password2}
+	return md5Hex(strings.Join(append(parts, flattenShpParams(shp)...), ":"))
+}
+
+func (p *RobokassaProvider) signSuccess(outSum string, invID int64, shp map[string]string) string {
+	parts := []string{outSum, strconv.FormatInt(invID, 10), p.password1}
+	return md5Hex(strings.Join(append(parts, flattenShpParams(shp)...), ":"))
+}
+
+func flattenShpParams(shp map[string]string) []string {
+	keys := make([]string, 0, len(shp))
+	for k := range shp {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, "Shp_"+k+"="+shp[k])
+	}
+	return out
+}
+
+func collectShp(form url.Values) map[string]string {
+	res := map[string]string{}
+	for k, v := range form {
+		if strings.HasPrefix(strings.ToLower(k), "shp_") && len(v) > 0 {
+			res[k[4:]] = v[0]
+		}
+	}
+	return res
+}
+
+func md5Hex(s string) string {
+	h := md5.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(h[:]))
+}
+
+func signJWT(claims map[string]interface{}, key string) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
@@ -8,11 +8,29 @@ func (h *Handler) RegisterWebhookRoutes(r *gin.RouterGroup) {
 		robokassa.POST("/result", h.ResultCallback)
 		robokassa.GET("/success", h.SuccessCallback)
 	}
+	payments := r.Group("/payments")
+	{
+		payments.POST("/:provider/callback", h.ProviderCallback)
+		payments.GET("/:provider/return", h.ProviderReturn)
+	}
 }
 
 func (h *Handler) RegisterProtectedRoutes(r *gin.RouterGroup) {
-	robokassa := r.Group("/payments/robokassa")
+	payments := r.Group("/payments")
+	{
+		payments.POST("/init", h.InitPayment)
+		payments.POST("/robokassa/init", h.InitPayment)
+		payments.POST("/refunds", h.RefundPayment)
+		payments.GET("/refunds", h.ListRefunds)
+	}
+}
+
+// RegisterAdminRoutes — отчёт сверки платежей; группа уже под AdminJWTAuth
+func (h *Handler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	payments := r.Group("/payments")
 	{
-		robokassa.POST("/init", h.InitPayment)
+		payments.GET("/discrepancies", h.ListDiscrepancies)
+		payments.POST("/discrepancies/:id/resolve", h.ResolveDiscrepancy)
+		payments.POST("/reconcile", h.RunReconciliation)
 	}
 }
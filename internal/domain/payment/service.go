@@ -2,16 +2,12 @@ package payment
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
-	"net/url"
 	"os"
 	"photostudio/internal/domain/booking"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,186 +16,307 @@ import (
 var (
 	ErrInvalidSignature = errors.New("invalid signature")
 	ErrAmountMismatch   = errors.New("amount mismatch")
+	ErrHoldExpired      = errors.New("booking hold expired")
+	ErrSplitPayment     = errors.New("booking is paid by participant shares")
+	ErrInvalidShare     = errors.New("invalid participant share")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrNothingToPay     = errors.New("booking has no outstanding balance")
+	ErrNotPayable       = errors.New("booking can no longer be paid")
 )
 
 type Service struct {
 	payments      paymentRepo
 	bookings      bookingReader
 	bookingWriter bookingPaymentWriter
+	notifications refundNotifier
+	ledger        ledgerRecorder
 	loggerf       func(format string, args ...interface{})
 
-	merchantLogin string
-	password1     string
-	password2     string
-	baseURL       string
-	resultURL     string
-	successURL    string
-	isTest        string
+	providers       map[string]Provider
+	defaultProvider string
+	depositPercent  int // минимальная предоплата, % от стоимости
 }
 
-func NewService(payments paymentRepo, bookings bookingReader, bookingWriter bookingPaymentWriter, loggerf func(format string, args ...interface{})) *Service {
+// NewService регистрирует переданных провайдеров; провайдер по умолчанию —
+// PAYMENT_DEFAULT_PROVIDER (robokassa, если не задан). Минимальная и по
+// умолчанию предоплата — PAYMENT_DEPOSIT_PERCENT (30%).
+func NewService(payments paymentRepo, bookings bookingReader, bookingWriter bookingPaymentWriter, notifications refundNotifier, ledger ledgerRecorder, loggerf func(format string, args ...interface{}), providers ...Provider) *Service {
 	if loggerf == nil {
 		loggerf = func(string, ...interface{}) {}
 	}
-	return &Service{
-		payments:      payments,
-		bookings:      bookings,
-		bookingWriter: bookingWriter,
-		loggerf:       loggerf,
-		merchantLogin: os.Getenv("ROBOKASSA_MERCHANT_LOGIN"),
-		password1:     os.Getenv("ROBOKASSA_PASSWORD1"),
-		password2:     os.Getenv("ROBOKASSA_PASSWORD2"),
-		baseURL:       envOrDefault("ROBOKASSA_BASE_URL", "https://auth.robokassa.ru/Merchant/Index.aspx"),
-		resultURL:     os.Getenv("ROBOKASSA_RESULT_URL"),
-		successURL:    os.Getenv("ROBOKASSA_SUCCESS_URL"),
-		isTest:        envOrDefault("ROBOKASSA_IS_TEST", "1"),
+	s := &Service{
+		payments:        payments,
+		bookings:        bookings,
+		bookingWriter:   bookingWriter,
+		notifications:   notifications,
+		ledger:          ledger,
+		loggerf:         loggerf,
+		providers:       map[string]Provider{},
+		defaultProvider: envOrDefault("PAYMENT_DEFAULT_PROVIDER", ProviderRobokassa),
+		depositPercent:  defaultDepositPercent,
 	}
+	if v, err := strconv.Atoi(os.Getenv("PAYMENT_DEPOSIT_PERCENT")); err == nil && v > 0 && v <= 100 {
+		s.depositPercent = v
+	}
+	for _, p := range providers {
+		s.RegisterProvider(p)
+	}
+	return s
+}
+
+func (s *Service) RegisterProvider(p Provider) {
+	s.providers[p.Name()] = p
 }
 
-func envOrDefault(name, def string) string {
-	if v := os.Getenv(name); v != "" {
-		return v
+func (s *Service) provider(name string) (Provider, error) {
+	if name == "" {
+		name = s.defaultProvider
 	}
-	return def
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return p, nil
 }
 
 func (s *Service) InitPayment(ctx context.Context, req InitPaymentRequest) (*InitPaymentResponse, error) {
-	if s.merchantLogin == "" || s.password1 == "" || s.password2 == "" {
-		return nil, fmt.Errorf("robokassa credentials are not configured")
+	prov, err := s.provider(req.Provider)
+	if err != nil {
+		return nil, err
 	}
-	if _, err := s.bookings.GetByID(ctx, req.BookingID); err != nil {
+	b, err := s.bookings.GetByID(ctx, req.BookingID)
+	if err != nil {
 		return nil, fmt.Errorf("booking check failed: %w", err)
 	}
+	// Оплату hold-а можно начать только пока слот ещё удерживается
+	if b.Status == booking.BookingHeld && b.HoldExpiresAt != nil && !b.HoldExpiresAt.After(time.Now()) {
+		return nil, ErrHoldExpired
+	}
+	kind, amount, err := s.invoiceAmount(ctx, b, req)
+	if err != nil {
+		return nil, err
+	}
+	var participantID *int64
+	if kind == KindShare {
+		participantID = &req.ParticipantID
+	} else {
+		// разделённую бронь оплачивают только долями, иначе возможна двойная оплата
+		participants, err := s.bookings.ListParticipants(ctx, req.BookingID)
+		if err != nil {
+			return nil, fmt.Errorf("participants check failed: %w", err)
+		}
+		if len(participants) > 0 {
+			return nil, ErrSplitPayment
+		}
+	}
 
 	invID := time.Now().UnixNano()
-	signature := s.generateSignatureForInit(req.OutSum, invID, req.ShpParams)
-
-	u := url.Values{}
-	u.Set("MerchantLogin", s.merchantLogin)
-	u.Set("OutSum", req.OutSum)
-	u.Set("InvId", strconv.FormatInt(invID, 10))
-	u.Set("Description", req.Description)
-	u.Set("SignatureValue", signature)
-	u.Set("IsTest", s.isTest)
-	if s.resultURL != "" {
-		u.Set("ResultURL", s.resultURL)
-	}
-	if s.successURL != "" {
-		u.Set("SuccessURL", s.successURL)
-	}
-	for k, v := range req.ShpParams {
-		u.Set("Shp_"+k, v)
-	}
-	paymentURL := s.baseURL + "?" + u.Encode()
-
-	shpRaw, _ := json.Marshal(req.ShpParams)
-	p := &RobokassaPayment{
-		BookingID:    req.BookingID,
-		OutSum:       req.OutSum,
-		InvID:        invID,
-		Description:  req.Description,
-		Status:       RobokassaPaymentStatus(booking.PaymentUnpaid),
-		Signature:    signature,
-		RobokassaURL: paymentURL,
-		ShpParams:    string(shpRaw),
+	init, err := prov.Init(ctx, ProviderInitRequest{
+		InvoiceID:   invID,
+		Amount:      formatAmount(amount),
+		Description: req.Description,
+		Params:      req.ShpParams,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	paramsRaw, _ := json.Marshal(req.ShpParams)
+	p := &Payment{
+		BookingID:     req.BookingID,
+		ParticipantID: participantID,
+		Provider:      prov.Name(),
+		InvoiceID:     invID,
+		ExternalID:    init.ExternalID,
+		Kind:          kind,
+		Amount:        amount,
+		Description:   req.Description,
+		Status:        StatusCreated,
+		PaymentURL:    init.PaymentURL,
+		Params:        string(paramsRaw),
 	}
 	if err := s.payments.Create(ctx, p); err != nil {
 		return nil, fmt.Errorf("save payment failed: %w", err)
 	}
-	if _, err := s.bookingWriter.UpdatePaymentStatusSystem(ctx, req.BookingID, booking.PaymentUnpaid); err != nil {
-		s.loggerf("level=error msg=failed to sync booking payment status on init booking_id=%d err=%v", req.BookingID, err)
+	if participantID != nil {
+		if err := s.bookingWriter.SetParticipantInvoice(ctx, *participantID, invID); err != nil {
+			s.loggerf("level=error msg=failed to save participant invoice participant_id=%d inv_id=%d err=%v", *participantID, invID, err)
+		}
 	}
 
-	return &InitPaymentResponse{InvID: invID, PaymentURL: paymentURL, Signature: signature, Status: string(booking.PaymentUnpaid)}, nil
+	return &InitPaymentResponse{
+		PaymentID:  p.ID,
+		Provider:   prov.Name(),
+		Kind:       string(kind),
+		Amount:     formatAmount(amount),
+		Balance:    formatAmount(b.TotalPrice - b.DepositAmount - amount),
+		InvID:      invID,
+		PaymentURL: init.PaymentURL,
+		Signature:  init.Signature,
+		Status:     string(booking.PaymentUnpaid),
+	}, nil
 }
 
-func (s *Service) HandleResultCallback(ctx context.Context, outSum string, invID int64, signature string, shpParams map[string]string, rawBody string) (string, error) {
-	valid := strings.EqualFold(signature, s.generateSignatureForResult(outSum, invID, shpParams))
-	s.loggerf("level=info msg=robokassa result signature validation inv_id=%d signature_valid=%t", invID, valid)
-	if !valid {
-		_ = s.payments.UpdateStatus(ctx, invID, RobokassaPaymentStatus(booking.PaymentUnpaid), rawBody, "invalid signature", nil)
+// HandleCallback обрабатывает server-to-server уведомление шлюза и возвращает
+// ответ, которого шлюз ждёт. Повторные уведомления об оплате идемпотентны.
+func (s *Service) HandleCallback(ctx context.Context, providerName string, req CallbackRequest) (string, error) {
+	prov, err := s.provider(providerName)
+	if err != nil {
+		return "", err
+	}
+	res, err := prov.VerifyCallback(ctx, CallbackNotify, req)
+	if errors.Is(err, ErrInvalidSignature) {
+		s.loggerf("level=info msg=payment callback signature validation provider=%s signature_valid=false", prov.Name())
+		if res != nil {
+			if p, lookupErr := s.payments.GetByInvoice(ctx, prov.Name(), res.InvoiceID); lookupErr == nil {
+				_ = s.payments.SaveFailureReason(ctx, p.ID, req.RawBody, "invalid signature")
+			}
+		}
 		return "", ErrInvalidSignature
 	}
-
-	p, err := s.payments.GetByInvID(ctx, invID)
 	if err != nil {
 		return "", err
 	}
-	if !amountEqual(outSum, p.OutSum) {
-		reason := fmt.Sprintf("amount mismatch callback=%s expected=%s", outSum, p.OutSum)
-		_ = s.payments.UpdateStatus(ctx, invID, RobokassaPaymentStatus(booking.PaymentUnpaid), rawBody, reason, nil)
-		return "", ErrAmountMismatch
-	}
+	s.loggerf("level=info msg=payment callback signature validation provider=%s inv_id=%d signature_valid=true", prov.Name(), res.InvoiceID)
 
-	changed, err := s.payments.MarkPaidIdempotent(ctx, invID, rawBody, time.Now().UTC())
+	p, err := s.payments.GetByInvoice(ctx, prov.Name(), res.InvoiceID)
 	if err != nil {
 		return "", err
 	}
-	if _, err = s.bookingWriter.UpdatePaymentStatusSystem(ctx, p.BookingID, booking.PaymentPaid); err != nil {
-		s.loggerf("level=error msg=failed to update booking payment status to paid booking_id=%d err=%v", p.BookingID, err)
+	if res.Status == StatusFailed {
+		if err := s.payments.MarkFailed(ctx, p.ID, req.RawBody, "declined by provider"); err != nil {
+			return "", err
+		}
+		return res.Ack, nil
+	}
+	if res.Status != StatusPaid {
+		// промежуточное уведомление: ждём следующего
+		s.loggerf("level=info msg=payment callback without final status provider=%s inv_id=%d status=%s", prov.Name(), res.InvoiceID, res.Status)
+		return res.Ack, nil
+	}
+	if !amountEqual(res.Amount, formatAmount(p.Amount)) {
+		reason := fmt.Sprintf("amount mismatch callback=%s expected=%s", res.Amount, formatAmount(p.Amount))
+		_ = s.payments.MarkFailed(ctx, p.ID, req.RawBody, reason)
+		return "", ErrAmountMismatch
 	}
 
-	if !changed {
-		s.loggerf("level=info msg=idempotent callback already paid inv_id=%d", invID)
+	if err := s.applyPaid(ctx, p, res.ExternalID, req.RawBody); err != nil {
+		return "", err
 	}
-	return "OK" + strconv.FormatInt(invID, 10), nil
+	return res.Ack, nil
 }
 
-func (s *Service) HandleSuccessCallback(ctx context.Context, outSum string, invID int64, signature string, shpParams map[string]string, rawBody string) (bool, error) {
-	if err := s.payments.SaveSuccessRawBody(ctx, invID, rawBody); err != nil {
-		s.loggerf("level=error msg=failed to save success callback body inv_id=%d err=%v", invID, err)
+// HandleReturn проверяет возврат покупателя со страницы оплаты. Оплату он не
+// подтверждает — счёт переходит в pending до уведомления шлюза.
+func (s *Service) HandleReturn(ctx context.Context, providerName string, req CallbackRequest) (bool, error) {
+	prov, err := s.provider(providerName)
+	if err != nil {
+		return false, err
 	}
-	valid := strings.EqualFold(signature, s.generateSignatureForSuccess(outSum, invID, shpParams))
-	s.loggerf("level=info msg=robokassa success signature validation inv_id=%d signature_valid=%t", invID, valid)
-	if !valid {
-		return false, ErrInvalidSignature
+	res, err := prov.VerifyCallback(ctx, CallbackReturn, req)
+	if res != nil {
+		if p, lookupErr := s.payments.GetByInvoice(ctx, prov.Name(), res.InvoiceID); lookupErr == nil {
+			if saveErr := s.payments.SaveReturnRawBody(ctx, p.ID, req.RawBody); saveErr != nil {
+				s.loggerf("level=error msg=failed to save return callback body provider=%s inv_id=%d err=%v", prov.Name(), res.InvoiceID, saveErr)
+			}
+		}
+	}
+	if err != nil {
+		s.loggerf("level=info msg=payment return validation failed provider=%s err=%v", prov.Name(), err)
+		return false, err
 	}
 
-	p, err := s.payments.GetByInvID(ctx, invID)
+	p, err := s.payments.GetByInvoice(ctx, prov.Name(), res.InvoiceID)
 	if err != nil {
 		return false, err
 	}
-	if !amountEqual(outSum, p.OutSum) {
-		s.loggerf("level=error msg=amount mismatch on success callback inv_id=%d callback_out_sum=%s expected_out_sum=%s", invID, outSum, p.OutSum)
+	if !amountEqual(res.Amount, formatAmount(p.Amount)) {
+		s.loggerf("level=error msg=amount mismatch on return callback provider=%s inv_id=%d callback_amount=%s expected_amount=%s", prov.Name(), res.InvoiceID, res.Amount, formatAmount(p.Amount))
 		return false, ErrAmountMismatch
 	}
 
-	if err := s.payments.UpdateStatusPendingIfNotPaid(ctx, invID, rawBody); err != nil {
-		s.loggerf("level=error msg=failed to set pending status from success callback inv_id=%d err=%v", invID, err)
+	if err := s.payments.UpdateStatusPendingIfNotPaid(ctx, p.ID, req.RawBody); err != nil {
+		s.loggerf("level=error msg=failed to set pending status from return callback provider=%s inv_id=%d err=%v", prov.Name(), res.InvoiceID, err)
 		return false, err
 	}
 	return true, nil
 }
 
-func (s *Service) generateSignatureForInit(outSum string, invID int64, shpParams map[string]string) string {
-	parts := []string{s.merchantLogin, outSum, strconv.FormatInt(invID, 10), s.password1}
-	parts = append(parts, flattenShpParams(shpParams)...)
-	return md5Hex(strings.Join(parts, ":"))
-}
-
-func (s *Service) generateSignatureForResult(outSum string, invID int64, shpParams map[string]string) string {
-	parts := []string{outSum, strconv.FormatInt(invID, 10), s.password2}
-	parts = append(parts, flattenShpParams(shpParams)...)
-	return md5Hex(strings.Join(parts, ":"))
+// RefreshStatus запрашивает состояние счёта у шлюза и применяет оплату,
+// если уведомление потерялось
+func (s *Service) RefreshStatus(ctx context.Context, providerName string, invoiceID int64) (*Payment, error) {
+	prov, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.payments.GetByInvoice(ctx, prov.Name(), invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	st, err := prov.Status(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if st.Status == StatusPaid && p.Status != StatusPaid && p.Status != StatusRefunded {
+		if !amountEqual(st.Amount, formatAmount(p.Amount)) {
+			return nil, ErrAmountMismatch
+		}
+		if err := s.applyPaid(ctx, p, st.ExternalID, ""); err != nil {
+			return nil, err
+		}
+	}
+	return s.payments.GetByInvoice(ctx, prov.Name(), invoiceID)
 }
 
-func (s *Service) generateSignatureForSuccess(outSum string, invID int64, shpParams map[string]string) string {
-	parts := []string{outSum, strconv.FormatInt(invID, 10), s.password1}
-	parts = append(parts, flattenShpParams(shpParams)...)
-	return md5Hex(strings.Join(parts, ":"))
+// applyPaid отмечает счёт оплаченным и переносит оплату на бронь
+func (s *Service) applyPaid(ctx context.Context, p *Payment, externalID, rawBody string) error {
+	paidAt := time.Now().UTC()
+	changed, err := s.payments.MarkPaidIdempotent(ctx, p.ID, externalID, rawBody, paidAt)
+	if err != nil {
+		return err
+	}
+	if changed {
+		s.recordLedgerPayment(ctx, p)
+	}
+	if p.ParticipantID != nil {
+		// доля участника: бронь оплачена и подтверждена, только когда покрыты все доли
+		b, err := s.bookingWriter.MarkParticipantPaid(ctx, *p.ParticipantID, paidAt)
+		if err != nil {
+			s.loggerf("level=error msg=failed to mark participant share paid booking_id=%d participant_id=%d inv_id=%d err=%v", p.BookingID, *p.ParticipantID, p.InvoiceID, err)
+		} else if b.Status == booking.BookingCancelled {
+			// Деньги получены, а бронь уже отменена — нужен возврат вручную
+			s.loggerf("level=error msg=participant paid for cancelled booking booking_id=%d participant_id=%d inv_id=%d", p.BookingID, *p.ParticipantID, p.InvoiceID)
+		}
+		return nil
+	}
+	if !changed {
+		// сумма уже учтена в брони при первом уведомлении
+		s.loggerf("level=info msg=idempotent callback already paid provider=%s inv_id=%d", p.Provider, p.InvoiceID)
+		return nil
+	}
+	b, err := s.bookingWriter.ApplyPayment(ctx, p.BookingID, p.Amount)
+	if err != nil {
+		s.loggerf("level=error msg=failed to apply payment to booking booking_id=%d inv_id=%d amount=%.2f err=%v", p.BookingID, p.InvoiceID, p.Amount, err)
+	} else if toCents(b.DepositAmount) > toCents(b.TotalPrice) {
+		// два счёта оплачены параллельно — переплату нужно вернуть
+		s.loggerf("level=error msg=booking overpaid booking_id=%d paid=%.2f total=%.2f", p.BookingID, b.DepositAmount, b.TotalPrice)
+	}
+	if _, err = s.bookingWriter.ConvertHold(ctx, p.BookingID); err != nil {
+		// Деньги получены, а слот уже занят — нужен возврат вручную
+		s.loggerf("level=error msg=failed to convert booking hold after payment booking_id=%d inv_id=%d err=%v", p.BookingID, p.InvoiceID, err)
+	}
+	return nil
 }
 
-func flattenShpParams(shp map[string]string) []string {
-	keys := make([]string, 0, len(shp))
-	for k := range shp {
-		keys = append(keys, k)
+// recordLedgerPayment проводит оплату в учёте выплат владельцу. Ошибка учёта
+// не отменяет принятую оплату — её видно в логах.
+func (s *Service) recordLedgerPayment(ctx context.Context, p *Payment) {
+	if s.ledger == nil {
+		return
 	}
-	sort.Strings(keys)
-	out := make([]string, 0, len(keys))
-	for _, k := range keys {
-		out = append(out, "Shp_"+k+"="+shp[k])
+	if err := s.ledger.RecordPayment(ctx, p.ID, p.BookingID, p.Amount); err != nil {
+		s.loggerf("level=error msg=failed to record payment in ledger booking_id=%d payment_id=%d err=%v", p.BookingID, p.ID, err)
 	}
-	return out
 }
 
 func amountEqual(a, b string) bool {
@@ -213,8 +330,3 @@ func amountEqual(a, b string) bool {
 	}
 	return ar.Cmp(br) == 0
 }
-
-func md5Hex(s string) string {
-	h := md5.Sum([]byte(s))
-	return strings.ToUpper(hex.EncodeToString(h[:]))
-}
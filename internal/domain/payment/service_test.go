@@ -3,85 +3,258 @@ package payment
 import (
 	"context"
 	"errors"
+	"net/url"
 	"photostudio/internal/domain/booking"
 	"testing"
 	"time"
 )
 
-type mockBookingReader struct{}
+type mockBookingReader struct {
+	booking *booking.Booking
+}
 
 func (m *mockBookingReader) GetByID(ctx context.Context, id int64) (*booking.Booking, error) {
-	return &booking.Booking{ID: id}, nil
+	if m.booking != nil {
+		return m.booking, nil
+	}
+	return &booking.Booking{ID: id, TotalPrice: 2500, PaymentStatus: booking.PaymentUnpaid}, nil
+}
+func (m *mockBookingReader) GetParticipant(ctx context.Context, id int64) (*booking.BookingParticipant, error) {
+	return nil, errors.New("not found")
+}
+func (m *mockBookingReader) ListParticipants(ctx context.Context, bookingID int64) ([]booking.BookingParticipant, error) {
+	return nil, nil
+}
+func (m *mockBookingReader) IsStudioOwnedByUser(ctx context.Context, studioID, userID int64) (bool, error) {
+	return userID == 100, nil
 }
 
-type mockBookingWriter struct{}
+type mockBookingWriter struct {
+	applied  []float64
+	refunded []float64
+}
 
-func (m *mockBookingWriter) UpdatePaymentStatus(ctx context.Context, bookingID int64, status domain.PaymentStatus) (*booking.Booking, error) {
+func (m *mockBookingWriter) UpdatePaymentStatus(ctx context.Context, bookingID int64, status booking.PaymentStatus) (*booking.Booking, error) {
+	return &booking.Booking{ID: bookingID, PaymentStatus: status}, nil
+}
+func (m *mockBookingWriter) UpdatePaymentStatusSystem(ctx context.Context, bookingID int64, status booking.PaymentStatus) (*booking.Booking, error) {
 	return &booking.Booking{ID: bookingID, PaymentStatus: status}, nil
 }
+func (m *mockBookingWriter) ApplyPayment(ctx context.Context, bookingID int64, amount float64) (*booking.Booking, error) {
+	m.applied = append(m.applied, amount)
+	return &booking.Booking{ID: bookingID}, nil
+}
+func (m *mockBookingWriter) ApplyRefund(ctx context.Context, bookingID int64, refunded float64) (*booking.Booking, error) {
+	m.refunded = append(m.refunded, refunded)
+	return &booking.Booking{ID: bookingID}, nil
+}
+func (m *mockBookingWriter) ConvertHold(ctx context.Context, bookingID int64) (*booking.Booking, error) {
+	return &booking.Booking{ID: bookingID}, nil
+}
+func (m *mockBookingWriter) SetParticipantInvoice(ctx context.Context, id, invID int64) error {
+	return nil
+}
+func (m *mockBookingWriter) MarkParticipantPaid(ctx context.Context, participantID int64, paidAt time.Time) (*booking.Booking, error) {
+	return &booking.Booking{}, nil
+}
 
+// mockPaymentRepo — счета в памяти; возвраты проверяются на настоящем репозитории
 type mockPaymentRepo struct {
-	payment             *RobokassaPayment
-	updateStatusCalls   int
+	paymentRepo
+	payments            []*Payment
+	markFailedCalls     int
 	markPaidCalls       int
 	pendingUpdateCalled int
 }
 
-func (m *mockPaymentRepo) Create(ctx context.Context, p *RobokassaPayment) error { return nil }
-func (m *mockPaymentRepo) GetByInvID(ctx context.Context, invID int64) (*RobokassaPayment, error) {
-	if m.payment == nil || m.payment.InvID != invID {
-		return nil, errors.New("not found")
+func (m *mockPaymentRepo) Create(ctx context.Context, p *Payment) error {
+	p.ID = int64(len(m.payments) + 1)
+	m.payments = append(m.payments, p)
+	return nil
+}
+func (m *mockPaymentRepo) GetByInvoice(ctx context.Context, provider string, invoiceID int64) (*Payment, error) {
+	for _, p := range m.payments {
+		if p.Provider == provider && p.InvoiceID == invoiceID {
+			return p, nil
+		}
 	}
-	return m.payment, nil
+	return nil, errors.New("not found")
+}
+func (m *mockPaymentRepo) MarkFailed(ctx context.Context, id int64, rawBody, reason string) error {
+	m.markFailedCalls++
+	return nil
 }
-func (m *mockPaymentRepo) UpdateStatus(ctx context.Context, invID int64, status domain.RobokassaPaymentStatus, rawBody, reason string, paidAt *time.Time) error {
-	m.updateStatusCalls++
+func (m *mockPaymentRepo) SaveFailureReason(ctx context.Context, id int64, rawBody, reason string) error {
 	return nil
 }
-func (m *mockPaymentRepo) UpdateStatusPendingIfNotPaid(ctx context.Context, invID int64, rawBody string) error {
+func (m *mockPaymentRepo) UpdateStatusPendingIfNotPaid(ctx context.Context, id int64, rawBody string) error {
 	m.pendingUpdateCalled++
 	return nil
 }
-func (m *mockPaymentRepo) SaveSuccessRawBody(ctx context.Context, invID int64, rawBody string) error {
+func (m *mockPaymentRepo) SaveReturnRawBody(ctx context.Context, id int64, rawBody string) error {
 	return nil
 }
-func (m *mockPaymentRepo) MarkPaidIdempotent(ctx context.Context, invID int64, rawBody string, paidAt time.Time) (bool, error) {
+func (m *mockPaymentRepo) MarkPaidIdempotent(ctx context.Context, id int64, externalID, rawBody string, paidAt time.Time) (bool, error) {
 	m.markPaidCalls++
+	for _, p := range m.payments {
+		if p.ID == id {
+			if p.Status == StatusPaid {
+				return false, nil
+			}
+			p.Status = StatusPaid
+		}
+	}
 	return true, nil
 }
 
-func TestHandleResultCallback_AmountMismatch(t *testing.T) {
-	repo := &mockPaymentRepo{payment: &RobokassaPayment{InvID: 99, OutSum: "100.00", BookingID: 1}}
-	svc := &Service{payments: repo, bookings: &mockBookingReader{}, bookingWriter: &mockBookingWriter{}, loggerf: func(string, ...interface{}) {}, password2: "p2", password1: "p1", merchantLogin: "m"}
+func newTestService(repo *mockPaymentRepo, writer *mockBookingWriter, providers ...Provider) *Service {
+	svc := NewService(repo, &mockBookingReader{}, writer, nil, nil, nil, providers...)
+	svc.defaultProvider = ProviderRobokassa
+	return svc
+}
+
+func TestHandleCallback_AmountMismatch(t *testing.T) {
+	fake := NewFakeProvider("kaspi")
+	repo := &mockPaymentRepo{payments: []*Payment{{ID: 1, Provider: "kaspi", InvoiceID: 99, Amount: 100, BookingID: 1}}}
+	svc := newTestService(repo, &mockBookingWriter{}, fake)
 
-	outSum := "50.00"
-	sig := svc.generateSignatureForResult(outSum, 99, nil)
-	_, err := svc.HandleResultCallback(context.Background(), outSum, 99, sig, nil, "raw")
+	_, err := svc.HandleCallback(context.Background(), "kaspi", fake.Callback(99, "50.00", StatusPaid))
 	if !errors.Is(err, ErrAmountMismatch) {
 		t.Fatalf("expected ErrAmountMismatch, got %v", err)
 	}
 	if repo.markPaidCalls != 0 {
 		t.Fatalf("expected MarkPaidIdempotent not called")
 	}
-	if repo.updateStatusCalls == 0 {
-		t.Fatalf("expected UpdateStatus called to mark failed")
+	if repo.markFailedCalls == 0 {
+		t.Fatalf("expected MarkFailed called")
 	}
 }
 
-func TestHandleSuccessCallback_AmountMismatch(t *testing.T) {
-	repo := &mockPaymentRepo{payment: &RobokassaPayment{InvID: 77, OutSum: "300.00", BookingID: 1}}
-	svc := &Service{payments: repo, bookings: &mockBookingReader{}, bookingWriter: &mockBookingWriter{}, loggerf: func(string, ...interface{}) {}, password2: "p2", password1: "p1", merchantLogin: "m"}
+func TestHandleReturn_AmountMismatch(t *testing.T) {
+	fake := NewFakeProvider("kaspi")
+	repo := &mockPaymentRepo{payments: []*Payment{{ID: 1, Provider: "kaspi", InvoiceID: 77, Amount: 300, BookingID: 1}}}
+	svc := newTestService(repo, &mockBookingWriter{}, fake)
 
-	outSum := "300"
-	sig := svc.generateSignatureForSuccess(outSum, 77, nil)
-	ok, err := svc.HandleSuccessCallback(context.Background(), outSum, 77, sig, nil, "raw")
+	ok, err := svc.HandleReturn(context.Background(), "kaspi", fake.Callback(77, "300", StatusPaid))
 	if err != nil || !ok {
 		t.Fatalf("expected success for equivalent numeric values, got ok=%v err=%v", ok, err)
 	}
 
-	badSig := svc.generateSignatureForSuccess("100.00", 77, nil)
-	ok, err = svc.HandleSuccessCallback(context.Background(), "100.00", 77, badSig, nil, "raw")
+	ok, err = svc.HandleReturn(context.Background(), "kaspi", fake.Callback(77, "100.00", StatusPaid))
 	if !errors.Is(err, ErrAmountMismatch) || ok {
 		t.Fatalf("expected amount mismatch, got ok=%v err=%v", ok, err)
 	}
-}
\ No newline at end of file
+}
+
+func TestInitAndCallback_SelectsProvider(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeProvider("halyk")
+	repo := &mockPaymentRepo{}
+	writer := &mockBookingWriter{}
+	svc := newTestService(repo, writer, fake)
+
+	if _, err := svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5, OutSum: "2500", Provider: "cloudpayments"}); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("expected ErrUnknownProvider, got %v", err)
+	}
+	// провайдер по умолчанию не зарегистрирован
+	if _, err := svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5, OutSum: "2500"}); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("expected ErrUnknownProvider for missing default, got %v", err)
+	}
+
+	resp, err := svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5, OutSum: "2500", Provider: "halyk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != "halyk" || resp.PaymentURL == "" || repo.payments[0].Amount != 2500 {
+		t.Fatalf("unexpected init response %+v", resp)
+	}
+
+	// подпись чужого провайдера не принимается
+	forged := NewFakeProvider("halyk")
+	forged.secret = "other"
+	if _, err := svc.HandleCallback(ctx, "halyk", forged.Callback(resp.InvID, "2500.00", StatusPaid)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		ack, err := svc.HandleCallback(ctx, "halyk", fake.Callback(resp.InvID, "2500.00", StatusPaid))
+		if err != nil || ack != "OK" {
+			t.Fatalf("callback %d: ack=%q err=%v", i, ack, err)
+		}
+	}
+	// повторное уведомление не добавляет сумму второй раз
+	if repo.payments[0].Status != StatusPaid || len(writer.applied) != 1 || writer.applied[0] != 2500 {
+		t.Fatalf("expected paid payment applied once, got status=%s applied=%v", repo.payments[0].Status, writer.applied)
+	}
+}
+
+func TestInitPayment_ServerComputedAmount(t *testing.T) {
+	ctx := context.Background()
+	b := &booking.Booking{ID: 5, TotalPrice: 50000, PaymentStatus: booking.PaymentUnpaid}
+	svc := NewService(&mockPaymentRepo{}, &mockBookingReader{booking: b}, &mockBookingWriter{}, nil, nil, nil, NewFakeProvider("kaspi"))
+	svc.defaultProvider = "kaspi"
+	svc.depositPercent = 30
+
+	// клиент не может назначить свою цену
+	if _, err := svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5, OutSum: "1"}); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount for a forged out_sum, got %v", err)
+	}
+	resp, err := svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5})
+	if err != nil || resp.Kind != string(KindFull) || resp.Amount != "50000.00" {
+		t.Fatalf("expected full price by default, got %+v err=%v", resp, err)
+	}
+	resp, err = svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5, Kind: "deposit"})
+	if err != nil || resp.Amount != "15000.00" || resp.Balance != "35000.00" {
+		t.Fatalf("expected 30%% deposit, got %+v err=%v", resp, err)
+	}
+	if _, err := svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5, Kind: "deposit", OutSum: "60000"}); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount for a deposit above the balance, got %v", err)
+	}
+	// предоплата меньше 30% стоимости не принимается
+	if _, err := svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5, Kind: "deposit", OutSum: "14999.99"}); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount for a deposit below the minimum, got %v", err)
+	}
+	resp, err = svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5, Kind: "deposit", OutSum: "20000"})
+	if err != nil || resp.Amount != "20000.00" {
+		t.Fatalf("expected a deposit above the minimum accepted, got %+v err=%v", resp, err)
+	}
+
+	// после предоплаты выставляется только остаток
+	b.DepositAmount = 15000
+	if _, err := svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5, Kind: "full"}); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount for full price after a deposit, got %v", err)
+	}
+	resp, err = svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5})
+	if err != nil || resp.Kind != string(KindBalance) || resp.Amount != "35000.00" || resp.Balance != "0.00" {
+		t.Fatalf("expected balance invoice, got %+v err=%v", resp, err)
+	}
+
+	b.DepositAmount, b.PaymentStatus = 50000, booking.PaymentPaid
+	if _, err := svc.InitPayment(ctx, InitPaymentRequest{BookingID: 5}); !errors.Is(err, ErrNothingToPay) {
+		t.Fatalf("expected ErrNothingToPay for a paid booking, got %v", err)
+	}
+}
+
+func TestRobokassaProvider_Signatures(t *testing.T) {
+	p := &RobokassaProvider{merchantLogin: "m", password1: "p1", password2: "p2", baseURL: "https://pay"}
+	shp := map[string]string{"booking_id": "5"}
+
+	init, err := p.Init(context.Background(), ProviderInitRequest{InvoiceID: 42, Amount: "100.00", Params: shp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if init.Signature != md5Hex("m:100.00:42:p1:Shp_booking_id=5") {
+		t.Fatalf("unexpected init signature %s", init.Signature)
+	}
+
+	form := url.Values{"OutSum": {"100.00"}, "InvId": {"42"}, "Shp_booking_id": {"5"}}
+	form.Set("SignatureValue", md5Hex("100.00:42:p2:Shp_booking_id=5"))
+	res, err := p.VerifyCallback(context.Background(), CallbackNotify, CallbackRequest{Form: form})
+	if err != nil || res.Ack != "OK42" || res.Status != StatusPaid || res.Params["booking_id"] != "5" {
+		t.Fatalf("unexpected result %+v err=%v", res, err)
+	}
+	// SuccessURL подписывается паролем #1
+	if _, err := p.VerifyCallback(context.Background(), CallbackReturn, CallbackRequest{Form: form}); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for return with result signature, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package ical — минимальная запись и чтение календарей iCalendar (RFC 5545):
+// VCALENDAR с VEVENT, экранирование текста и перенос длинных строк.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ContentType — MIME-тип для .ics и подписок
+const ContentType = "text/calendar; charset=utf-8"
+
+// Статусы VEVENT
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+const (
+	utcLayout = "20060102T150405Z"
+	// maxLineOctets — максимальная длина строки без CRLF (RFC 5545, 3.1)
+	maxLineOctets = 75
+)
+
+// Event — одно событие календаря. Время записывается в UTC, поэтому
+// событие однозначно в любом часовом поясе подписчика.
+type Event struct {
+	UID          string
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Status       string // TENTATIVE | CONFIRMED | CANCELLED
+	Sequence     int    // растёт при каждом изменении события
+	LastModified time.Time
+	// Transparent — TRANSP:TRANSPARENT: событие не занимает время
+	Transparent bool
+}
+
+// Calendar — набор событий
+type Calendar struct {
+	ProdID string
+	Name   string // X-WR-CALNAME — имя подписки в Google/Apple Calendar
+	// Timezone — IANA-пояс для отображения (X-WR-TIMEZONE); сами времена в UTC
+	Timezone string
+	// Method PUBLISH нужен для вложений .ics, в подписках не обязателен
+	Method string
+	Events []Event
+}
+
+// Encode записывает календарь в w
+func (c *Calendar) Encode(w io.Writer, now time.Time) error {
+	lw := &lineWriter{w: w}
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + c.ProdID)
+	lw.line("CALSCALE:GREGORIAN")
+	if c.Method != "" {
+		lw.line("METHOD:" + c.Method)
+	}
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+	if c.Timezone != "" {
+		lw.line("X-WR-TIMEZONE:" + c.Timezone)
+	}
+
+	stamp := now.UTC().Format(utcLayout)
+	for _, e := range c.Events {
+		lw.line("BEGIN:VEVENT")
+		lw.line("UID:" + e.UID)
+		lw.line("DTSTAMP:" + stamp)
+		lw.line("DTSTART:" + e.Start.UTC().Format(utcLayout))
+		lw.line("DTEND:" + e.End.UTC().Format(utcLayout))
+		lw.line("SUMMARY:" + escapeText(e.Summary))
+		if e.Description != "" {
+			lw.line("DESCRIPTION:" + escapeText(e.Description))
+		}
+		if e.Location != "" {
+			lw.line("LOCATION:" + escapeText(e.Location))
+		}
+		if e.Status != "" {
+			lw.line("STATUS:" + e.Status)
+		}
+		if e.Transparent {
+			lw.line("TRANSP:TRANSPARENT")
+		}
+		lw.line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		if !e.LastModified.IsZero() {
+			lw.line("LAST-MODIFIED:" + e.LastModified.UTC().Format(utcLayout))
+		}
+		lw.line("END:VEVENT")
+	}
+
+	lw.line("END:VCALENDAR")
+	return lw.err
+}
+
+// Bytes возвращает календарь целиком
+func (c *Calendar) Bytes(now time.Time) []byte {
+	var buf bytes.Buffer
+	_ = c.Encode(&buf, now)
+	return buf.Bytes()
+}
+
+// escapeText экранирует TEXT-значение (RFC 5545, 3.3.11)
+func escapeText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+	return r.Replace(s)
+}
+
+// lineWriter пишет строки с CRLF и переносит их длиннее 75 октетов,
+// не разрывая UTF-8 символы
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+	var b strings.Builder
+	limit := maxLineOctets
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > limit {
+			b.WriteString("\r\n ")
+			// в продолжении пробел занимает один октет
+			limit, n = maxLineOctets-1, 0
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	b.WriteString("\r\n")
+	_, lw.err = io.WriteString(lw.w, b.String())
+}
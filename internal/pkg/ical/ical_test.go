@@ -0,0 +1,57 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Encode(t *testing.T) {
+	almaty := time.FixedZone("Asia/Almaty", 5*60*60)
+	cal := &Calendar{
+		ProdID:   "-//Test//RU",
+		Timezone: "Asia/Almaty",
+		Method:   "PUBLISH",
+		Events: []Event{{
+			UID:         "booking-1@test",
+			Start:       time.Date(2026, 3, 10, 14, 0, 0, 0, almaty),
+			End:         time.Date(2026, 3, 10, 16, 0, 0, 0, almaty),
+			Summary:     "Зал; большой, светлый",
+			Description: "строка 1\nстрока 2 " + strings.Repeat("очень длинное описание ", 10),
+			Status:      StatusCancelled,
+			Sequence:    3,
+		}},
+	}
+	out := string(cal.Bytes(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)))
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"METHOD:PUBLISH\r\n",
+		"X-WR-TIMEZONE:Asia/Almaty\r\n",
+		"DTSTART:20260310T090000Z\r\n", // 14:00 в Алматы
+		"DTEND:20260310T110000Z\r\n",
+		`SUMMARY:Зал\; большой\, светлый` + "\r\n",
+		"STATUS:CANCELLED\r\n",
+		"SEQUENCE:3\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line is longer than 75 octets: %q", line)
+		}
+		if strings.ContainsRune(line, '\n') {
+			t.Errorf("bare LF in line %q", line)
+		}
+	}
+
+	// развёрнутое описание совпадает с исходным
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, `DESCRIPTION:строка 1\nстрока 2 очень длинное описание`) {
+		t.Errorf("description was not escaped or folded correctly:\n%s", unfolded)
+	}
+}
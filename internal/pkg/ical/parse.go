@@ -0,0 +1,277 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotCalendar — данные не похожи на iCalendar
+var ErrNotCalendar = errors.New("ical: not an iCalendar file")
+
+const (
+	localLayout = "20060102T150405"
+	dateLayout  = "20060102"
+	// maxLineBytes — длина развёрнутой строки, больше которой файл считается битым
+	maxLineBytes = 1 << 20
+)
+
+// Parse читает события VEVENT. Время без зоны и даты целодневных событий
+// считаются в зоне loc (если не задан TZID). Повторяющиеся события (RRULE)
+// не разворачиваются — учитывается только первое вхождение. События с
+// неразборчивым DTSTART пропускаются: внешние календари бывают неаккуратны.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events     []Event
+		inCalendar bool
+		cur        *eventProps
+	)
+	for _, raw := range lines {
+		name, params, value := splitLine(raw)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			inCalendar = true
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			cur = &eventProps{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if cur != nil {
+				if e, ok := cur.event(loc); ok {
+					events = append(events, e)
+				}
+			}
+			cur = nil
+		case cur != nil:
+			cur.set(name, params, value)
+		}
+	}
+	if !inCalendar {
+		return nil, ErrNotCalendar
+	}
+	return events, nil
+}
+
+// unfold склеивает перенесённые строки (RFC 5545, 3.1); принимает CRLF и LF
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxLineBytes)
+
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+// splitLine разбирает "NAME;PARAM=x;PARAM2=\"y:z\":value"
+func splitLine(line string) (string, map[string]string, string) {
+	inQuotes := false
+	colon := -1
+	for i := 0; i < len(line); i++ {
+		if line[i] == '"' {
+			inQuotes = !inQuotes
+		} else if line[i] == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// eventProps — свойства VEVENT до сборки события
+type eventProps struct {
+	uid, summary, description, location, status, transp string
+	start, end                                          prop
+	duration                                            string
+	sequence                                            int
+}
+
+type prop struct {
+	value  string
+	params map[string]string
+	set    bool
+}
+
+func (p *eventProps) set(name string, params map[string]string, value string) {
+	switch name {
+	case "UID":
+		p.uid = value
+	case "SUMMARY":
+		p.summary = unescapeText(value)
+	case "DESCRIPTION":
+		p.description = unescapeText(value)
+	case "LOCATION":
+		p.location = unescapeText(value)
+	case "STATUS":
+		p.status = strings.ToUpper(value)
+	case "TRANSP":
+		p.transp = strings.ToUpper(value)
+	case "DTSTART":
+		p.start = prop{value: value, params: params, set: true}
+	case "DTEND":
+		p.end = prop{value: value, params: params, set: true}
+	case "DURATION":
+		p.duration = value
+	case "SEQUENCE":
+		p.sequence, _ = strconv.Atoi(value)
+	}
+}
+
+func (p *eventProps) event(loc *time.Location) (Event, bool) {
+	if !p.start.set {
+		return Event{}, false
+	}
+	start, allDay, err := parseTime(p.start, loc)
+	if err != nil {
+		return Event{}, false
+	}
+
+	var end time.Time
+	switch {
+	case p.end.set:
+		if end, _, err = parseTime(p.end, loc); err != nil {
+			return Event{}, false
+		}
+	case p.duration != "":
+		d, err := parseDuration(p.duration)
+		if err != nil {
+			return Event{}, false
+		}
+		end = start.Add(d)
+	case allDay:
+		// целодневное событие без DTEND длится один день
+		end = start.AddDate(0, 0, 1)
+	default:
+		end = start
+	}
+
+	return Event{
+		UID:         p.uid,
+		Start:       start,
+		End:         end,
+		Summary:     p.summary,
+		Description: p.description,
+		Location:    p.location,
+		Status:      p.status,
+		Sequence:    p.sequence,
+		Transparent: p.transp == "TRANSPARENT",
+	}, true
+}
+
+// parseTime разбирает DATE-TIME (UTC, с TZID или «плавающее») и DATE
+func parseTime(p prop, loc *time.Location) (time.Time, bool, error) {
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	v := strings.TrimSpace(p.value)
+
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(v) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, v, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse(utcLayout, v)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation(localLayout, v, loc)
+	return t, false, err
+}
+
+// parseDuration разбирает длительность вида P1W, P1DT2H30M, PT45M
+func parseDuration(s string) (time.Duration, error) {
+	bad := errors.New("ical: invalid duration " + s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	if !strings.HasPrefix(s, "P") {
+		return 0, bad
+	}
+	s = s[1:]
+
+	var total time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			num += string(r)
+		case r == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, bad
+			}
+			num = ""
+			unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+			if inTime {
+				unit = map[rune]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+			}
+			u, ok := unit[r]
+			if !ok {
+				return 0, bad
+			}
+			total += time.Duration(n) * u
+		}
+	}
+	if num != "" {
+		return 0, bad
+	}
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
+
+// unescapeText — обратное к escapeText
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package ical
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	src := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		`DTSTART;TZID="Asia/Almaty":20260310T140000`,
+		"DTEND;TZID=Asia/Almaty:20260310T160000",
+		"UID:a@test",
+		`SUMMARY:Зал\; большой\, светлый`,
+		"DESCRIPTION:первая строка\\nвторая",
+		"  продолжение",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20260311",
+		"UID:b@test",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20260312T100000",
+		"DURATION:PT1H30M",
+		"STATUS:cancelled",
+		"TRANSP:TRANSPARENT",
+		"UID:c@test",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:not-a-date",
+		"UID:broken@test",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	loc := time.FixedZone("studio", 3*60*60)
+	events, err := Parse(strings.NewReader(src), loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events (broken one skipped), got %d", len(events))
+	}
+
+	a := events[0]
+	if !a.Start.Equal(time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)) || !a.End.Equal(time.Date(2026, 3, 10, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("TZID time parsed as %v - %v", a.Start.UTC(), a.End.UTC())
+	}
+	if a.Summary != "Зал; большой, светлый" || a.Description != "первая строка\nвторая продолжение" {
+		t.Errorf("text not unescaped/unfolded: %q / %q", a.Summary, a.Description)
+	}
+
+	b := events[1]
+	if !b.Start.Equal(time.Date(2026, 3, 11, 0, 0, 0, 0, loc)) || !b.End.Equal(time.Date(2026, 3, 12, 0, 0, 0, 0, loc)) {
+		t.Errorf("all-day event parsed as %v - %v", b.Start, b.End)
+	}
+
+	c := events[2]
+	if !c.Start.Equal(time.Date(2026, 3, 12, 10, 0, 0, 0, loc)) || c.End.Sub(c.Start) != 90*time.Minute {
+		t.Errorf("floating time with duration parsed as %v - %v", c.Start, c.End)
+	}
+	if c.Status != StatusCancelled || !c.Transparent {
+		t.Errorf("status/transp not parsed: %q %v", c.Status, c.Transparent)
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	in := &Calendar{ProdID: "-//Test//RU", Events: []Event{{
+		UID:         "x@test",
+		Start:       time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC),
+		End:         time.Date(2026, 3, 10, 11, 0, 0, 0, time.UTC),
+		Summary:     strings.Repeat("длинное название, ", 8),
+		Status:      StatusConfirmed,
+		Sequence:    2,
+		Transparent: true,
+	}}}
+
+	out, err := Parse(strings.NewReader(string(in.Bytes(time.Now()))), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(out))
+	}
+	got, want := out[0], in.Events[0]
+	if got.Summary != want.Summary || !got.Start.Equal(want.Start) || !got.End.Equal(want.End) ||
+		got.Sequence != 2 || got.Status != StatusConfirmed || !got.Transparent {
+		t.Fatalf("round trip mismatch: %+v", got)
+	}
+}
+
+func TestParse_NotCalendar(t *testing.T) {
+	if _, err := Parse(strings.NewReader("<html>login required</html>"), time.UTC); !errors.Is(err, ErrNotCalendar) {
+		t.Fatalf("expected ErrNotCalendar, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// DurationFromEnv читает длительность в формате time.ParseDuration из переменной
+// окружения. Пустое, некорректное или неположительное значение заменяется на def.
+func DurationFromEnv(name string, def time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("invalid %s=%q, using %v", name, v, def)
+	}
+	return def
+}
@@ -0,0 +1,8 @@
+-- Часовой пояс студии (IANA). Все расписания и доступность считаются в нём.
+ALTER TABLE studios ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Almaty';
+
+-- Западный Казахстан живёт в своих зонах
+UPDATE studios SET timezone = 'Asia/Aqtau'  WHERE city IN ('Актау', 'Aktau', 'Aqtau');
+UPDATE studios SET timezone = 'Asia/Atyrau' WHERE city IN ('Атырау', 'Atyrau');
+UPDATE studios SET timezone = 'Asia/Oral'   WHERE city IN ('Уральск', 'Орал', 'Uralsk', 'Oral');
+UPDATE studios SET timezone = 'Asia/Aqtobe' WHERE city IN ('Актобе', 'Aktobe', 'Aqtobe');
@@ -0,0 +1,23 @@
+-- Повторяющиеся брони (серии)
+CREATE TABLE IF NOT EXISTS booking_series (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    room_id      BIGINT NOT NULL REFERENCES rooms(id) ON DELETE RESTRICT,
+    studio_id    BIGINT NOT NULL REFERENCES studios(id) ON DELETE RESTRICT,
+    frequency    VARCHAR(20) NOT NULL CHECK (frequency IN ('weekly', 'biweekly', 'monthly')),
+    start_time   TIMESTAMPTZ NOT NULL,
+    end_time     TIMESTAMPTZ NOT NULL CHECK (end_time > start_time),
+    count        INT NOT NULL DEFAULT 0,
+    until        TIMESTAMPTZ,
+    timezone     VARCHAR(64) NOT NULL DEFAULT 'Asia/Almaty',
+    status       VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'cancelled')),
+    notes        TEXT,
+    created_at   TIMESTAMPTZ DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ DEFAULT NOW(),
+    cancelled_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_series_user ON booking_series(user_id);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS series_id BIGINT REFERENCES booking_series(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_bookings_series ON bookings(series_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_bookings_hold_expires;
+
+UPDATE bookings SET status = 'cancelled', cancelled_at = NOW() WHERE status = 'held';
+ALTER TABLE bookings DROP COLUMN IF EXISTS hold_expires_at;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('pending', 'confirmed', 'cancelled', 'completed'));
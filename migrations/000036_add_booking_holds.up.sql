@@ -0,0 +1,9 @@
+-- Временная блокировка слота до оплаты: бронь в статусе 'held' с TTL
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('held', 'pending', 'confirmed', 'cancelled', 'completed'));
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS hold_expires_at TIMESTAMPTZ;
+
+-- Для sweeper-а: быстро находить истёкшие holds
+CREATE INDEX IF NOT EXISTS idx_bookings_hold_expires ON bookings(hold_expires_at) WHERE status = 'held';
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS booking_status_history;
+
+DROP INDEX IF EXISTS idx_no_overbooking;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_no_overbooking ON bookings (
+    room_id,
+    tstzrange(start_time, end_time, '[)')
+) WHERE status NOT IN ('cancelled');
+
+UPDATE bookings SET status = 'cancelled' WHERE status IN ('no_show', 'rescheduled');
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('held', 'pending', 'confirmed', 'cancelled', 'completed'));
@@ -0,0 +1,25 @@
+-- Полная машина состояний брони: no_show и rescheduled
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('held', 'pending', 'confirmed', 'cancelled', 'completed', 'no_show', 'rescheduled'));
+
+-- rescheduled, как и cancelled, освобождает слот
+DROP INDEX IF EXISTS idx_no_overbooking;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_no_overbooking ON bookings (
+    room_id,
+    tstzrange(start_time, end_time, '[)')
+) WHERE status NOT IN ('cancelled', 'rescheduled');
+
+-- Аудит: кто, когда и почему менял статус
+CREATE TABLE IF NOT EXISTS booking_status_history (
+    id          BIGSERIAL PRIMARY KEY,
+    booking_id  BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status   VARCHAR(20) NOT NULL,
+    actor_id    BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    actor_role  VARCHAR(20) NOT NULL,
+    reason      TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_status_history_booking ON booking_status_history(booking_id, created_at);
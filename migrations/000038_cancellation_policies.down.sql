@@ -0,0 +1,8 @@
+UPDATE bookings SET payment_status = 'refunded' WHERE payment_status = 'partially_refunded';
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_payment_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_payment_status_check
+    CHECK (payment_status IN ('unpaid', 'paid', 'refunded'));
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS refund_amount;
+ALTER TABLE studios DROP COLUMN IF EXISTS cancellation_policy;
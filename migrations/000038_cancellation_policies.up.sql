@@ -0,0 +1,9 @@
+-- Политика отмены студии: ступени {hours_before, refund_percent}. NULL — полный возврат до начала.
+ALTER TABLE studios ADD COLUMN IF NOT EXISTS cancellation_policy JSONB;
+
+-- Сколько вернули клиенту при отмене
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS refund_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_payment_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_payment_status_check
+    CHECK (payment_status IN ('unpaid', 'paid', 'refunded', 'partially_refunded'));
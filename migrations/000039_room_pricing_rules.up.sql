@@ -0,0 +1,3 @@
+-- Правила цены комнаты: пиковые часы, надбавка за выходные, минимальная длительность,
+-- скидки за длительность, праздничные цены. NULL — почасовая price_per_hour_min.
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS pricing_rules JSONB;
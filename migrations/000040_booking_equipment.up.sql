@@ -0,0 +1,13 @@
+-- Аренда оборудования в брони. Название и цена фиксируются на момент брони.
+CREATE TABLE IF NOT EXISTS booking_equipment (
+    id           BIGSERIAL PRIMARY KEY,
+    booking_id   BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    equipment_id BIGINT NOT NULL REFERENCES equipment(id) ON DELETE RESTRICT,
+    name         VARCHAR(255) NOT NULL,
+    quantity     INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price   DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_equipment_booking ON booking_equipment(booking_id);
+CREATE INDEX IF NOT EXISTS idx_booking_equipment_equipment ON booking_equipment(equipment_id);
@@ -0,0 +1,2 @@
+ALTER TABLE rooms DROP COLUMN IF EXISTS buffer_after_minutes;
+ALTER TABLE rooms DROP COLUMN IF EXISTS buffer_before_minutes;
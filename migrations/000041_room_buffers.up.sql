@@ -0,0 +1,6 @@
+-- Время на подготовку до и уборку после брони (минуты). Между соседними бронями
+-- комнаты должен оставаться зазор buffer_after + buffer_before.
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS buffer_before_minutes INTEGER NOT NULL DEFAULT 0
+    CHECK (buffer_before_minutes BETWEEN 0 AND 240);
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS buffer_after_minutes INTEGER NOT NULL DEFAULT 0
+    CHECK (buffer_after_minutes BETWEEN 0 AND 240);
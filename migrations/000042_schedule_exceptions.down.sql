@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS room_blackouts;
+DROP TABLE IF EXISTS studio_date_overrides;
@@ -0,0 +1,29 @@
+-- Исключения из недельного расписания студии: выходной или особые часы на дату
+-- (календарный день в часовом поясе студии).
+CREATE TABLE IF NOT EXISTS studio_date_overrides (
+    id         BIGSERIAL PRIMARY KEY,
+    studio_id  BIGINT NOT NULL REFERENCES studios(id) ON DELETE CASCADE,
+    date       VARCHAR(10) NOT NULL,
+    is_closed  BOOLEAN NOT NULL DEFAULT FALSE,
+    open_time  VARCHAR(5),
+    close_time VARCHAR(5),
+    reason     VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (is_closed OR (open_time IS NOT NULL AND close_time IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_studio_date_overrides_studio_date ON studio_date_overrides(studio_id, date);
+
+-- Периоды, когда комната закрыта для брони (ремонт, обслуживание)
+CREATE TABLE IF NOT EXISTS room_blackouts (
+    id         BIGSERIAL PRIMARY KEY,
+    room_id    BIGINT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time   TIMESTAMPTZ NOT NULL,
+    reason     VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_blackouts_room_time ON room_blackouts(room_id, start_time, end_time);
@@ -0,0 +1,23 @@
+-- Лист ожидания занятых окон комнат. Когда бронь в окне отменяют, записи по
+-- порядку получают одноразовую ссылку claim_token, действующую до offer_expires_at.
+CREATE TABLE IF NOT EXISTS booking_waitlist (
+    id               BIGSERIAL PRIMARY KEY,
+    room_id          BIGINT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    studio_id        BIGINT NOT NULL REFERENCES studios(id) ON DELETE CASCADE,
+    user_id          BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_time       TIMESTAMPTZ NOT NULL,
+    end_time         TIMESTAMPTZ NOT NULL,
+    status           VARCHAR(20) NOT NULL DEFAULT 'waiting'
+        CHECK (status IN ('waiting', 'offered', 'claimed', 'expired', 'cancelled')),
+    claim_token      VARCHAR(64),
+    offer_expires_at TIMESTAMPTZ,
+    booking_id       BIGINT REFERENCES bookings(id) ON DELETE SET NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_waitlist_room_window ON booking_waitlist(room_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_booking_waitlist_user_id ON booking_waitlist(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_waitlist_claim_token ON booking_waitlist(claim_token);
+CREATE INDEX IF NOT EXISTS idx_booking_waitlist_active_offers ON booking_waitlist(offer_expires_at) WHERE status = 'offered';
@@ -0,0 +1,19 @@
+-- Групповые брони: несколько комнат студии (или вся студия) на одно окно
+CREATE TABLE IF NOT EXISTS booking_groups (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    studio_id   BIGINT NOT NULL REFERENCES studios(id) ON DELETE RESTRICT,
+    start_time  TIMESTAMPTZ NOT NULL,
+    end_time    TIMESTAMPTZ NOT NULL CHECK (end_time > start_time),
+    total_price DECIMAL(10,2) NOT NULL DEFAULT 0,
+    status      VARCHAR(20) NOT NULL DEFAULT 'pending',
+    notes       TEXT,
+    created_at  TIMESTAMPTZ DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_groups_user ON booking_groups(user_id);
+CREATE INDEX IF NOT EXISTS idx_booking_groups_studio ON booking_groups(studio_id);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS group_id BIGINT REFERENCES booking_groups(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_bookings_group ON bookings(group_id);
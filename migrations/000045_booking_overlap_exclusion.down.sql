@@ -0,0 +1,6 @@
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_no_overbooking ON bookings (
+    room_id,
+    tstzrange(start_time, end_time, '[)')
+) WHERE status NOT IN ('cancelled', 'rescheduled');
@@ -0,0 +1,19 @@
+-- Расписание напоминаний студии: {"hours_before": [24, 2]}. NULL — за 24ч и 2ч, [] — выключены.
+ALTER TABLE studios ADD COLUMN IF NOT EXISTS reminder_schedule JSONB;
+
+-- Напоминания клиентам о подтверждённых бронях; отправляются фоновым планировщиком
+CREATE TABLE IF NOT EXISTS booking_reminders (
+    id           BIGSERIAL PRIMARY KEY,
+    booking_id   BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hours_before INT NOT NULL DEFAULT 0,
+    remind_at    TIMESTAMPTZ NOT NULL,
+    status       VARCHAR(20) NOT NULL DEFAULT 'scheduled'
+        CHECK (status IN ('scheduled', 'sent', 'skipped', 'cancelled')),
+    sent_at      TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_reminders_booking_id ON booking_reminders(booking_id);
+CREATE INDEX IF NOT EXISTS idx_booking_reminders_due ON booking_reminders(status, remind_at);
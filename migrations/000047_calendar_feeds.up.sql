@@ -0,0 +1,11 @@
+-- Секретные ссылки-подписки (iCal) на брони владельца: всех студий или одной комнаты
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id         BIGSERIAL PRIMARY KEY,
+    owner_id   BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id    BIGINT REFERENCES rooms(id) ON DELETE CASCADE,
+    token      VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_feeds_token ON calendar_feeds(token);
+CREATE INDEX IF NOT EXISTS idx_calendar_feeds_owner_id ON calendar_feeds(owner_id);
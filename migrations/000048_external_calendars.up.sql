@@ -0,0 +1,29 @@
+-- Внешние календари комнат: ссылка iCal (перечитывается периодически) или загруженный .ics (url пуст)
+CREATE TABLE IF NOT EXISTS room_external_calendars (
+    id             BIGSERIAL PRIMARY KEY,
+    room_id        BIGINT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    owner_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name           VARCHAR(255) NOT NULL,
+    url            TEXT NOT NULL DEFAULT '',
+    last_synced_at TIMESTAMPTZ,
+    last_error     TEXT NOT NULL DEFAULT '',
+    block_count    INT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_external_calendars_room_id ON room_external_calendars(room_id);
+
+-- Занятое время из внешних календарей; заменяется целиком при каждом импорте
+CREATE TABLE IF NOT EXISTS external_busy_blocks (
+    id          BIGSERIAL PRIMARY KEY,
+    calendar_id BIGINT NOT NULL REFERENCES room_external_calendars(id) ON DELETE CASCADE,
+    room_id     BIGINT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    uid         VARCHAR(255),
+    start_time  TIMESTAMPTZ NOT NULL,
+    end_time    TIMESTAMPTZ NOT NULL,
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_external_busy_blocks_calendar_id ON external_busy_blocks(calendar_id);
+CREATE INDEX IF NOT EXISTS idx_external_busy_blocks_room_time ON external_busy_blocks(room_id, start_time);
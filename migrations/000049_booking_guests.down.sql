@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_bookings_guest_phone;
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_client_check;
+
+-- непривязанные гостевые брони не вернуть под NOT NULL
+DELETE FROM bookings WHERE user_id IS NULL;
+ALTER TABLE bookings ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS guest_email;
+ALTER TABLE bookings DROP COLUMN IF EXISTS guest_phone;
+ALTER TABLE bookings DROP COLUMN IF EXISTS guest_name;
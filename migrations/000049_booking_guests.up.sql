@@ -0,0 +1,11 @@
+-- Брони гостей без аккаунта (телефон, стойка): user_id пуст до привязки к клиенту
+ALTER TABLE bookings ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS guest_name  VARCHAR(255);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS guest_phone VARCHAR(50);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS guest_email VARCHAR(255);
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_client_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_client_check
+    CHECK (user_id IS NOT NULL OR guest_name IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_bookings_guest_phone ON bookings(guest_phone) WHERE user_id IS NULL;
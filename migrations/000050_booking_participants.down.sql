@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_robokassa_payments_participant_id;
+ALTER TABLE robokassa_payments DROP COLUMN IF EXISTS participant_id;
+DROP TABLE IF EXISTS booking_participants;
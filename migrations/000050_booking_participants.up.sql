@@ -0,0 +1,21 @@
+-- Участники брони, оплачивающие свои доли отдельными счетами
+CREATE TABLE IF NOT EXISTS booking_participants (
+    id           BIGSERIAL PRIMARY KEY,
+    booking_id   BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    user_id      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    name         VARCHAR(255) NOT NULL,
+    email        VARCHAR(255),
+    phone        VARCHAR(50),
+    share_amount DECIMAL(10,2) NOT NULL CHECK (share_amount > 0),
+    status       VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','paid')),
+    inv_id       BIGINT,
+    paid_at      TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_participants_booking_id ON booking_participants(booking_id);
+
+-- счёт Robokassa на долю участника
+ALTER TABLE robokassa_payments ADD COLUMN IF NOT EXISTS participant_id BIGINT REFERENCES booking_participants(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_robokassa_payments_participant_id ON robokassa_payments(participant_id);
@@ -0,0 +1,36 @@
+-- Платежи всех провайдеров (Robokassa, Kaspi, Halyk ePay, CloudPayments...)
+CREATE TABLE IF NOT EXISTS payments (
+    id                BIGSERIAL PRIMARY KEY,
+    booking_id        BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    participant_id    BIGINT REFERENCES booking_participants(id) ON DELETE SET NULL,
+    provider          VARCHAR(32) NOT NULL,
+    invoice_id        BIGINT NOT NULL,
+    external_id       VARCHAR(128),
+    amount            DECIMAL(12,2) NOT NULL,
+    description       TEXT,
+    status            VARCHAR(20) NOT NULL DEFAULT 'created' CHECK (status IN ('created','pending','paid','failed','refunded')),
+    payment_url       TEXT,
+    params            TEXT,
+    callback_raw_body TEXT,
+    return_raw_body   TEXT,
+    failure_reason    TEXT,
+    paid_at           TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_provider_invoice ON payments(provider, invoice_id);
+CREATE INDEX IF NOT EXISTS idx_payments_booking_id ON payments(booking_id);
+CREATE INDEX IF NOT EXISTS idx_payments_participant_id ON payments(participant_id);
+CREATE INDEX IF NOT EXISTS idx_payments_status ON payments(status);
+
+-- переносим счета Robokassa; robokassa_payments остаётся только для истории
+INSERT INTO payments (booking_id, participant_id, provider, invoice_id, amount, description, status,
+                      payment_url, params, callback_raw_body, return_raw_body, failure_reason,
+                      paid_at, created_at, updated_at)
+SELECT booking_id, participant_id, 'robokassa', inv_id, CAST(out_sum AS DECIMAL(12,2)), description,
+       CASE WHEN status IN ('created','pending','paid','failed') THEN status ELSE 'created' END,
+       robokassa_url, shp_params, result_raw_body, success_raw_body, failure_reason,
+       paid_at, created_at, updated_at
+FROM robokassa_payments
+ON CONFLICT (provider, invoice_id) DO NOTHING;
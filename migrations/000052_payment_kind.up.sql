@@ -0,0 +1,5 @@
+-- Вид счёта: полная оплата, предоплата, остаток или доля участника
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'full'
+    CHECK (kind IN ('full','deposit','balance','share'));
+
+UPDATE payments SET kind = 'share' WHERE participant_id IS NOT NULL;
@@ -0,0 +1,22 @@
+-- Возвраты по счетам через платёжного провайдера
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id              BIGSERIAL PRIMARY KEY,
+    payment_id      BIGINT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    booking_id      BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    provider        VARCHAR(32) NOT NULL,
+    amount          DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    reason          TEXT,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','succeeded','failed')),
+    external_id     VARCHAR(128),
+    idempotency_key VARCHAR(128) NOT NULL,
+    requested_by    BIGINT NOT NULL,
+    failure_reason  TEXT,
+    completed_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_refunds_idempotency_key ON payment_refunds(idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_payment_id ON payment_refunds(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_booking_id ON payment_refunds(booking_id);
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_status ON payment_refunds(status);
@@ -0,0 +1,20 @@
+-- Расхождения, найденные сверкой платежей (payments ↔ шлюз ↔ bookings.payment_status)
+CREATE TABLE IF NOT EXISTS payment_discrepancies (
+    id          BIGSERIAL PRIMARY KEY,
+    kind        VARCHAR(32) NOT NULL CHECK (kind IN ('amount_mismatch','stale_invoice','booking_unpaid','paid_without_payment','missed_callback')),
+    booking_id  BIGINT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    payment_id  BIGINT REFERENCES payments(id) ON DELETE CASCADE,
+    provider    VARCHAR(32),
+    details     TEXT,
+    status      VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open','resolved','dismissed')),
+    resolution  TEXT,
+    resolved_by VARCHAR(64),
+    resolved_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_discrepancies_kind ON payment_discrepancies(kind);
+CREATE INDEX IF NOT EXISTS idx_payment_discrepancies_booking_id ON payment_discrepancies(booking_id);
+CREATE INDEX IF NOT EXISTS idx_payment_discrepancies_payment_id ON payment_discrepancies(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_discrepancies_status ON payment_discrepancies(status);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS payout_items;
+DROP TABLE IF EXISTS payout_batches;
+DROP TABLE IF EXISTS commission_rates;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_transactions;
@@ -0,0 +1,81 @@
+-- Учёт долга платформы перед владельцами студий (двойная запись):
+-- оплаты, возвраты, ручные корректировки и выплаты
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id              BIGSERIAL PRIMARY KEY,
+    kind            VARCHAR(20) NOT NULL CHECK (kind IN ('payment','refund','adjustment','payout')),
+    reference       VARCHAR(128) NOT NULL,
+    owner_id        BIGINT NOT NULL REFERENCES users(id),
+    booking_id      BIGINT REFERENCES bookings(id) ON DELETE SET NULL,
+    payment_id      BIGINT REFERENCES payments(id) ON DELETE SET NULL,
+    refund_id       BIGINT REFERENCES payment_refunds(id) ON DELETE SET NULL,
+    payout_batch_id BIGINT,
+    amount          DECIMAL(12,2) NOT NULL,
+    commission_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+    commission      DECIMAL(12,2) NOT NULL DEFAULT 0,
+    description     TEXT,
+    created_by      VARCHAR(64),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_transactions_reference ON ledger_transactions(reference);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_kind ON ledger_transactions(kind);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_owner_id ON ledger_transactions(owner_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_booking_id ON ledger_transactions(booking_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_payment_id ON ledger_transactions(payment_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_payout_batch_id ON ledger_transactions(payout_batch_id);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id             BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES ledger_transactions(id) ON DELETE CASCADE,
+    account        VARCHAR(32) NOT NULL CHECK (account IN ('platform_cash','owner_payable','platform_commission')),
+    owner_id       BIGINT REFERENCES users(id),
+    debit          DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (debit >= 0),
+    credit         DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (credit >= 0),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (account <> 'owner_payable' OR owner_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_owner_id ON ledger_entries(owner_id);
+
+-- Комиссия платформы по тарифам владельцев; для тарифа без ставки —
+-- PLATFORM_COMMISSION_PERCENT (10%)
+CREATE TABLE IF NOT EXISTS commission_rates (
+    plan_id    VARCHAR(50) PRIMARY KEY REFERENCES subscription_plans(id) ON DELETE CASCADE,
+    percent    DECIMAL(5,2) NOT NULL CHECK (percent >= 0 AND percent <= 100),
+    updated_by VARCHAR(64),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO commission_rates (plan_id, percent)
+SELECT id, 10 FROM subscription_plans
+ON CONFLICT (plan_id) DO NOTHING;
+
+-- Пакеты выплат владельцам, подтверждаемые администратором
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id           BIGSERIAL PRIMARY KEY,
+    status       VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft','approved','cancelled')),
+    total        DECIMAL(12,2) NOT NULL,
+    items_count  INT NOT NULL,
+    created_by   VARCHAR(64),
+    approved_by  VARCHAR(64),
+    approved_at  TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_batches_status ON payout_batches(status);
+
+CREATE TABLE IF NOT EXISTS payout_items (
+    id             BIGSERIAL PRIMARY KEY,
+    batch_id       BIGINT NOT NULL REFERENCES payout_batches(id) ON DELETE CASCADE,
+    owner_id       BIGINT NOT NULL REFERENCES users(id),
+    amount         DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    transaction_id BIGINT REFERENCES ledger_transactions(id),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_items_batch_id ON payout_items(batch_id);
+CREATE INDEX IF NOT EXISTS idx_payout_items_owner_id ON payout_items(owner_id);
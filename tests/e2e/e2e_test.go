@@ -78,7 +78,19 @@ func setupTestSuite(t *testing.T) *E2ETestSuite {
 		&catalog.Studio{},
 		&catalog.Room{},
 		&catalog.Equipment{},
+		&catalog.StudioDateOverride{},
+		&catalog.RoomBlackout{},
 		&booking.Booking{},
+		&booking.BookingSeries{},
+		&booking.BookingGroup{},
+		&booking.BookingStatusHistory{},
+		&booking.BookingEquipment{},
+		&booking.WaitlistEntry{},
+		&booking.BookingReminder{},
+		&booking.CalendarFeed{},
+		&booking.ExternalCalendar{},
+		&booking.ExternalBusyBlock{},
+		&booking.BookingParticipant{},
 		&review.Review{},
 		&notification.Notification{},
 		&auth.VerificationCode{},
@@ -122,7 +134,7 @@ func setupTestSuite(t *testing.T) *E2ETestSuite {
 	catalogService := catalog.NewService(studioRepo, roomRepo, equipmentRepo, studioWorkingHoursRepo)
 	catalogHandler := catalog.NewHandler(catalogService, userRepo)
 
-	bookingService := booking.NewService(bookingRepo, roomRepo, nil, studioWorkingHoursRepo)
+	bookingService := booking.NewService(bookingRepo, roomRepo, nil, studioWorkingHoursRepo, nil)
 	bookingHandler := booking.NewHandler(bookingService)
 
 	reviewService := review.NewService(reviewRepo, bookingRepo, studioRepo)